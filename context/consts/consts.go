@@ -4,5 +4,16 @@ const USER_KEY_FOR_GIN_CONTEXT = "user"
 const GOCMS_HEADER_USER_CONTEXT_KEY = "X-GOCMS-USER-CONTEXT"
 const GOCMS_HEADER_TIMEZONE_KEY = "X-GOCMS-TIMEZONE"
 const GOCMS_HEADER_MICROSERVICE_SECRET = "X-GOCMS-MICROSERVICE-SECRET"
+const GOCMS_HEADER_PLUGIN_ID = "X-GOCMS-PLUGIN-ID"
+const GOCMS_HEADER_TIMESTAMP = "X-GOCMS-TIMESTAMP"
+const GOCMS_HEADER_NONCE = "X-GOCMS-NONCE"
+const GOCMS_HEADER_SIGNATURE = "X-GOCMS-SIGNATURE"
 
 const GOCMS_MIDDLEWARE_URL_SEGMENT = "middleware"
+
+// every token gocms signs has a type claim so a token issued for one purpose can't be used for another
+const TOKEN_TYPE_CLAIM = "typ"
+const TOKEN_TYPE_AUTH = "auth"
+const TOKEN_TYPE_DEVICE = "device"
+const TOKEN_TYPE_INVITATION = "invitation"
+const TOKEN_TYPE_USER_CONTEXT = "user_context"
@@ -44,3 +44,8 @@ func GetBoolOrFail(s string, settings map[string]setting_model.Setting) bool {
 	}
 	return b
 }
+
+// GetString is for settings where an empty value is allowed, usually to disable a feature.
+func GetString(s string, settings map[string]setting_model.Setting) string {
+	return settings[s].Value
+}
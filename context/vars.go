@@ -30,6 +30,8 @@ type dbVars struct {
 	PublicApiUrl        string
 	RedirectRootUrl     string
 	CorsHost            string
+	CorsAdminHost       string
+	CorsAllowCredentials bool
 	OpenRegistration    bool
 	SettingsRefreshRate int64
 
@@ -45,7 +47,51 @@ type dbVars struct {
 	UseTwoFactor           bool
 	PasswordComplexity     int64
 	PermissionsCacheLife   int64
+	UserPermissionsCacheLife int64
+	AclCachePollRate       int64
 	MicroserviceSecret	string
+	PasswordHashAlgorithm  string
+	Argon2Memory           int64
+	Argon2Iterations       int64
+	Argon2Parallelism      int64
+	BcryptCost             int64
+	PasswordMinLength      int64
+	PasswordHistoryCount   int64
+	PasswordMaxAge         int64
+	BreachedPasswordsDir   string
+	InvitationTimeout      int64
+	InvitationAcceptUrl    string
+	ImpersonationTimeout   int64
+	PluginRequestMaxAge    int64
+	UserContextTimeout     int64
+	AccountDeletionGraceDays int64
+	UserImportMaxRows      int64
+	UserDeletedRetentionDays int64
+	AuthCookies            bool
+	AuthCookieDomain       string
+	AuthCookieSecure       bool
+	AuthCookieSameSite     string
+
+	// Security Headers
+	FrameOptions          string
+	ReferrerPolicy        string
+	HstsMaxAge            int64
+	HstsIncludeSubdomains bool
+	CspMode               string
+	CspExtraSources       string
+	CspReportRetention    int64
+
+	// Storage
+	StorageBackend     string
+	StorageLocalDir    string
+	StoragePublicUrl   string
+	StorageS3Endpoint  string
+	StorageS3Region    string
+	StorageS3Bucket    string
+	StorageS3AccessKey string
+	StorageS3SecretKey string
+	UserPhotoMaxSize   int64
+	UserPhotoSizes     string
 
 	// rsa
 	rsaPriv             *rsa.PrivateKey
@@ -62,6 +108,8 @@ type dbVars struct {
 	// GoCMS
 	ActiveTheme           string
 	ActiveThemeAssetsBase string
+	DefaultLocale         string
+	EmailHeaderImage      string
 	LoginTitle            string
 	LoginSuccessRedirect  string
 	DisableDocumentationDisplay   bool
@@ -82,6 +130,8 @@ func (dbVars *dbVars) LoadDbVars(settings map[string]setting_model.Setting) {
 	dbVars.PublicApiUrl = GetStringOrFail("PUBLIC_API_URL", settings)
 	dbVars.RedirectRootUrl = GetStringOrFail("REDIRECT_ROOT_URL", settings)
 	dbVars.CorsHost = GetStringOrFail("CORS_HOST", settings)
+	dbVars.CorsAdminHost = GetString("CORS_ADMIN_HOST", settings)
+	dbVars.CorsAllowCredentials = GetBoolOrFail("CORS_ALLOW_CREDENTIALS", settings)
 	dbVars.SettingsRefreshRate = GetIntOrFail("SETTINGS_REFRESH_RATE", settings)
 
 	// Authentication
@@ -94,7 +144,51 @@ func (dbVars *dbVars) LoadDbVars(settings map[string]setting_model.Setting) {
 	dbVars.PasswordComplexity = GetIntOrFail("PASSWORD_COMPLEXITY", settings)
 	dbVars.OpenRegistration = GetBoolOrFail("OPEN_REGISTRATION", settings)
 	dbVars.PermissionsCacheLife = GetIntOrFail("PERMISSIONS_CACHE_LIFE", settings)
+	dbVars.UserPermissionsCacheLife = GetIntOrFail("USER_PERMISSIONS_CACHE_LIFE", settings)
+	dbVars.AclCachePollRate = GetIntOrFail("ACL_CACHE_POLL_RATE", settings)
 	dbVars.MicroserviceSecret = GetStringOrFail("MS_SECRET_KEY", settings)
+	dbVars.PasswordHashAlgorithm = GetStringOrFail("PASSWORD_HASH_ALGORITHM", settings)
+	dbVars.Argon2Memory = GetIntOrFail("ARGON2_MEMORY", settings)
+	dbVars.Argon2Iterations = GetIntOrFail("ARGON2_ITERATIONS", settings)
+	dbVars.Argon2Parallelism = GetIntOrFail("ARGON2_PARALLELISM", settings)
+	dbVars.BcryptCost = GetIntOrFail("BCRYPT_COST", settings)
+	dbVars.PasswordMinLength = GetIntOrFail("PASSWORD_MIN_LENGTH", settings)
+	dbVars.PasswordHistoryCount = GetIntOrFail("PASSWORD_HISTORY_COUNT", settings)
+	dbVars.PasswordMaxAge = GetIntOrFail("PASSWORD_MAX_AGE", settings)
+	dbVars.BreachedPasswordsDir = GetString("BREACHED_PASSWORDS_DIR", settings)
+	dbVars.InvitationTimeout = GetIntOrFail("INVITATION_TIMEOUT", settings)
+	dbVars.InvitationAcceptUrl = GetStringOrFail("INVITATION_ACCEPT_URL", settings)
+	dbVars.ImpersonationTimeout = GetIntOrFail("IMPERSONATION_TIMEOUT", settings)
+	dbVars.PluginRequestMaxAge = GetIntOrFail("PLUGIN_REQUEST_MAX_AGE", settings)
+	dbVars.UserContextTimeout = GetIntOrFail("USER_CONTEXT_TIMEOUT", settings)
+	dbVars.AccountDeletionGraceDays = GetIntOrFail("ACCOUNT_DELETION_GRACE_DAYS", settings)
+	dbVars.UserImportMaxRows = GetIntOrFail("USER_IMPORT_MAX_ROWS", settings)
+	dbVars.UserDeletedRetentionDays = GetIntOrFail("USER_DELETED_RETENTION_DAYS", settings)
+	dbVars.AuthCookies = GetBoolOrFail("AUTH_COOKIES", settings)
+	dbVars.AuthCookieDomain = GetString("AUTH_COOKIE_DOMAIN", settings)
+	dbVars.AuthCookieSecure = GetBoolOrFail("AUTH_COOKIE_SECURE", settings)
+	dbVars.AuthCookieSameSite = GetStringOrFail("AUTH_COOKIE_SAMESITE", settings)
+
+	// Security Headers
+	dbVars.FrameOptions = GetString("FRAME_OPTIONS", settings)
+	dbVars.ReferrerPolicy = GetString("REFERRER_POLICY", settings)
+	dbVars.HstsMaxAge = GetIntOrFail("HSTS_MAX_AGE", settings)
+	dbVars.HstsIncludeSubdomains = GetBoolOrFail("HSTS_INCLUDE_SUBDOMAINS", settings)
+	dbVars.CspMode = GetStringOrFail("CSP_MODE", settings)
+	dbVars.CspExtraSources = GetString("CSP_EXTRA_SOURCES", settings)
+	dbVars.CspReportRetention = GetIntOrFail("CSP_REPORT_RETENTION", settings)
+
+	// Storage
+	dbVars.StorageBackend = GetStringOrFail("STORAGE_BACKEND", settings)
+	dbVars.StorageLocalDir = GetStringOrFail("STORAGE_LOCAL_DIR", settings)
+	dbVars.StoragePublicUrl = GetString("STORAGE_PUBLIC_URL", settings)
+	dbVars.StorageS3Endpoint = GetString("STORAGE_S3_ENDPOINT", settings)
+	dbVars.StorageS3Region = GetString("STORAGE_S3_REGION", settings)
+	dbVars.StorageS3Bucket = GetString("STORAGE_S3_BUCKET", settings)
+	dbVars.StorageS3AccessKey = GetString("STORAGE_S3_ACCESS_KEY", settings)
+	dbVars.StorageS3SecretKey = GetString("STORAGE_S3_SECRET_KEY", settings)
+	dbVars.UserPhotoMaxSize = GetIntOrFail("USER_PHOTO_MAX_SIZE", settings)
+	dbVars.UserPhotoSizes = GetStringOrFail("USER_PHOTO_SIZES", settings)
 
 	// RSA
 	// rsa priv privKey
@@ -124,6 +218,8 @@ func (dbVars *dbVars) LoadDbVars(settings map[string]setting_model.Setting) {
 	// GoCMS
 	dbVars.ActiveTheme = GetStringOrFail("ACTIVE_THEME", settings)
 	dbVars.ActiveThemeAssetsBase = GetStringOrFail("ACTIVE_THEME_ASSETS_BASE", settings)
+	dbVars.DefaultLocale = GetStringOrFail("DEFAULT_LOCALE", settings)
+	dbVars.EmailHeaderImage = GetStringOrFail("EMAIL_HEADER_IMAGE", settings)
 	dbVars.LoginTitle = GetStringOrFail("GOCMS_LOGIN_TITLE", settings)
 	dbVars.LoginSuccessRedirect = GetStringOrFail("GOCMS_LOGIN_SUCCESS_REDIRECT", settings)
 	dbVars.DisableDocumentationDisplay = GetBoolOrFail("DISABLE_DOCUMENTATION_DISPLAY", settings)
@@ -0,0 +1,135 @@
+package access_control_controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_model"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/gin-gonic/gin"
+)
+
+type ExplainAdminController struct {
+	routes        *routes.Routes
+	servicesGroup *service.ServicesGroup
+	adminRoutes   *access_control_middleware.PermissionGroup
+}
+
+func DefaultExplainAdminController(routes *routes.Routes, sg *service.ServicesGroup) *ExplainAdminController {
+	explainAdminController := &ExplainAdminController{
+		routes:        routes,
+		servicesGroup: sg,
+		adminRoutes:   access_control_middleware.NewPermissionGroup(routes.Auth, "/admin/acl", sg.AclService, permissions.SUPER_ADMIN),
+	}
+
+	explainAdminController.Default()
+	return explainAdminController
+}
+
+func (eac *ExplainAdminController) Default() {
+	eac.adminRoutes.GET("/explain", eac.explain)
+	eac.adminRoutes.GET("/route", eac.getRoutes)
+}
+
+/**
+* @api {get} /admin/acl/explain?user=:user&apiKey=:apiKey&permission=:permission&method=:method&path=:path Explain Authorization
+* @apiName ExplainAuthorization
+* @apiGroup Admin ACL
+* @apiDescription Explain why a user is or isn't allowed a permission or route. Pass either permission, a permission
+* name or expression such as (blog.edit AND blog.publish) OR super_admin, or method and path of a route. The path can be a route pattern
+* such as /api/admin/group/:groupId or a url such as /api/admin/group/4. The same trace is available from the command
+* line with `gocms explain`. Disabled, deleted and banned users are always denied.
+*
+* @apiParam (Request) {string} user User id or email.
+* @apiParam (Request) {number} [apiKey] Id of one of the user's api keys. Only permissions in the scope of the key count.
+* @apiParam (Request) {string} [permission] Permission name or expression.
+* @apiParam (Request) {string} [method] Ex: GET
+* @apiParam (Request) {string} [path] Ex: /api/admin/group
+*
+* @apiUse AuthHeader
+* @apiUse Explanation
+* @apiPermission Admin
+ */
+func (eac *ExplainAdminController) explain(c *gin.Context) {
+
+	userId, ok := eac.getUserId(c)
+	if !ok {
+		return
+	}
+
+	var apiKeyId int64
+	if apiKey := c.Query("apiKey"); apiKey != "" {
+		var parseErr error
+		apiKeyId, parseErr = strconv.ParseInt(apiKey, 10, 64)
+		if parseErr != nil {
+			errors.Response(c, http.StatusBadRequest, "Invalid api key id.", parseErr)
+			return
+		}
+	}
+
+	var explanation *access_control_model.Explanation
+	var err error
+	if permission := c.Query("permission"); permission != "" {
+		requirement, parseErr := permissions.ParseExpression(permission)
+		if parseErr != nil {
+			errors.Response(c, http.StatusBadRequest, "Invalid permission expression.", parseErr)
+			return
+		}
+		explanation, err = eac.servicesGroup.AclService.Explain(userId, apiKeyId, requirement)
+	} else if c.Query("method") != "" && c.Query("path") != "" {
+		explanation, err = eac.servicesGroup.AclService.ExplainRoute(userId, apiKeyId, c.Query("method"), c.Query("path"))
+	} else {
+		errors.Response(c, http.StatusBadRequest, "Either permission or method and path are required.", nil)
+		return
+	}
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't explain authorization.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, explanation)
+}
+
+/**
+* @api {get} /admin/acl/route Get Protected Routes
+* @apiName GetProtectedRoutes
+* @apiGroup Admin ACL
+* @apiDescription Routes that require a permission, including plugin manifest routes.
+*
+* @apiUse AuthHeader
+* @apiSuccess (Response) {Object[]} routes
+* @apiSuccess (Response) {string} routes.method
+* @apiSuccess (Response) {string} routes.path
+* @apiSuccess (Response) {string[]} routes.permissions The user needs any one of these.
+* @apiSuccess (Response) {string} routes.source gocms or the id of the plugin that declares the route.
+* @apiPermission Admin
+ */
+func (eac *ExplainAdminController) getRoutes(c *gin.Context) {
+	c.JSON(http.StatusOK, eac.servicesGroup.AclService.GetRoutes())
+}
+
+// getUserId gets the user from the query by id or email
+func (eac *ExplainAdminController) getUserId(c *gin.Context) (int64, bool) {
+	user := strings.TrimSpace(c.Query("user"))
+	if user == "" {
+		errors.Response(c, http.StatusBadRequest, "user is required", nil)
+		return 0, false
+	}
+
+	userId, err := strconv.ParseInt(user, 10, 64)
+	if err == nil {
+		return userId, true
+	}
+
+	userByEmail, err := eac.servicesGroup.UserService.GetByEmail(user)
+	if err != nil {
+		errors.Response(c, http.StatusNotFound, "User not found.", err)
+		return 0, false
+	}
+	return userByEmail.Id, true
+}
@@ -5,23 +5,69 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/cqlcorp/gocms/context/consts"
 	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_service"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
 	"github.com/cqlcorp/gocms/utility/api_utility"
 	"github.com/cqlcorp/gocms/utility/errors"
 	"github.com/cqlcorp/gocms/utility/log"
 	"net/http"
 )
 
-// middleware
-func RequirePermission(aclService access_control_service.IAclService, permissions ...string) gin.HandlerFunc {
+// RequirePermission allows users holding any one of the permissions.
+func RequirePermission(aclService access_control_service.IAclService, permissionNames ...string) gin.HandlerFunc {
+	return RequireExpression(aclService, permissions.AnyOf(permissionNames...))
+}
+
+// RequireAllPermissions allows users holding every one of the permissions.
+func RequireAllPermissions(aclService access_control_service.IAclService, permissionNames ...string) gin.HandlerFunc {
+	return RequireExpression(aclService, permissions.AllOf(permissionNames...))
+}
+
+// RequirePermissionExpression allows users whose permissions meet the expression.
+// Ex: (blog.edit AND blog.publish) OR super_admin
+func RequirePermissionExpression(aclService access_control_service.IAclService, expression string) gin.HandlerFunc {
+	return RequireExpression(aclService, MustParseExpression(expression))
+}
+
+// MustParseExpression parses an expression when a route is registered. GoCMS doesn't start if the expression is
+// invalid or refers to a permission that doesn't exist, since the route could never be authorized.
+func MustParseExpression(expression string) permissions.Expression {
+	parsed, err := permissions.ParseExpression(expression)
+	if err != nil {
+		log.Criticalf("Invalid permission expression '%v': %s\n", expression, err.Error())
+	}
+	return parsed
+}
+
+func RequireExpression(aclService access_control_service.IAclService, expression permissions.Expression) gin.HandlerFunc {
+	allPermissions := aclService.GetPermissions()
+	for _, permissionName := range expression.Permissions() {
+		if _, ok := allPermissions[permissionName]; !ok {
+			log.Criticalf("Permission %v required by '%v' doesn't exist\n", permissionName, expression.String())
+		}
+	}
+
 	return func(c *gin.Context) {
 		authUser, _ := api_utility.GetUserFromContext(c)
-		for _, permission := range permissions {
-			isAuthorized, permissions, groups := aclService.IsAuthorizedWithContext(permission, authUser.Id)
+
+		var granted string
+		isAllowed := expression.Evaluate(func(permission string) bool {
+			// api keys can only use the permissions they were given
+			if !authUser.InApiKeyScope(permission) || !aclService.IsAuthorized(permission, authUser.Id) {
+				return false
+			}
+			if granted == "" {
+				granted = permission
+			}
+			return true
+		})
+
+		if isAllowed {
+			isAuthorized, userPermissions, groups := aclService.IsAuthorizedWithContext(granted, authUser.Id)
 			if isAuthorized {
 				// add permissions and roles to context
-				authUser.Permissions = permissions
+				authUser.Permissions = userPermissions
 				authUser.Groups = groups
-				log.Debugf("User %v has permissions %v\n", authUser.Id, permission)
+				log.Debugf("User %v meets permission requirement %v\n", authUser.Id, expression.String())
 				c.Set(consts.USER_KEY_FOR_GIN_CONTEXT, *authUser)
 				c.Next()
 				return
@@ -0,0 +1,57 @@
+package access_control_middleware
+
+import (
+	"path"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_model"
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_service"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionGroup is a route group with a permission requirement. Routes added to it are registered with the acl
+// service so admins can find out why a request was or wasn't authorized.
+type PermissionGroup struct {
+	*gin.RouterGroup
+	aclService  access_control_service.IAclService
+	requirement permissions.Expression
+}
+
+// NewPermissionGroup creates a group that requires any one of the permissions.
+func NewPermissionGroup(parent *gin.RouterGroup, relativePath string, aclService access_control_service.IAclService, permissionNames ...string) *PermissionGroup {
+	return NewExpressionGroup(parent, relativePath, aclService, permissions.AnyOf(permissionNames...))
+}
+
+// NewExpressionGroup creates a group that requires the permission expression to be met.
+func NewExpressionGroup(parent *gin.RouterGroup, relativePath string, aclService access_control_service.IAclService, requirement permissions.Expression) *PermissionGroup {
+	return &PermissionGroup{
+		RouterGroup: parent.Group(relativePath, RequireExpression(aclService, requirement)),
+		aclService:  aclService,
+		requirement: requirement,
+	}
+}
+
+func (pg *PermissionGroup) Handle(httpMethod string, relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	pg.aclService.RegisterRoute(httpMethod, path.Join(pg.BasePath(), relativePath), access_control_model.ROUTE_SOURCE_GOCMS, pg.requirement)
+	return pg.RouterGroup.Handle(httpMethod, relativePath, handlers...)
+}
+
+func (pg *PermissionGroup) GET(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return pg.Handle("GET", relativePath, handlers...)
+}
+
+func (pg *PermissionGroup) POST(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return pg.Handle("POST", relativePath, handlers...)
+}
+
+func (pg *PermissionGroup) PUT(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return pg.Handle("PUT", relativePath, handlers...)
+}
+
+func (pg *PermissionGroup) PATCH(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return pg.Handle("PATCH", relativePath, handlers...)
+}
+
+func (pg *PermissionGroup) DELETE(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return pg.Handle("DELETE", relativePath, handlers...)
+}
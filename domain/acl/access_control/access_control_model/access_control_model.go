@@ -0,0 +1,108 @@
+package access_control_model
+
+import (
+	"strings"
+)
+
+// Sources of route requirements.
+const (
+	ROUTE_SOURCE_GOCMS = "gocms"
+)
+
+// RouteRequirement is a route protected by a permission requirement.
+type RouteRequirement struct {
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Requirement string   `json:"requirement"`
+	Permissions []string `json:"permissions"`
+	Source      string   `json:"source"`
+}
+
+// MatchesRoute reports if the requirement is for the method and path. The path can be the registered pattern or a
+// concrete url such as /api/admin/group/4.
+func (rr *RouteRequirement) MatchesRoute(method string, path string) bool {
+	if !strings.EqualFold(rr.Method, method) {
+		return false
+	}
+
+	patternParts := strings.Split(strings.Trim(rr.Path, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, patternPart := range patternParts {
+		if strings.HasPrefix(patternPart, "*") {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if strings.HasPrefix(patternPart, ":") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if patternPart != pathParts[i] {
+			return false
+		}
+	}
+	return len(patternParts) == len(pathParts)
+}
+
+/**
+* @apiDefine Explanation
+* @apiSuccess (Response) {number} userId
+* @apiSuccess (Response) {number} [apiKeyId] Set when explaining a request made with one of the user's api keys.
+* @apiSuccess (Response) {string[]} [apiKeyScope] The permissions the api key is limited to.
+* @apiSuccess (Response) {Object} [route] The matched route when explaining a route.
+* @apiSuccess (Response) {string} route.method
+* @apiSuccess (Response) {string} route.path
+* @apiSuccess (Response) {string} route.requirement Ex: (blog.edit AND blog.publish) OR super_admin
+* @apiSuccess (Response) {string[]} route.permissions Every permission the requirement refers to.
+* @apiSuccess (Response) {string} route.source gocms or the id of the plugin whose manifest declares the route.
+* @apiSuccess (Response) {string} requirement The permission expression the user must meet.
+* @apiSuccess (Response) {string[]} required Every permission the requirement refers to.
+* @apiSuccess (Response) {boolean} allowed
+* @apiSuccess (Response) {string} reason
+* @apiSuccess (Response) {Object[]} groups Groups the user is in, directly or through a parent group.
+* @apiSuccess (Response) {number} groups.id
+* @apiSuccess (Response) {string} groups.name
+* @apiSuccess (Response) {boolean} groups.inherited True when the user is only in the group through a child group.
+* @apiSuccess (Response) {Object[]} grants Every permission the user holds and where it comes from.
+* @apiSuccess (Response) {string} grants.permission
+* @apiSuccess (Response) {string} grants.source "user" when assigned directly, "group" when inherited.
+* @apiSuccess (Response) {number} [grants.groupId]
+* @apiSuccess (Response) {string} [grants.groupName]
+* @apiSuccess (Response) {string[]} grants.satisfies Required permissions this grant gives.
+* @apiSuccess (Response) {string[]} trace Each step of the decision in order.
+ */
+type Explanation struct {
+	UserId      int64             `json:"userId"`
+	ApiKeyId    int64             `json:"apiKeyId,omitempty"`
+	ApiKeyScope []string          `json:"apiKeyScope,omitempty"`
+	Route       *RouteRequirement `json:"route,omitempty"`
+	Requirement string            `json:"requirement,omitempty"`
+	Required    []string          `json:"required"`
+	Allowed     bool              `json:"allowed"`
+	Reason      string            `json:"reason"`
+	Groups      []*ExplainedGroup `json:"groups"`
+	Grants      []*ExplainedGrant `json:"grants"`
+	Trace       []string          `json:"trace"`
+}
+
+type ExplainedGroup struct {
+	Id        int64  `json:"id"`
+	Name      string `json:"name"`
+	Inherited bool   `json:"inherited"`
+}
+
+type ExplainedGrant struct {
+	Permission string   `json:"permission"`
+	Source     string   `json:"source"`
+	GroupId    int64    `json:"groupId,omitempty"`
+	GroupName  string   `json:"groupName,omitempty"`
+	Satisfies  []string `json:"satisfies"`
+}
+
+// Step adds a line to the trace.
+func (explanation *Explanation) Step(step string) {
+	explanation.Trace = append(explanation.Trace, step)
+}
@@ -2,11 +2,16 @@ package access_control_service
 
 import (
 	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_model"
 	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_model"
 	"github.com/cqlcorp/gocms/init/repository"
 	"github.com/cqlcorp/gocms/utility/log"
+	"strconv"
+	"sync"
 	"time"
 	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
 )
 
 type IAclService interface {
@@ -14,17 +19,52 @@ type IAclService interface {
 	GetPermissions() map[string]permission_model.Permission
 	IsAuthorized(string, int64) bool
 	IsAuthorizedWithContext(permissionName string, userId int64) (bool, []*permission_model.Permission, []*group_model.Group)
+	CanDelegate(user *user_model.User, permissionNames ...string) bool
+
+	InvalidateUser(userId int64)
+	InvalidateAll()
+	PollCacheVersion()
+
+	RegisterRoute(method string, path string, source string, requirement permissions.Expression)
+	GetRoutes() []*access_control_model.RouteRequirement
+	Explain(userId int64, apiKeyId int64, requirement permissions.Expression) (*access_control_model.Explanation, error)
+	ExplainRoute(userId int64, apiKeyId int64, method string, path string) (*access_control_model.Explanation, error)
 }
 
+// ACL_CACHE_VERSION is the gocms_runtime row bumped whenever cached acl data changes. Instances poll it to find out
+// that another instance changed groups, permissions or memberships.
+const ACL_CACHE_VERSION = "ACL_CACHE_VERSION"
+
 type AclService struct {
 	Permissions       map[string]permission_model.Permission
 	permissionsAge    time.Time
 	RepositoriesGroup *repository.RepositoriesGroup
+
+	userCacheMutex sync.RWMutex
+	userCache      map[int64]*userAcl
+	generation     int64
+	cacheVersion   int64
+
+	routesMutex sync.RWMutex
+	routes      []*access_control_model.RouteRequirement
+}
+
+// userAcl is the cached effective permissions and direct groups of a user. Entries are replaced, never modified.
+type userAcl struct {
+	permissions []*permission_model.Permission
+	groups      []*group_model.Group
+	loaded      time.Time
 }
 
 func DefaultAclService(rg *repository.RepositoriesGroup) *AclService {
 	aclService := &AclService{
 		RepositoriesGroup: rg,
+		userCache:         make(map[int64]*userAcl),
+	}
+
+	version, err := aclService.getCacheVersion()
+	if err == nil {
+		aclService.cacheVersion = version
 	}
 
 	return aclService
@@ -67,7 +107,7 @@ func (as *AclService) IsAuthorizedWithContext(permissionName string, userId int6
 
 	// if authorized get users groups
 	if isAuthorized {
-		groups, err := as.RepositoriesGroup.GroupsRepository.GetUserGroups(userId)
+		groups, err := as.getUserGroups(userId)
 		if err != nil {
 			log.Errorf("Error getting users groups: %s\n", err.Error())
 			return false, nil, nil
@@ -86,20 +126,174 @@ func (as *AclService) IsAuthorized(permissionName string, userId int64) bool {
 	return isAuthorized
 }
 
+// CanDelegate reports if the user may hand the permissions to someone else. Super admins can delegate anything,
+// everyone else only permissions they hold and that are in scope for the api key they are using.
+func (as *AclService) CanDelegate(user *user_model.User, permissionNames ...string) bool {
+	if user.InApiKeyScope(permissions.SUPER_ADMIN) && as.IsAuthorized(permissions.SUPER_ADMIN, user.Id) {
+		return true
+	}
+	for _, permissionName := range permissionNames {
+		if !user.InApiKeyScope(permissionName) || !as.IsAuthorized(permissionName, user.Id) {
+			return false
+		}
+	}
+	return true
+}
+
 func (as *AclService) isAuthorized(permissionName string, userId int64) (bool, []*permission_model.Permission) {
+	cachedPermission, ok := as.GetPermissions()[permissionName] // use function to verify that cache is refreshed
+	if !ok {
+		log.Warningf("Permission %v doesn't exist\n", permissionName)
+		return false, nil
+	}
+
 	// get user permissions
-	permissions, err := as.RepositoriesGroup.PermissionsRepository.GetUserPermissions(userId)
+	userPermissions, err := as.getUserPermissions(userId)
 	if err != nil {
 		log.Errorf("Error getting users permissions: %s\n", err.Error())
 		return false, nil
 	}
 
-	// loop over permissions and see if they match the request one
-	for _, permission := range permissions {
-		cachedPermissions := as.GetPermissions() // use function to verify that cache is refreshed
-		if permission.Id == cachedPermissions[permissionName].Id {
-			return true, permissions
+	// loop over permissions and see if they match the request one, directly or through a wildcard or super_admin
+	for _, permission := range userPermissions {
+		if permission.Id == cachedPermission.Id || permissions.Matches(permission.Name, cachedPermission.Name) {
+			return true, userPermissions
 		}
 	}
 	return false, nil
 }
+
+// InvalidateUser drops the cached permissions of one user. Call it after changing the users permissions or groups.
+func (as *AclService) InvalidateUser(userId int64) {
+	as.userCacheMutex.Lock()
+	delete(as.userCache, userId)
+	as.generation++
+	as.userCacheMutex.Unlock()
+
+	as.bumpCacheVersion()
+}
+
+// InvalidateAll drops every cached user. Call it after changes that can affect many users such as group permissions,
+// parent groups or deleted permissions.
+func (as *AclService) InvalidateAll() {
+	as.clearUserCache()
+	as.bumpCacheVersion()
+}
+
+// PollCacheVersion clears the caches when another instance has changed acl data since the last poll.
+func (as *AclService) PollCacheVersion() {
+	version, err := as.getCacheVersion()
+	if err != nil {
+		return
+	}
+
+	as.userCacheMutex.Lock()
+	changed := version != as.cacheVersion
+	as.cacheVersion = version
+	as.userCacheMutex.Unlock()
+
+	if changed {
+		log.Debugf("Acl cache version changed to %v, clearing caches\n", version)
+		as.clearUserCache()
+		as.RefreshPermissionsCache()
+	}
+}
+
+func (as *AclService) getUserPermissions(userId int64) ([]*permission_model.Permission, error) {
+	entry := as.getUserAcl(userId)
+	if entry != nil {
+		return entry.permissions, nil
+	}
+
+	generation := as.getGeneration()
+	permissions, err := as.RepositoriesGroup.PermissionsRepository.GetUserPermissions(userId)
+	if err != nil {
+		return nil, err
+	}
+	as.setUserAcl(userId, generation, nil, &userAcl{permissions: permissions, loaded: time.Now()})
+	return permissions, nil
+}
+
+func (as *AclService) getUserGroups(userId int64) ([]*group_model.Group, error) {
+	entry := as.getUserAcl(userId)
+	if entry != nil && entry.groups != nil {
+		return entry.groups, nil
+	}
+
+	generation := as.getGeneration()
+	groups, err := as.RepositoriesGroup.GroupsRepository.GetUserGroups(userId)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		as.setUserAcl(userId, generation, entry, &userAcl{permissions: entry.permissions, groups: groups, loaded: entry.loaded})
+	}
+	return groups, nil
+}
+
+// getUserAcl returns the cached entry for the user or nil when there is none or it has expired.
+func (as *AclService) getUserAcl(userId int64) *userAcl {
+	as.userCacheMutex.RLock()
+	defer as.userCacheMutex.RUnlock()
+
+	entry, ok := as.userCache[userId]
+	if !ok || time.Since(entry.loaded).Seconds() > float64(context.Config.DbVars.UserPermissionsCacheLife) {
+		return nil
+	}
+	return entry
+}
+
+// setUserAcl caches the entry unless the cache was invalidated while it was being loaded, or it was meant to replace
+// an entry that is no longer cached.
+func (as *AclService) setUserAcl(userId int64, generation int64, replaces *userAcl, entry *userAcl) {
+	if context.Config.DbVars.UserPermissionsCacheLife <= 0 {
+		return
+	}
+
+	as.userCacheMutex.Lock()
+	defer as.userCacheMutex.Unlock()
+
+	if generation != as.generation {
+		return
+	}
+	if replaces != nil && as.userCache[userId] != replaces {
+		return
+	}
+	as.userCache[userId] = entry
+}
+
+func (as *AclService) getGeneration() int64 {
+	as.userCacheMutex.RLock()
+	defer as.userCacheMutex.RUnlock()
+	return as.generation
+}
+
+func (as *AclService) clearUserCache() {
+	as.userCacheMutex.Lock()
+	as.userCache = make(map[int64]*userAcl)
+	as.generation++
+	as.userCacheMutex.Unlock()
+}
+
+func (as *AclService) getCacheVersion() (int64, error) {
+	runtime, err := as.RepositoriesGroup.RuntimeRepository.GetByName(ACL_CACHE_VERSION)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(runtime.Value, 10, 64)
+}
+
+// bumpCacheVersion tells other instances to clear their caches. If no other instance bumped the version since the
+// last poll this instance is already up to date and doesn't need to clear its own caches again.
+func (as *AclService) bumpCacheVersion() {
+	version, err := as.RepositoriesGroup.RuntimeRepository.Increment(ACL_CACHE_VERSION)
+	if err != nil {
+		return
+	}
+
+	as.userCacheMutex.Lock()
+	if version == as.cacheVersion+1 {
+		as.cacheVersion = version
+	}
+	as.userCacheMutex.Unlock()
+}
@@ -0,0 +1,192 @@
+package access_control_service
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"github.com/cqlcorp/gocms/domain/acl/group/group_repository"
+	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_model"
+	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_repository"
+	"github.com/cqlcorp/gocms/domain/runtime/runtime_model"
+	"github.com/cqlcorp/gocms/domain/runtime/runtime_repository"
+	"github.com/cqlcorp/gocms/init/repository"
+)
+
+// fakePermissionsRepository gives user 1 "content.read", onLoad runs while user permissions are being loaded
+type fakePermissionsRepository struct {
+	permission_repository.IPermissionsRepository
+	loads  int
+	onLoad func()
+}
+
+func (f *fakePermissionsRepository) GetAll() (*[]permission_model.Permission, error) {
+	return &[]permission_model.Permission{{Id: 10, Name: "content.read"}, {Id: 11, Name: "content.write"}}, nil
+}
+
+func (f *fakePermissionsRepository) GetUserPermissions(userId int64) ([]*permission_model.Permission, error) {
+	f.loads++
+	if f.onLoad != nil {
+		f.onLoad()
+	}
+	if userId == 1 {
+		return []*permission_model.Permission{{Id: 10, Name: "content.read"}}, nil
+	}
+	return []*permission_model.Permission{}, nil
+}
+
+type fakeGroupsRepository struct {
+	group_repository.IGroupsRepository
+	loads int
+}
+
+func (f *fakeGroupsRepository) GetUserGroups(userId int64) ([]*group_model.Group, error) {
+	f.loads++
+	return []*group_model.Group{{Id: 1, Name: "Editors"}}, nil
+}
+
+// fakeRuntimeRepository is shared between services to stand in for instances using the same database
+type fakeRuntimeRepository struct {
+	runtime_repository.IRuntimeRepository
+	version int64
+}
+
+func (f *fakeRuntimeRepository) GetByName(name string) (*runtime_model.Runtime, error) {
+	return &runtime_model.Runtime{Name: name, Value: strconv.FormatInt(f.version, 10)}, nil
+}
+
+func (f *fakeRuntimeRepository) Increment(name string) (int64, error) {
+	f.version++
+	return f.version, nil
+}
+
+func testService(runtime *fakeRuntimeRepository) (*AclService, *fakePermissionsRepository, *fakeGroupsRepository) {
+	context.Config.DbVars.PermissionsCacheLife = 60
+	context.Config.DbVars.UserPermissionsCacheLife = 60
+
+	permissionsRepository := &fakePermissionsRepository{}
+	groupsRepository := &fakeGroupsRepository{}
+	return DefaultAclService(&repository.RepositoriesGroup{
+		PermissionsRepository: permissionsRepository,
+		GroupsRepository:      groupsRepository,
+		RuntimeRepository:     runtime,
+	}), permissionsRepository, groupsRepository
+}
+
+func TestUserCache(t *testing.T) {
+	as, repo, _ := testService(&fakeRuntimeRepository{})
+
+	steps := []struct {
+		name      string
+		before    func()
+		wantLoads int
+	}{
+		{"first check", nil, 1},
+		{"cached", nil, 1},
+		{"other user invalidated", func() { as.InvalidateUser(2) }, 1},
+		{"user invalidated", func() { as.InvalidateUser(1) }, 2},
+		{"cached again", nil, 2},
+		{"all invalidated", func() { as.InvalidateAll() }, 3},
+		{"expired", func() { as.userCache[1].loaded = time.Now().Add(-time.Hour) }, 4},
+	}
+	for _, step := range steps {
+		if step.before != nil {
+			step.before()
+		}
+		if !as.IsAuthorized("content.read", 1) {
+			t.Errorf("%v: IsAuthorized() = false", step.name)
+		}
+		if repo.loads != step.wantLoads {
+			t.Errorf("%v: loaded permissions %v times, want %v", step.name, repo.loads, step.wantLoads)
+		}
+	}
+
+	if as.IsAuthorized("content.write", 1) {
+		t.Error("IsAuthorized() = true for a permission the user doesn't have")
+	}
+}
+
+func TestUserCacheDisabled(t *testing.T) {
+	as, repo, _ := testService(&fakeRuntimeRepository{})
+	context.Config.DbVars.UserPermissionsCacheLife = 0
+
+	as.IsAuthorized("content.read", 1)
+	as.IsAuthorized("content.read", 1)
+	if repo.loads != 2 {
+		t.Errorf("loaded permissions %v times with the cache disabled, want 2", repo.loads)
+	}
+}
+
+// permissions loaded before an invalidation mustn't be cached after it
+func TestInvalidateWhileLoading(t *testing.T) {
+	as, repo, _ := testService(&fakeRuntimeRepository{})
+
+	repo.onLoad = func() { as.InvalidateUser(1) }
+	as.IsAuthorized("content.read", 1)
+	repo.onLoad = nil
+
+	as.IsAuthorized("content.read", 1)
+	if repo.loads != 2 {
+		t.Errorf("loaded permissions %v times, want 2 as the first load was invalidated", repo.loads)
+	}
+}
+
+func TestGroupsCachedWithPermissions(t *testing.T) {
+	as, _, groupsRepository := testService(&fakeRuntimeRepository{})
+
+	for i := 0; i < 2; i++ {
+		ok, permissions, groups := as.IsAuthorizedWithContext("content.read", 1)
+		if !ok || len(permissions) != 1 || len(groups) != 1 {
+			t.Fatalf("IsAuthorizedWithContext() = %v, %v, %v", ok, permissions, groups)
+		}
+	}
+	if groupsRepository.loads != 1 {
+		t.Errorf("loaded groups %v times, want 1", groupsRepository.loads)
+	}
+
+	as.InvalidateUser(1)
+	as.IsAuthorizedWithContext("content.read", 1)
+	if groupsRepository.loads != 2 {
+		t.Errorf("loaded groups %v times after invalidating, want 2", groupsRepository.loads)
+	}
+}
+
+func TestPollCacheVersion(t *testing.T) {
+	runtime := &fakeRuntimeRepository{version: 5}
+	first, firstRepo, _ := testService(runtime)
+	second, secondRepo, _ := testService(runtime)
+
+	first.IsAuthorized("content.read", 1)
+	second.IsAuthorized("content.read", 1)
+
+	// nothing changed
+	first.PollCacheVersion()
+	second.PollCacheVersion()
+	first.IsAuthorized("content.read", 1)
+	second.IsAuthorized("content.read", 1)
+	if firstRepo.loads != 1 || secondRepo.loads != 1 {
+		t.Fatalf("loaded permissions %v and %v times without changes, want 1", firstRepo.loads, secondRepo.loads)
+	}
+
+	// the first instance changes the user, the second finds out when it polls
+	first.InvalidateUser(1)
+	second.IsAuthorized("content.read", 1)
+	if secondRepo.loads != 1 {
+		t.Errorf("second instance loaded permissions %v times before polling, want 1", secondRepo.loads)
+	}
+	second.PollCacheVersion()
+	second.IsAuthorized("content.read", 1)
+	if secondRepo.loads != 2 {
+		t.Errorf("second instance loaded permissions %v times after polling, want 2", secondRepo.loads)
+	}
+
+	// the first instance already cleared its own cache and doesn't clear it again
+	first.IsAuthorized("content.read", 1)
+	first.PollCacheVersion()
+	first.IsAuthorized("content.read", 1)
+	if firstRepo.loads != 2 {
+		t.Errorf("first instance loaded permissions %v times, want 2", firstRepo.loads)
+	}
+}
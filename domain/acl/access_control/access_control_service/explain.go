@@ -0,0 +1,308 @@
+package access_control_service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_model"
+	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_model"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/utility/errors"
+)
+
+// RegisterRoute records the permissions a route requires so that authorization decisions can be explained. The
+// requirement is nil for routes that only need authentication.
+func (as *AclService) RegisterRoute(method string, path string, source string, requirement permissions.Expression) {
+	as.routesMutex.Lock()
+	defer as.routesMutex.Unlock()
+
+	route := &access_control_model.RouteRequirement{
+		Method: strings.ToUpper(method),
+		Path:   path,
+		Source: source,
+	}
+	if requirement != nil {
+		route.Requirement = requirement.String()
+		route.Permissions = requirement.Permissions()
+	}
+	as.routes = append(as.routes, route)
+}
+
+func (as *AclService) GetRoutes() []*access_control_model.RouteRequirement {
+	as.routesMutex.RLock()
+	defer as.routesMutex.RUnlock()
+
+	return append([]*access_control_model.RouteRequirement{}, as.routes...)
+}
+
+// ExplainRoute explains whether the user can use a route. The path can be the route pattern or a concrete url. When
+// apiKeyId isn't 0 the request is explained as if it was made with that key of the user.
+func (as *AclService) ExplainRoute(userId int64, apiKeyId int64, method string, path string) (*access_control_model.Explanation, error) {
+	var route *access_control_model.RouteRequirement
+	for _, registered := range as.GetRoutes() {
+		if registered.MatchesRoute(method, path) {
+			route = registered
+			break
+		}
+	}
+
+	if route == nil || route.Requirement == "" {
+		step := fmt.Sprintf("No permission requirements are registered for %v %v.", strings.ToUpper(method), path)
+		if route != nil {
+			step = fmt.Sprintf("Route %v %v is registered by %v without permissions.", route.Method, route.Path, route.Source)
+		}
+		explanation, err := as.explain(userId, apiKeyId, nil, step)
+		if err != nil {
+			return nil, err
+		}
+		explanation.Route = route
+		return explanation, nil
+	}
+
+	requirement, err := permissions.ParseExpression(route.Requirement)
+	if err != nil {
+		return nil, err
+	}
+	explanation, err := as.explain(userId, apiKeyId, requirement, fmt.Sprintf("Route %v %v, registered by %v, requires %v.", route.Method, route.Path, route.Source, route.Requirement))
+	if err != nil {
+		return nil, err
+	}
+	explanation.Route = route
+	return explanation, nil
+}
+
+// Explain traces how the user meets the permission requirement. It reads from the database rather than the caches so
+// it shows the current state. When apiKeyId isn't 0 only the permissions in the scope of that key of the user count.
+func (as *AclService) Explain(userId int64, apiKeyId int64, requirement permissions.Expression) (*access_control_model.Explanation, error) {
+	return as.explain(userId, apiKeyId, requirement, fmt.Sprintf("Requires %v.", requirement.String()))
+}
+
+// explain traces the decision for a requirement, a nil requirement only needs authentication. Users that can't
+// authenticate are always denied, whatever permissions they hold.
+func (as *AclService) explain(userId int64, apiKeyId int64, requirement permissions.Expression, steps ...string) (*access_control_model.Explanation, error) {
+	explanation := &access_control_model.Explanation{
+		UserId:   userId,
+		ApiKeyId: apiKeyId,
+		Groups: []*access_control_model.ExplainedGroup{},
+		Grants: []*access_control_model.ExplainedGrant{},
+		Trace:  steps,
+	}
+	var permissionNames []string
+	if requirement != nil {
+		permissionNames = requirement.Permissions()
+		explanation.Requirement = requirement.String()
+		explanation.Required = permissionNames
+	}
+
+	user, err := as.RepositoriesGroup.UsersRepository.Get(userId)
+	if err != nil {
+		return nil, errors.NewToUser(fmt.Sprintf("No user with id %v.", userId))
+	}
+	explanation.Step(fmt.Sprintf("User %v (%v) is %v.", user.Id, user.Email, enabledText(user.Enabled)))
+	blocked := blockedReason(user)
+
+	if apiKeyId != 0 {
+		apiKey, err := as.RepositoriesGroup.ApiKeyRepository.Get(apiKeyId)
+		if err != nil || apiKey.UserId != user.Id {
+			return nil, errors.NewToUser(fmt.Sprintf("User %v has no api key with id %v.", user.Id, apiKeyId))
+		}
+		apiKey.Permissions, err = as.RepositoriesGroup.ApiKeyRepository.GetPermissions(apiKey.Id)
+		if err != nil {
+			return nil, err
+		}
+		user.ApiKeyId = apiKey.Id
+		user.ApiKeyScope = apiKey.GetScope()
+		explanation.ApiKeyScope = user.ApiKeyScope
+		if len(user.ApiKeyScope) == 0 {
+			explanation.Step(fmt.Sprintf("Authenticated with api key %v (%v), which is limited to no permissions.", apiKey.Name, apiKey.Id))
+		} else {
+			explanation.Step(fmt.Sprintf("Authenticated with api key %v (%v), which is limited to %v.", apiKey.Name, apiKey.Id, strings.Join(user.ApiKeyScope, ", ")))
+		}
+		if blocked == "" && apiKey.IsExpired() {
+			blocked = "The api key has expired, so every request made with it is denied."
+		}
+	}
+
+	groupNames, err := as.explainGroups(explanation)
+	if err != nil {
+		return nil, err
+	}
+
+	userPermissions, err := as.RepositoriesGroup.PermissionsRepository.GetUserPermissions(userId)
+	if err != nil {
+		return nil, err
+	}
+	explanation.Grants = explainGrants(userPermissions, groupNames, permissionNames)
+	if len(explanation.Grants) == 0 {
+		explanation.Step("The user holds no permissions.")
+	}
+
+	if requirement == nil {
+		explanation.Allowed = true
+		explanation.Reason = "The route doesn't require a permission, only the authentication of its route group."
+		explanation.Step(explanation.Reason)
+		return denyBlocked(explanation, blocked), nil
+	}
+
+	// work out which of the referenced permissions the user has, then evaluate the requirement with them
+	allPermissions := as.GetPermissions()
+	held := make(map[string]bool, len(permissionNames))
+	var reasons []string
+	for _, permissionName := range permissionNames {
+		if _, ok := allPermissions[permissionName]; !ok {
+			explanation.Step(fmt.Sprintf("Permission %v doesn't exist, so nobody can be granted it.", permissionName))
+			continue
+		}
+		grant := findGrant(explanation.Grants, permissionName)
+		if grant == nil {
+			explanation.Step(fmt.Sprintf("No permission the user holds grants %v.", permissionName))
+			continue
+		}
+		reason := fmt.Sprintf("%v is granted by %v %v", permissionName, grant.Permission, grantSourceText(grant))
+		if !user.InApiKeyScope(permissionName) {
+			explanation.Step(reason + ", but it isn't in the scope of the api key.")
+			continue
+		}
+		held[permissionName] = true
+		reasons = append(reasons, reason)
+		explanation.Step(reason + ".")
+	}
+
+	explanation.Allowed = requirement.Evaluate(func(permission string) bool {
+		return held[permission]
+	})
+	if explanation.Allowed {
+		explanation.Reason = fmt.Sprintf("%v is met: %v.", requirement.String(), strings.Join(reasons, "; "))
+	} else if user.ApiKeyId != 0 {
+		explanation.Reason = fmt.Sprintf("The permissions the user holds within the scope of the api key don't meet %v.", requirement.String())
+	} else if len(permissionNames) == 1 {
+		explanation.Reason = fmt.Sprintf("The user doesn't hold %v, a wildcard covering it or super_admin.", permissionNames[0])
+	} else {
+		explanation.Reason = fmt.Sprintf("The permissions the user holds don't meet %v.", requirement.String())
+	}
+	explanation.Step(explanation.Reason)
+	return denyBlocked(explanation, blocked), nil
+}
+
+// blockedReason returns why the user can't authenticate at all, or an empty string when they can. It mirrors the checks
+// of the authentication middleware.
+func blockedReason(user *user_model.User) string {
+	if !user.Enabled {
+		return "The user is disabled, so every request is denied."
+	}
+	if user.IsDeleted() {
+		return "The user is deleted, so every request is denied."
+	}
+	if user.IsBanned() {
+		return "The user is banned, so every request is denied. " + user.BanMessage()
+	}
+	return ""
+}
+
+// denyBlocked ends the explanation with a deny step when the user or api key can't authenticate.
+func denyBlocked(explanation *access_control_model.Explanation, blocked string) *access_control_model.Explanation {
+	if blocked == "" {
+		return explanation
+	}
+	explanation.Allowed = false
+	explanation.Reason = blocked
+	explanation.Step(blocked)
+	return explanation
+}
+
+// findGrant returns the first grant that gives the permission
+func findGrant(grants []*access_control_model.ExplainedGrant, permissionName string) *access_control_model.ExplainedGrant {
+	for _, grant := range grants {
+		for _, satisfied := range grant.Satisfies {
+			if satisfied == permissionName {
+				return grant
+			}
+		}
+	}
+	return nil
+}
+
+// explainGroups adds the users groups to the explanation and returns the name of every group by id.
+func (as *AclService) explainGroups(explanation *access_control_model.Explanation) (map[int64]string, error) {
+	allGroups, err := as.RepositoriesGroup.GroupsRepository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	groupNames := make(map[int64]string, len(*allGroups))
+	for _, group := range *allGroups {
+		groupNames[group.Id] = group.Name
+	}
+
+	userGroups, err := as.RepositoriesGroup.GroupsRepository.GetUserGroups(explanation.UserId)
+	if err != nil {
+		return nil, err
+	}
+	links, err := as.RepositoriesGroup.GroupsRepository.GetParentLinks()
+	if err != nil {
+		return nil, err
+	}
+
+	direct := make(map[int64]bool, len(userGroups))
+	var groupIds []int64
+	for _, group := range userGroups {
+		direct[group.Id] = true
+		groupIds = append(groupIds, group.Id)
+	}
+	for _, groupId := range group_model.Ancestors(links, groupIds...) {
+		explanation.Groups = append(explanation.Groups, &access_control_model.ExplainedGroup{
+			Id:        groupId,
+			Name:      groupNames[groupId],
+			Inherited: !direct[groupId],
+		})
+		if direct[groupId] {
+			explanation.Step(fmt.Sprintf("User is a member of group %v (%v).", groupNames[groupId], groupId))
+		} else {
+			explanation.Step(fmt.Sprintf("User inherits group %v (%v) through a child group.", groupNames[groupId], groupId))
+		}
+	}
+	if len(userGroups) == 0 {
+		explanation.Step("User isn't a member of any group.")
+	}
+
+	return groupNames, nil
+}
+
+// explainGrants lists every permission the user holds and which of the required permissions each one gives.
+func explainGrants(userPermissions []*permission_model.Permission, groupNames map[int64]string, required []string) []*access_control_model.ExplainedGrant {
+	grants := []*access_control_model.ExplainedGrant{}
+	for _, permission := range userPermissions {
+		grant := &access_control_model.ExplainedGrant{
+			Permission: permission.Name,
+			Source:     permission_model.PERMISSION_SOURCE_USER,
+			Satisfies:  []string{},
+		}
+		if permission.InheritedFromGroupId != 0 {
+			grant.Source = permission_model.PERMISSION_SOURCE_GROUP
+			grant.GroupId = permission.InheritedFromGroupId
+			grant.GroupName = groupNames[permission.InheritedFromGroupId]
+		}
+		for _, permissionName := range required {
+			if permissions.Matches(permission.Name, permissionName) {
+				grant.Satisfies = append(grant.Satisfies, permissionName)
+			}
+		}
+		grants = append(grants, grant)
+	}
+	return grants
+}
+
+func grantSourceText(grant *access_control_model.ExplainedGrant) string {
+	if grant.Source == permission_model.PERMISSION_SOURCE_GROUP {
+		return fmt.Sprintf("through group %v (%v)", grant.GroupName, grant.GroupId)
+	}
+	return "assigned directly to the user"
+}
+
+func enabledText(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled and can't sign in"
+}
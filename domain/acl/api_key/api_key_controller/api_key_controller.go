@@ -0,0 +1,123 @@
+package api_key_controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_model"
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/impersonation/impersonation_middleware"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/api_utility"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"net/http"
+	"strconv"
+)
+
+type ApiKeyController struct {
+	routes        *routes.Routes
+	ServicesGroup *service.ServicesGroup
+}
+
+func DefaultApiKeyController(routes *routes.Routes, sg *service.ServicesGroup) *ApiKeyController {
+	apiKeyController := &ApiKeyController{
+		routes:        routes,
+		ServicesGroup: sg,
+	}
+	apiKeyController.Default()
+	return apiKeyController
+}
+
+func (akc *ApiKeyController) Default() {
+	akc.routes.Auth.POST("/user/api-key", api_key_middleware.RequireUserToken(), impersonation_middleware.BlockWhileImpersonating(), akc.add)
+	akc.routes.Auth.GET("/user/api-key", akc.getAll)
+	akc.routes.Auth.DELETE("/user/api-key/:apiKeyId", api_key_middleware.RequireUserToken(), impersonation_middleware.BlockWhileImpersonating(), akc.delete)
+}
+
+/**
+* @api {post} /user/api-key Create Api Key
+* @apiDescription Create a personal api key for scripts and CI jobs. Send it as Authorization: Bearer {token}.
+* The token is only returned once so store it somewhere safe. Api keys can't be used to create more api keys.
+* @apiName AddApiKey
+* @apiGroup User
+*
+* @apiUse AuthHeader
+* @apiUse ApiKeyInput
+* @apiUse ApiKeyDisplay
+* @apiPermission Authenticated
+ */
+func (akc *ApiKeyController) add(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	var apiKeyInput api_key_model.ApiKeyInput
+	err := c.BindJSON(&apiKeyInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	apiKey, token, err := akc.ServicesGroup.ApiKeyService.Create(authUser.Id, apiKeyInput.Name, apiKeyInput.Permissions, apiKeyInput.ExpiresIn)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't create api key.", err)
+		return
+	}
+
+	apiKeyDisplay := apiKey.GetApiKeyDisplay()
+	apiKeyDisplay.Token = token
+
+	c.JSON(http.StatusOK, apiKeyDisplay)
+}
+
+/**
+* @api {get} /user/api-key Get Api Keys
+* @apiName GetApiKeys
+* @apiGroup User
+*
+* @apiUse AuthHeader
+* @apiUse ApiKeyDisplay
+* @apiPermission Authenticated
+ */
+func (akc *ApiKeyController) getAll(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	apiKeys, err := akc.ServicesGroup.ApiKeyService.GetByUserId(authUser.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get api keys.", err)
+		return
+	}
+
+	apiKeyDisplays := make([]*api_key_model.ApiKeyDisplay, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		apiKeyDisplays[i] = apiKey.GetApiKeyDisplay()
+	}
+
+	c.JSON(http.StatusOK, apiKeyDisplays)
+}
+
+/**
+* @api {delete} /user/api-key/:apiKeyId Delete Api Key
+* @apiName DeleteApiKey
+* @apiGroup User
+*
+* @apiUse AuthHeader
+* @apiPermission Authenticated
+ */
+func (akc *ApiKeyController) delete(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	apiKeyId, err := strconv.ParseInt(c.Param("apiKeyId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Id Field", err)
+		return
+	}
+
+	err = akc.ServicesGroup.ApiKeyService.Delete(authUser.Id, apiKeyId)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't delete api key.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,21 @@
+package api_key_middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/cqlcorp/gocms/utility/api_utility"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"net/http"
+)
+
+// RequireUserToken stops account management, such as changing a password or email or managing api keys, from being
+// done with an api key. Those actions need the user's own login token.
+func RequireUserToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authUser, ok := api_utility.GetUserFromContext(c)
+		if ok && authUser.ApiKeyId != 0 {
+			errors.Response(c, http.StatusForbidden, "This action can't be done with an api key.", nil)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,83 @@
+package api_key_model
+
+import (
+	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_model"
+	"time"
+)
+
+// TOKEN_PREFIX starts every api key so they are easy to recognise in logs and secret scanners.
+const TOKEN_PREFIX = "gocms_"
+
+// ApiKey is a personal access token. Only a hash of the secret part of the token is stored.
+type ApiKey struct {
+	Id          int64      `db:"id"`
+	UserId      int64      `db:"userId"`
+	Name        string     `db:"name"`
+	Prefix      string     `db:"prefix"`
+	Hash        string     `db:"hash"`
+	Expires     *time.Time `db:"expires"`
+	LastUsed    *time.Time `db:"lastUsed"`
+	Created     time.Time  `db:"created"`
+	Permissions []*permission_model.Permission
+}
+
+// IsExpired reports if the key has an expiry that has passed.
+func (apiKey *ApiKey) IsExpired() bool {
+	return apiKey.Expires != nil && time.Now().After(*apiKey.Expires)
+}
+
+// GetScope returns the names of the permissions the key is limited to.
+func (apiKey *ApiKey) GetScope() []string {
+	scope := make([]string, len(apiKey.Permissions))
+	for i, permission := range apiKey.Permissions {
+		scope[i] = permission.Name
+	}
+	return scope
+}
+
+/**
+* @apiDefine ApiKeyDisplay
+* @apiSuccess (Response) {number} id
+* @apiSuccess (Response) {string} name
+* @apiSuccess (Response) {string} prefix Identifies the key without revealing it.
+* @apiSuccess (Response) {string[]} permissions The permissions the key is limited to.
+* @apiSuccess (Response) {string} [expires]
+* @apiSuccess (Response) {string} [lastUsed]
+* @apiSuccess (Response) {string} created
+* @apiSuccess (Response) {string} [token] The full key. Only returned when the key is created.
+ */
+type ApiKeyDisplay struct {
+	Id          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Permissions []string   `json:"permissions"`
+	Expires     *time.Time `json:"expires,omitempty"`
+	LastUsed    *time.Time `json:"lastUsed,omitempty"`
+	Created     time.Time  `json:"created"`
+	Token       string     `json:"token,omitempty"`
+}
+
+func (apiKey *ApiKey) GetApiKeyDisplay() *ApiKeyDisplay {
+	apiKeyDisplay := ApiKeyDisplay{
+		Id:          apiKey.Id,
+		Name:        apiKey.Name,
+		Prefix:      TOKEN_PREFIX + apiKey.Prefix,
+		Permissions: apiKey.GetScope(),
+		Expires:     apiKey.Expires,
+		LastUsed:    apiKey.LastUsed,
+		Created:     apiKey.Created,
+	}
+	return &apiKeyDisplay
+}
+
+/**
+* @apiDefine ApiKeyInput
+* @apiParam (Request) {string} name What the key is used for.
+* @apiParam (Request) {string[]} [permissions] Names of permissions the key may use. Must be permissions you have.
+* @apiParam (Request) {number} [expiresIn] Minutes until the key expires. Keys without an expiry last until deleted.
+ */
+type ApiKeyInput struct {
+	Name        string   `json:"name" binding:"required"`
+	Permissions []string `json:"permissions"`
+	ExpiresIn   int64    `json:"expiresIn"`
+}
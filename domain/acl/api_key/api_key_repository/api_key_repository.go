@@ -0,0 +1,137 @@
+package api_key_repository
+
+import (
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_model"
+	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_model"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type IApiKeyRepository interface {
+	Add(*api_key_model.ApiKey) error
+	Get(int64) (*api_key_model.ApiKey, error)
+	GetByPrefix(string) (*api_key_model.ApiKey, error)
+	GetByUserId(int64) ([]*api_key_model.ApiKey, error)
+	Delete(int64) error
+	SetLastUsed(int64) error
+	AddPermission(apiKeyId int64, permissionId int64) error
+	GetPermissions(apiKeyId int64) ([]*permission_model.Permission, error)
+}
+
+type ApiKeyRepository struct {
+	database *sqlx.DB
+}
+
+func DefaultApiKeyRepository(dbx *sqlx.DB) *ApiKeyRepository {
+	apiKeyRepository := &ApiKeyRepository{
+		database: dbx,
+	}
+
+	return apiKeyRepository
+}
+
+func (akr *ApiKeyRepository) Add(apiKey *api_key_model.ApiKey) error {
+	apiKey.Created = time.Now()
+	// insert row
+	result, err := akr.database.NamedExec(`
+	INSERT INTO gocms_api_keys (userId, name, prefix, hash, expires, created) VALUES (:userId, :name, :prefix, :hash, :expires, :created)
+	`, apiKey)
+	if err != nil {
+		log.Errorf("Error adding api key to database: %s\n", err.Error())
+		return err
+	}
+
+	// add id to api key object
+	id, _ := result.LastInsertId()
+	apiKey.Id = id
+
+	return nil
+}
+
+func (akr *ApiKeyRepository) Get(id int64) (*api_key_model.ApiKey, error) {
+	var apiKey api_key_model.ApiKey
+	err := akr.database.Get(&apiKey, `
+	SELECT * FROM gocms_api_keys WHERE id=?
+	`, id)
+	if err != nil {
+		log.Errorf("Error getting api key %v from database: %s\n", id, err.Error())
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+func (akr *ApiKeyRepository) GetByPrefix(prefix string) (*api_key_model.ApiKey, error) {
+	var apiKey api_key_model.ApiKey
+	err := akr.database.Get(&apiKey, `
+	SELECT * FROM gocms_api_keys WHERE prefix=?
+	`, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+func (akr *ApiKeyRepository) GetByUserId(userId int64) ([]*api_key_model.ApiKey, error) {
+	var apiKeys []*api_key_model.ApiKey
+	err := akr.database.Select(&apiKeys, `
+	SELECT * FROM gocms_api_keys WHERE userId=? ORDER BY created DESC
+	`, userId)
+	if err != nil {
+		log.Errorf("Error getting api keys for user %v from database: %s\n", userId, err.Error())
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (akr *ApiKeyRepository) Delete(id int64) error {
+	_, err := akr.database.Exec(`
+	DELETE FROM gocms_api_keys WHERE id=?
+	`, id)
+	if err != nil {
+		log.Errorf("Error deleting api key %v from database: %s\n", id, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (akr *ApiKeyRepository) SetLastUsed(id int64) error {
+	_, err := akr.database.Exec(`
+	UPDATE gocms_api_keys SET lastUsed=? WHERE id=?
+	`, time.Now(), id)
+	if err != nil {
+		log.Errorf("Error updating api key %v last used: %s\n", id, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (akr *ApiKeyRepository) AddPermission(apiKeyId int64, permissionId int64) error {
+	_, err := akr.database.NamedExec(`
+	INSERT INTO gocms_api_keys_to_permissions (apiKeyId, permissionId) VALUES (:apiKeyId, :permissionId)
+	`, map[string]interface{}{"apiKeyId": apiKeyId, "permissionId": permissionId})
+	if err != nil {
+		log.Errorf("Error adding permission %v to api key %v: %s\n", permissionId, apiKeyId, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (akr *ApiKeyRepository) GetPermissions(apiKeyId int64) ([]*permission_model.Permission, error) {
+	var permissions []*permission_model.Permission
+	err := akr.database.Select(&permissions, `
+	SELECT perms.id, perms.name, perms.description
+	FROM gocms_api_keys_to_permissions AS aktp
+	JOIN gocms_permissions AS perms
+	ON aktp.permissionId = perms.id
+	WHERE aktp.apiKeyId = ?
+	`, apiKeyId)
+	if err != nil {
+		log.Errorf("Error getting permissions for api key %v from database: %s\n", apiKeyId, err.Error())
+		return nil, err
+	}
+	return permissions, nil
+}
@@ -0,0 +1,166 @@
+package api_key_service
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_service"
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_model"
+	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/log"
+	"strings"
+	"time"
+)
+
+const (
+	prefixLength = 8
+	secretLength = 40
+)
+
+type IApiKeyService interface {
+	Create(userId int64, name string, permissionNames []string, expiresIn int64) (*api_key_model.ApiKey, string, error)
+	GetByUserId(int64) ([]*api_key_model.ApiKey, error)
+	Delete(userId int64, apiKeyId int64) error
+	Authenticate(string) (*api_key_model.ApiKey, error)
+}
+
+type ApiKeyService struct {
+	AclService        access_control_service.IAclService
+	RepositoriesGroup *repository.RepositoriesGroup
+}
+
+func DefaultApiKeyService(rg *repository.RepositoriesGroup, aclService *access_control_service.AclService) *ApiKeyService {
+	apiKeyService := &ApiKeyService{
+		AclService:        aclService,
+		RepositoriesGroup: rg,
+	}
+
+	return apiKeyService
+}
+
+// Create makes a new api key for the user limited to the given permissions, which the user must have. The returned
+// token is the only time the full key is available. expiresIn is in minutes, 0 for a key that doesn't expire.
+func (aks *ApiKeyService) Create(userId int64, name string, permissionNames []string, expiresIn int64) (*api_key_model.ApiKey, string, error) {
+
+	// scope can only be a subset of the users own permissions
+	allPermissions := aks.AclService.GetPermissions()
+	for _, permissionName := range permissionNames {
+		if _, ok := allPermissions[permissionName]; !ok {
+			return nil, "", errors.NewToUser(fmt.Sprintf("Permission %v doesn't exist.", permissionName))
+		}
+		if !aks.AclService.IsAuthorized(permissionName, userId) {
+			return nil, "", errors.NewToUser(fmt.Sprintf("You don't have the permission %v.", permissionName))
+		}
+	}
+
+	prefix, err := utility.GenerateRandomString(prefixLength)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := utility.GenerateRandomString(secretLength)
+	if err != nil {
+		return nil, "", err
+	}
+
+	apiKey := &api_key_model.ApiKey{
+		UserId: userId,
+		Name:   name,
+		Prefix: prefix,
+		Hash:   hashSecret(secret),
+	}
+	if expiresIn > 0 {
+		expires := time.Now().Add(time.Minute * time.Duration(expiresIn))
+		apiKey.Expires = &expires
+	}
+
+	err = aks.RepositoriesGroup.ApiKeyRepository.Add(apiKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, permissionName := range permissionNames {
+		permission := allPermissions[permissionName]
+		err = aks.RepositoriesGroup.ApiKeyRepository.AddPermission(apiKey.Id, permission.Id)
+		if err != nil {
+			return nil, "", err
+		}
+		apiKey.Permissions = append(apiKey.Permissions, &permission)
+	}
+
+	return apiKey, api_key_model.TOKEN_PREFIX + prefix + secret, nil
+}
+
+func (aks *ApiKeyService) GetByUserId(userId int64) ([]*api_key_model.ApiKey, error) {
+	apiKeys, err := aks.RepositoriesGroup.ApiKeyRepository.GetByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, apiKey := range apiKeys {
+		apiKey.Permissions, err = aks.RepositoriesGroup.ApiKeyRepository.GetPermissions(apiKey.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return apiKeys, nil
+}
+
+// Delete removes an api key owned by the user.
+func (aks *ApiKeyService) Delete(userId int64, apiKeyId int64) error {
+	apiKey, err := aks.RepositoriesGroup.ApiKeyRepository.Get(apiKeyId)
+	if err != nil {
+		return err
+	}
+
+	if apiKey.UserId != userId {
+		return errors.NewToUser("You can only delete your own api keys.")
+	}
+
+	return aks.RepositoriesGroup.ApiKeyRepository.Delete(apiKeyId)
+}
+
+// Authenticate finds the api key for a token and verifies it hasn't expired.
+func (aks *ApiKeyService) Authenticate(token string) (*api_key_model.ApiKey, error) {
+
+	if !strings.HasPrefix(token, api_key_model.TOKEN_PREFIX) || len(token) != len(api_key_model.TOKEN_PREFIX)+prefixLength+secretLength {
+		return nil, errors.New("Api key is not in the expected format.")
+	}
+	token = strings.TrimPrefix(token, api_key_model.TOKEN_PREFIX)
+	prefix, secret := token[:prefixLength], token[prefixLength:]
+
+	apiKey, err := aks.RepositoriesGroup.ApiKeyRepository.GetByPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(apiKey.Hash), []byte(hashSecret(secret))) != 1 {
+		return nil, errors.New("Api key doesn't match.")
+	}
+
+	if apiKey.IsExpired() {
+		return nil, errors.New("Api key has expired.")
+	}
+
+	apiKey.Permissions, err = aks.RepositoriesGroup.ApiKeyRepository.GetPermissions(apiKey.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = aks.RepositoriesGroup.ApiKeyRepository.SetLastUsed(apiKey.Id)
+	if err != nil { // log error but don't fail
+		log.Errorf("Error setting api key %v last used: %s\n", apiKey.Id, err.Error())
+	}
+
+	return apiKey, nil
+}
+
+// hashSecret uses sha256 rather than the password hasher. The secret is random and long so a slow hash adds nothing,
+// and it is checked on every request.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
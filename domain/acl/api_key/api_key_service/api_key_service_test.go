@@ -0,0 +1,183 @@
+package api_key_service
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_service"
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_model"
+	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_model"
+	"github.com/cqlcorp/gocms/init/repository"
+)
+
+// fakeAclService knows two permissions, user 1 has only "content.read"
+type fakeAclService struct {
+	access_control_service.IAclService
+}
+
+func (fakeAclService) GetPermissions() map[string]permission_model.Permission {
+	return map[string]permission_model.Permission{
+		"content.read":  {Id: 10, Name: "content.read"},
+		"content.write": {Id: 11, Name: "content.write"},
+	}
+}
+
+func (fakeAclService) IsAuthorized(permissionName string, userId int64) bool {
+	return userId == 1 && permissionName == "content.read"
+}
+
+type fakeApiKeyRepository struct {
+	apiKeys     map[int64]*api_key_model.ApiKey
+	permissions map[int64][]*permission_model.Permission
+	deleted     []int64
+}
+
+func (f *fakeApiKeyRepository) Add(apiKey *api_key_model.ApiKey) error {
+	apiKey.Id = int64(len(f.apiKeys) + 1)
+	f.apiKeys[apiKey.Id] = apiKey
+	return nil
+}
+
+func (f *fakeApiKeyRepository) Get(id int64) (*api_key_model.ApiKey, error) {
+	apiKey, ok := f.apiKeys[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return apiKey, nil
+}
+
+func (f *fakeApiKeyRepository) GetByPrefix(prefix string) (*api_key_model.ApiKey, error) {
+	for _, apiKey := range f.apiKeys {
+		if apiKey.Prefix == prefix {
+			return apiKey, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeApiKeyRepository) GetByUserId(userId int64) ([]*api_key_model.ApiKey, error) {
+	return nil, nil
+}
+
+func (f *fakeApiKeyRepository) Delete(id int64) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func (f *fakeApiKeyRepository) SetLastUsed(id int64) error {
+	return nil
+}
+
+func (f *fakeApiKeyRepository) AddPermission(apiKeyId int64, permissionId int64) error {
+	f.permissions[apiKeyId] = append(f.permissions[apiKeyId], &permission_model.Permission{Id: permissionId})
+	return nil
+}
+
+func (f *fakeApiKeyRepository) GetPermissions(apiKeyId int64) ([]*permission_model.Permission, error) {
+	return f.permissions[apiKeyId], nil
+}
+
+func testService() (*ApiKeyService, *fakeApiKeyRepository) {
+	repo := &fakeApiKeyRepository{
+		apiKeys:     map[int64]*api_key_model.ApiKey{},
+		permissions: map[int64][]*permission_model.Permission{},
+	}
+	return &ApiKeyService{
+		AclService:        fakeAclService{},
+		RepositoriesGroup: &repository.RepositoriesGroup{ApiKeyRepository: repo},
+	}, repo
+}
+
+func TestCreateScope(t *testing.T) {
+	tests := []struct {
+		name        string
+		userId      int64
+		permissions []string
+		valid       bool
+	}{
+		{"held permission", 1, []string{"content.read"}, true},
+		{"no permissions", 1, nil, true},
+		{"permission not held", 1, []string{"content.write"}, false},
+		{"one permission not held", 1, []string{"content.read", "content.write"}, false},
+		{"unknown permission", 1, []string{"content.delete"}, false},
+		{"other user", 2, []string{"content.read"}, false},
+	}
+	for _, test := range tests {
+		service, repo := testService()
+		apiKey, token, err := service.Create(test.userId, "test", test.permissions, 0)
+		if (err == nil) != test.valid {
+			t.Errorf("%v: Create(%v) error = %v, want valid %v", test.name, test.permissions, err, test.valid)
+			continue
+		}
+		if !test.valid {
+			if len(repo.apiKeys) != 0 {
+				t.Errorf("%v: Create() stored a key it refused", test.name)
+			}
+			continue
+		}
+		if len(apiKey.Permissions) != len(test.permissions) || len(repo.permissions[apiKey.Id]) != len(test.permissions) {
+			t.Errorf("%v: key has permissions %v, want %v", test.name, apiKey.GetScope(), test.permissions)
+		}
+		if !strings.HasPrefix(token, api_key_model.TOKEN_PREFIX+apiKey.Prefix) || strings.Contains(apiKey.Hash, token[len(api_key_model.TOKEN_PREFIX)+prefixLength:]) {
+			t.Errorf("%v: token %q doesn't match the stored key", test.name, token)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	service, repo := testService()
+	_, token, err := service.Create(1, "test", []string{"content.read"}, 0)
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	_, expiredToken, err := service.Create(1, "expired", nil, 0)
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	expired := time.Now().Add(-time.Minute)
+	repo.apiKeys[2].Expires = &expired
+
+	wrongSecret := token[:len(token)-1] + "x"
+	if wrongSecret == token {
+		wrongSecret = token[:len(token)-1] + "y"
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"valid", token, true},
+		{"expired", expiredToken, false},
+		{"wrong secret", wrongSecret, false},
+		{"missing prefix", strings.TrimPrefix(token, api_key_model.TOKEN_PREFIX), false},
+		{"too short", token[:len(token)-1], false},
+		{"unknown key", api_key_model.TOKEN_PREFIX + strings.Repeat("a", prefixLength+secretLength), false},
+	}
+	for _, test := range tests {
+		apiKey, err := service.Authenticate(test.token)
+		if (err == nil) != test.valid {
+			t.Errorf("%v: Authenticate() error = %v, want valid %v", test.name, err, test.valid)
+		}
+		if test.valid && (apiKey.Id != 1 || len(apiKey.Permissions) != 1) {
+			t.Errorf("%v: Authenticate() = key %v with %v permissions, want key 1 with 1", test.name, apiKey.Id, len(apiKey.Permissions))
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	service, repo := testService()
+	apiKey, _, _ := service.Create(1, "test", nil, 0)
+
+	if err := service.Delete(2, apiKey.Id); err == nil {
+		t.Error("Delete() let another user delete the key")
+	}
+	if err := service.Delete(1, apiKey.Id); err != nil {
+		t.Errorf("Delete() error: %v", err)
+	}
+	if len(repo.deleted) != 1 || repo.deleted[0] != apiKey.Id {
+		t.Errorf("deleted %v, want [%v]", repo.deleted, apiKey.Id)
+	}
+}
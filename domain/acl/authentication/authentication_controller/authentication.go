@@ -3,16 +3,22 @@ package authentication_controller
 import (
 	"github.com/dgrijalva/jwt-go"
 	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/context/consts"
 	"github.com/cqlcorp/gocms/init/service"
 	"github.com/cqlcorp/gocms/routes"
 	"github.com/cqlcorp/gocms/utility"
 	"time"
 	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
 )
 
 const (
-	REDIRECT_LOGIN         = "login"
-	REDIRECT_VERIFY_DEVICE = "verifyDevice"
+	REDIRECT_LOGIN            = "login"
+	REDIRECT_VERIFY_DEVICE    = "verifyDevice"
+	REDIRECT_EXPIRED_PASSWORD = "expiredPassword"
 )
 
 type AuthController struct {
@@ -41,11 +47,14 @@ func DefaultAuthController(routes *routes.Routes, sg *service.ServicesGroup) *Au
 func (ac *AuthController) Default() {
 	ac.routes.Public.POST("/register", ac.register)
 	ac.routes.Public.POST("/login", ac.login)
+	ac.routes.Public.POST("/logout", ac.logout)
 	ac.routes.Public.POST("/login/facebook", ac.loginFacebook)
 	ac.routes.Public.POST("/login/google", ac.loginGoogle)
 	ac.routes.Public.POST("/reset-password", ac.resetPassword)
 	ac.routes.Public.PUT("/reset-password", ac.setPassword)
+	ac.routes.Public.PUT("/expired-password", ac.changeExpiredPassword)
 	ac.routes.Auth.GET("/verify", ac.verifyUser)
+	ac.routes.Root.GET("/.well-known/jwks.json", ac.getJwks)
 
 	if context.Config.DbVars.UseTwoFactor {
 		ac.routes.PreTwofactor.GET("/verify-device", ac.getDeviceCode)
@@ -63,6 +72,7 @@ func (ac *AuthController) createToken(userId int64) (string, error) {
 
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
 		"userId": userId,
+		consts.TOKEN_TYPE_CLAIM: consts.TOKEN_TYPE_AUTH,
 		"iat": time.Now().Unix(),
 		"exp": expire.Unix() * 1000, // get milliseconds,
 
@@ -75,3 +85,17 @@ func (ac *AuthController) createToken(userId int64) (string, error) {
 
 	return tokenString, nil
 }
+
+// rejectBlockedUser responds and returns true when the user can't log in because they were deleted or banned. Deleted
+// users are rejected like unknown users.
+func rejectBlockedUser(c *gin.Context, user *user_model.User) bool {
+	if user.IsDeleted() {
+		errors.ResponseWithSoftRedirect(c, http.StatusUnauthorized, errors.ApiError_Bad_Email_Password, REDIRECT_LOGIN)
+		return true
+	}
+	if user.IsBanned() {
+		errors.ResponseWithSoftRedirect(c, http.StatusForbidden, user.BanMessage(), REDIRECT_LOGIN)
+		return true
+	}
+	return false
+}
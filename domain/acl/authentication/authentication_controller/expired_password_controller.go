@@ -0,0 +1,57 @@
+package authentication_controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"net/http"
+	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_model"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_model"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_service"
+)
+
+/**
+* @api {put} /expired-password Change Expired Password
+* @apiDescription Used when login responds with the expiredPassword redirect. After the password is changed the user can login as normal.
+* @apiName ChangeExpiredPassword
+* @apiGroup Authentication
+*
+* @apiUse ExpiredPasswordInput
+ */
+func (ac *AuthController) changeExpiredPassword(c *gin.Context) {
+
+	var expiredPasswordInput authentication_model.ExpiredPasswordInput
+	err := c.BindJSON(&expiredPasswordInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	// auth user with the expired password
+	user, authed := ac.ServicesGroup.AuthService.AuthUser(expiredPasswordInput.Email, expiredPasswordInput.Password)
+	if !authed || !user.Enabled {
+		errors.ResponseWithSoftRedirect(c, http.StatusUnauthorized, errors.ApiError_Bad_Email_Password, REDIRECT_LOGIN)
+		return
+	}
+	if rejectBlockedUser(c, user) {
+		return
+	}
+
+	// only expired passwords can be changed without logging in
+	if !ac.ServicesGroup.PasswordPolicyService.IsExpired(user) {
+		errors.ResponseWithSoftRedirect(c, http.StatusBadRequest, "Password has not expired.", REDIRECT_LOGIN)
+		return
+	}
+
+	// change password
+	err = ac.ServicesGroup.UserService.UpdatePassword(user.Id, expiredPasswordInput.NewPassword)
+	if fieldErrors, ok := err.(errors.FieldErrors); ok {
+		errors.ResponseWithFieldErrors(c, http.StatusBadRequest, password_policy_model.ApiError_Password_Policy, fieldErrors.Rename(password_policy_service.PASSWORD_FIELD, "newPassword"))
+		return
+	}
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't change password.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,23 @@
+package authentication_controller
+
+import (
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/utility/gocms_plugin_util/user_context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+/**
+* @api {get} /.well-known/jwks.json Get Json Web Key Set
+* @apiDescription Public keys used to verify tokens signed by gocms, such as the X-GOCMS-USER-CONTEXT token forwarded to plugins.
+* @apiName GetJwks
+* @apiGroup Authentication
+ */
+func (ac *AuthController) getJwks(c *gin.Context) {
+	jwks := user_context.JWKS{
+		Keys: []*user_context.JWK{user_context.NewJWK(context.Config.DbVars.RSAPub)},
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
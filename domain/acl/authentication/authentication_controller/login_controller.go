@@ -5,6 +5,8 @@ import (
 	"github.com/cqlcorp/gocms/utility/errors"
 	"net/http"
 	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_model"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_model"
+	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_cookie"
 )
 
 /**
@@ -33,6 +35,11 @@ func (ac *AuthController) login(c *gin.Context) {
 		return
 	}
 
+	// deleted and banned users can't log in
+	if rejectBlockedUser(c, user) {
+		return
+	}
+
 	// verify user is enabled
 	if !user.Enabled {
 		errors.ResponseWithSoftRedirect(c, http.StatusUnauthorized, errors.ApiError_Bad_Email_Password, REDIRECT_LOGIN)
@@ -46,6 +53,12 @@ func (ac *AuthController) login(c *gin.Context) {
 		return
 	}
 
+	// verify password hasn't expired
+	if ac.ServicesGroup.PasswordPolicyService.IsExpired(user) {
+		errors.ResponseWithSoftRedirect(c, http.StatusForbidden, password_policy_model.ApiError_Password_Expired, REDIRECT_EXPIRED_PASSWORD)
+		return
+	}
+
 	// create token
 	tokenString, err := ac.createToken(user.Id)
 	if err != nil {
@@ -53,7 +66,7 @@ func (ac *AuthController) login(c *gin.Context) {
 		return
 	}
 
-	c.Header("X-AUTH-TOKEN", tokenString)
+	authentication_cookie.SetAuthToken(c, tokenString)
 
 	c.JSON(http.StatusOK, user.GetUserDisplay())
 	return
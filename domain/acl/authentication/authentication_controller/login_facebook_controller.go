@@ -10,6 +10,7 @@ import (
 	"github.com/cqlcorp/gocms/utility/rest"
 	"net/http"
 	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_cookie"
 )
 
 type fbData struct {
@@ -79,6 +80,11 @@ func (ac *AuthController) loginFacebook(c *gin.Context) {
 		return
 	}
 
+	// deleted and banned users can't log in
+	if user != nil && rejectBlockedUser(c, user) {
+		return
+	}
+
 	// if user doesn't exist and registration is closed reject
 	if user == nil && !context.Config.DbVars.OpenRegistration {
 		errors.ResponseWithSoftRedirect(c, http.StatusUnauthorized, "Registration Is Closed.", REDIRECT_LOGIN)
@@ -116,7 +122,10 @@ func (ac *AuthController) loginFacebook(c *gin.Context) {
 	// merge facebook data into account
 	user.MaxAge = me.AgeRange.Max
 	user.MinAge = me.AgeRange.Min
-	user.Photo = me.Picture.Data.Url
+	// an uploaded photo takes precedence
+	if user.PhotoKey == "" {
+		user.Photo = me.Picture.Data.Url
+	}
 	user.FullName = me.Name
 
 	// set gender
@@ -143,7 +152,7 @@ func (ac *AuthController) loginFacebook(c *gin.Context) {
 		return
 	}
 
-	c.Header("X-AUTH-TOKEN", tokenString)
+	authentication_cookie.SetAuthToken(c, tokenString)
 
 	c.JSON(http.StatusOK, user.GetUserDisplay())
 	return
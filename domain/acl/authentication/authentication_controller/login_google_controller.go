@@ -13,6 +13,7 @@ import (
 	"github.com/cqlcorp/gocms/domain/user/user_model"
 	"github.com/cqlcorp/gocms/utility/log"
 	"github.com/cqlcorp/gocms/utility/rest"
+	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_cookie"
 )
 
 type gImage struct {
@@ -85,6 +86,11 @@ func (ac *AuthController) loginGoogle(c *gin.Context) {
 		return
 	}
 
+	// deleted and banned users can't log in
+	if user != nil && rejectBlockedUser(c, user) {
+		return
+	}
+
 	// if user doesn't exist and registration is closed reject
 	if user == nil && !context.Config.DbVars.OpenRegistration {
 		errors.ResponseWithSoftRedirect(c, http.StatusUnauthorized, "Registration Is Closed.", REDIRECT_LOGIN)
@@ -122,7 +128,10 @@ func (ac *AuthController) loginGoogle(c *gin.Context) {
 	// merge in google data
 	user.MaxAge = me.AgeRange.Max
 	user.MinAge = me.AgeRange.Min
-	user.Photo = strings.Replace(me.Picture.Url, "?sz=50", "", -1)
+	// an uploaded photo takes precedence
+	if user.PhotoKey == "" {
+		user.Photo = strings.Replace(me.Picture.Url, "?sz=50", "", -1)
+	}
 	user.FullName = me.Name
 
 	// update user with merged data
@@ -140,7 +149,7 @@ func (ac *AuthController) loginGoogle(c *gin.Context) {
 		return
 	}
 
-	c.Header("X-AUTH-TOKEN", tokenString)
+	authentication_cookie.SetAuthToken(c, tokenString)
 
 	c.JSON(http.StatusOK, user.GetUserDisplay())
 	return
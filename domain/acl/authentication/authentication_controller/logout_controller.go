@@ -0,0 +1,20 @@
+package authentication_controller
+
+import (
+	"net/http"
+
+	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_cookie"
+	"github.com/gin-gonic/gin"
+)
+
+/**
+* @api {post} /logout Logout
+* @apiName Logout
+* @apiGroup Authentication
+* @apiDescription Removes the auth cookies of a browser client in cookie mode. The cookies are HttpOnly so the client
+* can't remove them itself. Clients using the x-auth-token header just discard their token.
+ */
+func (ac *AuthController) logout(c *gin.Context) {
+	authentication_cookie.Clear(c)
+	c.Status(http.StatusOK)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/cqlcorp/gocms/context"
 	"github.com/cqlcorp/gocms/domain/user/user_model"
 	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_model"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_model"
 )
 
 /**
@@ -22,7 +23,7 @@ import (
 func (auc *AuthController) register(c *gin.Context) {
 
 	if !context.Config.DbVars.OpenRegistration {
-		errors.ResponseWithSoftRedirect(c, http.StatusUnauthorized, "Registration Is Closed.", REDIRECT_LOGIN)
+		errors.ResponseWithSoftRedirect(c, http.StatusUnauthorized, "Registration Is Closed. An invitation is required.", REDIRECT_LOGIN)
 		return
 	}
 
@@ -43,6 +44,10 @@ func (auc *AuthController) register(c *gin.Context) {
 
 	// add user
 	err = auc.ServicesGroup.UserService.Add(user)
+	if fieldErrors, ok := err.(errors.FieldErrors); ok {
+		errors.ResponseWithFieldErrors(c, http.StatusBadRequest, password_policy_model.ApiError_Password_Policy, fieldErrors)
+		return
+	}
 	if err != nil {
 		errors.Response(c, http.StatusInternalServerError, errors.ApiError_Server, err)
 		return
@@ -5,6 +5,7 @@ import (
 	"github.com/cqlcorp/gocms/utility/errors"
 	"net/http"
 	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_model"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_model"
 	"github.com/cqlcorp/gocms/utility/log"
 )
 
@@ -52,20 +53,27 @@ func (ac *AuthController) setPassword(c *gin.Context) {
 		return
 	}
 
-	// verify password complexity
-	if !ac.ServicesGroup.AuthService.PasswordIsComplex(resetPassword.Password) {
-		errors.Response(c, http.StatusBadRequest, "Password is not complex enough.", err)
+	// get user, deleted users can't reset their password
+	user, err := ac.ServicesGroup.UserService.GetActiveByEmail(resetPassword.Email)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't reset password.", err)
 		return
 	}
 
-	// get user
-	user, err := ac.ServicesGroup.UserService.GetByEmail(resetPassword.Email)
-	if err != nil {
-		errors.Response(c, http.StatusBadRequest, "Couldn't reset password.", err)
+	// check the code before the password, the policy compares it with the current and previous passwords so it must
+	// only run for someone holding a valid code
+	if ok := ac.ServicesGroup.AuthService.CheckPasswordResetCode(user.Id, resetPassword.ResetCode); !ok {
+		errors.ResponseWithSoftRedirect(c, http.StatusUnauthorized, "Error resetting password.", REDIRECT_LOGIN)
 		return
 	}
 
-	// verify code
+	// verify password policy before the code is used up
+	if fieldErrors := ac.ServicesGroup.PasswordPolicyService.Validate(user, resetPassword.Password); fieldErrors != nil {
+		errors.ResponseWithFieldErrors(c, http.StatusBadRequest, password_policy_model.ApiError_Password_Policy, fieldErrors)
+		return
+	}
+
+	// use up the code
 	if ok := ac.ServicesGroup.AuthService.VerifyPasswordResetCode(user.Id, resetPassword.ResetCode); !ok {
 		errors.ResponseWithSoftRedirect(c, http.StatusUnauthorized, "Error resetting password.", REDIRECT_LOGIN)
 		return
@@ -73,6 +81,10 @@ func (ac *AuthController) setPassword(c *gin.Context) {
 
 	// reset password
 	err = ac.ServicesGroup.UserService.UpdatePassword(user.Id, resetPassword.Password)
+	if fieldErrors, ok := err.(errors.FieldErrors); ok {
+		errors.ResponseWithFieldErrors(c, http.StatusBadRequest, password_policy_model.ApiError_Password_Policy, fieldErrors)
+		return
+	}
 	if err != nil {
 		errors.Response(c, http.StatusBadRequest, "Couldn't reset password.", err)
 		return
@@ -7,9 +7,11 @@ import (
 	"time"
 
 	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/context/consts"
 	"github.com/cqlcorp/gocms/utility/api_utility"
 	"github.com/cqlcorp/gocms/utility"
 	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_cookie"
 )
 
 // Verify device form structure
@@ -56,6 +58,7 @@ func (ac *AuthController) verifyDevice(c *gin.Context) {
 	// generate device token
 	expire := time.Now().Add(time.Minute * utility.GetTimeout(context.Config.DbVars.DeviceAuthTimeout))
 	deviceToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		consts.TOKEN_TYPE_CLAIM: consts.TOKEN_TYPE_DEVICE,
 		"iat": time.Now().Unix(),
 		"exp": expire.Unix() * 1000, // get milliseconds,
 	})
@@ -65,7 +68,7 @@ func (ac *AuthController) verifyDevice(c *gin.Context) {
 		return
 	}
 
-	c.Header("X-DEVICE-TOKEN", deviceTokenString)
+	authentication_cookie.SetDeviceToken(c, deviceTokenString)
 
 	c.String(http.StatusOK, "ok")
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/cqlcorp/gocms/utility/api_utility"
 	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_cookie"
 	"net/http"
 	"strconv"
 )
@@ -39,7 +40,7 @@ func (ac *AuthController) verifyUser(c *gin.Context) {
 			return
 		}
 
-		c.Header("X-AUTH-TOKEN", tokenString)
+		authentication_cookie.SetAuthToken(c, tokenString)
 
 	}
 
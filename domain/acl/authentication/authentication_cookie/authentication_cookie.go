@@ -0,0 +1,197 @@
+package authentication_cookie
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/utility"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	AUTH_COOKIE         = "gocms_auth"
+	DEVICE_COOKIE       = "gocms_device"
+	IMPERSONATOR_COOKIE = "gocms_impersonator"
+	// CSRF_COOKIE is readable by javascript so the client can echo it in the CSRF_HEADER.
+	CSRF_COOKIE = "gocms_csrf"
+
+	AUTH_HEADER   = "X-AUTH-TOKEN"
+	DEVICE_HEADER = "X-DEVICE-TOKEN"
+	CSRF_HEADER   = "X-CSRF-Token"
+
+	// AUTH_MODE_HEADER set to AUTH_MODE_COOKIE by browser clients that want tokens in cookies instead of headers.
+	AUTH_MODE_HEADER = "X-AUTH-MODE"
+	AUTH_MODE_COOKIE = "cookie"
+
+	// gin context keys
+	FROM_COOKIE_KEY = "GOCMS-AUTH-FROM-COOKIE"
+	CSRF_FAILED_KEY = "GOCMS-CSRF-FAILED"
+)
+
+// Enabled is true when AUTH_COOKIES allows cookie authentication.
+func Enabled() bool {
+	return context.Config.DbVars.AuthCookies
+}
+
+// UseCookies is true when tokens issued on this request should be sent as cookies, either because the client asked
+// for cookie mode or because it is already authenticated with a cookie.
+func UseCookies(c *gin.Context) bool {
+	if !Enabled() {
+		return false
+	}
+	return strings.EqualFold(c.Request.Header.Get(AUTH_MODE_HEADER), AUTH_MODE_COOKIE) || FromCookie(c)
+}
+
+// FromCookie is true when the user on the request was authenticated with the auth cookie.
+func FromCookie(c *gin.Context) bool {
+	fromCookie, ok := c.Get(FROM_COOKIE_KEY)
+	return ok && fromCookie == true
+}
+
+// GetAuthToken returns the auth token from the X-AUTH-TOKEN header, or the auth cookie when cookies are enabled.
+func GetAuthToken(c *gin.Context) (token string, fromCookie bool) {
+	if token := c.Request.Header.Get(AUTH_HEADER); token != "" {
+		return token, false
+	}
+	if !Enabled() {
+		return "", false
+	}
+	if token, err := c.Cookie(AUTH_COOKIE); err == nil && token != "" {
+		return token, true
+	}
+	return "", false
+}
+
+// GetDeviceToken returns the device token from the X-DEVICE-TOKEN header, or the device cookie when cookies are enabled.
+func GetDeviceToken(c *gin.Context) string {
+	if token := c.Request.Header.Get(DEVICE_HEADER); token != "" {
+		return token
+	}
+	if !Enabled() {
+		return ""
+	}
+	token, _ := c.Cookie(DEVICE_COOKIE)
+	return token
+}
+
+// SetAuthToken sends a new auth token to the client, as an HttpOnly cookie with its csrf token in cookie mode,
+// otherwise in the X-AUTH-TOKEN header.
+func SetAuthToken(c *gin.Context, token string) {
+	if !UseCookies(c) {
+		c.Header(AUTH_HEADER, token)
+		return
+	}
+	maxAge := authMaxAge()
+	setCookie(c, AUTH_COOKIE, token, maxAge, true)
+	setCsrf(c, token, maxAge)
+}
+
+// SetDeviceToken sends a new device token to the client, as an HttpOnly cookie in cookie mode, otherwise in the
+// X-DEVICE-TOKEN header.
+func SetDeviceToken(c *gin.Context, token string) {
+	if !UseCookies(c) {
+		c.Header(DEVICE_HEADER, token)
+		return
+	}
+	maxAge := int(utility.GetTimeout(context.Config.DbVars.DeviceAuthTimeout) * time.Minute / time.Second)
+	setCookie(c, DEVICE_COOKIE, token, maxAge, true)
+}
+
+// StartImpersonation keeps the admins auth cookie aside while the impersonation token replaces it.
+func StartImpersonation(c *gin.Context, token string) {
+	if FromCookie(c) {
+		if adminToken, err := c.Cookie(AUTH_COOKIE); err == nil {
+			setCookie(c, IMPERSONATOR_COOKIE, adminToken, authMaxAge(), true)
+		}
+	}
+	SetAuthToken(c, token)
+}
+
+// StopImpersonation restores the admins auth cookie. The admin has to log in again if it is missing.
+func StopImpersonation(c *gin.Context) {
+	if !FromCookie(c) {
+		return
+	}
+	adminToken, err := c.Cookie(IMPERSONATOR_COOKIE)
+	clearCookie(c, IMPERSONATOR_COOKIE, true)
+	if err != nil || adminToken == "" {
+		clearCookie(c, AUTH_COOKIE, true)
+		clearCookie(c, CSRF_COOKIE, false)
+		return
+	}
+	SetAuthToken(c, adminToken)
+}
+
+// Clear removes every auth cookie. HttpOnly cookies can only be removed by the server.
+func Clear(c *gin.Context) {
+	clearCookie(c, AUTH_COOKIE, true)
+	clearCookie(c, DEVICE_COOKIE, true)
+	clearCookie(c, IMPERSONATOR_COOKIE, true)
+	clearCookie(c, CSRF_COOKIE, false)
+}
+
+// ValidCsrf checks the X-CSRF-Token header of a cookie authenticated request against the csrf token of its auth token.
+// Safe methods don't change state so they don't need one.
+func ValidCsrf(c *gin.Context, authToken string) bool {
+	switch c.Request.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return true
+	}
+	sent := c.Request.Header.Get(CSRF_HEADER)
+	if sent == "" {
+		return false
+	}
+	return hmac.Equal([]byte(sent), []byte(csrfToken(authToken)))
+}
+
+// csrfToken is derived from the auth token so it can't be planted by a sibling domain and needs no server side state.
+func csrfToken(authToken string) string {
+	key := hmac.New(sha256.New, []byte(context.Config.DbVars.MicroserviceSecret))
+	key.Write([]byte("gocms-csrf"))
+	mac := hmac.New(sha256.New, key.Sum(nil))
+	mac.Write([]byte(authToken))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func setCsrf(c *gin.Context, authToken string, maxAge int) {
+	csrf := csrfToken(authToken)
+	setCookie(c, CSRF_COOKIE, csrf, maxAge, false)
+	c.Header(CSRF_HEADER, csrf)
+}
+
+func authMaxAge() int {
+	return int(utility.GetTimeout(context.Config.DbVars.UserAuthTimeout) * time.Minute / time.Second)
+}
+
+func setCookie(c *gin.Context, name string, value string, maxAge int, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   context.Config.DbVars.AuthCookieDomain,
+		MaxAge:   maxAge,
+		Secure:   context.Config.DbVars.AuthCookieSecure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite(),
+	})
+}
+
+func clearCookie(c *gin.Context, name string, httpOnly bool) {
+	setCookie(c, name, "", -1, httpOnly)
+}
+
+func sameSite() http.SameSite {
+	switch strings.ToLower(context.Config.DbVars.AuthCookieSameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
@@ -0,0 +1,145 @@
+package authentication_cookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/gin-gonic/gin"
+)
+
+// serve runs handle for the request inside a gin handler and returns the response
+func serve(req *http.Request, handle func(c *gin.Context)) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Handle(req.Method, "/test", handle)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func setCookieConfig(enabled bool) {
+	context.Config.DbVars.AuthCookies = enabled
+	context.Config.DbVars.MicroserviceSecret = "secret"
+	context.Config.DbVars.AuthCookieSameSite = "strict"
+	context.Config.DbVars.AuthCookieSecure = true
+}
+
+func TestValidCsrf(t *testing.T) {
+	setCookieConfig(true)
+	defer setCookieConfig(false)
+
+	tests := []struct {
+		name   string
+		method string
+		csrf   string
+		want   bool
+	}{
+		{"get without token", "GET", "", true},
+		{"head without token", "HEAD", "", true},
+		{"options without token", "OPTIONS", "", true},
+		{"post without token", "POST", "", false},
+		{"post with token", "POST", csrfToken("auth-token"), true},
+		{"put with token", "PUT", csrfToken("auth-token"), true},
+		{"delete with token of another auth token", "DELETE", csrfToken("other-token"), false},
+		{"patch with the auth token", "PATCH", "auth-token", false},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/test", nil)
+		if test.csrf != "" {
+			req.Header.Set(CSRF_HEADER, test.csrf)
+		}
+		var got bool
+		serve(req, func(c *gin.Context) { got = ValidCsrf(c, "auth-token") })
+		if got != test.want {
+			t.Errorf("%v: ValidCsrf() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCsrfTokenUsesSecret(t *testing.T) {
+	setCookieConfig(true)
+	defer setCookieConfig(false)
+
+	first := csrfToken("auth-token")
+	context.Config.DbVars.MicroserviceSecret = "rotated"
+	if csrfToken("auth-token") == first {
+		t.Error("csrfToken() doesn't depend on the secret")
+	}
+}
+
+func TestGetAuthToken(t *testing.T) {
+	defer setCookieConfig(false)
+
+	tests := []struct {
+		name           string
+		enabled        bool
+		header         string
+		cookie         string
+		wantToken      string
+		wantFromCookie bool
+	}{
+		{"header", true, "header-token", "", "header-token", false},
+		{"header before cookie", true, "header-token", "cookie-token", "header-token", false},
+		{"cookie", true, "", "cookie-token", "cookie-token", true},
+		{"cookie while disabled", false, "", "cookie-token", "", false},
+		{"nothing", true, "", "", "", false},
+	}
+	for _, test := range tests {
+		setCookieConfig(test.enabled)
+		req := httptest.NewRequest("GET", "/test", nil)
+		if test.header != "" {
+			req.Header.Set(AUTH_HEADER, test.header)
+		}
+		if test.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: AUTH_COOKIE, Value: test.cookie})
+		}
+		var token string
+		var fromCookie bool
+		serve(req, func(c *gin.Context) { token, fromCookie = GetAuthToken(c) })
+		if token != test.wantToken || fromCookie != test.wantFromCookie {
+			t.Errorf("%v: GetAuthToken() = %q, %v, want %q, %v", test.name, token, fromCookie, test.wantToken, test.wantFromCookie)
+		}
+	}
+}
+
+func TestSetAuthToken(t *testing.T) {
+	defer setCookieConfig(false)
+
+	// header mode
+	setCookieConfig(true)
+	w := serve(httptest.NewRequest("POST", "/test", nil), func(c *gin.Context) { SetAuthToken(c, "auth-token") })
+	if w.Header().Get(AUTH_HEADER) != "auth-token" || len(w.Result().Cookies()) != 0 {
+		t.Errorf("header mode: got header %q and cookies %v", w.Header().Get(AUTH_HEADER), w.Result().Cookies())
+	}
+
+	// cookie mode
+	req := httptest.NewRequest("POST", "/test", nil)
+	req.Header.Set(AUTH_MODE_HEADER, AUTH_MODE_COOKIE)
+	w = serve(req, func(c *gin.Context) { SetAuthToken(c, "auth-token") })
+	if w.Header().Get(AUTH_HEADER) != "" {
+		t.Error("cookie mode: auth token sent in a header")
+	}
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	auth, csrf := cookies[AUTH_COOKIE], cookies[CSRF_COOKIE]
+	if auth == nil || auth.Value != "auth-token" || !auth.HttpOnly || !auth.Secure || auth.SameSite != http.SameSiteStrictMode {
+		t.Errorf("cookie mode: auth cookie %+v", auth)
+	}
+	if csrf == nil || csrf.Value != csrfToken("auth-token") || csrf.HttpOnly {
+		t.Errorf("cookie mode: csrf cookie %+v", csrf)
+	}
+	if w.Header().Get(CSRF_HEADER) != csrfToken("auth-token") {
+		t.Errorf("cookie mode: csrf header %q", w.Header().Get(CSRF_HEADER))
+	}
+
+	// cookie mode is ignored while cookies are disabled
+	setCookieConfig(false)
+	w = serve(req, func(c *gin.Context) { SetAuthToken(c, "auth-token") })
+	if w.Header().Get(AUTH_HEADER) != "auth-token" || len(w.Result().Cookies()) != 0 {
+		t.Errorf("disabled: got header %q and cookies %v", w.Header().Get(AUTH_HEADER), w.Result().Cookies())
+	}
+}
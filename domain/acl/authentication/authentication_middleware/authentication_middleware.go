@@ -2,9 +2,12 @@ package authentication_middleware
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/cqlcorp/gocms/context"
 	"github.com/cqlcorp/gocms/context/consts"
+	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_cookie"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
 	"github.com/cqlcorp/gocms/init/service"
 	"github.com/cqlcorp/gocms/routes"
 	"github.com/cqlcorp/gocms/utility/api_utility"
@@ -14,6 +17,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// BANNED_KEY holds the ban message when a banned user sent a valid token, so authenticated routes can explain why
+// they are refused
+const BANNED_KEY = "gocms-banned"
+
 type AuthMiddleware struct {
 	ServicesGroup *service.ServicesGroup
 }
@@ -51,20 +58,34 @@ func (am *AuthMiddleware) RequireAuthenticatedDevice() gin.HandlerFunc {
 // getAuthedUserIfPresent
 func (am *AuthMiddleware) addUserToContextIfValidToken(c *gin.Context) {
 
-	// get token
-	authHeader := c.Request.Header.Get("X-AUTH-TOKEN")
+	// api keys are sent as bearer tokens
+	if apiKey := getBearerToken(c); apiKey != "" {
+		am.addUserToContextIfValidApiKey(c, apiKey)
+		return
+	}
+
+	// get token from the header or, for browser clients in cookie mode, the auth cookie
+	authHeader, fromCookie := authentication_cookie.GetAuthToken(c)
+
+	// a cookie is sent by the browser on any request so state changing requests must prove they came from our client
+	if fromCookie && !authentication_cookie.ValidCsrf(c, authHeader) {
+		c.Set(authentication_cookie.CSRF_FAILED_KEY, true)
+		c.Next()
+		return
+	}
 
 	if authHeader == "" {
 		c.Next()
 		return
 	} else {
 		// parse token
-		token, err := am.verifyToken(authHeader)
+		token, err := am.verifyToken(authHeader, consts.TOKEN_TYPE_AUTH)
 		if err != nil {
 			c.Next()
 			return
 		} else {
-			userId, ok := token.Claims.(jwt.MapClaims)["userId"].(float64)
+			claims := token.Claims.(jwt.MapClaims)
+			userId, ok := claims["userId"].(float64)
 			if !ok {
 				c.Next()
 				return
@@ -77,11 +98,32 @@ func (am *AuthMiddleware) addUserToContextIfValidToken(c *gin.Context) {
 				} else {
 
 					// verify user is enabled
-					if !user.Enabled {
+					if !user.Enabled || user.IsDeleted() {
+						c.Next()
+						return
+					}
+					if user.IsBanned() {
+						c.Set(BANNED_KEY, user.BanMessage())
 						c.Next()
 						return
 					}
+
+					// verify impersonation is still active and the admin still has access
+					if impersonatedBy, ok := claims["impersonatedBy"].(float64); ok {
+						impersonationId, _ := claims["impersonationId"].(float64)
+						if !am.ServicesGroup.ImpersonationService.Verify(int64(impersonationId), int64(impersonatedBy), user.Id) ||
+							!am.ServicesGroup.AclService.IsAuthorized(permissions.SUPER_ADMIN, int64(impersonatedBy)) {
+							c.Next()
+							return
+						}
+						user.ImpersonatedBy = int64(impersonatedBy)
+						user.ImpersonationId = int64(impersonationId)
+					}
+
 					c.Set(consts.USER_KEY_FOR_GIN_CONTEXT, *user)
+					if fromCookie {
+						c.Set(authentication_cookie.FROM_COOKIE_KEY, true)
+					}
 					// continue
 					c.Next()
 					return
@@ -91,11 +133,54 @@ func (am *AuthMiddleware) addUserToContextIfValidToken(c *gin.Context) {
 	}
 }
 
+// addUserToContextIfValidApiKey
+func (am *AuthMiddleware) addUserToContextIfValidApiKey(c *gin.Context, token string) {
+
+	apiKey, err := am.ServicesGroup.ApiKeyService.Authenticate(token)
+	if err != nil {
+		c.Next()
+		return
+	}
+
+	user, err := am.ServicesGroup.UserService.Get(apiKey.UserId)
+	if err != nil || !user.Enabled || user.IsDeleted() {
+		c.Next()
+		return
+	}
+	if user.IsBanned() {
+		c.Set(BANNED_KEY, user.BanMessage())
+		c.Next()
+		return
+	}
+
+	user.ApiKeyId = apiKey.Id
+	user.ApiKeyScope = apiKey.GetScope()
+	c.Set(consts.USER_KEY_FOR_GIN_CONTEXT, *user)
+	c.Next()
+}
+
+// getBearerToken returns the token from an Authorization: Bearer header
+func getBearerToken(c *gin.Context) string {
+	authorization := c.Request.Header.Get("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+	return ""
+}
+
 // requireAuthedUser middleware
 func (am *AuthMiddleware) requireAuthedUser(c *gin.Context) {
 
 	user, ok := api_utility.GetUserFromContext(c)
 	if !ok {
+		if _, csrfFailed := c.Get(authentication_cookie.CSRF_FAILED_KEY); csrfFailed {
+			errors.Response(c, http.StatusForbidden, errors.ApiError_Csrf, nil)
+			return
+		}
+		if banMessage, banned := c.Get(BANNED_KEY); banned {
+			errors.Response(c, http.StatusForbidden, banMessage.(string), nil)
+			return
+		}
 		errors.Response(c, http.StatusUnauthorized, errors.ApiError_UserToken, nil)
 		return
 	}
@@ -110,8 +195,14 @@ func (am *AuthMiddleware) requireAuthedUser(c *gin.Context) {
 // requireAuthedDevice
 func (am *AuthMiddleware) requireAuthedDevice(c *gin.Context) {
 
-	// get for deviceAuthToken header if it exists
-	authDeviceHeader := c.Request.Header.Get("X-DEVICE-TOKEN")
+	// api keys are created by an authenticated device and aren't tied to one
+	if user, ok := api_utility.GetUserFromContext(c); ok && user.ApiKeyId != 0 {
+		c.Next()
+		return
+	}
+
+	// get for deviceAuthToken header or cookie if it exists
+	authDeviceHeader := authentication_cookie.GetDeviceToken(c)
 
 	// if auth token is empty fail
 	if authDeviceHeader == "" {
@@ -120,7 +211,7 @@ func (am *AuthMiddleware) requireAuthedDevice(c *gin.Context) {
 	}
 
 	// parse token
-	_, err := am.verifyToken(authDeviceHeader)
+	_, err := am.verifyToken(authDeviceHeader, consts.TOKEN_TYPE_DEVICE)
 	if err != nil {
 		errors.Response(c, http.StatusUnauthorized, errors.ApiError_DeviceToken, err)
 		return
@@ -131,8 +222,8 @@ func (am *AuthMiddleware) requireAuthedDevice(c *gin.Context) {
 
 }
 
-// verifyToken
-func (am *AuthMiddleware) verifyToken(authHeader string) (*jwt.Token, error) {
+// verifyToken checks the signature of a token and that it was issued as the given type of token
+func (am *AuthMiddleware) verifyToken(authHeader string, tokenType string) (*jwt.Token, error) {
 	token, err := jwt.Parse(authHeader, func(token *jwt.Token) (interface{}, error) {
 		if jwt.SigningMethodRS256 != token.Method {
 			return nil, errors.New("Token signing method does not match.")
@@ -151,5 +242,11 @@ func (am *AuthMiddleware) verifyToken(authHeader string) (*jwt.Token, error) {
 		return nil, err
 	}
 
+	// check the token was issued for this purpose, an invitation or device token is not a login token
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims[consts.TOKEN_TYPE_CLAIM] != tokenType {
+		return nil, errors.New("Token type does not match.")
+	}
+
 	return token, nil
 }
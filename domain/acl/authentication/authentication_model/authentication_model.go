@@ -14,11 +14,14 @@ package authentication_model
  * @apiDefine AuthHeader
  * @apiParam (Request-Header) {String} x-auth-token x-auth-token JWT User Token unique token generated for user at login.
  * @apiParam (Request-Header) {String} [x-device-token] x-device-token JWT Device Token unique token generated for device at verification. *Required when Two-Factor enabled.
+ * @apiParam (Request-Header) {String} [x-csrf-token] x-csrf-token Value of the gocms_csrf cookie. *Required on POST, PUT, PATCH and DELETE when authenticated with the gocms_auth cookie instead of x-auth-token.
  */
 
 /**
  * @apiDefine AuthHeaderResponse
- * @apiSuccess (Response-Header) {string} x-auth-token
+ * @apiParam (Request-Header) {String} [x-auth-mode] Send "cookie" to receive the tokens as HttpOnly gocms_auth and gocms_device cookies instead of headers. Requires AUTH_COOKIES.
+ * @apiSuccess (Response-Header) {string} x-auth-token Not sent in cookie mode.
+ * @apiSuccess (Response-Header) {string} [x-csrf-token] Cookie mode only, also set in the gocms_csrf cookie.
  */
 
 /**
@@ -64,3 +67,15 @@ type ResetPasswordInput struct {
 	Password  string `json:"password" binding:"required"`
 	ResetCode string `json:"resetCode" binding:"required"`
 }
+
+/**
+* @apiDefine ExpiredPasswordInput
+* @apiParam (Request) {string} email
+* @apiParam (Request) {string} password The expired password.
+* @apiParam (Request) {string} newPassword
+ */
+type ExpiredPasswordInput struct {
+	Email       string `json:"email" binding:"required"`
+	Password    string `json:"password" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
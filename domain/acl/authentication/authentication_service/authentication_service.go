@@ -1,8 +1,10 @@
 package authentication_service
 
 import (
-	"fmt"
+	"database/sql"
 	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/acl/authentication/password_hasher"
+	"github.com/cqlcorp/gocms/domain/mail/mail_model"
 	"github.com/cqlcorp/gocms/domain/mail/mail_service"
 	"github.com/cqlcorp/gocms/domain/secure_code/security_code_model"
 	"github.com/cqlcorp/gocms/domain/user/user_model"
@@ -10,7 +12,6 @@ import (
 	"github.com/cqlcorp/gocms/utility"
 	"github.com/cqlcorp/gocms/utility/log"
 	"github.com/nbutton23/zxcvbn-go"
-	"golang.org/x/crypto/bcrypt"
 	"time"
 )
 
@@ -18,11 +19,13 @@ type IAuthService interface {
 	AuthUser(string, string) (*user_model.User, bool)
 	HashPassword(string) (string, error)
 	SendPasswordResetCode(string) error
+	SendPasswordSetCode(*user_model.User) error
 	VerifyPassword(string, string) bool
+	CheckPasswordResetCode(int64, string) bool
 	VerifyPasswordResetCode(int64, string) bool
 	SendTwoFactorCode(*user_model.User) error
 	VerifyTwoFactorCode(int64, string) bool
-	PasswordIsComplex(string) bool
+	PasswordIsComplex(string, ...string) bool
 	GetRandomCode(int64) (string, string, error)
 }
 
@@ -53,38 +56,41 @@ func (as *AuthService) AuthUser(email string, password string) (*user_model.User
 	}
 
 	// check password
-	if ok := as.VerifyPassword(dbUser.Password, password); !ok {
+	passwordHasher := password_hasher.DefaultPasswordHasher()
+	if ok := passwordHasher.Verify(dbUser.Password, password); !ok {
 		return nil, false
 	}
 
+	// upgrade the stored hash if it was created with an old algorithm or parameters
+	if passwordHasher.NeedsRehash(dbUser.Password) {
+		hashedPassword, err := passwordHasher.Hash(password)
+		if err != nil { // log error but don't fail
+			log.Errorf("Error rehashing password for user %v: %v\n", dbUser.Id, err.Error())
+		} else if err := as.RepositoriesGroup.UsersRepository.UpdatePassword(dbUser.Id, hashedPassword); err != nil {
+			log.Errorf("Error updating rehashed password for user %v: %v\n", dbUser.Id, err.Error())
+		} else {
+			dbUser.Password = hashedPassword
+		}
+	}
+
 	return dbUser, true
 }
 
 func (as *AuthService) VerifyPassword(passwordHash string, password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password))
-	if err != nil {
-		log.Warningf("Error comparing hashes: %s", err.Error())
-		return false
-	}
+	return password_hasher.DefaultPasswordHasher().Verify(passwordHash, password)
+}
 
-	return true
+// CheckPasswordResetCode checks the code like VerifyPasswordResetCode without using it up, so the new password can be
+// validated before the code is spent.
+func (as *AuthService) CheckPasswordResetCode(id int64, code string) bool {
+	return as.latestPasswordResetCode(id, code) != nil
 }
 
+// VerifyPasswordResetCode checks the latest reset code of the user, or their set password code if they were sent one.
 func (as *AuthService) VerifyPasswordResetCode(id int64, code string) bool {
 
-	// get code
-	secureCode, err := as.RepositoriesGroup.SecureCodeRepository.GetLatestForUserByType(id, security_code_model.Code_ResetPassword)
-	if err != nil {
-		log.Errorf("error getting latest password reset code: %s", err.Error())
-		return false
-	}
-
-	if ok := as.VerifyPassword(secureCode.Code, code); !ok {
-		return false
-	}
-
-	// check within time
-	if time.Since(secureCode.Created) > (time.Minute * time.Duration(context.Config.DbVars.PasswordResetTimeout)) {
+	secureCode := as.latestPasswordResetCode(id, code)
+	if secureCode == nil {
 		return false
 	}
 
@@ -101,13 +107,7 @@ func (as *AuthService) VerifyPasswordResetCode(id int64, code string) bool {
 				if err != nil { // log error but don't fail
 					log.Errorf("Verify password reset code, error setting primary email to verified: %v\n", err.Error())
 				} else { // email user to be nice
-					mail := mail_service.Mail{
-						To:       email.Email,
-						Subject:  "We Activated Your Account",
-						Body:     "You successfully reset your password. We also noticed that your account had not yet been activated, so we activated it. You can now login to our system.\n\n Thanks.",
-						BodyHTML: fmt.Sprintf("<h1>Password Reset & Account Activation</h1><p>You successfully reset your password.<br/><br/>We also noticed that your account had not yet been activated, so we activated it. You can now login!<br/><br/> Thanks.</p>"),
-					}
-					as.MailService.Send(&mail)
+					as.MailService.SendTemplate(email.Email, mail_model.Template_Account_Activated, as.userLocale(id), nil)
 				}
 			}
 		}
@@ -123,11 +123,14 @@ func (as *AuthService) VerifyPasswordResetCode(id int64, code string) bool {
 
 func (as *AuthService) SendPasswordResetCode(email string) error {
 
-	// get user
+	// get user, deleted users aren't sent codes
 	user, err := as.RepositoriesGroup.UsersRepository.GetByEmail(email)
 	if err != nil {
 		return err
 	}
+	if user.IsDeleted() {
+		return sql.ErrNoRows
+	}
 
 	// create reset code
 	code, hashedCode, err := as.GetRandomCode(6)
@@ -148,13 +151,9 @@ func (as *AuthService) SendPasswordResetCode(email string) error {
 	expireTimeStr := time.Now().Add(time.Minute * time.Duration(context.Config.DbVars.PasswordResetTimeout)).Format("03:04 pm")
 
 	// send email
-	as.MailService.Send(&mail_service.Mail{
-		To:      user.Email,
-		Subject: "Password Reset Requested",
-		Body: "To reset your password enter the code below into the app:\n" +
-			code + "\n\nThe code will expire at: " +
-			expireTimeStr + ".",
-		BodyHTML: fmt.Sprintf("<h1>Password Reset</h1><p>To reset your password enter the code below into the app:</p><h3>%v</h3><p>The code will expire at: <b>%v</b></p>", code, expireTimeStr),
+	err = as.MailService.SendTemplate(user.Email, mail_model.Template_Password_Reset, user.Locale, map[string]interface{}{
+		"code":    code,
+		"expires": expireTimeStr,
 	})
 	if err != nil {
 		log.Errorf("Error sending mail: " + err.Error())
@@ -163,6 +162,79 @@ func (as *AuthService) SendPasswordResetCode(email string) error {
 	return nil
 }
 
+// SendPasswordSetCode emails a user created without a password a code to choose one through the reset password
+// endpoint. The code is valid for INVITATION_TIMEOUT minutes.
+func (as *AuthService) SendPasswordSetCode(user *user_model.User) error {
+	if user.IsDeleted() {
+		return sql.ErrNoRows
+	}
+
+	code, hashedCode, err := as.GetRandomCode(12)
+	if err != nil {
+		return err
+	}
+
+	err = as.RepositoriesGroup.SecureCodeRepository.Add(&security_code_model.SecureCode{
+		UserId: user.Id,
+		Type:   security_code_model.Code_SetPassword,
+		Code:   hashedCode,
+	})
+	if err != nil {
+		return err
+	}
+
+	expireTimeStr := time.Now().Add(time.Minute * time.Duration(context.Config.DbVars.InvitationTimeout)).Format("01/02/2006 03:04 pm")
+
+	// send email
+	err = as.MailService.SendTemplate(user.Email, mail_model.Template_Password_Set, user.Locale, map[string]interface{}{
+		"code":    code,
+		"expires": expireTimeStr,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// userLocale returns the locale emails to the user are sent in, empty for the default.
+func (as *AuthService) userLocale(id int64) string {
+	user, err := as.RepositoriesGroup.UsersRepository.Get(id)
+	if err != nil {
+		return ""
+	}
+	return user.Locale
+}
+
+// latestPasswordResetCode returns the latest reset code of the user, or their set password code if they were sent one,
+// if it matches and hasn't expired.
+func (as *AuthService) latestPasswordResetCode(id int64, code string) *security_code_model.SecureCode {
+	secureCode := as.verifyLatestCode(id, security_code_model.Code_ResetPassword, code, context.Config.DbVars.PasswordResetTimeout)
+	if secureCode == nil {
+		secureCode = as.verifyLatestCode(id, security_code_model.Code_SetPassword, code, context.Config.DbVars.InvitationTimeout)
+	}
+	return secureCode
+}
+
+// verifyLatestCode returns the latest code of the type if it matches and hasn't expired. Timeout is in minutes.
+func (as *AuthService) verifyLatestCode(id int64, codeType security_code_model.SecureCodeType, code string, timeout int64) *security_code_model.SecureCode {
+	secureCode, err := as.RepositoriesGroup.SecureCodeRepository.GetLatestForUserByType(id, codeType)
+	if err != nil {
+		log.Errorf("error getting latest secure code: %s", err.Error())
+		return nil
+	}
+
+	if ok := as.VerifyPassword(secureCode.Code, code); !ok {
+		return nil
+	}
+
+	// check within time
+	if time.Since(secureCode.Created) > (time.Minute * time.Duration(timeout)) {
+		return nil
+	}
+	return secureCode
+}
+
 func (as *AuthService) SendTwoFactorCode(user *user_model.User) error {
 
 	// create code
@@ -184,11 +256,9 @@ func (as *AuthService) SendTwoFactorCode(user *user_model.User) error {
 	expireTimeStr := time.Now().Add(time.Minute * time.Duration(context.Config.DbVars.TwoFactorCodeTimeout)).Format("03:04 pm")
 
 	// send email
-	as.MailService.Send(&mail_service.Mail{
-		To:       user.Email,
-		Subject:  "Device Verification",
-		Body:     "Your verification code is: " + code + "\n\nThe code will expire at: " + expireTimeStr + ".",
-		BodyHTML: fmt.Sprintf("<h1>Verification Code</h1><p>Your verification code is: </p><h3>%v</h3><p>The code will expire at: <b>%v</b></p>", code, expireTimeStr),
+	err = as.MailService.SendTemplate(user.Email, mail_model.Template_Device_Code, user.Locale, map[string]interface{}{
+		"code":    code,
+		"expires": expireTimeStr,
 	})
 	if err != nil {
 		log.Errorf("Error sending mail: " + err.Error())
@@ -224,13 +294,7 @@ func (as *AuthService) VerifyTwoFactorCode(id int64, code string) bool {
 }
 
 func (as *AuthService) HashPassword(password string) (string, error) {
-
-	bPassword := []byte(password)
-	hashedPassword, err := bcrypt.GenerateFromPassword(bPassword, bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(hashedPassword), nil
+	return password_hasher.DefaultPasswordHasher().Hash(password)
 }
 
 func (as *AuthService) GetRandomCode(length int64) (string, string, error) {
@@ -249,8 +313,9 @@ func (as *AuthService) GetRandomCode(length int64) (string, string, error) {
 	return code, hashedCode, nil
 }
 
-func (as *AuthService) PasswordIsComplex(password string) bool {
-	userInputs := []string{}
+// PasswordIsComplex checks the zxcvbn score of the password. userInputs are details about the user, such as their name
+// and email, that would make the password easier to guess.
+func (as *AuthService) PasswordIsComplex(password string, userInputs ...string) bool {
 	score := zxcvbn.PasswordStrength(password, userInputs)
 	if score.Score < int(context.Config.DbVars.PasswordComplexity) {
 		return false
@@ -0,0 +1,143 @@
+package password_hasher
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/cqlcorp/gocms/utility"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	ARGON2ID_DEFAULT_MEMORY      uint32 = 64 * 1024
+	ARGON2ID_DEFAULT_ITERATIONS  uint32 = 3
+	ARGON2ID_DEFAULT_PARALLELISM uint8  = 2
+	ARGON2ID_SALT_LENGTH                = 16
+	ARGON2ID_KEY_LENGTH          uint32 = 32
+)
+
+// Argon2idHasher hashes passwords with argon2id and encodes them in the PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2idHasher struct {
+	// Memory in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+type argon2idParams struct {
+	version     int
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (ah *Argon2idHasher) Id() string {
+	return ALGORITHM_ARGON2ID
+}
+
+func (ah *Argon2idHasher) Hash(password string) (string, error) {
+	memory, iterations, parallelism := ah.getParams()
+
+	salt, err := utility.GenerateRandomBytes(ARGON2ID_SALT_LENGTH)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, ARGON2ID_KEY_LENGTH)
+
+	encodedHash := fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		ALGORITHM_ARGON2ID,
+		argon2.Version,
+		memory,
+		iterations,
+		parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+
+	return encodedHash, nil
+}
+
+func (ah *Argon2idHasher) Matches(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$"+ALGORITHM_ARGON2ID+"$")
+}
+
+func (ah *Argon2idHasher) Verify(encodedHash string, password string) (bool, error) {
+	params, err := decodeArgon2id(encodedHash)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+
+	return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+}
+
+func (ah *Argon2idHasher) NeedsRehash(encodedHash string) bool {
+	params, err := decodeArgon2id(encodedHash)
+	if err != nil {
+		return true
+	}
+
+	memory, iterations, parallelism := ah.getParams()
+
+	return params.version != argon2.Version ||
+		params.memory != memory ||
+		params.iterations != iterations ||
+		params.parallelism != parallelism ||
+		uint32(len(params.key)) != ARGON2ID_KEY_LENGTH
+}
+
+// getParams returns the configured parameters falling back on defaults for anything unset
+func (ah *Argon2idHasher) getParams() (memory uint32, iterations uint32, parallelism uint8) {
+	memory = ah.Memory
+	if memory == 0 {
+		memory = ARGON2ID_DEFAULT_MEMORY
+	}
+	iterations = ah.Iterations
+	if iterations == 0 {
+		iterations = ARGON2ID_DEFAULT_ITERATIONS
+	}
+	parallelism = ah.Parallelism
+	if parallelism == 0 {
+		parallelism = ARGON2ID_DEFAULT_PARALLELISM
+	}
+	return memory, iterations, parallelism
+}
+
+func decodeArgon2id(encodedHash string) (*argon2idParams, error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 || parts[1] != ALGORITHM_ARGON2ID {
+		return nil, errors.New("argon2id hash is not in the expected format")
+	}
+
+	var params argon2idParams
+	_, err := fmt.Sscanf(parts[2], "v=%d", &params.version)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism)
+	if err != nil {
+		return nil, err
+	}
+
+	params.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, err
+	}
+
+	params.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, err
+	}
+
+	return &params, nil
+}
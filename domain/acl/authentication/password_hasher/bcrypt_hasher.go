@@ -0,0 +1,56 @@
+package password_hasher
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher is kept so hashes created before argon2id became the default can still be verified.
+type BcryptHasher struct {
+	Cost int
+}
+
+func (bh *BcryptHasher) Id() string {
+	return ALGORITHM_BCRYPT
+}
+
+func (bh *BcryptHasher) Hash(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bh.getCost())
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
+
+func (bh *BcryptHasher) Matches(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") ||
+		strings.HasPrefix(encodedHash, "$2b$") ||
+		strings.HasPrefix(encodedHash, "$2y$")
+}
+
+func (bh *BcryptHasher) Verify(encodedHash string, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (bh *BcryptHasher) NeedsRehash(encodedHash string) bool {
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	if err != nil {
+		return true
+	}
+	return cost != bh.getCost()
+}
+
+func (bh *BcryptHasher) getCost() int {
+	if bh.Cost < bcrypt.MinCost {
+		return bcrypt.DefaultCost
+	}
+	return bh.Cost
+}
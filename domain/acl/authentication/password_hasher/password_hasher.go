@@ -0,0 +1,106 @@
+package password_hasher
+
+import (
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/utility/log"
+)
+
+const (
+	ALGORITHM_ARGON2ID = "argon2id"
+	ALGORITHM_BCRYPT   = "bcrypt"
+)
+
+// IHasher is implemented by each supported password hashing algorithm.
+type IHasher interface {
+	// Id is the algorithm name as used in the PASSWORD_HASH_ALGORITHM setting.
+	Id() string
+	// Hash creates a new encoded hash for the password.
+	Hash(password string) (string, error)
+	// Matches reports if the encoded hash was created by this algorithm.
+	Matches(encodedHash string) bool
+	// Verify compares a password against an encoded hash created by this algorithm.
+	Verify(encodedHash string, password string) (bool, error)
+	// NeedsRehash reports if the encoded hash was created with different parameters than the hasher is configured with.
+	NeedsRehash(encodedHash string) bool
+}
+
+// PasswordHasher hashes with the current algorithm while still verifying hashes created by any supported algorithm.
+type PasswordHasher struct {
+	Current IHasher
+	Hashers []IHasher
+}
+
+// DefaultPasswordHasher creates a password hasher using the algorithm and parameters from the settings.
+func DefaultPasswordHasher() *PasswordHasher {
+	argon2idHasher := &Argon2idHasher{
+		Memory:      uint32(context.Config.DbVars.Argon2Memory),
+		Iterations:  uint32(context.Config.DbVars.Argon2Iterations),
+		Parallelism: uint8(context.Config.DbVars.Argon2Parallelism),
+	}
+	bcryptHasher := &BcryptHasher{
+		Cost: int(context.Config.DbVars.BcryptCost),
+	}
+
+	var current IHasher
+	switch context.Config.DbVars.PasswordHashAlgorithm {
+	case ALGORITHM_ARGON2ID:
+		current = argon2idHasher
+	case ALGORITHM_BCRYPT:
+		current = bcryptHasher
+	default:
+		log.Warningf("Unknown password hash algorithm %v, defaulting to %v\n", context.Config.DbVars.PasswordHashAlgorithm, ALGORITHM_ARGON2ID)
+		current = argon2idHasher
+	}
+
+	passwordHasher := &PasswordHasher{
+		Current: current,
+		Hashers: []IHasher{argon2idHasher, bcryptHasher},
+	}
+
+	return passwordHasher
+}
+
+// Hash creates a new encoded hash with the current algorithm.
+func (ph *PasswordHasher) Hash(password string) (string, error) {
+	return ph.Current.Hash(password)
+}
+
+// Verify compares a password against an encoded hash created by any supported algorithm.
+func (ph *PasswordHasher) Verify(encodedHash string, password string) bool {
+	hasher := ph.getHasher(encodedHash)
+	if hasher == nil {
+		log.Warningf("Error comparing hashes: unknown hash format\n")
+		return false
+	}
+
+	ok, err := hasher.Verify(encodedHash, password)
+	if err != nil {
+		log.Warningf("Error comparing hashes: %s\n", err.Error())
+		return false
+	}
+
+	return ok
+}
+
+// NeedsRehash reports if the encoded hash should be replaced with one created by the current algorithm and parameters.
+func (ph *PasswordHasher) NeedsRehash(encodedHash string) bool {
+	hasher := ph.getHasher(encodedHash)
+	if hasher == nil {
+		return true
+	}
+
+	if hasher.Id() != ph.Current.Id() {
+		return true
+	}
+
+	return hasher.NeedsRehash(encodedHash)
+}
+
+func (ph *PasswordHasher) getHasher(encodedHash string) IHasher {
+	for _, hasher := range ph.Hashers {
+		if hasher.Matches(encodedHash) {
+			return hasher
+		}
+	}
+	return nil
+}
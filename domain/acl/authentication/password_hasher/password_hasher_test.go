@@ -0,0 +1,106 @@
+package password_hasher
+
+import (
+	"strings"
+	"testing"
+)
+
+// small parameters keep the tests fast
+func testHashers() (*Argon2idHasher, *BcryptHasher) {
+	return &Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1}, &BcryptHasher{Cost: 4}
+}
+
+func TestHashAndVerify(t *testing.T) {
+	argon2idHasher, bcryptHasher := testHashers()
+	for _, current := range []IHasher{argon2idHasher, bcryptHasher} {
+		ph := &PasswordHasher{Current: current, Hashers: []IHasher{argon2idHasher, bcryptHasher}}
+		hash, err := ph.Hash("correct horse")
+		if err != nil {
+			t.Fatalf("%v: Hash() error: %v", current.Id(), err)
+		}
+		if !current.Matches(hash) {
+			t.Errorf("%v: hash %q isn't in the hashers format", current.Id(), hash)
+		}
+		if !ph.Verify(hash, "correct horse") {
+			t.Errorf("%v: Verify() rejected the password", current.Id())
+		}
+		if ph.Verify(hash, "wrong horse") {
+			t.Errorf("%v: Verify() accepted the wrong password", current.Id())
+		}
+		if ph.NeedsRehash(hash) {
+			t.Errorf("%v: NeedsRehash() = true for a fresh hash", current.Id())
+		}
+	}
+}
+
+func TestArgon2idSalted(t *testing.T) {
+	argon2idHasher, _ := testHashers()
+	first, _ := argon2idHasher.Hash("password")
+	second, _ := argon2idHasher.Hash("password")
+	if first == second {
+		t.Error("hashing the same password twice gave the same hash")
+	}
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	argon2idHasher, bcryptHasher := testHashers()
+	ph := &PasswordHasher{Current: argon2idHasher, Hashers: []IHasher{argon2idHasher, bcryptHasher}}
+	for _, hash := range []string{
+		"",
+		"plaintext",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5",
+		"$2a$04$short",
+	} {
+		if ph.Verify(hash, "password") {
+			t.Errorf("Verify(%q) accepted a malformed hash", hash)
+		}
+		if !ph.NeedsRehash(hash) {
+			t.Errorf("NeedsRehash(%q) = false for a malformed hash", hash)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	argon2idHasher, bcryptHasher := testHashers()
+	argon2idHash, _ := argon2idHasher.Hash("password")
+	bcryptHash, _ := bcryptHasher.Hash("password")
+
+	tests := []struct {
+		name    string
+		current IHasher
+		hash    string
+		want    bool
+	}{
+		{"same argon2id parameters", argon2idHasher, argon2idHash, false},
+		{"argon2id memory changed", &Argon2idHasher{Memory: 2048, Iterations: 1, Parallelism: 1}, argon2idHash, true},
+		{"argon2id iterations changed", &Argon2idHasher{Memory: 1024, Iterations: 2, Parallelism: 1}, argon2idHash, true},
+		{"argon2id parallelism changed", &Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 2}, argon2idHash, true},
+		{"bcrypt hash with argon2id current", argon2idHasher, bcryptHash, true},
+		{"same bcrypt cost", bcryptHasher, bcryptHash, false},
+		{"bcrypt cost changed", &BcryptHasher{Cost: 5}, bcryptHash, true},
+		{"argon2id hash with bcrypt current", bcryptHasher, argon2idHash, true},
+	}
+	for _, test := range tests {
+		ph := &PasswordHasher{Current: test.current, Hashers: []IHasher{test.current, argon2idHasher, bcryptHasher}}
+		if got := ph.NeedsRehash(test.hash); got != test.want {
+			t.Errorf("%v: NeedsRehash() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// a bcrypt hash still verifies after argon2id becomes the current algorithm so users can log in and be rehashed
+func TestVerifyPreviousAlgorithm(t *testing.T) {
+	argon2idHasher, bcryptHasher := testHashers()
+	bcryptHash, _ := bcryptHasher.Hash("password")
+	ph := &PasswordHasher{Current: argon2idHasher, Hashers: []IHasher{argon2idHasher, bcryptHasher}}
+	if !ph.Verify(bcryptHash, "password") {
+		t.Error("Verify() rejected a bcrypt hash")
+	}
+
+	rehashed, _ := ph.Hash("password")
+	if !strings.HasPrefix(rehashed, "$argon2id$") || ph.NeedsRehash(rehashed) {
+		t.Errorf("rehash %q isn't a current argon2id hash", rehashed)
+	}
+}
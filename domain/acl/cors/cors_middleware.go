@@ -1,27 +1,148 @@
 package cors
 
 import (
-	"github.com/gin-gonic/gin"
-	"github.com/cqlcorp/gocms/context"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cqlcorp/gocms/init/service"
 	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/gin-gonic/gin"
 )
 
-func CORS() gin.HandlerFunc {
-	log.Debugf("Adding CORS Middleware\n")
+var defaultAllowedHeaders = []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "X-Auth-Token", "X-Device-Token", "X-Auth-Mode"}
+var defaultExposedHeaders = []string{"Content-Length", "X-Auth-Token", "X-Device-Token", "X-CSRF-Token"}
+
+const allowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+
+var headerNameRegex = regexp.MustCompile("^[A-Za-z0-9-]+$")
+
+type CorsMiddleware struct {
+	ServicesGroup  *service.ServicesGroup
+	overrides      []*routePolicy
+	allowedHeaders string
+	exposedHeaders string
+}
+
+type routePolicy struct {
+	prefix string
+	source PolicySource
+}
+
+func DefaultCorsMiddleware(sg *service.ServicesGroup) *CorsMiddleware {
+
+	corsMiddleware := &CorsMiddleware{
+		ServicesGroup: sg,
+	}
+	corsMiddleware.loadPluginHeaders()
+
 	return corsMiddleware
 }
 
-func corsMiddleware(c *gin.Context) {
-	c.Writer.Header().Set("Access-Control-Allow-Origin", context.Config.DbVars.CorsHost)
-	c.Writer.Header().Set("Access-Control-Max-Age", "86400")
-	c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, UPDATE")
-	c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Auth-Token, X-Device-Token")
-	c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, X-Auth-Token, X-Device-Token")
-	c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+// CORS returns the top level middleware. It must be applied to the engine so preflight requests reach it before routing.
+func (cm *CorsMiddleware) CORS() gin.HandlerFunc {
+	log.Debugf("Adding CORS Middleware\n")
+	return cm.corsMiddleware
+}
 
-	if c.Request.Method == "OPTIONS" {
-		c.AbortWithStatus(204)
-	} else {
+// Override uses a different policy for every route under the group. The most specific override wins.
+func (cm *CorsMiddleware) Override(group *gin.RouterGroup, source PolicySource) {
+	prefix := strings.TrimSuffix(group.BasePath(), "/")
+	log.Debugf("Adding CORS policy override for %v\n", prefix)
+	cm.overrides = append(cm.overrides, &routePolicy{prefix: prefix, source: source})
+	sort.SliceStable(cm.overrides, func(i, j int) bool {
+		return len(cm.overrides[i].prefix) > len(cm.overrides[j].prefix)
+	})
+}
+
+func (cm *CorsMiddleware) corsMiddleware(c *gin.Context) {
+	origin := c.Request.Header.Get("Origin")
+	if origin == "" {
+		// not a cross origin request
 		c.Next()
+		return
+	}
+
+	policy := cm.policyFor(c.Request.URL.Path)
+	preflight := c.Request.Method == "OPTIONS" && c.Request.Header.Get("Access-Control-Request-Method") != ""
+
+	if policy.allowsAnyOrigin() {
+		c.Writer.Header().Set("Access-Control-Allow-Origin", ANY_ORIGIN)
+	} else {
+		// the response depends on the origin so caches must key on it
+		c.Writer.Header().Add("Vary", "Origin")
+		if !policy.allowsOrigin(origin) {
+			if preflight {
+				log.Debugf("CORS preflight from origin %v denied for %v\n", origin, c.Request.URL.Path)
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+		c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+		if policy.AllowCredentials {
+			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+	c.Writer.Header().Set("Access-Control-Expose-Headers", cm.exposedHeaders)
+
+	if preflight {
+		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+		c.Writer.Header().Set("Access-Control-Allow-Headers", cm.allowedHeaders)
+		c.AbortWithStatus(http.StatusNoContent)
+		return
+	}
+
+	c.Next()
+}
+
+func (cm *CorsMiddleware) policyFor(requestPath string) *Policy {
+	for _, override := range cm.overrides {
+		if requestPath == override.prefix || strings.HasPrefix(requestPath, override.prefix+"/") {
+			return override.source()
+		}
+	}
+	return DefaultPolicy()
+}
+
+// loadPluginHeaders adds the extra headers active plugins declare in their manifest to the allowed and exposed lists.
+func (cm *CorsMiddleware) loadPluginHeaders() {
+	allowed := append([]string{}, defaultAllowedHeaders...)
+	exposed := append([]string{}, defaultExposedHeaders...)
+
+	for _, plugin := range cm.ServicesGroup.PluginsService.GetActivePlugins() {
+		pluginCors := plugin.Manifest.Services.Cors
+		if pluginCors == nil {
+			continue
+		}
+		allowed = addHeaders(plugin.Manifest.Id, allowed, pluginCors.AllowedHeaders)
+		exposed = addHeaders(plugin.Manifest.Id, exposed, pluginCors.ExposedHeaders)
+	}
+
+	cm.allowedHeaders = strings.Join(allowed, ", ")
+	cm.exposedHeaders = strings.Join(exposed, ", ")
+}
+
+func addHeaders(pluginId string, headers []string, extra []string) []string {
+	for _, header := range extra {
+		if !headerNameRegex.MatchString(header) {
+			log.Warningf("Plugin %v -> CORS header '%v' is not a valid header name and was skipped\n", pluginId, header)
+			continue
+		}
+		header = http.CanonicalHeaderKey(header)
+		exists := false
+		for _, h := range headers {
+			if h == header {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			headers = append(headers, header)
+		}
 	}
+	return headers
 }
@@ -0,0 +1,138 @@
+package cors
+
+import (
+	"strings"
+
+	"github.com/cqlcorp/gocms/context"
+)
+
+const ANY_ORIGIN = "*"
+
+// Policy is the cors configuration applied to a group of routes.
+type Policy struct {
+	// AllowedOrigins comma separated list of origins allowed to make cross origin requests. A "*" in a pattern
+	// matches within a single host label or the port, ex: https://*.example.com or http://localhost:*. Patterns with
+	// a "*" in the registrable domain or top level domain, ex: https://*.com or https://app.*, are ignored. A lone "*"
+	// allows any origin, in which case credentials are never allowed. A "*" entry in a list with other origins is ignored.
+	AllowedOrigins string
+	// AllowCredentials allows cookies and authorization headers to be sent by the browser for echoed origins.
+	AllowCredentials bool
+}
+
+// PolicySource returns the policy for a request. Sources are called per request so setting refreshes take effect.
+type PolicySource func() *Policy
+
+// DefaultPolicy is the policy for every route without an override, configured by CORS_HOST.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		AllowedOrigins:   context.Config.DbVars.CorsHost,
+		AllowCredentials: context.Config.DbVars.CorsAllowCredentials,
+	}
+}
+
+// AdminPolicy is the policy for the admin api, configured by CORS_ADMIN_HOST. Falls back to the default policy when unset.
+func AdminPolicy() *Policy {
+	if strings.TrimSpace(context.Config.DbVars.CorsAdminHost) == "" {
+		return DefaultPolicy()
+	}
+	return &Policy{
+		AllowedOrigins:   context.Config.DbVars.CorsAdminHost,
+		AllowCredentials: context.Config.DbVars.CorsAllowCredentials,
+	}
+}
+
+// allowsAnyOrigin is true when the allowlist is the lone wildcard.
+func (p *Policy) allowsAnyOrigin() bool {
+	return strings.TrimSpace(p.AllowedOrigins) == ANY_ORIGIN
+}
+
+// allowsOrigin checks the request origin against each pattern in the allowlist. A bare "*" entry is skipped, only
+// allowsAnyOrigin handles it, so it can't echo every origin with credentials.
+func (p *Policy) allowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range strings.Split(p.AllowedOrigins, ",") {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" || pattern == ANY_ORIGIN {
+			continue
+		}
+		if matchOrigin(pattern, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOrigin matches an origin against a pattern. In the host a "*" matches one or more letters, digits or "-" so it
+// never spans a ".", in the port it matches one or more digits. Patterns with a "*" in the scheme or in the last two
+// labels of the host never match, they would allow origins on domains we don't own.
+func matchOrigin(pattern, origin string) bool {
+	if strings.IndexByte(pattern, '*') < 0 {
+		return pattern == origin
+	}
+
+	patternScheme, patternHost, patternPort, ok := splitOrigin(pattern)
+	if !ok || !validHostPattern(patternHost) {
+		return false
+	}
+	originScheme, originHost, originPort, ok := splitOrigin(origin)
+	if !ok {
+		return false
+	}
+
+	return patternScheme == originScheme &&
+		matchPart(patternHost, originHost, isLabelChar) &&
+		matchPart(patternPort, originPort, isDigit)
+}
+
+// splitOrigin splits scheme://host:port, the port is empty when there isn't one.
+func splitOrigin(origin string) (scheme, host, port string, ok bool) {
+	i := strings.Index(origin, "://")
+	if i < 0 {
+		return "", "", "", false
+	}
+	scheme, host = origin[:i], origin[i+3:]
+	if strings.IndexByte(scheme, '*') >= 0 {
+		return "", "", "", false
+	}
+	if p := strings.LastIndexByte(host, ':'); p >= 0 {
+		host, port = host[:p], host[p+1:]
+	}
+	return scheme, host, port, true
+}
+
+// validHostPattern refuses a "*" in the registrable domain or top level domain, ex: *.com, app.*, *.example.*
+func validHostPattern(host string) bool {
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		if strings.IndexByte(label, '*') >= 0 && i >= len(labels)-2 {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPart matches s against a pattern where each "*" matches one or more characters accepted by isChar.
+func matchPart(pattern, s string, isChar func(byte) bool) bool {
+	star := strings.IndexByte(pattern, '*')
+	if star < 0 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, pattern[:star]) {
+		return false
+	}
+	rest := pattern[star+1:]
+	for i := star; i < len(s) && isChar(s[i]); i++ {
+		if matchPart(rest, s[i+1:], isChar) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLabelChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,450 @@
+package group_controller
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/domain/logs/log_model"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/api_utility"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/sqlUtl"
+	"net/http"
+	"strconv"
+)
+
+type GroupAdminController struct {
+	routes        *routes.Routes
+	servicesGroup *service.ServicesGroup
+	adminRoutes   *access_control_middleware.PermissionGroup
+}
+
+func DefaultGroupAdminController(routes *routes.Routes, sg *service.ServicesGroup) *GroupAdminController {
+	groupAdminController := &GroupAdminController{
+		routes:        routes,
+		servicesGroup: sg,
+		adminRoutes:   access_control_middleware.NewPermissionGroup(routes.Auth, "/admin", sg.AclService, permissions.SUPER_ADMIN, permissions.MANAGE_ACL),
+	}
+
+	groupAdminController.Default()
+	return groupAdminController
+}
+
+/**
+* @apiDefine ManageAcl Manage ACL
+* User must be a super admin or have the manage_acl permission. Users who are not super admins can only grant
+* permissions they have, and only change groups whose permissions they have.
+ */
+func (gac *GroupAdminController) Default() {
+	gac.adminRoutes.GET("/group", gac.getAll)
+	gac.adminRoutes.POST("/group", gac.add)
+	gac.adminRoutes.GET("/group/:groupId", gac.get)
+	gac.adminRoutes.PUT("/group/:groupId", gac.update)
+	gac.adminRoutes.DELETE("/group/:groupId", gac.delete)
+	gac.adminRoutes.GET("/group/:groupId/user", gac.getMembers)
+	gac.adminRoutes.POST("/group/:groupId/user/:userId", gac.addMember)
+	gac.adminRoutes.DELETE("/group/:groupId/user/:userId", gac.removeMember)
+	gac.adminRoutes.GET("/group/:groupId/parent", gac.getParents)
+	gac.adminRoutes.POST("/group/:groupId/parent/:parentGroupId", gac.addParent)
+	gac.adminRoutes.DELETE("/group/:groupId/parent/:parentGroupId", gac.removeParent)
+}
+
+/**
+* @api {get} /admin/group Get All Groups
+* @apiName GetAllGroups
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiUse GroupDisplay
+* @apiPermission ManageAcl
+ */
+func (gac *GroupAdminController) getAll(c *gin.Context) {
+
+	groups, err := gac.servicesGroup.GroupService.GetAll()
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get groups.", err)
+		return
+	}
+
+	groupDisplays := make([]*group_model.GroupDisplay, len(*groups))
+	for i, group := range *groups {
+		groupDisplays[i] = group.GetGroupDisplay()
+	}
+
+	c.JSON(http.StatusOK, groupDisplays)
+}
+
+/**
+* @api {post} /admin/group Create Group
+* @apiName AddGroup
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiUse GroupInput
+* @apiUse GroupDisplay
+* @apiPermission ManageAcl
+ */
+func (gac *GroupAdminController) add(c *gin.Context) {
+
+	var groupInput group_model.GroupInput
+	err := c.BindJSON(&groupInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	group := &group_model.Group{
+		Name:        groupInput.Name,
+		Description: groupInput.Description,
+	}
+	err = gac.servicesGroup.GroupService.Add(group)
+	if err != nil {
+		if sqlUtl.ErrDupEtry(err) {
+			errors.Response(c, http.StatusBadRequest, "A group with this name already exists.", err)
+			return
+		}
+		errors.Response(c, http.StatusBadRequest, "Couldn't create group.", err)
+		return
+	}
+
+	group, err = gac.servicesGroup.GroupService.Get(group.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get group.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group.GetGroupDisplay())
+}
+
+/**
+* @api {get} /admin/group/:groupId Get Group
+* @apiName GetGroup
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiUse GroupDisplay
+* @apiPermission ManageAcl
+ */
+func (gac *GroupAdminController) get(c *gin.Context) {
+
+	group, ok := gac.getGroupFromParam(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, group.GetGroupDisplay())
+}
+
+/**
+* @api {put} /admin/group/:groupId Update Group
+* @apiDescription Rename a group or change its description.
+* @apiName UpdateGroup
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiUse GroupInput
+* @apiUse GroupDisplay
+* @apiPermission ManageAcl
+ */
+func (gac *GroupAdminController) update(c *gin.Context) {
+
+	group, ok := gac.getGroupFromParam(c)
+	if !ok || !gac.requireDelegation(c, group.Id) {
+		return
+	}
+
+	var groupInput group_model.GroupInput
+	err := c.BindJSON(&groupInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	group.Name = groupInput.Name
+	group.Description = groupInput.Description
+	err = gac.servicesGroup.GroupService.Update(group)
+	if err != nil {
+		if sqlUtl.ErrDupEtry(err) {
+			errors.Response(c, http.StatusBadRequest, "A group with this name already exists.", err)
+			return
+		}
+		errors.Response(c, http.StatusBadRequest, "Couldn't update group.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group.GetGroupDisplay())
+}
+
+/**
+* @api {delete} /admin/group/:groupId Delete Group
+* @apiDescription Delete a group. Members lose any permissions they inherited from it.
+* @apiName DeleteGroup
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiPermission ManageAcl
+ */
+func (gac *GroupAdminController) delete(c *gin.Context) {
+
+	group, ok := gac.getGroupFromParam(c)
+	if !ok || !gac.requireDelegation(c, group.Id) {
+		return
+	}
+
+	err := gac.servicesGroup.GroupService.Delete(group.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't delete group.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {get} /admin/group/:groupId/user Get Group Members
+* @apiName GetGroupMembers
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiUse GroupMemberDisplay
+* @apiPermission ManageAcl
+ */
+func (gac *GroupAdminController) getMembers(c *gin.Context) {
+
+	group, ok := gac.getGroupFromParam(c)
+	if !ok {
+		return
+	}
+
+	members, err := gac.servicesGroup.GroupService.GetGroupMembers(group.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get group members.", err)
+		return
+	}
+	if members == nil {
+		members = []*group_model.GroupMember{}
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+/**
+* @api {post} /admin/group/:groupId/user/:userId Add User To Group
+* @apiDescription Add a user to a group. Admins without super_admin can only use groups whose permissions they hold.
+* @apiName AddUserToGroupById
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiPermission ManageAcl
+ */
+func (gac *GroupAdminController) addMember(c *gin.Context) {
+
+	group, ok := gac.getGroupFromParam(c)
+	if !ok || !gac.requireDelegation(c, group.Id) {
+		return
+	}
+	userId, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "userId is missing or not an integer", err)
+		return
+	}
+
+	err = gac.servicesGroup.GroupService.AddUserToGroupById(userId, group.Id)
+	if err != nil {
+		if sqlUtl.ErrDupEtry(err) {
+			errors.Response(c, http.StatusBadRequest, "User is already a member of this group", err)
+			return
+		}
+		errors.Response(c, http.StatusBadRequest, "Couldn't add user to group.", err)
+		return
+	}
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+	gac.servicesGroup.LogService.RecordAudit(userId, authUser.Id, log_model.Audit_Group_Add, fmt.Sprintf("group %v (%v)", group.Name, group.Id))
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {delete} /admin/group/:groupId/user/:userId Remove User From Group
+* @apiName RemoveUserFromGroupById
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiPermission ManageAcl
+ */
+func (gac *GroupAdminController) removeMember(c *gin.Context) {
+
+	group, ok := gac.getGroupFromParam(c)
+	if !ok || !gac.requireDelegation(c, group.Id) {
+		return
+	}
+	userId, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "userId is missing or not an integer", err)
+		return
+	}
+
+	err = gac.servicesGroup.GroupService.RemoveUserFromGroupById(userId, group.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't remove user from group.", err)
+		return
+	}
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+	gac.servicesGroup.LogService.RecordAudit(userId, authUser.Id, log_model.Audit_Group_Remove, fmt.Sprintf("group %v (%v)", group.Name, group.Id))
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {get} /admin/group/:groupId/parent Get Parent Groups
+* @apiDescription Groups this group directly inherits permissions from.
+* @apiName GetParentGroups
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiUse GroupDisplay
+* @apiPermission ManageAcl
+ */
+func (gac *GroupAdminController) getParents(c *gin.Context) {
+
+	group, ok := gac.getGroupFromParam(c)
+	if !ok {
+		return
+	}
+
+	parentGroups, err := gac.servicesGroup.GroupService.GetParentGroups(group.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get parent groups.", err)
+		return
+	}
+
+	groupDisplays := make([]*group_model.GroupDisplay, len(parentGroups))
+	for i, parentGroup := range parentGroups {
+		groupDisplays[i] = parentGroup.GetGroupDisplay()
+	}
+
+	c.JSON(http.StatusOK, groupDisplays)
+}
+
+/**
+* @api {post} /admin/group/:groupId/parent/:parentGroupId Add Parent Group
+* @apiDescription Members of the group also get every permission of the parent group and the groups above it.
+* A group can't become its own ancestor.
+* @apiName AddParentGroup
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiPermission ManageAcl
+ */
+func (gac *GroupAdminController) addParent(c *gin.Context) {
+
+	group, ok := gac.getGroupFromParam(c)
+	if !ok || !gac.requireDelegation(c, group.Id) {
+		return
+	}
+	parentGroup, ok := gac.getGroupFromParamName(c, "parentGroupId")
+	if !ok || !gac.requireDelegation(c, parentGroup.Id) {
+		return
+	}
+
+	err := gac.servicesGroup.GroupService.AddParentGroup(group.Id, parentGroup.Id)
+	if err != nil {
+		if sqlUtl.ErrDupEtry(err) {
+			errors.Response(c, http.StatusBadRequest, "Group already inherits from this group.", err)
+			return
+		}
+		errors.Response(c, http.StatusBadRequest, "Couldn't add parent group.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {delete} /admin/group/:groupId/parent/:parentGroupId Remove Parent Group
+* @apiName RemoveParentGroup
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiPermission ManageAcl
+ */
+func (gac *GroupAdminController) removeParent(c *gin.Context) {
+
+	group, ok := gac.getGroupFromParam(c)
+	if !ok || !gac.requireDelegation(c, group.Id) {
+		return
+	}
+	parentGroupId, err := strconv.ParseInt(c.Param("parentGroupId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "parentGroupId is missing or not an integer", err)
+		return
+	}
+
+	err = gac.servicesGroup.GroupService.RemoveParentGroup(group.Id, parentGroupId)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't remove parent group.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (gac *GroupAdminController) getGroupFromParam(c *gin.Context) (*group_model.Group, bool) {
+	return gac.getGroupFromParamName(c, "groupId")
+}
+
+func (gac *GroupAdminController) getGroupFromParamName(c *gin.Context, param string) (*group_model.Group, bool) {
+	groupId, err := strconv.ParseInt(c.Param(param), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, fmt.Sprintf("%v is missing or not an integer", param), err)
+		return nil, false
+	}
+
+	group, err := gac.servicesGroup.GroupService.Get(groupId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errors.Response(c, http.StatusNotFound, "Group not found.", err)
+			return nil, false
+		}
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get group.", err)
+		return nil, false
+	}
+
+	return group, true
+}
+
+// requireDelegation stops admins from changing groups that carry permissions they don't have themselves, including
+// permissions inherited from parent groups
+func (gac *GroupAdminController) requireDelegation(c *gin.Context, groupId int64) bool {
+	groupIds, err := gac.servicesGroup.GroupService.GetAncestors(groupId)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get parent groups.", err)
+		return false
+	}
+
+	var permissionNames []string
+	for _, id := range groupIds {
+		groupPermissions, err := gac.servicesGroup.PermissionService.GetGroupPermissions(id)
+		if err != nil {
+			errors.Response(c, http.StatusInternalServerError, "Couldn't get group permissions.", err)
+			return false
+		}
+		for _, permission := range groupPermissions {
+			permissionNames = append(permissionNames, permission.Name)
+		}
+	}
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+	if !gac.servicesGroup.AclService.CanDelegate(authUser, permissionNames...) {
+		errors.Response(c, http.StatusForbidden, "You can only manage groups whose permissions you have.", nil)
+		return false
+	}
+
+	return true
+}
@@ -2,6 +2,8 @@ package group_controller
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_auth/plugin_auth_middleware"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_auth/plugin_auth_model"
 	"github.com/cqlcorp/gocms/init/service"
 	"github.com/cqlcorp/gocms/routes"
 	"net/http"
@@ -25,15 +27,15 @@ func DefaultInternalGroupController(iRoutes *routes.InternalRoutes, sg *service.
 }
 
 func (ec *InternalGroupController) InternalDefault() {
-	ec.internalRoutes.InternalRoot.POST("/acl/addUser/:userId/toGroupByName/:groupName", ec.addUserToGroupByName)
-	ec.internalRoutes.InternalRoot.DELETE("/acl/removeUser/:userId/fromGroupByName/:groupName", ec.removeUserFromGroupByName)
+	ec.internalRoutes.InternalRoot.POST("/acl/addUser/:userId/toGroupByName/:groupName", plugin_auth_middleware.RequireScope(plugin_auth_model.SCOPE_ACL_GROUPS), ec.addUserToGroupByName)
+	ec.internalRoutes.InternalRoot.DELETE("/acl/removeUser/:userId/fromGroupByName/:groupName", plugin_auth_middleware.RequireScope(plugin_auth_model.SCOPE_ACL_GROUPS), ec.removeUserFromGroupByName)
 }
 
 /**
 * @api {post} (internal)/acl/addUser/:userId/toGroupByName/:groupName (Internal) Add User To Group By Name
 * @apiName AddUserToGroup
 * @apiGroup (Internal) ACL
-* @apiDescription (Internal) add a user to an acl group by userId and groupName
+* @apiDescription (Internal) add a user to an acl group by userId and groupName. Requires the acl.groups plugin scope.
  */
 func (ec *InternalGroupController) addUserToGroupByName(c *gin.Context) {
 
@@ -50,6 +52,12 @@ func (ec *InternalGroupController) addUserToGroupByName(c *gin.Context) {
 		return
 	}
 
+	// plugins can't add deleted users to groups
+	if _, err := ec.servicesGroup.UserService.GetActive(userId); err != nil {
+		errors.Response(c, http.StatusNotFound, errors.ApiError_UserDoesntExist, err)
+		return
+	}
+
 	err = ec.servicesGroup.GroupService.AddUserToGroupByName(userId, groupName)
 	if err != nil {
 		if sqlUtl.ErrDupEtry(err) {
@@ -67,7 +75,7 @@ func (ec *InternalGroupController) addUserToGroupByName(c *gin.Context) {
 * @api {delete} (internal)/acl/removeUser/:userId/fromGroupByName/:groupName (Internal) Remove User From Group By Name
 * @apiName RemoveUserFromGroup
 * @apiGroup (Internal) ACL
-* @apiDescription (Internal) remove a user from an acl group by userId and groupName
+* @apiDescription (Internal) remove a user from an acl group by userId and groupName. Requires the acl.groups plugin scope.
  */
 func (ec *InternalGroupController) removeUserFromGroupByName(c *gin.Context) {
 
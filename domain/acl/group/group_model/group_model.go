@@ -11,3 +11,85 @@ type Group struct {
 	Created      time.Time `db:"created"`
 	LastModified time.Time `db:"lastModified"`
 }
+
+/**
+* @apiDefine GroupDisplay
+* @apiSuccess (Response) {number} id
+* @apiSuccess (Response) {string} name
+* @apiSuccess (Response) {string} description
+* @apiSuccess (Response) {Date} created
+* @apiSuccess (Response) {Date} lastModified
+ */
+type GroupDisplay struct {
+	Id           int64     `json:"id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+/**
+* @apiDefine GroupInput
+* @apiParam (Request) {string} name Unique name of the group, 255 characters max.
+* @apiParam (Request) {string} [description]
+ */
+type GroupInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+/**
+* @apiDefine GroupMemberDisplay
+* @apiSuccess (Response) {number} userId
+* @apiSuccess (Response) {string} fullName
+* @apiSuccess (Response) {string} email
+* @apiSuccess (Response) {Date} added When the user was added to the group.
+ */
+type GroupMember struct {
+	UserId   int64     `json:"userId" db:"userId"`
+	FullName string    `json:"fullName" db:"fullName"`
+	Email    string    `json:"email" db:"email"`
+	Added    time.Time `json:"added" db:"added"`
+}
+
+// GroupParent links a group to a parent group it inherits permissions from.
+type GroupParent struct {
+	GroupId       int64 `db:"groupId"`
+	ParentGroupId int64 `db:"parentGroupId"`
+}
+
+// Ancestors walks the parent links breadth first and returns the given groups followed by every group they inherit
+// from. Each group appears once, so a diamond or a cycle in the links can't loop forever.
+func Ancestors(links []*GroupParent, groupIds ...int64) []int64 {
+	parents := make(map[int64][]int64)
+	for _, link := range links {
+		parents[link.GroupId] = append(parents[link.GroupId], link.ParentGroupId)
+	}
+
+	seen := make(map[int64]bool)
+	var ancestors []int64
+	queue := append([]int64{}, groupIds...)
+	for len(queue) > 0 {
+		groupId := queue[0]
+		queue = queue[1:]
+		if seen[groupId] {
+			continue
+		}
+		seen[groupId] = true
+		ancestors = append(ancestors, groupId)
+		queue = append(queue, parents[groupId]...)
+	}
+
+	return ancestors
+}
+
+// GetGroupDisplay helper function to get groupDisplay from group object
+func (group *Group) GetGroupDisplay() *GroupDisplay {
+	return &GroupDisplay{
+		Id:           group.Id,
+		Name:         group.Name,
+		Description:  group.Description,
+		Created:      group.Created,
+		LastModified: group.LastModified,
+	}
+}
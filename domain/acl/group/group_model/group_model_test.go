@@ -0,0 +1,36 @@
+package group_model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAncestors(t *testing.T) {
+	// 1 -> 2 -> 4, 1 -> 3 -> 4 (diamond), 5 -> 6 -> 5 (cycle)
+	links := []*GroupParent{
+		{GroupId: 1, ParentGroupId: 2},
+		{GroupId: 1, ParentGroupId: 3},
+		{GroupId: 2, ParentGroupId: 4},
+		{GroupId: 3, ParentGroupId: 4},
+		{GroupId: 5, ParentGroupId: 6},
+		{GroupId: 6, ParentGroupId: 5},
+	}
+	tests := []struct {
+		name     string
+		groupIds []int64
+		want     []int64
+	}{
+		{"no groups", nil, nil},
+		{"group without parents", []int64{7}, []int64{7}},
+		{"single parent", []int64{2}, []int64{2, 4}},
+		{"diamond", []int64{1}, []int64{1, 2, 3, 4}},
+		{"cycle", []int64{5}, []int64{5, 6}},
+		{"several groups", []int64{3, 5}, []int64{3, 5, 4, 6}},
+		{"duplicate groups", []int64{2, 2, 4}, []int64{2, 4}},
+	}
+	for _, test := range tests {
+		if got := Ancestors(links, test.groupIds...); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: Ancestors(%v) = %v, want %v", test.name, test.groupIds, got, test.want)
+		}
+	}
+}
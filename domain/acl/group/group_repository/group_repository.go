@@ -2,14 +2,24 @@ package group_repository
 
 import (
 	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"github.com/cqlcorp/gocms/utility/errors"
 	"github.com/cqlcorp/gocms/utility/log"
 	"github.com/jmoiron/sqlx"
 )
 
 type IGroupsRepository interface {
 	Add(*group_model.Group) error
+	Upsert(*group_model.Group) error
+	Get(int64) (*group_model.Group, error)
+	Update(*group_model.Group) error
 	Delete(int64) error
 	GetAll() (*[]group_model.Group, error)
+	GetGroupMembers(groupId int64) ([]*group_model.GroupMember, error)
+
+	GetParentLinks() ([]*group_model.GroupParent, error)
+	GetParentGroups(groupId int64) ([]*group_model.Group, error)
+	AddParentGroup(groupId int64, parentGroupId int64) error
+	RemoveParentGroup(groupId int64, parentGroupId int64) error
 
 	GetUserGroups(userId int64) ([]*group_model.Group, error)
 	AddUserToGroupById(userId int64, groupId int64) error
@@ -49,6 +59,48 @@ func (pr *GroupsRepository) Add(group *group_model.Group) error {
 
 }
 
+// Upsert adds the group or updates the description if one with the same name exists
+func (pr *GroupsRepository) Upsert(group *group_model.Group) error {
+
+	result, err := pr.database.NamedExec(`
+	INSERT INTO gocms_groups (name, description) VALUES (:name, :description)
+	ON DUPLICATE KEY UPDATE description=VALUES(description), id=LAST_INSERT_ID(id)
+	`, group)
+	if err != nil {
+		log.Errorf("Error upserting group %v: %s\n", group.Name, err.Error())
+		return err
+	}
+	id, _ := result.LastInsertId()
+	group.Id = id
+
+	return nil
+}
+
+// Get gets a group via groupId
+func (pr *GroupsRepository) Get(groupId int64) (*group_model.Group, error) {
+	var group group_model.Group
+	err := pr.database.Get(&group, "SELECT * FROM gocms_groups WHERE id=?", groupId)
+	if err != nil {
+		log.Errorf("Error getting group %v from database: %s\n", groupId, err.Error())
+		return nil, err
+	}
+	return &group, nil
+}
+
+// Update updates the name and description of a group
+func (pr *GroupsRepository) Update(group *group_model.Group) error {
+
+	_, err := pr.database.NamedExec(`
+	UPDATE gocms_groups SET name=:name, description=:description WHERE id=:id
+	`, group)
+	if err != nil {
+		log.Errorf("Error updating group %v in database: %s\n", group.Id, err.Error())
+		return err
+	}
+
+	return nil
+}
+
 // Delete deletes a user group via groupId
 func (pr *GroupsRepository) Delete(groupId int64) error {
 
@@ -93,12 +145,120 @@ func (pr *GroupsRepository) GetUserGroups(userId int64) ([]*group_model.Group, e
 	return userGroups, nil
 }
 
+// GetGroupMembers get users assigned to a given group via groupId
+func (pr *GroupsRepository) GetGroupMembers(groupId int64) ([]*group_model.GroupMember, error) {
+	var members []*group_model.GroupMember
+	err := pr.database.Select(&members, `
+	SELECT u.id as userId, u.fullName, u.email, utg.created as added
+	FROM gocms_users_to_groups as utg
+	JOIN gocms_users as u
+	ON utg.userId = u.id
+	WHERE utg.groupId = ?
+	ORDER BY u.fullName
+	`, groupId)
+	if err != nil {
+		log.Errorf("Error getting members of group %v from database: %s\n", groupId, err.Error())
+		return nil, err
+	}
+	return members, nil
+}
+
+const parentLinksQuery = "SELECT groupId, parentGroupId FROM gocms_groups_to_parent_groups"
+
+// SelectParentLinks get every group to parent group link. Shared with the other repositories that walk group parents.
+func SelectParentLinks(q sqlx.Queryer) ([]*group_model.GroupParent, error) {
+	var links []*group_model.GroupParent
+	err := sqlx.Select(q, &links, parentLinksQuery)
+	if err != nil {
+		log.Errorf("Error getting parent group links from database: %s\n", err.Error())
+		return nil, err
+	}
+	return links, nil
+}
+
+// GetParentLinks get every group to parent group link
+func (pr *GroupsRepository) GetParentLinks() ([]*group_model.GroupParent, error) {
+	return SelectParentLinks(pr.database)
+}
+
+// GetParentGroups get the groups a given group directly inherits from via groupId
+func (pr *GroupsRepository) GetParentGroups(groupId int64) ([]*group_model.Group, error) {
+	var parentGroups []*group_model.Group
+	err := pr.database.Select(&parentGroups, `
+	SELECT grps.*
+	FROM gocms_groups_to_parent_groups as gtpg
+	JOIN gocms_groups as grps
+	ON gtpg.parentGroupId = grps.id
+	WHERE gtpg.groupId = ?
+	`, groupId)
+	if err != nil {
+		log.Errorf("Error getting parent groups for group %v from database: %s\n", groupId, err.Error())
+		return nil, err
+	}
+	return parentGroups, nil
+}
+
+// AddParentGroup makes a group inherit the permissions of the parent group. Links that would create a cycle are
+// refused. The links are locked while checking so two concurrent links can't form a cycle together.
+func (pr *GroupsRepository) AddParentGroup(groupId int64, parentGroupId int64) error {
+
+	tx, err := pr.database.Beginx()
+	if err != nil {
+		log.Errorf("Error starting transaction to add parent group %v to group %v: %s\n", parentGroupId, groupId, err.Error())
+		return err
+	}
+	defer tx.Rollback()
+
+	// the parent must not already inherit from the group
+	var links []*group_model.GroupParent
+	err = tx.Select(&links, parentLinksQuery+" FOR UPDATE")
+	if err != nil {
+		log.Errorf("Error locking parent group links: %s\n", err.Error())
+		return err
+	}
+	for _, ancestorId := range group_model.Ancestors(links, parentGroupId) {
+		if ancestorId == groupId {
+			return errors.NewToUser("A group can't inherit from itself or from one of its own child groups.")
+		}
+	}
+
+	_, err = tx.NamedExec(`
+	INSERT INTO gocms_groups_to_parent_groups (groupId, parentGroupId) VALUES (:groupId, :parentGroupId)
+	`, map[string]interface{}{"groupId": groupId, "parentGroupId": parentGroupId})
+	if err != nil {
+		log.Errorf("Error adding parent group %v to group %v: %s\n", parentGroupId, groupId, err.Error())
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Errorf("Error committing parent group %v for group %v: %s\n", parentGroupId, groupId, err.Error())
+		return err
+	}
+	return nil
+}
+
+// RemoveParentGroup removes the parent group from a group
+func (pr *GroupsRepository) RemoveParentGroup(groupId int64, parentGroupId int64) error {
+
+	_, err := pr.database.NamedExec(`
+	DELETE FROM gocms_groups_to_parent_groups
+	WHERE groupId=:groupId
+	AND parentGroupId=:parentGroupId
+	`, map[string]interface{}{"groupId": groupId, "parentGroupId": parentGroupId})
+	if err != nil {
+		log.Errorf("Error removing parent group %v from group %v: %s\n", parentGroupId, groupId, err.Error())
+		return err
+	}
+	return nil
+}
+
 // AddUserToGroupById adds a user to the group via userId and groupId
 func (pr *GroupsRepository) AddUserToGroupById(userId int64, groupId int64) error {
 
 	// insert user
 	_, err := pr.database.NamedExec(`
-	INSERT INTO gocms_users_to_groups (userId, groupId) VALUES (?, :groupId)
+	INSERT INTO gocms_users_to_groups (userId, groupId) VALUES (:userId, :groupId)
 	`, map[string]interface{}{"userId": userId, "groupId": groupId})
 	if err != nil {
 		log.Errorf("Error adding user %v to group %v: %s\n", userId, groupId, err.Error())
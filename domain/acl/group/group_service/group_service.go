@@ -1,32 +1,160 @@
 package group_service
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_service"
+	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
 	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/errors"
 )
 
+const maxNameLength = 255
+
 type IGroupService interface {
-	//Add(group *group_model.Group) error
-	//	Delete(int64) error
-	//	GetAll() (*[]group_model.Group, error)
-	//
-	//	GetUserGroups(userId int) ([]*group_model.Group, error)
-		AddUserToGroupByName(userId int64, groupName string) error
-		RemoveUserFromGroupByName(userId int64, groupName string) error
-	//
+	Add(group *group_model.Group) error
+	Get(int64) (*group_model.Group, error)
+	Update(group *group_model.Group) error
+	Delete(int64) error
+	GetAll() (*[]group_model.Group, error)
+	GetGroupMembers(groupId int64) ([]*group_model.GroupMember, error)
+
+	GetParentGroups(groupId int64) ([]*group_model.Group, error)
+	GetAncestors(groupId int64) ([]int64, error)
+	AddParentGroup(groupId int64, parentGroupId int64) error
+	RemoveParentGroup(groupId int64, parentGroupId int64) error
+
+	GetUserGroups(userId int64) ([]*group_model.Group, error)
+	AddUserToGroupById(userId int64, groupId int64) error
+	RemoveUserFromGroupById(userId int64, groupId int64) error
+	AddUserToGroupByName(userId int64, groupName string) error
+	RemoveUserFromGroupByName(userId int64, groupName string) error
 }
 
 type GroupService struct {
 	RepositoriesGroup *repository.RepositoriesGroup
+	AclService        access_control_service.IAclService
 }
 
-func DefaultGroupService(rg *repository.RepositoriesGroup) *GroupService {
+func DefaultGroupService(rg *repository.RepositoriesGroup, aclService access_control_service.IAclService) *GroupService {
 	groupService := &GroupService{
 		RepositoriesGroup: rg,
+		AclService:        aclService,
 	}
 
 	return groupService
 }
 
+func (gs *GroupService) Add(group *group_model.Group) error {
+
+	err := validateName(group)
+	if err != nil {
+		return err
+	}
+
+	return gs.RepositoriesGroup.GroupsRepository.Add(group)
+}
+
+func (gs *GroupService) Get(groupId int64) (*group_model.Group, error) {
+	return gs.RepositoriesGroup.GroupsRepository.Get(groupId)
+}
+
+// Update renames a group and changes its description.
+func (gs *GroupService) Update(group *group_model.Group) error {
+
+	err := validateName(group)
+	if err != nil {
+		return err
+	}
+
+	err = gs.RepositoriesGroup.GroupsRepository.Update(group)
+	if err != nil {
+		return err
+	}
+
+	// cached users hold the names of their groups
+	gs.AclService.InvalidateAll()
+	return nil
+}
+
+// Delete removes a group. Members lose the permissions they inherited from it.
+func (gs *GroupService) Delete(groupId int64) error {
+	err := gs.RepositoriesGroup.GroupsRepository.Delete(groupId)
+	if err != nil {
+		return err
+	}
+
+	gs.AclService.InvalidateAll()
+	return nil
+}
+
+func (gs *GroupService) GetAll() (*[]group_model.Group, error) {
+	return gs.RepositoriesGroup.GroupsRepository.GetAll()
+}
+
+func (gs *GroupService) GetGroupMembers(groupId int64) ([]*group_model.GroupMember, error) {
+	return gs.RepositoriesGroup.GroupsRepository.GetGroupMembers(groupId)
+}
+
+func (gs *GroupService) GetParentGroups(groupId int64) ([]*group_model.Group, error) {
+	return gs.RepositoriesGroup.GroupsRepository.GetParentGroups(groupId)
+}
+
+// GetAncestors returns the group id followed by the ids of every group it inherits from.
+func (gs *GroupService) GetAncestors(groupId int64) ([]int64, error) {
+	links, err := gs.RepositoriesGroup.GroupsRepository.GetParentLinks()
+	if err != nil {
+		return nil, err
+	}
+	return group_model.Ancestors(links, groupId), nil
+}
+
+// AddParentGroup makes the group inherit the parent groups permissions. Links that would create a cycle are refused.
+func (gs *GroupService) AddParentGroup(groupId int64, parentGroupId int64) error {
+
+	err := gs.RepositoriesGroup.GroupsRepository.AddParentGroup(groupId, parentGroupId)
+	if err != nil {
+		return err
+	}
+
+	gs.AclService.InvalidateAll()
+	return nil
+}
+
+func (gs *GroupService) RemoveParentGroup(groupId int64, parentGroupId int64) error {
+	err := gs.RepositoriesGroup.GroupsRepository.RemoveParentGroup(groupId, parentGroupId)
+	if err != nil {
+		return err
+	}
+
+	gs.AclService.InvalidateAll()
+	return nil
+}
+
+func (gs *GroupService) GetUserGroups(userId int64) ([]*group_model.Group, error) {
+	return gs.RepositoriesGroup.GroupsRepository.GetUserGroups(userId)
+}
+
+func (gs *GroupService) AddUserToGroupById(userId int64, groupId int64) error {
+	err := gs.RepositoriesGroup.GroupsRepository.AddUserToGroupById(userId, groupId)
+	if err != nil {
+		return err
+	}
+
+	gs.AclService.InvalidateUser(userId)
+	return nil
+}
+
+func (gs *GroupService) RemoveUserFromGroupById(userId int64, groupId int64) error {
+	err := gs.RepositoriesGroup.GroupsRepository.RemoveUserFromGroupById(userId, groupId)
+	if err != nil {
+		return err
+	}
+
+	gs.AclService.InvalidateUser(userId)
+	return nil
+}
 
 func (gs *GroupService) AddUserToGroupByName(userId int64, groupName string) error {
 
@@ -35,6 +163,7 @@ func (gs *GroupService) AddUserToGroupByName(userId int64, groupName string) err
 		return err
 	}
 
+	gs.AclService.InvalidateUser(userId)
 	return nil
 }
 
@@ -46,5 +175,17 @@ func (gs *GroupService) RemoveUserFromGroupByName(userId int64, groupName string
 		return err
 	}
 
+	gs.AclService.InvalidateUser(userId)
+	return nil
+}
+
+func validateName(group *group_model.Group) error {
+	group.Name = strings.TrimSpace(group.Name)
+	if group.Name == "" {
+		return errors.NewToUser("Group name is required.")
+	}
+	if len(group.Name) > maxNameLength {
+		return errors.NewToUser(fmt.Sprintf("Group name can't be longer than %v characters.", maxNameLength))
+	}
 	return nil
 }
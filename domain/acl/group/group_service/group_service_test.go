@@ -0,0 +1,66 @@
+package group_service
+
+import (
+	"testing"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_service"
+	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"github.com/cqlcorp/gocms/domain/acl/group/group_repository"
+	"github.com/cqlcorp/gocms/init/repository"
+)
+
+type fakeAclService struct {
+	access_control_service.IAclService
+	invalidatedAll   int
+	invalidatedUsers []int64
+}
+
+func (f *fakeAclService) InvalidateAll() {
+	f.invalidatedAll++
+}
+
+func (f *fakeAclService) InvalidateUser(userId int64) {
+	f.invalidatedUsers = append(f.invalidatedUsers, userId)
+}
+
+type fakeGroupsRepository struct {
+	group_repository.IGroupsRepository
+}
+
+func (fakeGroupsRepository) Update(group *group_model.Group) error                 { return nil }
+func (fakeGroupsRepository) Delete(groupId int64) error                            { return nil }
+func (fakeGroupsRepository) RemoveParentGroup(groupId int64, parentId int64) error { return nil }
+func (fakeGroupsRepository) AddUserToGroupById(userId int64, groupId int64) error  { return nil }
+func (fakeGroupsRepository) RemoveUserFromGroupById(userId int64, groupId int64) error {
+	return nil
+}
+
+func TestInvalidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(gs *GroupService) error
+		wantAll   int
+		wantUsers int
+		wantErr   bool
+	}{
+		{"rename", func(gs *GroupService) error { return gs.Update(&group_model.Group{Id: 1, Name: "Editors"}) }, 1, 0, false},
+		{"invalid name", func(gs *GroupService) error { return gs.Update(&group_model.Group{Id: 1, Name: " "}) }, 0, 0, true},
+		{"delete", func(gs *GroupService) error { return gs.Delete(1) }, 1, 0, false},
+		{"remove parent", func(gs *GroupService) error { return gs.RemoveParentGroup(1, 2) }, 1, 0, false},
+		{"add user", func(gs *GroupService) error { return gs.AddUserToGroupById(4, 1) }, 0, 1, false},
+		{"remove user", func(gs *GroupService) error { return gs.RemoveUserFromGroupById(4, 1) }, 0, 1, false},
+	}
+	for _, test := range tests {
+		aclService := &fakeAclService{}
+		gs := &GroupService{
+			RepositoriesGroup: &repository.RepositoriesGroup{GroupsRepository: fakeGroupsRepository{}},
+			AclService:        aclService,
+		}
+		if err := test.change(gs); (err != nil) != test.wantErr {
+			t.Errorf("%v: error = %v, want error %v", test.name, err, test.wantErr)
+		}
+		if aclService.invalidatedAll != test.wantAll || len(aclService.invalidatedUsers) != test.wantUsers {
+			t.Errorf("%v: invalidated all %v times and %v users, want %v and %v", test.name, aclService.invalidatedAll, aclService.invalidatedUsers, test.wantAll, test.wantUsers)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package impersonation_controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/api_utility"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_cookie"
+	"net/http"
+	"strconv"
+)
+
+type ImpersonationController struct {
+	routes        *routes.Routes
+	ServicesGroup *service.ServicesGroup
+	adminRoutes   *access_control_middleware.PermissionGroup
+}
+
+func DefaultImpersonationController(routes *routes.Routes, sg *service.ServicesGroup) *ImpersonationController {
+	impersonationController := &ImpersonationController{
+		routes:        routes,
+		ServicesGroup: sg,
+		adminRoutes:   access_control_middleware.NewPermissionGroup(routes.Auth, "/admin", sg.AclService, permissions.SUPER_ADMIN),
+	}
+
+	impersonationController.Default()
+	return impersonationController
+}
+
+func (ic *ImpersonationController) Default() {
+	ic.adminRoutes.POST("/user/:userId/impersonate", ic.start)
+	ic.routes.Auth.DELETE("/impersonate", ic.stop)
+}
+
+/**
+* @api {post} /admin/user/:userId/impersonate Impersonate User
+* @apiDescription Get a time limited token to act as the user. Password and email changes are blocked while impersonating.
+* Super admins can't be impersonated. Every impersonation is recorded in the audit log.
+* @apiName ImpersonateUser
+* @apiGroup Admin
+*
+* @apiUse AuthHeader
+* @apiUse UserDisplay
+* @apiUse AuthHeaderResponse
+* @apiPermission Admin
+ */
+func (ic *ImpersonationController) start(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	userId, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Id Field", err)
+		return
+	}
+
+	user, err := ic.ServicesGroup.UserService.Get(userId)
+	if err != nil {
+		errors.Response(c, http.StatusNotFound, errors.ApiError_UserDoesntExist, err)
+		return
+	}
+
+	if !user.Enabled || user.IsDeleted() {
+		errors.Response(c, http.StatusBadRequest, errors.ApiError_User_Disabled, nil)
+		return
+	}
+
+	// admins can't use impersonation to gain another admins access
+	if ic.ServicesGroup.AclService.IsAuthorized(permissions.SUPER_ADMIN, user.Id) {
+		errors.Response(c, http.StatusForbidden, "Super admins can't be impersonated.", nil)
+		return
+	}
+
+	tokenString, err := ic.ServicesGroup.ImpersonationService.Start(authUser.Id, user.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't start impersonation.", err)
+		return
+	}
+
+	authentication_cookie.StartImpersonation(c, tokenString)
+
+	c.JSON(http.StatusOK, user.GetUserDisplay())
+}
+
+/**
+* @api {delete} /impersonate Stop Impersonating
+* @apiDescription Ends the impersonation. The impersonation token stops working immediately.
+* @apiName StopImpersonating
+* @apiGroup Admin
+*
+* @apiUse AuthHeader
+ */
+func (ic *ImpersonationController) stop(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	if authUser.ImpersonatedBy == 0 {
+		errors.Response(c, http.StatusBadRequest, "You are not impersonating a user.", nil)
+		return
+	}
+
+	err := ic.ServicesGroup.ImpersonationService.Stop(authUser.ImpersonationId)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't stop impersonation.", err)
+		return
+	}
+
+	authentication_cookie.StopImpersonation(c)
+
+	c.Status(http.StatusOK)
+}
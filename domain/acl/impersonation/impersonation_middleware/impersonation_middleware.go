@@ -0,0 +1,21 @@
+package impersonation_middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/cqlcorp/gocms/utility/api_utility"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"net/http"
+)
+
+// BlockWhileImpersonating stops sensitive actions, such as changing a password or email, from being done by an admin
+// impersonating the user.
+func BlockWhileImpersonating() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authUser, ok := api_utility.GetUserFromContext(c)
+		if ok && authUser.ImpersonatedBy != 0 {
+			errors.Response(c, http.StatusForbidden, "This action is not allowed while impersonating a user.", nil)
+			return
+		}
+		c.Next()
+	}
+}
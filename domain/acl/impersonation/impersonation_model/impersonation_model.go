@@ -0,0 +1,18 @@
+package impersonation_model
+
+import "time"
+
+type Impersonation struct {
+	Id           int64     `json:"id" db:"id"`
+	AdminId      int64     `json:"adminId" db:"adminId"`
+	UserId       int64     `json:"userId" db:"userId"`
+	IsActive     bool      `json:"isActive" db:"isActive"`
+	Expires      time.Time `json:"expires" db:"expires"`
+	Created      time.Time `json:"created" db:"created"`
+	LastModified time.Time `json:"lastModified" db:"lastModified"`
+}
+
+// IsValid reports if the impersonation can still be used.
+func (impersonation *Impersonation) IsValid() bool {
+	return impersonation.IsActive && time.Now().Before(impersonation.Expires)
+}
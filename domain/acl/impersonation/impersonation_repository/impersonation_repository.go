@@ -0,0 +1,82 @@
+package impersonation_repository
+
+import (
+	"github.com/cqlcorp/gocms/domain/acl/impersonation/impersonation_model"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type IImpersonationRepository interface {
+	Add(*impersonation_model.Impersonation) error
+	Get(int64) (*impersonation_model.Impersonation, error)
+	SetInactive(int64) error
+	GetByUserId(int64) ([]*impersonation_model.Impersonation, error)
+}
+
+type ImpersonationRepository struct {
+	database *sqlx.DB
+}
+
+func DefaultImpersonationRepository(dbx *sqlx.DB) *ImpersonationRepository {
+	impersonationRepository := &ImpersonationRepository{
+		database: dbx,
+	}
+
+	return impersonationRepository
+}
+
+func (ir *ImpersonationRepository) Add(impersonation *impersonation_model.Impersonation) error {
+	impersonation.Created = time.Now()
+	// insert row
+	result, err := ir.database.NamedExec(`
+	INSERT INTO gocms_impersonations (adminId, userId, isActive, expires) VALUES (:adminId, :userId, :isActive, :expires)
+	`, impersonation)
+	if err != nil {
+		log.Errorf("Error adding impersonation to database: %s\n", err.Error())
+		return err
+	}
+
+	// add id to impersonation object
+	id, _ := result.LastInsertId()
+	impersonation.Id = id
+
+	return nil
+}
+
+func (ir *ImpersonationRepository) Get(id int64) (*impersonation_model.Impersonation, error) {
+	var impersonation impersonation_model.Impersonation
+	err := ir.database.Get(&impersonation, `
+	SELECT * FROM gocms_impersonations WHERE id=?
+	`, id)
+	if err != nil {
+		log.Errorf("Error getting impersonation %v from database: %s\n", id, err.Error())
+		return nil, err
+	}
+	return &impersonation, nil
+}
+
+func (ir *ImpersonationRepository) SetInactive(id int64) error {
+	_, err := ir.database.Exec(`
+	UPDATE gocms_impersonations SET isActive=0 WHERE id=?
+	`, id)
+	if err != nil {
+		log.Errorf("Error ending impersonation %v in database: %s\n", id, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetByUserId returns the impersonations of the user, or by the user when they are an admin.
+func (ir *ImpersonationRepository) GetByUserId(userId int64) ([]*impersonation_model.Impersonation, error) {
+	var impersonations []*impersonation_model.Impersonation
+	err := ir.database.Select(&impersonations, `
+	SELECT * FROM gocms_impersonations WHERE userId=? OR adminId=? ORDER BY id
+	`, userId, userId)
+	if err != nil {
+		log.Errorf("Error getting impersonations for user %v from database: %s\n", userId, err.Error())
+		return nil, err
+	}
+	return impersonations, nil
+}
@@ -0,0 +1,102 @@
+package impersonation_service
+
+import (
+	"fmt"
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/context/consts"
+	"github.com/cqlcorp/gocms/domain/acl/impersonation/impersonation_model"
+	"github.com/cqlcorp/gocms/domain/logs/log_model"
+	"github.com/cqlcorp/gocms/domain/logs/log_service"
+	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/dgrijalva/jwt-go"
+	"time"
+)
+
+type IImpersonationService interface {
+	Start(adminId int64, userId int64) (string, error)
+	Stop(impersonationId int64) error
+	Verify(impersonationId int64, adminId int64, userId int64) bool
+}
+
+type ImpersonationService struct {
+	LogService        log_service.ILogService
+	RepositoriesGroup *repository.RepositoriesGroup
+}
+
+func DefaultImpersonationService(rg *repository.RepositoriesGroup, logService *log_service.LogService) *ImpersonationService {
+	impersonationService := &ImpersonationService{
+		LogService:        logService,
+		RepositoriesGroup: rg,
+	}
+
+	return impersonationService
+}
+
+// Start records a new impersonation and returns a user token for it. The token claims hold both the impersonated
+// user, as userId so it works anywhere a normal token does, and the admin as impersonatedBy.
+func (is *ImpersonationService) Start(adminId int64, userId int64) (string, error) {
+
+	if adminId == userId {
+		return "", errors.NewToUser("You can't impersonate yourself.")
+	}
+
+	impersonation := &impersonation_model.Impersonation{
+		AdminId:  adminId,
+		UserId:   userId,
+		IsActive: true,
+		Expires:  time.Now().Add(time.Minute * time.Duration(context.Config.DbVars.ImpersonationTimeout)),
+	}
+	err := is.RepositoriesGroup.ImpersonationRepository.Add(impersonation)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"userId":          userId,
+		"impersonatedBy":  adminId,
+		"impersonationId": impersonation.Id,
+		consts.TOKEN_TYPE_CLAIM: consts.TOKEN_TYPE_AUTH,
+		"iat":             time.Now().Unix(),
+		"exp":             impersonation.Expires.Unix(),
+	})
+	tokenString, err := token.SignedString(context.Config.DbVars.GetRsaPrivateKey(true))
+	if err != nil {
+		log.Errorf("Error signing impersonation token for user %v: %v\n", userId, err.Error())
+		return "", err
+	}
+
+	is.LogService.RecordAudit(userId, adminId, log_model.Audit_Impersonation_Start, fmt.Sprintf("impersonation %v expires %v", impersonation.Id, impersonation.Expires.Format(time.RFC3339)))
+
+	return tokenString, nil
+}
+
+// Stop ends an impersonation so its token can no longer be used.
+func (is *ImpersonationService) Stop(impersonationId int64) error {
+
+	impersonation, err := is.RepositoriesGroup.ImpersonationRepository.Get(impersonationId)
+	if err != nil {
+		return err
+	}
+
+	err = is.RepositoriesGroup.ImpersonationRepository.SetInactive(impersonationId)
+	if err != nil {
+		return err
+	}
+
+	is.LogService.RecordAudit(impersonation.UserId, impersonation.AdminId, log_model.Audit_Impersonation_Stop, fmt.Sprintf("impersonation %v", impersonation.Id))
+
+	return nil
+}
+
+// Verify checks that the impersonation from a token is still active and matches the token claims.
+func (is *ImpersonationService) Verify(impersonationId int64, adminId int64, userId int64) bool {
+
+	impersonation, err := is.RepositoriesGroup.ImpersonationRepository.Get(impersonationId)
+	if err != nil {
+		return false
+	}
+
+	return impersonation.IsValid() && impersonation.AdminId == adminId && impersonation.UserId == userId
+}
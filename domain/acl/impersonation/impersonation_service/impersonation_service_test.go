@@ -0,0 +1,115 @@
+package impersonation_service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/cqlcorp/gocms/domain/acl/impersonation/impersonation_model"
+	"github.com/cqlcorp/gocms/domain/logs/log_model"
+	"github.com/cqlcorp/gocms/domain/logs/log_service"
+	"github.com/cqlcorp/gocms/init/repository"
+)
+
+type fakeImpersonationRepository struct {
+	impersonations map[int64]*impersonation_model.Impersonation
+	added          int
+}
+
+func (f *fakeImpersonationRepository) Add(impersonation *impersonation_model.Impersonation) error {
+	f.added++
+	return nil
+}
+
+func (f *fakeImpersonationRepository) Get(id int64) (*impersonation_model.Impersonation, error) {
+	impersonation, ok := f.impersonations[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return impersonation, nil
+}
+
+func (f *fakeImpersonationRepository) SetInactive(id int64) error {
+	f.impersonations[id].IsActive = false
+	return nil
+}
+
+func (f *fakeImpersonationRepository) GetByUserId(userId int64) ([]*impersonation_model.Impersonation, error) {
+	return nil, nil
+}
+
+type fakeLogService struct {
+	log_service.ILogService
+	actions []string
+}
+
+func (f *fakeLogService) RecordAudit(userId int64, actorId int64, action string, detail string) error {
+	f.actions = append(f.actions, action)
+	return nil
+}
+
+func testService() (*ImpersonationService, *fakeImpersonationRepository, *fakeLogService) {
+	repo := &fakeImpersonationRepository{impersonations: map[int64]*impersonation_model.Impersonation{
+		1: {Id: 1, AdminId: 2, UserId: 3, IsActive: true, Expires: time.Now().Add(time.Hour)},
+		2: {Id: 2, AdminId: 2, UserId: 3, IsActive: false, Expires: time.Now().Add(time.Hour)},
+		3: {Id: 3, AdminId: 2, UserId: 3, IsActive: true, Expires: time.Now().Add(-time.Minute)},
+	}}
+	logService := &fakeLogService{}
+	return &ImpersonationService{
+		LogService:        logService,
+		RepositoriesGroup: &repository.RepositoriesGroup{ImpersonationRepository: repo},
+	}, repo, logService
+}
+
+func TestVerify(t *testing.T) {
+	service, _, _ := testService()
+
+	tests := []struct {
+		name            string
+		impersonationId int64
+		adminId         int64
+		userId          int64
+		want            bool
+	}{
+		{"active", 1, 2, 3, true},
+		{"stopped", 2, 2, 3, false},
+		{"expired", 3, 2, 3, false},
+		{"missing", 4, 2, 3, false},
+		{"other admin", 1, 5, 3, false},
+		{"other user", 1, 2, 5, false},
+	}
+	for _, test := range tests {
+		if got := service.Verify(test.impersonationId, test.adminId, test.userId); got != test.want {
+			t.Errorf("%v: Verify(%v, %v, %v) = %v, want %v", test.name, test.impersonationId, test.adminId, test.userId, got, test.want)
+		}
+	}
+}
+
+func TestStop(t *testing.T) {
+	service, _, logService := testService()
+
+	if err := service.Stop(1); err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+	if service.Verify(1, 2, 3) {
+		t.Error("Verify() = true after the impersonation was stopped")
+	}
+	if len(logService.actions) != 1 || logService.actions[0] != log_model.Audit_Impersonation_Stop {
+		t.Errorf("Stop() audited %v, want [%v]", logService.actions, log_model.Audit_Impersonation_Stop)
+	}
+
+	if err := service.Stop(4); err == nil {
+		t.Error("Stop() of a missing impersonation didn't fail")
+	}
+}
+
+func TestStartSelf(t *testing.T) {
+	service, repo, logService := testService()
+
+	if _, err := service.Start(2, 2); err == nil {
+		t.Error("Start() let an admin impersonate themselves")
+	}
+	if repo.added != 0 || len(logService.actions) != 0 {
+		t.Errorf("Start() recorded an impersonation of the admin themselves")
+	}
+}
@@ -0,0 +1,75 @@
+package password_history_repository
+
+import (
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_model"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type IPasswordHistoryRepository interface {
+	Add(*password_policy_model.PasswordHistory) error
+	GetLatestForUser(int64, int64) ([]password_policy_model.PasswordHistory, error)
+	DeleteAllButLatestForUser(int64, int64) error
+}
+
+type PasswordHistoryRepository struct {
+	database *sqlx.DB
+}
+
+func DefaultPasswordHistoryRepository(dbx *sqlx.DB) *PasswordHistoryRepository {
+
+	passwordHistoryRepository := &PasswordHistoryRepository{
+		database: dbx,
+	}
+
+	return passwordHistoryRepository
+}
+
+func (phr *PasswordHistoryRepository) Add(passwordHistory *password_policy_model.PasswordHistory) error {
+	passwordHistory.Created = time.Now()
+	// insert row
+	result, err := phr.database.NamedExec(`
+	INSERT INTO gocms_password_history (userId, password, created) VALUES (:userId, :password, :created)
+	`, passwordHistory)
+	if err != nil {
+		log.Errorf("Error adding password history to database: %s", err.Error())
+		return err
+	}
+
+	// add id to password history object
+	id, _ := result.LastInsertId()
+	passwordHistory.Id = id
+
+	return nil
+}
+
+// get the latest passwords for a user, newest first
+func (phr *PasswordHistoryRepository) GetLatestForUser(userId int64, limit int64) ([]password_policy_model.PasswordHistory, error) {
+	var passwordHistory []password_policy_model.PasswordHistory
+	err := phr.database.Select(&passwordHistory, `
+	SELECT * FROM gocms_password_history WHERE userId=? ORDER BY created DESC, id DESC LIMIT ?
+	`, userId, limit)
+	if err != nil {
+		log.Errorf("Error getting password history for user from database: %s", err.Error())
+		return nil, err
+	}
+	return passwordHistory, nil
+}
+
+// remove all but the newest passwords for a user
+func (phr *PasswordHistoryRepository) DeleteAllButLatestForUser(userId int64, keep int64) error {
+	_, err := phr.database.Exec(`
+	DELETE FROM gocms_password_history WHERE userId=? AND id NOT IN (
+		SELECT id FROM (
+			SELECT id FROM gocms_password_history WHERE userId=? ORDER BY created DESC, id DESC LIMIT ?
+		) AS latest
+	)
+	`, userId, userId, keep)
+	if err != nil {
+		log.Errorf("Error deleting old password history from database: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
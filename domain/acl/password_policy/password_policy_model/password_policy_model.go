@@ -0,0 +1,15 @@
+package password_policy_model
+
+import "time"
+
+const (
+	ApiError_Password_Policy  = "Password does not meet the requirements."
+	ApiError_Password_Expired = "Your password has expired and must be changed."
+)
+
+type PasswordHistory struct {
+	Id       int64     `db:"id"`
+	UserId   int64     `db:"userId"`
+	Password string    `db:"password"`
+	Created  time.Time `db:"created"`
+}
@@ -0,0 +1,42 @@
+package password_policy_service
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isBreached looks the password up in a local copy of a breached password list.
+// The list is split using k-anonymity prefixes the same way as the Pwned Passwords range API. The upper case
+// SHA-1 of the password is split after the first 5 characters, the prefix names the file <dir>/<PREFIX>.txt and
+// each line of that file holds a remaining suffix optionally followed by :<count>.
+func isBreached(dir string, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(dir, prefix+".txt"))
+	if os.IsNotExist(err) { // no breached passwords share this prefix
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, ":"); i >= 0 {
+			line = line[:i]
+		}
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
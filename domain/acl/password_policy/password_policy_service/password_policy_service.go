@@ -0,0 +1,157 @@
+package password_policy_service
+
+import (
+	"fmt"
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_service"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_model"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const PASSWORD_FIELD = "password"
+
+type IPasswordPolicyService interface {
+	Validate(*user_model.User, string) errors.FieldErrors
+	AddToHistory(int64, string) error
+	IsExpired(*user_model.User) bool
+}
+
+type PasswordPolicyService struct {
+	AuthService       authentication_service.IAuthService
+	RepositoriesGroup *repository.RepositoriesGroup
+}
+
+func DefaultPasswordPolicyService(rg *repository.RepositoriesGroup, authService *authentication_service.AuthService) *PasswordPolicyService {
+	passwordPolicyService := &PasswordPolicyService{
+		AuthService:       authService,
+		RepositoriesGroup: rg,
+	}
+
+	return passwordPolicyService
+}
+
+// Validate checks a new password for the user against every policy rule and returns an error for each rule it breaks.
+// The user may not exist yet, in which case the password history is not checked.
+func (pps *PasswordPolicyService) Validate(user *user_model.User, password string) errors.FieldErrors {
+	fieldErrors := errors.FieldErrors{}
+
+	// minimum length
+	minLength := int(context.Config.DbVars.PasswordMinLength)
+	if utf8.RuneCountInString(password) < minLength {
+		fieldErrors.Add(PASSWORD_FIELD, fmt.Sprintf("Password must be at least %d characters long.", minLength))
+	}
+
+	// complexity using the users own details so they can't be used to pad a weak password
+	if !pps.AuthService.PasswordIsComplex(password, getUserInputs(user)...) {
+		fieldErrors.Add(PASSWORD_FIELD, "Password is too easy to guess. Avoid common words and parts of your name or email.")
+	}
+
+	// known breached passwords
+	if context.Config.DbVars.BreachedPasswordsDir != "" {
+		breached, err := isBreached(context.Config.DbVars.BreachedPasswordsDir, password)
+		if err != nil { // log error but don't fail
+			log.Errorf("Error checking breached passwords: %s\n", err.Error())
+		} else if breached {
+			fieldErrors.Add(PASSWORD_FIELD, "Password has appeared in a data breach. Please choose a different password.")
+		}
+	}
+
+	// password history
+	if user != nil && user.Id != 0 && context.Config.DbVars.PasswordHistoryCount > 0 && pps.isInHistory(user, password) {
+		fieldErrors.Add(PASSWORD_FIELD, fmt.Sprintf("Password must not match any of your last %d passwords.", context.Config.DbVars.PasswordHistoryCount))
+	}
+
+	if len(fieldErrors) > 0 {
+		return fieldErrors
+	}
+
+	return nil
+}
+
+// AddToHistory records a new password hash for the user and removes any history no longer needed by the policy.
+func (pps *PasswordPolicyService) AddToHistory(userId int64, passwordHash string) error {
+	err := pps.RepositoriesGroup.PasswordHistoryRepository.Add(&password_policy_model.PasswordHistory{
+		UserId:   userId,
+		Password: passwordHash,
+	})
+	if err != nil {
+		return err
+	}
+
+	// always keep the latest so the password age is known
+	keep := context.Config.DbVars.PasswordHistoryCount
+	if keep < 1 {
+		keep = 1
+	}
+
+	return pps.RepositoriesGroup.PasswordHistoryRepository.DeleteAllButLatestForUser(userId, keep)
+}
+
+// IsExpired reports if the users password is older than the maximum password age.
+func (pps *PasswordPolicyService) IsExpired(user *user_model.User) bool {
+	if context.Config.DbVars.PasswordMaxAge <= 0 {
+		return false
+	}
+
+	// users who have never changed their password are aged from account creation
+	lastChanged := user.Created
+	passwordHistory, err := pps.RepositoriesGroup.PasswordHistoryRepository.GetLatestForUser(user.Id, 1)
+	if err != nil {
+		log.Errorf("Error getting password age for user %v: %s\n", user.Id, err.Error())
+		return false
+	}
+	if len(passwordHistory) > 0 {
+		lastChanged = passwordHistory[0].Created
+	}
+
+	return time.Since(lastChanged) > time.Hour*24*time.Duration(context.Config.DbVars.PasswordMaxAge)
+}
+
+func (pps *PasswordPolicyService) isInHistory(user *user_model.User, password string) bool {
+
+	// the current password may predate the history
+	if user.Password != "" && pps.AuthService.VerifyPassword(user.Password, password) {
+		return true
+	}
+
+	passwordHistory, err := pps.RepositoriesGroup.PasswordHistoryRepository.GetLatestForUser(user.Id, context.Config.DbVars.PasswordHistoryCount)
+	if err != nil {
+		log.Errorf("Error getting password history for user %v: %s\n", user.Id, err.Error())
+		return false
+	}
+
+	for _, previous := range passwordHistory {
+		if pps.AuthService.VerifyPassword(previous.Password, password) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getUserInputs(user *user_model.User) []string {
+	userInputs := []string{}
+	if user == nil {
+		return userInputs
+	}
+
+	if user.FullName != "" {
+		userInputs = append(userInputs, user.FullName)
+		userInputs = append(userInputs, strings.Fields(user.FullName)...)
+	}
+
+	if user.Email != "" {
+		userInputs = append(userInputs, user.Email)
+		if at := strings.Index(user.Email, "@"); at > 0 {
+			userInputs = append(userInputs, user.Email[:at])
+		}
+	}
+
+	return userInputs
+}
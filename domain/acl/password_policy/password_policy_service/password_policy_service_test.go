@@ -0,0 +1,213 @@
+package password_policy_service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_service"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_model"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/init/repository"
+)
+
+// fakeAuthService hashes by prefixing so history checks don't need a real hasher. Passwords containing "weak" aren't
+// complex, neither are passwords containing one of the user inputs.
+type fakeAuthService struct {
+	authentication_service.IAuthService
+}
+
+func (fakeAuthService) VerifyPassword(passwordHash string, password string) bool {
+	return passwordHash == "hash:"+password
+}
+
+func (fakeAuthService) PasswordIsComplex(password string, userInputs ...string) bool {
+	for _, input := range userInputs {
+		if strings.Contains(strings.ToLower(password), strings.ToLower(input)) {
+			return false
+		}
+	}
+	return !strings.Contains(password, "weak")
+}
+
+type fakeHistoryRepository struct {
+	history []password_policy_model.PasswordHistory
+	added   []*password_policy_model.PasswordHistory
+	kept    int64
+}
+
+func (f *fakeHistoryRepository) Add(history *password_policy_model.PasswordHistory) error {
+	f.added = append(f.added, history)
+	return nil
+}
+
+func (f *fakeHistoryRepository) GetLatestForUser(userId int64, limit int64) ([]password_policy_model.PasswordHistory, error) {
+	if int64(len(f.history)) > limit {
+		return f.history[:limit], nil
+	}
+	return f.history, nil
+}
+
+func (f *fakeHistoryRepository) DeleteAllButLatestForUser(userId int64, keep int64) error {
+	f.kept = keep
+	return nil
+}
+
+func testService(history ...string) (*PasswordPolicyService, *fakeHistoryRepository) {
+	repo := &fakeHistoryRepository{}
+	for _, password := range history {
+		repo.history = append(repo.history, password_policy_model.PasswordHistory{UserId: 4, Password: "hash:" + password})
+	}
+	return &PasswordPolicyService{
+		AuthService:       fakeAuthService{},
+		RepositoriesGroup: &repository.RepositoriesGroup{PasswordHistoryRepository: repo},
+	}, repo
+}
+
+func setPolicy(minLength int64, historyCount int64, maxAge int64, breachedDir string) {
+	context.Config.DbVars.PasswordMinLength = minLength
+	context.Config.DbVars.PasswordHistoryCount = historyCount
+	context.Config.DbVars.PasswordMaxAge = maxAge
+	context.Config.DbVars.BreachedPasswordsDir = breachedDir
+}
+
+func TestValidate(t *testing.T) {
+	setPolicy(10, 2, 0, "")
+	defer setPolicy(0, 0, 0, "")
+
+	service, _ := testService("previous-one", "previous-two", "previous-three")
+	user := &user_model.User{Id: 4, FullName: "Jane Doe", Email: "jane.doe@example.com", Password: "hash:current-password"}
+	newUser := &user_model.User{FullName: "Jane Doe", Email: "jane.doe@example.com"}
+
+	tests := []struct {
+		name     string
+		user     *user_model.User
+		password string
+		valid    bool
+	}{
+		{"valid", user, "a fresh password", true},
+		{"too short", user, "short", false},
+		{"not complex", user, "weak but long enough", false},
+		{"contains the users name", user, "hello jane doe 123", false},
+		{"contains the email name", user, "jane.doe-rules-2024", false},
+		{"current password", user, "current-password", false},
+		{"previous password", user, "previous-two", false},
+		{"older than the history", user, "previous-three", true},
+		{"new user skips history", newUser, "previous-one", true},
+		{"no user", nil, "a fresh password", true},
+	}
+	for _, test := range tests {
+		fieldErrors := service.Validate(test.user, test.password)
+		if (fieldErrors == nil) != test.valid {
+			t.Errorf("%v: Validate(%q) = %v, want valid %v", test.name, test.password, fieldErrors, test.valid)
+		}
+		for _, fieldError := range fieldErrors {
+			if fieldError.Field != PASSWORD_FIELD {
+				t.Errorf("%v: error on field %q, want %q", test.name, fieldError.Field, PASSWORD_FIELD)
+			}
+		}
+	}
+}
+
+func TestValidateHistoryDisabled(t *testing.T) {
+	setPolicy(0, 0, 0, "")
+
+	service, _ := testService("previous-one")
+	user := &user_model.User{Id: 4, Password: "hash:current-password"}
+	if fieldErrors := service.Validate(user, "previous-one"); fieldErrors != nil {
+		t.Errorf("Validate() with history disabled = %v, want valid", fieldErrors)
+	}
+}
+
+func TestValidateBreached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "breached")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// SHA-1 of "password1234" is E6B6AFBD6D76BB5D2041542D7D2E3FAC5BB05593
+	err = ioutil.WriteFile(filepath.Join(dir, "E6B6A.txt"), []byte("0000000000000000000000000000000000A:1\nfbd6d76bb5d2041542d7d2e3fac5bb05593:12\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setPolicy(0, 0, 0, dir)
+	defer setPolicy(0, 0, 0, "")
+
+	service, _ := testService()
+	if fieldErrors := service.Validate(nil, "password1234"); fieldErrors == nil {
+		t.Error("Validate() accepted a breached password")
+	}
+	if fieldErrors := service.Validate(nil, "password12345"); fieldErrors != nil {
+		t.Errorf("Validate() = %v for a password that isn't breached", fieldErrors)
+	}
+}
+
+func TestAddToHistory(t *testing.T) {
+	tests := []struct {
+		historyCount int64
+		wantKept     int64
+	}{
+		{5, 5},
+		{1, 1},
+		{0, 1}, // the latest is kept for the password age
+	}
+	for _, test := range tests {
+		setPolicy(0, test.historyCount, 0, "")
+		service, repo := testService()
+		if err := service.AddToHistory(4, "hash:new"); err != nil {
+			t.Fatalf("AddToHistory() error: %v", err)
+		}
+		if len(repo.added) != 1 || repo.added[0].UserId != 4 || repo.added[0].Password != "hash:new" {
+			t.Errorf("history count %v: added %v", test.historyCount, repo.added)
+		}
+		if repo.kept != test.wantKept {
+			t.Errorf("history count %v: kept %v, want %v", test.historyCount, repo.kept, test.wantKept)
+		}
+	}
+	setPolicy(0, 0, 0, "")
+}
+
+func TestIsExpired(t *testing.T) {
+	defer setPolicy(0, 0, 0, "")
+	old := &user_model.User{Id: 4, Created: time.Now().AddDate(0, 0, -100)}
+
+	setPolicy(0, 0, 0, "")
+	service, repo := testService()
+	if service.IsExpired(old) {
+		t.Error("IsExpired() = true without a maximum age")
+	}
+
+	setPolicy(0, 0, 90, "")
+	if !service.IsExpired(old) {
+		t.Error("IsExpired() = false for a password older than the maximum age")
+	}
+
+	repo.history = []password_policy_model.PasswordHistory{{UserId: 4, Password: "hash:x", Created: time.Now().AddDate(0, 0, -10)}}
+	if service.IsExpired(old) {
+		t.Error("IsExpired() = true for a recently changed password")
+	}
+}
+
+func TestGetUserInputs(t *testing.T) {
+	tests := []struct {
+		user *user_model.User
+		want []string
+	}{
+		{nil, []string{}},
+		{&user_model.User{}, []string{}},
+		{&user_model.User{FullName: "Jane Doe", Email: "jd@example.com"}, []string{"Jane Doe", "Jane", "Doe", "jd@example.com", "jd"}},
+		{&user_model.User{Email: "@example.com"}, []string{"@example.com"}},
+	}
+	for _, test := range tests {
+		if got := getUserInputs(test.user); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("getUserInputs(%+v) = %v, want %v", test.user, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package permissions
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/cqlcorp/gocms/utility/errors"
+)
+
+// Operators of permission expressions. They are matched case insensitively.
+const (
+	OPERATOR_AND = "AND"
+	OPERATOR_OR  = "OR"
+)
+
+// Expression is a requirement on the permissions a user holds, such as (blog.edit AND blog.publish) OR super_admin.
+type Expression interface {
+	// Evaluate reports if the requirement is met. has is asked about each permission as it's needed.
+	Evaluate(has func(permission string) bool) bool
+	// Permissions lists every permission the expression refers to in the order they appear.
+	Permissions() []string
+	String() string
+}
+
+type permissionExpression struct {
+	name string
+}
+
+type operatorExpression struct {
+	operator string
+	operands []Expression
+}
+
+// AnyOf is met when the user holds at least one of the permissions.
+func AnyOf(names ...string) Expression {
+	return newOperator(OPERATOR_OR, names)
+}
+
+// AllOf is met when the user holds every one of the permissions.
+func AllOf(names ...string) Expression {
+	return newOperator(OPERATOR_AND, names)
+}
+
+func newOperator(operator string, names []string) Expression {
+	if len(names) == 1 {
+		return &permissionExpression{name: names[0]}
+	}
+	operands := make([]Expression, len(names))
+	for i, name := range names {
+		operands[i] = &permissionExpression{name: name}
+	}
+	return &operatorExpression{operator: operator, operands: operands}
+}
+
+func (pe *permissionExpression) Evaluate(has func(permission string) bool) bool {
+	return has(pe.name)
+}
+
+func (pe *permissionExpression) Permissions() []string {
+	return []string{pe.name}
+}
+
+func (pe *permissionExpression) String() string {
+	return pe.name
+}
+
+func (oe *operatorExpression) Evaluate(has func(permission string) bool) bool {
+	for _, operand := range oe.operands {
+		met := operand.Evaluate(has)
+		if oe.operator == OPERATOR_OR && met {
+			return true
+		}
+		if oe.operator == OPERATOR_AND && !met {
+			return false
+		}
+	}
+	return oe.operator == OPERATOR_AND
+}
+
+func (oe *operatorExpression) Permissions() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, operand := range oe.operands {
+		for _, name := range operand.Permissions() {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func (oe *operatorExpression) String() string {
+	parts := make([]string, len(oe.operands))
+	for i, operand := range oe.operands {
+		parts[i] = operand.String()
+		if _, ok := operand.(*operatorExpression); ok {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " "+oe.operator+" ")
+}
+
+// MapPermissions returns a copy of the expression with every permission name replaced. It's used to resolve names,
+// such as plugin permissions that are declared without the plugin namespace.
+func MapPermissions(expression Expression, mapName func(name string) (string, error)) (Expression, error) {
+	switch e := expression.(type) {
+	case *permissionExpression:
+		name, err := mapName(e.name)
+		if err != nil {
+			return nil, err
+		}
+		return &permissionExpression{name: name}, nil
+	case *operatorExpression:
+		operands := make([]Expression, len(e.operands))
+		for i, operand := range e.operands {
+			mapped, err := MapPermissions(operand, mapName)
+			if err != nil {
+				return nil, err
+			}
+			operands[i] = mapped
+		}
+		return &operatorExpression{operator: e.operator, operands: operands}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("unknown permission expression %v", expression))
+}
+
+// ParseExpression parses permission names joined with AND and OR. AND binds tighter than OR and parentheses group.
+// Ex: (blog.edit AND blog.publish) OR super_admin
+func ParseExpression(expression string) (Expression, error) {
+	p := &expressionParser{tokens: tokenize(expression)}
+	if len(p.tokens) == 0 {
+		return nil, errors.NewToUser("Permission expression is empty.")
+	}
+
+	parsed, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.position < len(p.tokens) {
+		return nil, errors.NewToUser(fmt.Sprintf("Unexpected '%v' in permission expression '%v'.", p.tokens[p.position], expression))
+	}
+	return parsed, nil
+}
+
+type expressionParser struct {
+	tokens   []string
+	position int
+}
+
+func (p *expressionParser) peek() string {
+	if p.position < len(p.tokens) {
+		return p.tokens[p.position]
+	}
+	return ""
+}
+
+func (p *expressionParser) parseOr() (Expression, error) {
+	return p.parseOperator(OPERATOR_OR, p.parseAnd)
+}
+
+func (p *expressionParser) parseAnd() (Expression, error) {
+	return p.parseOperator(OPERATOR_AND, p.parseOperand)
+}
+
+func (p *expressionParser) parseOperator(operator string, parseOperand func() (Expression, error)) (Expression, error) {
+	first, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	operands := []Expression{first}
+	for strings.EqualFold(p.peek(), operator) {
+		p.position++
+		next, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &operatorExpression{operator: operator, operands: operands}, nil
+}
+
+func (p *expressionParser) parseOperand() (Expression, error) {
+	token := p.peek()
+	p.position++
+
+	switch {
+	case token == "":
+		return nil, errors.NewToUser("Permission expression ends unexpectedly.")
+	case token == "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.NewToUser("Permission expression is missing a closing parenthesis.")
+		}
+		p.position++
+		return inner, nil
+	case token == ")" || strings.EqualFold(token, OPERATOR_AND) || strings.EqualFold(token, OPERATOR_OR):
+		return nil, errors.NewToUser(fmt.Sprintf("Expected a permission but found '%v'.", token))
+	}
+
+	if token != SUPER_ADMIN {
+		err := ValidateName(token)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &permissionExpression{name: token}, nil
+}
+
+// tokenize splits an expression into parentheses and words
+func tokenize(expression string) []string {
+	var tokens []string
+	var word bytes.Buffer
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range expression {
+		switch {
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
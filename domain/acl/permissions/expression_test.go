@@ -0,0 +1,104 @@
+package permissions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		expression  string
+		string      string
+		permissions []string
+	}{
+		{"blog.edit", "blog.edit", []string{"blog.edit"}},
+		{"blog.edit OR blog.publish", "blog.edit OR blog.publish", []string{"blog.edit", "blog.publish"}},
+		{"blog.edit and blog.publish", "blog.edit AND blog.publish", []string{"blog.edit", "blog.publish"}},
+		{"a AND b OR c", "(a AND b) OR c", []string{"a", "b", "c"}},
+		{"a OR b AND c", "a OR (b AND c)", []string{"a", "b", "c"}},
+		{"(a OR b) AND c", "(a OR b) AND c", []string{"a", "b", "c"}},
+		{"((blog.edit AND blog.publish)) OR super_admin", "(blog.edit AND blog.publish) OR super_admin", []string{"blog.edit", "blog.publish", "super_admin"}},
+		{"a OR a", "a OR a", []string{"a"}},
+		{"blog.*", "blog.*", []string{"blog.*"}},
+	}
+	for _, test := range tests {
+		parsed, err := ParseExpression(test.expression)
+		if err != nil {
+			t.Errorf("ParseExpression(%q) failed: %v", test.expression, err)
+			continue
+		}
+		if got := parsed.String(); got != test.string {
+			t.Errorf("ParseExpression(%q).String() = %q, want %q", test.expression, got, test.string)
+		}
+		if got := parsed.Permissions(); !reflect.DeepEqual(got, test.permissions) {
+			t.Errorf("ParseExpression(%q).Permissions() = %v, want %v", test.expression, got, test.permissions)
+		}
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"   ",
+		"a AND",
+		"OR a",
+		"a b",
+		"(a OR b",
+		"a OR b)",
+		"()",
+		"a AND (OR b)",
+		"*",
+		"blog.*.edit",
+	}
+	for _, expression := range tests {
+		if _, err := ParseExpression(expression); err == nil {
+			t.Errorf("ParseExpression(%q) succeeded, want an error", expression)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expression string
+		held       []string
+		want       bool
+	}{
+		{"a", []string{"a"}, true},
+		{"a", []string{}, false},
+		{"a OR b", []string{"b"}, true},
+		{"a OR b", []string{"c"}, false},
+		{"a AND b", []string{"a"}, false},
+		{"a AND b", []string{"a", "b"}, true},
+		{"(a AND b) OR c", []string{"c"}, true},
+		{"(a AND b) OR c", []string{"a"}, false},
+		{"a AND (b OR c)", []string{"a", "c"}, true},
+	}
+	for _, test := range tests {
+		parsed, err := ParseExpression(test.expression)
+		if err != nil {
+			t.Fatalf("ParseExpression(%q) failed: %v", test.expression, err)
+		}
+		held := make(map[string]bool)
+		for _, name := range test.held {
+			held[name] = true
+		}
+		got := parsed.Evaluate(func(permission string) bool {
+			return held[permission]
+		})
+		if got != test.want {
+			t.Errorf("%q with %v = %v, want %v", test.expression, test.held, got, test.want)
+		}
+	}
+}
+
+func TestAnyOfAllOf(t *testing.T) {
+	if got := AnyOf("a").String(); got != "a" {
+		t.Errorf("AnyOf(a) = %q, want a", got)
+	}
+	if got := AnyOf("a", "b").String(); got != "a OR b" {
+		t.Errorf("AnyOf(a, b) = %q, want a OR b", got)
+	}
+	if got := AllOf("a", "b").String(); got != "a AND b" {
+		t.Errorf("AllOf(a, b) = %q, want a AND b", got)
+	}
+}
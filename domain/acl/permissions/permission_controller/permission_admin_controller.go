@@ -0,0 +1,469 @@
+package permission_controller
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_model"
+	"github.com/cqlcorp/gocms/domain/logs/log_model"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/api_utility"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/sqlUtl"
+	"net/http"
+	"strconv"
+)
+
+type PermissionAdminController struct {
+	routes        *routes.Routes
+	servicesGroup *service.ServicesGroup
+	adminRoutes   *access_control_middleware.PermissionGroup
+}
+
+func DefaultPermissionAdminController(routes *routes.Routes, sg *service.ServicesGroup) *PermissionAdminController {
+	permissionAdminController := &PermissionAdminController{
+		routes:        routes,
+		servicesGroup: sg,
+		adminRoutes:   access_control_middleware.NewPermissionGroup(routes.Auth, "/admin", sg.AclService, permissions.SUPER_ADMIN, permissions.MANAGE_ACL),
+	}
+
+	permissionAdminController.Default()
+	return permissionAdminController
+}
+
+func (pac *PermissionAdminController) Default() {
+	pac.adminRoutes.GET("/permission", pac.getAll)
+	pac.adminRoutes.POST("/permission", pac.add)
+	pac.adminRoutes.GET("/permission/expand", pac.expand)
+	pac.adminRoutes.PUT("/permission/:permissionId", pac.update)
+	pac.adminRoutes.DELETE("/permission/:permissionId", pac.delete)
+
+	pac.adminRoutes.GET("/group/:groupId/permission", pac.getGroupPermissions)
+	pac.adminRoutes.POST("/group/:groupId/permission/:permissionId", pac.addGroupPermission)
+	pac.adminRoutes.DELETE("/group/:groupId/permission/:permissionId", pac.removeGroupPermission)
+
+	pac.adminRoutes.GET("/user/:userId/permission", pac.getUserPermissions)
+	pac.adminRoutes.POST("/user/:userId/permission/:permissionId", pac.addUserPermission)
+	pac.adminRoutes.DELETE("/user/:userId/permission/:permissionId", pac.removeUserPermission)
+}
+
+/**
+* @api {get} /admin/permission Get All Permissions
+* @apiName GetAllPermissions
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiUse PermissionDisplay
+* @apiPermission ManageAcl
+ */
+func (pac *PermissionAdminController) getAll(c *gin.Context) {
+
+	allPermissions, err := pac.servicesGroup.PermissionService.GetAll()
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get permissions.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pac.toDisplays(*allPermissions))
+}
+
+/**
+* @api {get} /admin/permission/expand?name=:name Expand Permission Name
+* @apiDescription Validate a permission name and list the existing permissions it grants. Ex: blog.* lists
+* blog.post.create, blog.post.delete and any other permission starting with blog.
+* @apiName ExpandPermission
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiSuccess (Response) {string} name
+* @apiSuccess (Response) {string[]} expands
+* @apiPermission ManageAcl
+ */
+func (pac *PermissionAdminController) expand(c *gin.Context) {
+
+	name := c.Query("name")
+	if name == "" {
+		errors.Response(c, http.StatusBadRequest, "name is required", nil)
+		return
+	}
+
+	expanded, err := pac.servicesGroup.PermissionService.Expand(name)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Invalid permission name.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"name": name, "expands": expanded})
+}
+
+/**
+* @api {post} /admin/permission Create Permission
+* @apiName AddPermission
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiUse PermissionInput
+* @apiUse PermissionDisplay
+* @apiPermission ManageAcl
+ */
+func (pac *PermissionAdminController) add(c *gin.Context) {
+
+	var permissionInput permission_model.PermissionInput
+	err := c.BindJSON(&permissionInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	permission := &permission_model.Permission{
+		Name:        permissionInput.Name,
+		Description: permissionInput.Description,
+	}
+	err = pac.servicesGroup.PermissionService.Add(permission)
+	if err != nil {
+		if sqlUtl.ErrDupEtry(err) {
+			errors.Response(c, http.StatusBadRequest, "A permission with this name already exists.", err)
+			return
+		}
+		errors.Response(c, http.StatusBadRequest, "Couldn't create permission.", err)
+		return
+	}
+
+	permission, err = pac.servicesGroup.PermissionService.Get(permission.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get permission.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pac.toDisplay(permission))
+}
+
+/**
+* @api {put} /admin/permission/:permissionId Update Permission
+* @apiDescription Rename a permission or change its description. Built in permissions can't be renamed.
+* @apiName UpdatePermission
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiUse PermissionInput
+* @apiUse PermissionDisplay
+* @apiPermission ManageAcl
+ */
+func (pac *PermissionAdminController) update(c *gin.Context) {
+
+	permission, ok := pac.getPermissionFromParam(c)
+	if !ok || !pac.requireDelegation(c, permission.Name) {
+		return
+	}
+
+	var permissionInput permission_model.PermissionInput
+	err := c.BindJSON(&permissionInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	permission.Name = permissionInput.Name
+	permission.Description = permissionInput.Description
+	err = pac.servicesGroup.PermissionService.Update(permission)
+	if err != nil {
+		if sqlUtl.ErrDupEtry(err) {
+			errors.Response(c, http.StatusBadRequest, "A permission with this name already exists.", err)
+			return
+		}
+		errors.Response(c, http.StatusBadRequest, "Couldn't update permission.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pac.toDisplay(permission))
+}
+
+/**
+* @api {delete} /admin/permission/:permissionId Delete Permission
+* @apiDescription Delete a permission and remove it from every user and group. Built in permissions can't be deleted.
+* @apiName DeletePermission
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiPermission ManageAcl
+ */
+func (pac *PermissionAdminController) delete(c *gin.Context) {
+
+	permission, ok := pac.getPermissionFromParam(c)
+	if !ok || !pac.requireDelegation(c, permission.Name) {
+		return
+	}
+
+	err := pac.servicesGroup.PermissionService.Delete(permission.Id)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't delete permission.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {get} /admin/group/:groupId/permission Get Group Permissions
+* @apiName GetGroupPermissions
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiUse PermissionDisplay
+* @apiPermission ManageAcl
+ */
+func (pac *PermissionAdminController) getGroupPermissions(c *gin.Context) {
+
+	groupId, ok := pac.getGroupIdFromParam(c)
+	if !ok {
+		return
+	}
+
+	groupPermissions, err := pac.servicesGroup.PermissionService.GetGroupPermissions(groupId)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get group permissions.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pac.toDisplaysFromPointers(groupPermissions))
+}
+
+/**
+* @api {post} /admin/group/:groupId/permission/:permissionId Assign Permission To Group
+* @apiName AddGroupPermission
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiPermission ManageAcl
+ */
+func (pac *PermissionAdminController) addGroupPermission(c *gin.Context) {
+
+	groupId, ok := pac.getGroupIdFromParam(c)
+	if !ok {
+		return
+	}
+	permission, ok := pac.getPermissionFromParam(c)
+	if !ok || !pac.requireDelegation(c, permission.Name) {
+		return
+	}
+
+	err := pac.servicesGroup.PermissionService.AddGroupToPermission(groupId, permission.Id)
+	if err != nil {
+		if sqlUtl.ErrDupEtry(err) {
+			errors.Response(c, http.StatusBadRequest, "Group already has this permission.", err)
+			return
+		}
+		errors.Response(c, http.StatusBadRequest, "Couldn't assign permission to group.", err)
+		return
+	}
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+	pac.servicesGroup.LogService.RecordAudit(0, authUser.Id, log_model.Audit_Group_Permission_Add, fmt.Sprintf("group %v: permission %v (%v)", groupId, permission.Name, permission.Id))
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {delete} /admin/group/:groupId/permission/:permissionId Unassign Permission From Group
+* @apiName RemoveGroupPermission
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiPermission ManageAcl
+ */
+func (pac *PermissionAdminController) removeGroupPermission(c *gin.Context) {
+
+	groupId, ok := pac.getGroupIdFromParam(c)
+	if !ok {
+		return
+	}
+	permission, ok := pac.getPermissionFromParam(c)
+	if !ok || !pac.requireDelegation(c, permission.Name) {
+		return
+	}
+
+	err := pac.servicesGroup.PermissionService.RemoveGroupFromPermission(groupId, permission.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't unassign permission from group.", err)
+		return
+	}
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+	pac.servicesGroup.LogService.RecordAudit(0, authUser.Id, log_model.Audit_Group_Permission_Remove, fmt.Sprintf("group %v: permission %v (%v)", groupId, permission.Name, permission.Id))
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {get} /admin/user/:userId/permission Get User Effective Permissions
+* @apiDescription Every permission the user has, with whether it was assigned directly or inherited from a group.
+* @apiName GetUserPermissions
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiUse EffectivePermissionDisplay
+* @apiPermission ManageAcl
+ */
+func (pac *PermissionAdminController) getUserPermissions(c *gin.Context) {
+
+	userId, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "userId is missing or not an integer", err)
+		return
+	}
+
+	effectivePermissions, err := pac.servicesGroup.PermissionService.GetUserPermissions(userId)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get user permissions.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, effectivePermissions)
+}
+
+/**
+* @api {post} /admin/user/:userId/permission/:permissionId Assign Permission To User
+* @apiName AddUserPermission
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiPermission ManageAcl
+ */
+func (pac *PermissionAdminController) addUserPermission(c *gin.Context) {
+
+	userId, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "userId is missing or not an integer", err)
+		return
+	}
+	permission, ok := pac.getPermissionFromParam(c)
+	if !ok || !pac.requireDelegation(c, permission.Name) {
+		return
+	}
+
+	err = pac.servicesGroup.PermissionService.AddUserToPermission(userId, permission.Id)
+	if err != nil {
+		if sqlUtl.ErrDupEtry(err) {
+			errors.Response(c, http.StatusBadRequest, "User already has this permission.", err)
+			return
+		}
+		errors.Response(c, http.StatusBadRequest, "Couldn't assign permission to user.", err)
+		return
+	}
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+	pac.servicesGroup.LogService.RecordAudit(userId, authUser.Id, log_model.Audit_Permission_Add, fmt.Sprintf("permission %v (%v)", permission.Name, permission.Id))
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {delete} /admin/user/:userId/permission/:permissionId Unassign Permission From User
+* @apiDescription Removes a directly assigned permission. Permissions inherited from groups are not affected.
+* @apiName RemoveUserPermission
+* @apiGroup Admin ACL
+*
+* @apiUse AuthHeader
+* @apiPermission ManageAcl
+ */
+func (pac *PermissionAdminController) removeUserPermission(c *gin.Context) {
+
+	userId, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "userId is missing or not an integer", err)
+		return
+	}
+	permission, ok := pac.getPermissionFromParam(c)
+	if !ok || !pac.requireDelegation(c, permission.Name) {
+		return
+	}
+
+	err = pac.servicesGroup.PermissionService.RemoveUserFromPermission(userId, permission.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't unassign permission from user.", err)
+		return
+	}
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+	pac.servicesGroup.LogService.RecordAudit(userId, authUser.Id, log_model.Audit_Permission_Remove, fmt.Sprintf("permission %v (%v)", permission.Name, permission.Id))
+
+	c.Status(http.StatusOK)
+}
+
+func (pac *PermissionAdminController) getPermissionFromParam(c *gin.Context) (*permission_model.Permission, bool) {
+	permissionId, err := strconv.ParseInt(c.Param("permissionId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "permissionId is missing or not an integer", err)
+		return nil, false
+	}
+
+	permission, err := pac.servicesGroup.PermissionService.Get(permissionId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errors.Response(c, http.StatusNotFound, "Permission not found.", err)
+			return nil, false
+		}
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get permission.", err)
+		return nil, false
+	}
+
+	return permission, true
+}
+
+func (pac *PermissionAdminController) getGroupIdFromParam(c *gin.Context) (int64, bool) {
+	groupId, err := strconv.ParseInt(c.Param("groupId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "groupId is missing or not an integer", err)
+		return 0, false
+	}
+
+	_, err = pac.servicesGroup.GroupService.Get(groupId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errors.Response(c, http.StatusNotFound, "Group not found.", err)
+			return 0, false
+		}
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get group.", err)
+		return 0, false
+	}
+
+	return groupId, true
+}
+
+// requireDelegation stops admins from granting or taking away permissions they don't have themselves
+func (pac *PermissionAdminController) requireDelegation(c *gin.Context, permissionName string) bool {
+	authUser, _ := api_utility.GetUserFromContext(c)
+	if !pac.servicesGroup.AclService.CanDelegate(authUser, permissionName) {
+		errors.Response(c, http.StatusForbidden, fmt.Sprintf("You don't have the permission %v.", permissionName), nil)
+		return false
+	}
+	return true
+}
+
+// toDisplay gets the permission display and lists the permissions a wildcard grants.
+func (pac *PermissionAdminController) toDisplay(permission *permission_model.Permission) *permission_model.PermissionDisplay {
+	permissionDisplay := permission.GetPermissionDisplay()
+	if permissions.IsWildcard(permission.Name) || permission.Name == permissions.SUPER_ADMIN {
+		permissionDisplay.Expands, _ = pac.servicesGroup.PermissionService.Expand(permission.Name)
+	}
+	return permissionDisplay
+}
+
+func (pac *PermissionAdminController) toDisplays(allPermissions []permission_model.Permission) []*permission_model.PermissionDisplay {
+	permissionDisplays := make([]*permission_model.PermissionDisplay, len(allPermissions))
+	for i := range allPermissions {
+		permissionDisplays[i] = pac.toDisplay(&allPermissions[i])
+	}
+	return permissionDisplays
+}
+
+func (pac *PermissionAdminController) toDisplaysFromPointers(allPermissions []*permission_model.Permission) []*permission_model.PermissionDisplay {
+	permissionDisplays := make([]*permission_model.PermissionDisplay, len(allPermissions))
+	for i, permission := range allPermissions {
+		permissionDisplays[i] = pac.toDisplay(permission)
+	}
+	return permissionDisplays
+}
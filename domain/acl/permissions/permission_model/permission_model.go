@@ -4,6 +4,11 @@ import (
 	"time"
 )
 
+const (
+	PERMISSION_SOURCE_USER  = "user"
+	PERMISSION_SOURCE_GROUP = "group"
+)
+
 // Permission base permission struct for database transactions
 type Permission struct {
 	Id                   int64     `db:"id"`
@@ -13,3 +18,65 @@ type Permission struct {
 	Created              time.Time `db:"created"`
 	LastModified         time.Time `db:"lastModified"`
 }
+
+/**
+* @apiDefine PermissionDisplay
+* @apiSuccess (Response) {number} id
+* @apiSuccess (Response) {string} name
+* @apiSuccess (Response) {string} description
+* @apiSuccess (Response) {Date} created
+* @apiSuccess (Response) {string[]} [expands] For wildcard permissions such as blog.*, the permissions it grants.
+ */
+type PermissionDisplay struct {
+	Id          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Created     time.Time `json:"created"`
+	Expands     []string  `json:"expands,omitempty"`
+}
+
+/**
+* @apiDefine PermissionInput
+* @apiParam (Request) {string} name Unique name of the permission, 255 characters max. Dot separated parts of letters,
+* numbers, _ and -. End with .* to grant every permission below it. Ex: blog.post.create or blog.*
+* @apiParam (Request) {string} [description]
+ */
+type PermissionInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+/**
+* @apiDefine EffectivePermissionDisplay
+* @apiSuccess (Response) {number} id
+* @apiSuccess (Response) {string} name
+* @apiSuccess (Response) {string} description
+* @apiSuccess (Response) {string[]} [expands] For wildcard permissions, the permissions it grants.
+* @apiSuccess (Response) {Object[]} sources Where the user gets the permission from.
+* @apiSuccess (Response) {string} sources.type "user" when assigned directly, "group" when inherited.
+* @apiSuccess (Response) {number} [sources.groupId]
+* @apiSuccess (Response) {string} [sources.groupName]
+ */
+type EffectivePermission struct {
+	Id          int64               `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Expands     []string            `json:"expands,omitempty"`
+	Sources     []*PermissionSource `json:"sources"`
+}
+
+type PermissionSource struct {
+	Type      string `json:"type"`
+	GroupId   int64  `json:"groupId,omitempty"`
+	GroupName string `json:"groupName,omitempty"`
+}
+
+// GetPermissionDisplay helper function to get permissionDisplay from permission object
+func (permission *Permission) GetPermissionDisplay() *PermissionDisplay {
+	return &PermissionDisplay{
+		Id:          permission.Id,
+		Name:        permission.Name,
+		Description: permission.Description,
+		Created:     permission.Created,
+	}
+}
@@ -1,6 +1,8 @@
 package permission_repository
 
 import (
+	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"github.com/cqlcorp/gocms/domain/acl/group/group_repository"
 	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_model"
 	"github.com/cqlcorp/gocms/utility/log"
 	"github.com/jmoiron/sqlx"
@@ -8,6 +10,9 @@ import (
 
 type IPermissionsRepository interface {
 	Add(*permission_model.Permission) error
+	Upsert(*permission_model.Permission) error
+	Get(int64) (*permission_model.Permission, error)
+	Update(*permission_model.Permission) error
 	Delete(int64) error
 	GetAll() (*[]permission_model.Permission, error)
 
@@ -51,6 +56,48 @@ func (pr *PermissionsRepository) Add(permission *permission_model.Permission) er
 
 }
 
+// Upsert adds the permission or updates the description if one with the same name exists
+func (pr *PermissionsRepository) Upsert(permission *permission_model.Permission) error {
+
+	result, err := pr.database.NamedExec(`
+	INSERT INTO gocms_permissions (name, description) VALUES (:name, :description)
+	ON DUPLICATE KEY UPDATE description=VALUES(description), id=LAST_INSERT_ID(id)
+	`, permission)
+	if err != nil {
+		log.Errorf("Error upserting permission %v: %s\n", permission.Name, err.Error())
+		return err
+	}
+	id, _ := result.LastInsertId()
+	permission.Id = id
+
+	return nil
+}
+
+// Get gets a permission via permissionId
+func (pr *PermissionsRepository) Get(permissionId int64) (*permission_model.Permission, error) {
+	var permission permission_model.Permission
+	err := pr.database.Get(&permission, "SELECT * FROM gocms_permissions WHERE id=?", permissionId)
+	if err != nil {
+		log.Errorf("Error getting permission %v from database: %s\n", permissionId, err.Error())
+		return nil, err
+	}
+	return &permission, nil
+}
+
+// Update updates the name and description of a permission
+func (pr *PermissionsRepository) Update(permission *permission_model.Permission) error {
+
+	_, err := pr.database.NamedExec(`
+	UPDATE gocms_permissions SET name=:name, description=:description WHERE id=:id
+	`, permission)
+	if err != nil {
+		log.Errorf("Error updating permission %v in database: %s\n", permission.Id, err.Error())
+		return err
+	}
+
+	return nil
+}
+
 // Delete deletes a user permission via permissionId
 func (pr *PermissionsRepository) Delete(permissionId int64) error {
 
@@ -76,32 +123,61 @@ func (pr *PermissionsRepository) GetAll() (*[]permission_model.Permission, error
 	return &permissions, nil
 }
 
-// GetUserPermissions get permissions assigned to a given user via userId
+// GetUserPermissions get permissions assigned to a given user via userId. Permissions are inherited through the
+// users groups and every parent group above them. InheritedFromGroupId is the group that holds the permission, or 0
+// when it is assigned to the user directly. A permission is returned once for each place it comes from.
 func (pr *PermissionsRepository) GetUserPermissions(userId int64) ([]*permission_model.Permission, error) {
 
 	var permissions []*permission_model.Permission
 	err := pr.database.Select(&permissions, `
 	SELECT perms.id AS 'id', perms.name AS 'name',
-	perms.description AS 'description', groupId AS 'inheritedFromGroupId'
-	FROM (
-		SELECT permissionId, groupId FROM gocms_groups_to_permissions
-		WHERE groupId IN (
-			SELECT groupId FROM gocms_users_to_groups
-			WHERE userId = ?
-		)
-		UNION
-		SELECT permissionId, 0 AS groupId FROM gocms_users_to_permissions
-		WHERE userId = ?
-	) AS permIds
+	perms.description AS 'description', 0 AS 'inheritedFromGroupId'
+	FROM gocms_users_to_permissions AS utp
 	JOIN gocms_permissions AS perms
-	ON permIds.permissionId = perms.id
-	`, userId, userId)
+	ON utp.permissionId = perms.id
+	WHERE utp.userId = ?
+	`, userId)
 	if err != nil {
 		log.Errorf("Error getting all permissions for user %v from database: %s\n", userId, err.Error())
 		return nil, err
 	}
 
-	return permissions, nil
+	// find every group the user is in directly or through a parent group
+	var groupIds []int64
+	err = pr.database.Select(&groupIds, "SELECT groupId FROM gocms_users_to_groups WHERE userId = ?", userId)
+	if err != nil {
+		log.Errorf("Error getting groups for user %v from database: %s\n", userId, err.Error())
+		return nil, err
+	}
+	if len(groupIds) == 0 {
+		return permissions, nil
+	}
+	links, err := group_repository.SelectParentLinks(pr.database)
+	if err != nil {
+		return nil, err
+	}
+	groupIds = group_model.Ancestors(links, groupIds...)
+
+	query, args, err := sqlx.In(`
+	SELECT perms.id AS 'id', perms.name AS 'name',
+	perms.description AS 'description', gtp.groupId AS 'inheritedFromGroupId'
+	FROM gocms_groups_to_permissions AS gtp
+	JOIN gocms_permissions AS perms
+	ON gtp.permissionId = perms.id
+	WHERE gtp.groupId IN (?)
+	`, groupIds)
+	if err != nil {
+		log.Errorf("Error building group permissions query for user %v: %s\n", userId, err.Error())
+		return nil, err
+	}
+	var groupPermissions []*permission_model.Permission
+	err = pr.database.Select(&groupPermissions, pr.database.Rebind(query), args...)
+	if err != nil {
+		log.Errorf("Error getting group permissions for user %v from database: %s\n", userId, err.Error())
+		return nil, err
+	}
+
+	return append(permissions, groupPermissions...), nil
 }
 
 // AddUserToPermission adds a user to the permission via userId and permissionId
@@ -170,7 +246,7 @@ func (pr *PermissionsRepository) RemoveGroupFromPermission(groupId int64, permis
 func (pr *PermissionsRepository) GetGroupPermissions(groupId int64) ([]*permission_model.Permission, error) {
 	var groupPermissions []*permission_model.Permission
 	err := pr.database.Select(&groupPermissions, `
-	SELECT permissionId as id, name, description
+	SELECT permissionId as id, name, description, perms.created
 	FROM (
 		SELECT permissionId from gocms_groups_to_permissions
 		WHERE groupId = ?
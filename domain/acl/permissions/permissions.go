@@ -1,4 +1,84 @@
 package permissions
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cqlcorp/gocms/utility/errors"
+)
+
 const SUPER_ADMIN = "super_admin"
 
+const INVITE_USERS = "invite_users"
+
+const MANAGE_ACL = "manage_acl"
+
+const MANAGE_POLICIES = "manage_policies"
+
+// ANY matches every permission. Holding super_admin is the same as holding ANY.
+const ANY = "*"
+
+// SEPARATOR splits hierarchical permission names. Ex: blog.post.create
+const SEPARATOR = "."
+
+var segmentRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// builtIn permissions are referenced by gocms itself so can't be renamed or deleted.
+var builtIn = []string{SUPER_ADMIN, INVITE_USERS, MANAGE_ACL, MANAGE_POLICIES}
+
+// IsBuiltIn reports if the permission is one gocms depends on.
+func IsBuiltIn(name string) bool {
+	for _, permission := range builtIn {
+		if permission == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWildcard reports if the name grants every permission below it. Ex: blog.*
+func IsWildcard(name string) bool {
+	return strings.HasSuffix(name, SEPARATOR+ANY)
+}
+
+// Matches reports if holding the granted permission gives the requested one. blog.* gives blog.post.create and
+// blog.post.*, super_admin gives everything.
+func Matches(granted string, requested string) bool {
+	if granted == requested || granted == ANY || granted == SUPER_ADMIN {
+		return true
+	}
+	if IsWildcard(granted) {
+		return strings.HasPrefix(requested, strings.TrimSuffix(granted, ANY))
+	}
+	return false
+}
+
+// Expand returns the names the pattern matches, not including the pattern itself.
+func Expand(pattern string, names []string) []string {
+	expanded := []string{}
+	for _, name := range names {
+		if name != pattern && Matches(pattern, name) {
+			expanded = append(expanded, name)
+		}
+	}
+	return expanded
+}
+
+// ValidateName checks a permission name is made of dot separated segments of letters, numbers, _ and -. A * is only
+// allowed as the last segment.
+func ValidateName(name string) error {
+	if name == ANY {
+		return errors.NewToUser(fmt.Sprintf("%v on its own is reserved, assign %v instead.", ANY, SUPER_ADMIN))
+	}
+	segments := strings.Split(name, SEPARATOR)
+	for i, segment := range segments {
+		if segment == ANY && i == len(segments)-1 {
+			continue
+		}
+		if !segmentRegex.MatchString(segment) {
+			return errors.NewToUser(fmt.Sprintf("%v isn't a valid permission name. Use dot separated parts of letters, numbers, _ and -, optionally ending with .*", name))
+		}
+	}
+	return nil
+}
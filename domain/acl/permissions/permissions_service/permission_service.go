@@ -1,31 +1,46 @@
 package permission_service
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_service"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
 	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_model"
 	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/log"
 )
 
+const maxNameLength = 255
+
 type IPermissionService interface {
 	Add(*permission_model.Permission) error
-	//	Delete(int64) error
-	//	GetAll() (*[]permission_model.Permission, error)
-	//
-	//	GetUserPermissions(userId int) ([]*permission_model.Permission, error)
-	//	AddUserToPermission(userId int, permissionId int) error
-	//	RemoveUserFromPermission(userId int, permissionId int) error
-	//
-	//	GetGroupPermissions(groupId int) ([]*permission_model.Permission, error)
-	//	AddGroupToPermission(groupId int, permissionId int) error
-	//	RemoveGroupFromPermission(groupId int, permissionId int) erro
+	Get(int64) (*permission_model.Permission, error)
+	Update(*permission_model.Permission) error
+	Delete(int64) error
+	GetAll() (*[]permission_model.Permission, error)
+	Expand(name string) ([]string, error)
+
+	GetUserPermissions(userId int64) ([]*permission_model.EffectivePermission, error)
+	AddUserToPermission(userId int64, permissionId int64) error
+	RemoveUserFromPermission(userId int64, permissionId int64) error
+
+	GetGroupPermissions(groupId int64) ([]*permission_model.Permission, error)
+	AddGroupToPermission(groupId int64, permissionId int64) error
+	RemoveGroupFromPermission(groupId int64, permissionId int64) error
 }
 
 type PermissionService struct {
 	RepositoriesGroup *repository.RepositoriesGroup
+	AclService        access_control_service.IAclService
 }
 
-func DefaultPermissionService(rg *repository.RepositoriesGroup) *PermissionService {
+func DefaultPermissionService(rg *repository.RepositoriesGroup, aclService access_control_service.IAclService) *PermissionService {
 	permissionService := &PermissionService{
 		RepositoriesGroup: rg,
+		AclService:        aclService,
 	}
 
 	return permissionService
@@ -33,9 +48,205 @@ func DefaultPermissionService(rg *repository.RepositoriesGroup) *PermissionServi
 
 func (ps *PermissionService) Add(permission *permission_model.Permission) error {
 
-	err := ps.RepositoriesGroup.PermissionsRepository.Add(permission)
+	err := validateName(permission)
+	if err != nil {
+		return err
+	}
+
+	err = ps.RepositoriesGroup.PermissionsRepository.Add(permission)
+	if err != nil {
+		return err
+	}
+
+	// other instances need to load the new permission too
+	ps.AclService.RefreshPermissionsCache()
+	ps.AclService.InvalidateAll()
+	return nil
+}
+
+func (ps *PermissionService) Get(permissionId int64) (*permission_model.Permission, error) {
+	return ps.RepositoriesGroup.PermissionsRepository.Get(permissionId)
+}
+
+// Update changes the name and description of a permission. Built in permissions can't be renamed.
+func (ps *PermissionService) Update(permission *permission_model.Permission) error {
+
+	err := validateName(permission)
+	if err != nil {
+		return err
+	}
+
+	existing, err := ps.RepositoriesGroup.PermissionsRepository.Get(permission.Id)
+	if err != nil {
+		return err
+	}
+	if existing.Name != permission.Name && permissions.IsBuiltIn(existing.Name) {
+		return errors.NewToUser(fmt.Sprintf("%v is a built in permission and can't be renamed.", existing.Name))
+	}
+
+	err = ps.RepositoriesGroup.PermissionsRepository.Update(permission)
+	if err != nil {
+		return err
+	}
+
+	ps.AclService.RefreshPermissionsCache()
+	ps.AclService.InvalidateAll()
+	return nil
+}
+
+// Delete removes a permission from every user and group. Built in permissions can't be deleted.
+func (ps *PermissionService) Delete(permissionId int64) error {
+
+	existing, err := ps.RepositoriesGroup.PermissionsRepository.Get(permissionId)
+	if err != nil {
+		return err
+	}
+	if permissions.IsBuiltIn(existing.Name) {
+		return errors.NewToUser(fmt.Sprintf("%v is a built in permission and can't be deleted.", existing.Name))
+	}
+
+	err = ps.RepositoriesGroup.PermissionsRepository.Delete(permissionId)
 	if err != nil {
-		return nil
+		return err
 	}
+
+	ps.AclService.RefreshPermissionsCache()
+	ps.AclService.InvalidateAll()
 	return nil
 }
+
+func (ps *PermissionService) GetAll() (*[]permission_model.Permission, error) {
+	return ps.RepositoriesGroup.PermissionsRepository.GetAll()
+}
+
+// Expand validates a permission name and returns every existing permission it grants. For names without a wildcard
+// that is only the permission itself, super_admin expands to everything.
+func (ps *PermissionService) Expand(name string) ([]string, error) {
+	name = strings.TrimSpace(name)
+	if name != permissions.SUPER_ADMIN {
+		err := permissions.ValidateName(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	allPermissions := ps.AclService.GetPermissions()
+	names := make([]string, 0, len(allPermissions))
+	for permissionName := range allPermissions {
+		names = append(names, permissionName)
+	}
+	sort.Strings(names)
+
+	expanded := permissions.Expand(name, names)
+	if _, ok := allPermissions[name]; ok && !permissions.IsWildcard(name) && name != permissions.SUPER_ADMIN {
+		expanded = append(expanded, name)
+	}
+	return expanded, nil
+}
+
+// GetUserPermissions gets the users effective permissions along with whether each was assigned directly or through
+// one of the users groups.
+func (ps *PermissionService) GetUserPermissions(userId int64) ([]*permission_model.EffectivePermission, error) {
+
+	userPermissions, err := ps.RepositoriesGroup.PermissionsRepository.GetUserPermissions(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// permissions can come from parent groups the user isn't a member of, so look names up in every group
+	groups, err := ps.RepositoriesGroup.GroupsRepository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	groupNames := make(map[int64]string, len(*groups))
+	for _, group := range *groups {
+		groupNames[group.Id] = group.Name
+	}
+
+	// merge the rows for each permission keeping every source
+	effectivePermissions := []*permission_model.EffectivePermission{}
+	byId := make(map[int64]*permission_model.EffectivePermission)
+	for _, permission := range userPermissions {
+		effectivePermission, ok := byId[permission.Id]
+		if !ok {
+			effectivePermission = &permission_model.EffectivePermission{
+				Id:          permission.Id,
+				Name:        permission.Name,
+				Description: permission.Description,
+			}
+			if permissions.IsWildcard(permission.Name) || permission.Name == permissions.SUPER_ADMIN {
+				effectivePermission.Expands, _ = ps.Expand(permission.Name)
+			}
+			byId[permission.Id] = effectivePermission
+			effectivePermissions = append(effectivePermissions, effectivePermission)
+		}
+
+		source := &permission_model.PermissionSource{Type: permission_model.PERMISSION_SOURCE_USER}
+		if permission.InheritedFromGroupId != 0 {
+			source = &permission_model.PermissionSource{
+				Type:      permission_model.PERMISSION_SOURCE_GROUP,
+				GroupId:   permission.InheritedFromGroupId,
+				GroupName: groupNames[permission.InheritedFromGroupId],
+			}
+		}
+		effectivePermission.Sources = append(effectivePermission.Sources, source)
+	}
+
+	return effectivePermissions, nil
+}
+
+func (ps *PermissionService) AddUserToPermission(userId int64, permissionId int64) error {
+	err := ps.RepositoriesGroup.PermissionsRepository.AddUserToPermission(userId, permissionId)
+	if err != nil {
+		return err
+	}
+
+	ps.AclService.InvalidateUser(userId)
+	return nil
+}
+
+func (ps *PermissionService) RemoveUserFromPermission(userId int64, permissionId int64) error {
+	err := ps.RepositoriesGroup.PermissionsRepository.RemoveUserFromPermission(userId, permissionId)
+	if err != nil {
+		return err
+	}
+
+	ps.AclService.InvalidateUser(userId)
+	return nil
+}
+
+func (ps *PermissionService) GetGroupPermissions(groupId int64) ([]*permission_model.Permission, error) {
+	return ps.RepositoriesGroup.PermissionsRepository.GetGroupPermissions(groupId)
+}
+
+func (ps *PermissionService) AddGroupToPermission(groupId int64, permissionId int64) error {
+	err := ps.RepositoriesGroup.PermissionsRepository.AddGroupToPermission(groupId, permissionId)
+	if err != nil {
+		return err
+	}
+
+	ps.AclService.InvalidateAll()
+	return nil
+}
+
+func (ps *PermissionService) RemoveGroupFromPermission(groupId int64, permissionId int64) error {
+	err := ps.RepositoriesGroup.PermissionsRepository.RemoveGroupFromPermission(groupId, permissionId)
+	if err != nil {
+		return err
+	}
+
+	ps.AclService.InvalidateAll()
+	return nil
+}
+
+func validateName(permission *permission_model.Permission) error {
+	permission.Name = strings.TrimSpace(permission.Name)
+	if permission.Name == "" {
+		return errors.NewToUser("Permission name is required.")
+	}
+	if len(permission.Name) > maxNameLength {
+		log.Debugf("Permission name %v is too long\n", permission.Name)
+		return errors.NewToUser(fmt.Sprintf("Permission name can't be longer than %v characters.", maxNameLength))
+	}
+	return permissions.ValidateName(permission.Name)
+}
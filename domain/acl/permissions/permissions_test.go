@@ -0,0 +1,77 @@
+package permissions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		granted   string
+		requested string
+		want      bool
+	}{
+		{"blog.edit", "blog.edit", true},
+		{"blog.edit", "blog.publish", false},
+		{"blog.*", "blog.edit", true},
+		{"blog.*", "blog.post.create", true},
+		{"blog.*", "blog.post.*", true},
+		{"blog.*", "blog", false},
+		{"blog.*", "blogger.edit", false},
+		{"blog.post.*", "blog.edit", false},
+		{"blog.edit", "blog.*", false},
+		{ANY, "blog.edit", true},
+		{SUPER_ADMIN, "blog.edit", true},
+		{SUPER_ADMIN, SUPER_ADMIN, true},
+		{"blog.*", SUPER_ADMIN, false},
+		{"manage_acl", SUPER_ADMIN, false},
+	}
+	for _, test := range tests {
+		if got := Matches(test.granted, test.requested); got != test.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", test.granted, test.requested, got, test.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	names := []string{"blog.edit", "blog.post.create", "blog.*", "blogger.edit", "shop.order"}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"blog.*", []string{"blog.edit", "blog.post.create"}},
+		{"blog.post.*", []string{"blog.post.create"}},
+		{"blog.edit", []string{}},
+		{"shop.*", []string{"shop.order"}},
+		{"none.*", []string{}},
+	}
+	for _, test := range tests {
+		if got := Expand(test.pattern, names); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Expand(%q) = %v, want %v", test.pattern, got, test.want)
+		}
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"blog", true},
+		{"blog.edit", true},
+		{"blog.post-draft.create_1", true},
+		{"blog.*", true},
+		{ANY, false},
+		{"*.edit", false},
+		{"blog.*.edit", false},
+		{"blog..edit", false},
+		{"blog.", false},
+		{"blog edit", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if err := ValidateName(test.name); (err == nil) != test.valid {
+			t.Errorf("ValidateName(%q) = %v, want valid %v", test.name, err, test.valid)
+		}
+	}
+}
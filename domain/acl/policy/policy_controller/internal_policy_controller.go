@@ -0,0 +1,151 @@
+package policy_controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cqlcorp/gocms/domain/acl/policy/policy_model"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_auth/plugin_auth_middleware"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_auth/plugin_auth_model"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/sqlUtl"
+	"github.com/gin-gonic/gin"
+)
+
+type InternalPolicyController struct {
+	internalRoutes *routes.InternalRoutes
+	servicesGroup  *service.ServicesGroup
+}
+
+func DefaultInternalPolicyController(iRoutes *routes.InternalRoutes, sg *service.ServicesGroup) *InternalPolicyController {
+	internalPolicyController := &InternalPolicyController{
+		internalRoutes: iRoutes,
+		servicesGroup:  sg,
+	}
+	internalPolicyController.InternalDefault()
+	return internalPolicyController
+}
+
+func (ipc *InternalPolicyController) InternalDefault() {
+	ipc.internalRoutes.InternalRoot.POST("/acl/check", plugin_auth_middleware.RequireScope(plugin_auth_model.SCOPE_ACL_CHECK), ipc.check)
+	ipc.internalRoutes.InternalRoot.PUT("/acl/resourceType", plugin_auth_middleware.RequireScope(plugin_auth_model.SCOPE_ACL_GRANTS), ipc.registerResourceType)
+	ipc.internalRoutes.InternalRoot.POST("/acl/grant", plugin_auth_middleware.RequireScope(plugin_auth_model.SCOPE_ACL_GRANTS), ipc.grant)
+	ipc.internalRoutes.InternalRoot.DELETE("/acl/grant/:grantId", plugin_auth_middleware.RequireScope(plugin_auth_model.SCOPE_ACL_GRANTS), ipc.revoke)
+}
+
+/**
+* @api {post} (internal)/acl/check (Internal) Check Resource Policy
+* @apiName CheckPolicy
+* @apiGroup (Internal) ACL
+* @apiDescription (Internal) decide if a user may perform an action on a resource. Super admins are always allowed,
+* then deny rules, ownership, grants and allow rules are applied in that order. Requires the acl.check plugin scope.
+*
+* @apiUse CheckInput
+* @apiUse Decision
+ */
+func (ipc *InternalPolicyController) check(c *gin.Context) {
+
+	var checkInput policy_model.CheckInput
+	err := c.BindJSON(&checkInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	// deleted users are denied everything, even if they were super admins
+	if _, err := ipc.servicesGroup.UserService.GetActive(checkInput.UserId); err != nil {
+		c.JSON(http.StatusOK, &policy_model.Decision{Allowed: false, Reason: "user doesn't exist"})
+		return
+	}
+
+	decision, err := ipc.servicesGroup.PolicyService.Check(checkInput.GetUser(), checkInput.Action, checkInput.Resource)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't check policy.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, decision)
+}
+
+/**
+* @api {put} (internal)/acl/resourceType (Internal) Register Resource Type
+* @apiName RegisterResourceType
+* @apiGroup (Internal) ACL
+* @apiDescription (Internal) add or update a resource type that grants and rules can be written for. Requires the
+* acl.grants plugin scope.
+*
+* @apiUse ResourceTypeInput
+ */
+func (ipc *InternalPolicyController) registerResourceType(c *gin.Context) {
+
+	var resourceTypeInput policy_model.ResourceTypeInput
+	err := c.BindJSON(&resourceTypeInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	_, err = ipc.servicesGroup.PolicyService.RegisterResourceType(&resourceTypeInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't register resource type.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {post} (internal)/acl/grant (Internal) Add Grant
+* @apiName AddGrant
+* @apiGroup (Internal) ACL
+* @apiDescription (Internal) give a user or group an action on a resource. Requires the acl.grants plugin scope.
+*
+* @apiUse GrantInput
+* @apiSuccess (Response) {number} id
+ */
+func (ipc *InternalPolicyController) grant(c *gin.Context) {
+
+	var grantInput policy_model.GrantInput
+	err := c.BindJSON(&grantInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	grant, err := ipc.servicesGroup.PolicyService.Grant(&grantInput)
+	if err != nil {
+		if sqlUtl.ErrDupEtry(err) {
+			errors.Response(c, http.StatusBadRequest, "This grant already exists.", err)
+			return
+		}
+		errors.Response(c, http.StatusBadRequest, "Couldn't add grant.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": grant.Id})
+}
+
+/**
+* @api {delete} (internal)/acl/grant/:grantId (Internal) Remove Grant
+* @apiName RemoveGrant
+* @apiGroup (Internal) ACL
+* @apiDescription (Internal) remove a grant. Requires the acl.grants plugin scope.
+ */
+func (ipc *InternalPolicyController) revoke(c *gin.Context) {
+
+	grantId, err := strconv.ParseInt(c.Param("grantId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "grantId is missing or not an integer", err)
+		return
+	}
+
+	err = ipc.servicesGroup.PolicyService.Revoke(grantId)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't remove grant.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,279 @@
+package policy_controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/domain/acl/policy/policy_model"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/sqlUtl"
+	"github.com/gin-gonic/gin"
+)
+
+type PolicyAdminController struct {
+	routes        *routes.Routes
+	servicesGroup *service.ServicesGroup
+	adminRoutes   *access_control_middleware.PermissionGroup
+}
+
+func DefaultPolicyAdminController(routes *routes.Routes, sg *service.ServicesGroup) *PolicyAdminController {
+	policyAdminController := &PolicyAdminController{
+		routes:        routes,
+		servicesGroup: sg,
+		adminRoutes:   access_control_middleware.NewPermissionGroup(routes.Auth, "/admin/policy", sg.AclService, permissions.SUPER_ADMIN, permissions.MANAGE_POLICIES),
+	}
+
+	policyAdminController.Default()
+	return policyAdminController
+}
+
+/**
+* @apiDefine ManagePolicies Manage Policies
+* User must be a super admin or have the manage_policies permission.
+ */
+func (pac *PolicyAdminController) Default() {
+	pac.adminRoutes.GET("/resourceType", pac.getResourceTypes)
+	pac.adminRoutes.PUT("/resourceType", pac.registerResourceType)
+	pac.adminRoutes.GET("/rule", pac.getRules)
+	pac.adminRoutes.POST("/rule", pac.addRule)
+	pac.adminRoutes.DELETE("/rule/:ruleId", pac.deleteRule)
+	pac.adminRoutes.GET("/grant/:resourceType/:resourceId", pac.getGrants)
+	pac.adminRoutes.POST("/grant", pac.addGrant)
+	pac.adminRoutes.DELETE("/grant/:grantId", pac.deleteGrant)
+	pac.adminRoutes.POST("/check", pac.check)
+}
+
+/**
+* @api {get} /admin/policy/resourceType Get Resource Types
+* @apiName GetResourceTypes
+* @apiGroup Admin Policy
+*
+* @apiUse AuthHeader
+* @apiPermission ManagePolicies
+ */
+func (pac *PolicyAdminController) getResourceTypes(c *gin.Context) {
+
+	resourceTypes, err := pac.servicesGroup.PolicyService.GetResourceTypes()
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get resource types.", err)
+		return
+	}
+
+	resourceTypeDisplays := make([]gin.H, len(resourceTypes))
+	for i, resourceType := range resourceTypes {
+		resourceTypeDisplays[i] = gin.H{
+			"name":         resourceType.Name,
+			"description":  resourceType.Description,
+			"ownerActions": resourceType.GetOwnerActions(),
+		}
+	}
+
+	c.JSON(http.StatusOK, resourceTypeDisplays)
+}
+
+/**
+* @api {put} /admin/policy/resourceType Register Resource Type
+* @apiName PutResourceType
+* @apiGroup Admin Policy
+*
+* @apiUse AuthHeader
+* @apiUse ResourceTypeInput
+* @apiPermission ManagePolicies
+ */
+func (pac *PolicyAdminController) registerResourceType(c *gin.Context) {
+
+	var resourceTypeInput policy_model.ResourceTypeInput
+	err := c.BindJSON(&resourceTypeInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	_, err = pac.servicesGroup.PolicyService.RegisterResourceType(&resourceTypeInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't register resource type.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {get} /admin/policy/rule Get Rules
+* @apiName GetRules
+* @apiGroup Admin Policy
+*
+* @apiUse AuthHeader
+* @apiPermission ManagePolicies
+ */
+func (pac *PolicyAdminController) getRules(c *gin.Context) {
+
+	rules, err := pac.servicesGroup.PolicyService.GetRules()
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get rules.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+/**
+* @api {post} /admin/policy/rule Add Rule
+* @apiName AddRule
+* @apiGroup Admin Policy
+*
+* @apiUse AuthHeader
+* @apiUse RuleInput
+* @apiPermission ManagePolicies
+ */
+func (pac *PolicyAdminController) addRule(c *gin.Context) {
+
+	var ruleInput policy_model.RuleInput
+	err := c.BindJSON(&ruleInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	rule, err := pac.servicesGroup.PolicyService.AddRule(&ruleInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't add rule.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+/**
+* @api {delete} /admin/policy/rule/:ruleId Delete Rule
+* @apiName DeleteRule
+* @apiGroup Admin Policy
+*
+* @apiUse AuthHeader
+* @apiPermission ManagePolicies
+ */
+func (pac *PolicyAdminController) deleteRule(c *gin.Context) {
+
+	ruleId, err := strconv.ParseInt(c.Param("ruleId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "ruleId is missing or not an integer", err)
+		return
+	}
+
+	err = pac.servicesGroup.PolicyService.DeleteRule(ruleId)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't delete rule.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {get} /admin/policy/grant/:resourceType/:resourceId Get Grants
+* @apiName GetGrants
+* @apiGroup Admin Policy
+* @apiDescription Grants on the resource, including grants on every resource of the type.
+*
+* @apiUse AuthHeader
+* @apiPermission ManagePolicies
+ */
+func (pac *PolicyAdminController) getGrants(c *gin.Context) {
+
+	grants, err := pac.servicesGroup.PolicyService.GetGrants(c.Param("resourceType"), c.Param("resourceId"))
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get grants.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
+
+/**
+* @api {post} /admin/policy/grant Add Grant
+* @apiName AddPolicyGrant
+* @apiGroup Admin Policy
+*
+* @apiUse AuthHeader
+* @apiUse GrantInput
+* @apiPermission ManagePolicies
+ */
+func (pac *PolicyAdminController) addGrant(c *gin.Context) {
+
+	var grantInput policy_model.GrantInput
+	err := c.BindJSON(&grantInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	grant, err := pac.servicesGroup.PolicyService.Grant(&grantInput)
+	if err != nil {
+		if sqlUtl.ErrDupEtry(err) {
+			errors.Response(c, http.StatusBadRequest, "This grant already exists.", err)
+			return
+		}
+		errors.Response(c, http.StatusBadRequest, "Couldn't add grant.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, grant)
+}
+
+/**
+* @api {delete} /admin/policy/grant/:grantId Remove Grant
+* @apiName RemovePolicyGrant
+* @apiGroup Admin Policy
+*
+* @apiUse AuthHeader
+* @apiPermission ManagePolicies
+ */
+func (pac *PolicyAdminController) deleteGrant(c *gin.Context) {
+
+	grantId, err := strconv.ParseInt(c.Param("grantId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "grantId is missing or not an integer", err)
+		return
+	}
+
+	err = pac.servicesGroup.PolicyService.Revoke(grantId)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't remove grant.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {post} /admin/policy/check Check Resource Policy
+* @apiName AdminCheckPolicy
+* @apiGroup Admin Policy
+* @apiDescription Test how a policy decision is made for a user.
+*
+* @apiUse AuthHeader
+* @apiUse CheckInput
+* @apiUse Decision
+* @apiPermission ManagePolicies
+ */
+func (pac *PolicyAdminController) check(c *gin.Context) {
+
+	var checkInput policy_model.CheckInput
+	err := c.BindJSON(&checkInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	decision, err := pac.servicesGroup.PolicyService.Check(checkInput.GetUser(), checkInput.Action, checkInput.Resource)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't check policy.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, decision)
+}
@@ -0,0 +1,185 @@
+package policy_model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+)
+
+const (
+	ANY = "*"
+
+	SUBJECT_USER  = "user"
+	SUBJECT_GROUP = "group"
+
+	EFFECT_ALLOW = "allow"
+	EFFECT_DENY  = "deny"
+)
+
+// Condition operators
+const (
+	OPERATOR_EQ       = "eq"
+	OPERATOR_NE       = "ne"
+	OPERATOR_IN       = "in"
+	OPERATOR_CONTAINS = "contains"
+	OPERATOR_GT       = "gt"
+	OPERATOR_GTE      = "gte"
+	OPERATOR_LT       = "lt"
+	OPERATOR_LTE      = "lte"
+	OPERATOR_EXISTS   = "exists"
+)
+
+// ResourceType is a kind of object that policies can be written for, such as "blog.post". Owners of a resource get
+// the OwnerActions on it.
+type ResourceType struct {
+	Id           int64     `db:"id"`
+	Name         string    `db:"name"`
+	Description  string    `db:"description"`
+	OwnerActions string    `db:"ownerActions"`
+	Created      time.Time `db:"created"`
+}
+
+// GetOwnerActions returns the comma separated owner actions as a slice.
+func (resourceType *ResourceType) GetOwnerActions() []string {
+	var actions []string
+	for _, action := range strings.Split(resourceType.OwnerActions, ",") {
+		if action = strings.TrimSpace(action); action != "" {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+/**
+* @apiDefine ResourceTypeInput
+* @apiParam (Request) {string} name Unique name, plugins should prefix it with their id. Ex: blog.post
+* @apiParam (Request) {string} [description]
+* @apiParam (Request) {string[]} [ownerActions] Actions the owner of a resource can always perform. Use * for all.
+ */
+type ResourceTypeInput struct {
+	Name         string   `json:"name" binding:"required"`
+	Description  string   `json:"description"`
+	OwnerActions []string `json:"ownerActions"`
+}
+
+// Grant gives a user or group an action on one object, or on every object of a type when ResourceId is "*".
+type Grant struct {
+	Id           int64     `json:"id" db:"id"`
+	ResourceType string    `json:"resourceType" db:"resourceType"`
+	ResourceId   string    `json:"resourceId" db:"resourceId"`
+	SubjectType  string    `json:"subjectType" db:"subjectType"`
+	SubjectId    int64     `json:"subjectId" db:"subjectId"`
+	Action       string    `json:"action" db:"action"`
+	Created      time.Time `json:"created" db:"created"`
+}
+
+/**
+* @apiDefine GrantInput
+* @apiParam (Request) {string} resourceType
+* @apiParam (Request) {string} resourceId Id of the object or * for every object of the type.
+* @apiParam (Request) {string} subjectType user or group
+* @apiParam (Request) {number} subjectId
+* @apiParam (Request) {string} action Ex: edit. Use * for all actions.
+ */
+type GrantInput struct {
+	ResourceType string `json:"resourceType" binding:"required"`
+	ResourceId   string `json:"resourceId" binding:"required"`
+	SubjectType  string `json:"subjectType" binding:"required"`
+	SubjectId    int64  `json:"subjectId" binding:"required"`
+	Action       string `json:"action" binding:"required"`
+}
+
+// Rule allows or denies an action on a resource type when all of its conditions match. Deny rules win over everything
+// except super admins.
+type Rule struct {
+	Id             int64        `json:"id" db:"id"`
+	ResourceType   string       `json:"resourceType" db:"resourceType"`
+	Action         string       `json:"action" db:"action"`
+	Effect         string       `json:"effect" db:"effect"`
+	Description    string       `json:"description" db:"description"`
+	ConditionsData string       `json:"-" db:"conditions"`
+	Conditions     []*Condition `json:"conditions" db:"-"`
+	Created        time.Time    `json:"created" db:"created"`
+}
+
+/**
+* @apiDefine RuleInput
+* @apiParam (Request) {string} resourceType
+* @apiParam (Request) {string} action Use * for all actions.
+* @apiParam (Request) {string} effect allow or deny
+* @apiParam (Request) {string} [description]
+* @apiParam (Request) {Object[]} conditions All must match for the rule to apply.
+* @apiParam (Request) {string} conditions.field Ex: user.id, user.groups, user.groupNames, user.permissions, user.email, resource.id, resource.ownerId or resource.<attribute>
+* @apiParam (Request) {string} conditions.operator eq, ne, in, contains, gt, gte, lt, lte or exists
+* @apiParam (Request) {any} [conditions.value] Literal value to compare against.
+* @apiParam (Request) {string} [conditions.valueField] Field to compare against instead of a literal value. Ex: resource.teamId
+ */
+type RuleInput struct {
+	ResourceType string       `json:"resourceType" binding:"required"`
+	Action       string       `json:"action" binding:"required"`
+	Effect       string       `json:"effect" binding:"required"`
+	Description  string       `json:"description"`
+	Conditions   []*Condition `json:"conditions"`
+}
+
+// Condition compares a user or resource field with a literal value or another field.
+type Condition struct {
+	Field      string      `json:"field"`
+	Operator   string      `json:"operator"`
+	Value      interface{} `json:"value,omitempty"`
+	ValueField string      `json:"valueField,omitempty"`
+}
+
+/**
+* @apiDefine CheckInput
+* @apiParam (Request) {number} userId
+* @apiParam (Request) {string} action
+* @apiParam (Request) {Object} resource
+* @apiParam (Request) {string} resource.type
+* @apiParam (Request) {string} resource.id
+* @apiParam (Request) {number} [resource.ownerId]
+* @apiParam (Request) {Object} [resource.attributes] Fields conditions can check. Ex: {"teamId": 4, "status": "draft"}
+* @apiParam (Request) {number} [apiKeyId] Set when the user authenticated with an api key, from the user context.
+* @apiParam (Request) {string[]} [apiKeyScope] Scope of the api key, super admins only bypass policies when it covers
+* super_admin.
+ */
+type CheckInput struct {
+	UserId      int64     `json:"userId" binding:"required"`
+	Action      string    `json:"action" binding:"required"`
+	Resource    *Resource `json:"resource" binding:"required"`
+	ApiKeyId    int64     `json:"apiKeyId,omitempty"`
+	ApiKeyScope []string  `json:"apiKeyScope,omitempty"`
+}
+
+// GetUser returns the user the check is made for with the api key they authenticated with.
+func (input *CheckInput) GetUser() *user_model.User {
+	return &user_model.User{
+		Id:          input.UserId,
+		ApiKeyId:    input.ApiKeyId,
+		ApiKeyScope: input.ApiKeyScope,
+	}
+}
+
+// Resource is the object a check is made against. Gocms doesn't store resources, the caller describes them.
+type Resource struct {
+	Type       string                 `json:"type"`
+	Id         string                 `json:"id"`
+	OwnerId    int64                  `json:"ownerId,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+/**
+* @apiDefine Decision
+* @apiSuccess (Response) {boolean} allowed
+* @apiSuccess (Response) {string} reason What decided the outcome. Ex: owner, grant:12, rule:3
+ */
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+}
+
+// MatchesAction reports if a policy action covers the requested action.
+func MatchesAction(policyAction string, action string) bool {
+	return policyAction == ANY || policyAction == action
+}
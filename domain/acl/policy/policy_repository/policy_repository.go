@@ -0,0 +1,182 @@
+package policy_repository
+
+import (
+	"encoding/json"
+
+	"github.com/cqlcorp/gocms/domain/acl/policy/policy_model"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/jmoiron/sqlx"
+)
+
+type IPolicyRepository interface {
+	UpsertResourceType(*policy_model.ResourceType) error
+	GetResourceType(name string) (*policy_model.ResourceType, error)
+	GetResourceTypes() ([]*policy_model.ResourceType, error)
+
+	AddGrant(*policy_model.Grant) error
+	GetGrant(int64) (*policy_model.Grant, error)
+	DeleteGrant(int64) error
+	GetGrantsForResource(resourceType string, resourceId string) ([]*policy_model.Grant, error)
+
+	AddRule(*policy_model.Rule) error
+	DeleteRule(int64) error
+	GetRules() ([]*policy_model.Rule, error)
+	GetRulesForResourceType(resourceType string) ([]*policy_model.Rule, error)
+}
+
+type PolicyRepository struct {
+	database *sqlx.DB
+}
+
+func DefaultPolicyRepository(dbx *sqlx.DB) *PolicyRepository {
+	policyRepository := &PolicyRepository{
+		database: dbx,
+	}
+
+	return policyRepository
+}
+
+// UpsertResourceType adds the resource type or updates it if one with the same name exists
+func (pr *PolicyRepository) UpsertResourceType(resourceType *policy_model.ResourceType) error {
+	result, err := pr.database.NamedExec(`
+	INSERT INTO gocms_acl_resource_types (name, description, ownerActions) VALUES (:name, :description, :ownerActions)
+	ON DUPLICATE KEY UPDATE description=VALUES(description), ownerActions=VALUES(ownerActions), id=LAST_INSERT_ID(id)
+	`, resourceType)
+	if err != nil {
+		log.Errorf("Error upserting resource type %v: %s\n", resourceType.Name, err.Error())
+		return err
+	}
+	id, _ := result.LastInsertId()
+	resourceType.Id = id
+
+	return nil
+}
+
+func (pr *PolicyRepository) GetResourceType(name string) (*policy_model.ResourceType, error) {
+	var resourceType policy_model.ResourceType
+	err := pr.database.Get(&resourceType, "SELECT * FROM gocms_acl_resource_types WHERE name=?", name)
+	if err != nil {
+		log.Errorf("Error getting resource type %v from database: %s\n", name, err.Error())
+		return nil, err
+	}
+	return &resourceType, nil
+}
+
+func (pr *PolicyRepository) GetResourceTypes() ([]*policy_model.ResourceType, error) {
+	var resourceTypes []*policy_model.ResourceType
+	err := pr.database.Select(&resourceTypes, "SELECT * FROM gocms_acl_resource_types ORDER BY name")
+	if err != nil {
+		log.Errorf("Error getting resource types from database: %s\n", err.Error())
+		return nil, err
+	}
+	return resourceTypes, nil
+}
+
+func (pr *PolicyRepository) AddGrant(grant *policy_model.Grant) error {
+	result, err := pr.database.NamedExec(`
+	INSERT INTO gocms_acl_grants (resourceType, resourceId, subjectType, subjectId, action)
+	VALUES (:resourceType, :resourceId, :subjectType, :subjectId, :action)
+	`, grant)
+	if err != nil {
+		log.Errorf("Error adding grant on %v %v to database: %s\n", grant.ResourceType, grant.ResourceId, err.Error())
+		return err
+	}
+	id, _ := result.LastInsertId()
+	grant.Id = id
+
+	return nil
+}
+
+func (pr *PolicyRepository) GetGrant(grantId int64) (*policy_model.Grant, error) {
+	var grant policy_model.Grant
+	err := pr.database.Get(&grant, "SELECT * FROM gocms_acl_grants WHERE id=?", grantId)
+	if err != nil {
+		log.Errorf("Error getting grant %v from database: %s\n", grantId, err.Error())
+		return nil, err
+	}
+	return &grant, nil
+}
+
+func (pr *PolicyRepository) DeleteGrant(grantId int64) error {
+	_, err := pr.database.Exec("DELETE FROM gocms_acl_grants WHERE id=?", grantId)
+	if err != nil {
+		log.Errorf("Error deleting grant %v from database: %s\n", grantId, err.Error())
+		return err
+	}
+	return nil
+}
+
+// GetGrantsForResource get grants on the object and grants on every object of its type
+func (pr *PolicyRepository) GetGrantsForResource(resourceType string, resourceId string) ([]*policy_model.Grant, error) {
+	var grants []*policy_model.Grant
+	err := pr.database.Select(&grants, `
+	SELECT * FROM gocms_acl_grants
+	WHERE resourceType = ?
+	AND (resourceId = ? OR resourceId = ?)
+	`, resourceType, resourceId, policy_model.ANY)
+	if err != nil {
+		log.Errorf("Error getting grants for %v %v from database: %s\n", resourceType, resourceId, err.Error())
+		return nil, err
+	}
+	return grants, nil
+}
+
+func (pr *PolicyRepository) AddRule(rule *policy_model.Rule) error {
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return err
+	}
+	rule.ConditionsData = string(conditions)
+
+	result, err := pr.database.NamedExec(`
+	INSERT INTO gocms_acl_rules (resourceType, action, effect, description, conditions)
+	VALUES (:resourceType, :action, :effect, :description, :conditions)
+	`, rule)
+	if err != nil {
+		log.Errorf("Error adding rule for %v to database: %s\n", rule.ResourceType, err.Error())
+		return err
+	}
+	id, _ := result.LastInsertId()
+	rule.Id = id
+
+	return nil
+}
+
+func (pr *PolicyRepository) DeleteRule(ruleId int64) error {
+	_, err := pr.database.Exec("DELETE FROM gocms_acl_rules WHERE id=?", ruleId)
+	if err != nil {
+		log.Errorf("Error deleting rule %v from database: %s\n", ruleId, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (pr *PolicyRepository) GetRules() ([]*policy_model.Rule, error) {
+	var rules []*policy_model.Rule
+	err := pr.database.Select(&rules, "SELECT * FROM gocms_acl_rules ORDER BY resourceType, id")
+	if err != nil {
+		log.Errorf("Error getting rules from database: %s\n", err.Error())
+		return nil, err
+	}
+	return parseConditions(rules), nil
+}
+
+func (pr *PolicyRepository) GetRulesForResourceType(resourceType string) ([]*policy_model.Rule, error) {
+	var rules []*policy_model.Rule
+	err := pr.database.Select(&rules, "SELECT * FROM gocms_acl_rules WHERE resourceType=? ORDER BY id", resourceType)
+	if err != nil {
+		log.Errorf("Error getting rules for %v from database: %s\n", resourceType, err.Error())
+		return nil, err
+	}
+	return parseConditions(rules), nil
+}
+
+func parseConditions(rules []*policy_model.Rule) []*policy_model.Rule {
+	for _, rule := range rules {
+		err := json.Unmarshal([]byte(rule.ConditionsData), &rule.Conditions)
+		if err != nil {
+			log.Errorf("Error parsing conditions of rule %v: %v\n", rule.Id, err.Error())
+		}
+	}
+	return rules
+}
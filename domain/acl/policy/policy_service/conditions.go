@@ -0,0 +1,323 @@
+package policy_service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"github.com/cqlcorp/gocms/domain/acl/policy/policy_model"
+	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/log"
+)
+
+const (
+	userPrefix     = "user."
+	resourcePrefix = "resource."
+)
+
+var userFields = map[string]bool{
+	"user.id":          true,
+	"user.email":       true,
+	"user.groups":      true,
+	"user.groupNames":  true,
+	"user.permissions": true,
+}
+
+// subject loads the user fields a check needs the first time they are used.
+type subject struct {
+	userId int64
+	rg     *repository.RepositoriesGroup
+
+	groupsLoaded bool
+	groupIds     []int64
+	groupNames   []string
+
+	email       *string
+	permissions []string
+
+	// err is the first error loading a field. Conditions on fields that couldn't be loaded don't match, so a check must
+	// fail once it is set or a deny rule could be skipped.
+	err error
+}
+
+func (s *subject) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *subject) loadGroups() {
+	if s.groupsLoaded {
+		return
+	}
+	s.groupsLoaded = true
+
+	groups, err := s.rg.GroupsRepository.GetUserGroups(s.userId)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	links, err := s.rg.GroupsRepository.GetParentLinks()
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	var ids []int64
+	for _, group := range groups {
+		ids = append(ids, group.Id)
+	}
+	s.groupIds = group_model.Ancestors(links, ids...)
+
+	allGroups, err := s.rg.GroupsRepository.GetAll()
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	names := make(map[int64]string, len(*allGroups))
+	for _, group := range *allGroups {
+		names[group.Id] = group.Name
+	}
+	for _, id := range s.groupIds {
+		s.groupNames = append(s.groupNames, names[id])
+	}
+}
+
+// inGroup reports if the user is in the group directly or through a child group.
+func (s *subject) inGroup(groupId int64) bool {
+	s.loadGroups()
+	for _, id := range s.groupIds {
+		if id == groupId {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *subject) field(name string) (interface{}, bool) {
+	switch name {
+	case "user.id":
+		return s.userId, true
+	case "user.email":
+		if s.email == nil {
+			user, err := s.rg.UsersRepository.Get(s.userId)
+			if err != nil {
+				s.fail(err)
+				return nil, false
+			}
+			s.email = &user.Email
+		}
+		return *s.email, true
+	case "user.groups":
+		s.loadGroups()
+		return s.groupIds, true
+	case "user.groupNames":
+		s.loadGroups()
+		return s.groupNames, true
+	case "user.permissions":
+		if s.permissions == nil {
+			permissions, err := s.rg.PermissionsRepository.GetUserPermissions(s.userId)
+			if err != nil {
+				s.fail(err)
+				return nil, false
+			}
+			s.permissions = []string{}
+			for _, permission := range permissions {
+				s.permissions = append(s.permissions, permission.Name)
+			}
+		}
+		return s.permissions, true
+	}
+	return nil, false
+}
+
+func resourceField(resource *policy_model.Resource, name string) (interface{}, bool) {
+	switch name {
+	case "resource.id":
+		return resource.Id, true
+	case "resource.type":
+		return resource.Type, true
+	case "resource.ownerId":
+		return resource.OwnerId, resource.OwnerId != 0
+	}
+	value, ok := resource.Attributes[strings.TrimPrefix(name, resourcePrefix)]
+	return value, ok && value != nil
+}
+
+func (s *subject) lookup(resource *policy_model.Resource, name string) (interface{}, bool) {
+	if strings.HasPrefix(name, userPrefix) {
+		return s.field(name)
+	}
+	return resourceField(resource, name)
+}
+
+// matches reports if every condition of the rule holds. Deny rules fail closed, a condition comparing a field that
+// isn't set counts as holding so a missing attribute can't be used to get around the rule.
+func (s *subject) matches(rule *policy_model.Rule, resource *policy_model.Resource) bool {
+	failClosed := rule.Effect == policy_model.EFFECT_DENY
+	for _, condition := range rule.Conditions {
+		if failClosed && s.missingOperand(condition, resource) {
+			continue
+		}
+		if !s.evaluate(condition, resource) {
+			return false
+		}
+	}
+	return true
+}
+
+// missingOperand reports if a field the condition compares isn't set. Exists checks for a missing field on purpose.
+func (s *subject) missingOperand(condition *policy_model.Condition, resource *policy_model.Resource) bool {
+	if condition.Operator == policy_model.OPERATOR_EXISTS {
+		return false
+	}
+	if _, ok := s.lookup(resource, condition.Field); !ok {
+		return true
+	}
+	if condition.ValueField != "" {
+		if _, ok := s.lookup(resource, condition.ValueField); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *subject) evaluate(condition *policy_model.Condition, resource *policy_model.Resource) bool {
+	value, ok := s.lookup(resource, condition.Field)
+	if condition.Operator == policy_model.OPERATOR_EXISTS {
+		if expected, isBool := condition.Value.(bool); isBool && !expected {
+			return !ok
+		}
+		return ok
+	}
+	if !ok {
+		return false
+	}
+
+	other := condition.Value
+	if condition.ValueField != "" {
+		other, ok = s.lookup(resource, condition.ValueField)
+		if !ok {
+			return false
+		}
+	}
+
+	switch condition.Operator {
+	case policy_model.OPERATOR_EQ:
+		return equal(value, other)
+	case policy_model.OPERATOR_NE:
+		return !equal(value, other)
+	case policy_model.OPERATOR_IN:
+		return contains(other, value)
+	case policy_model.OPERATOR_CONTAINS:
+		if str, isString := value.(string); isString {
+			return strings.Contains(str, fmt.Sprint(other))
+		}
+		return contains(value, other)
+	case policy_model.OPERATOR_GT, policy_model.OPERATOR_GTE, policy_model.OPERATOR_LT, policy_model.OPERATOR_LTE:
+		a, aOk := number(value)
+		b, bOk := number(other)
+		if !aOk || !bOk {
+			return false
+		}
+		switch condition.Operator {
+		case policy_model.OPERATOR_GT:
+			return a > b
+		case policy_model.OPERATOR_GTE:
+			return a >= b
+		case policy_model.OPERATOR_LT:
+			return a < b
+		default:
+			return a <= b
+		}
+	}
+
+	log.Warningf("Unknown policy operator %v\n", condition.Operator)
+	return false
+}
+
+func validateCondition(condition *policy_model.Condition) error {
+	if condition == nil {
+		return errors.NewToUser("Conditions can't be empty.")
+	}
+	if !validField(condition.Field) {
+		return errors.NewToUser(fmt.Sprintf("Unknown condition field '%v'.", condition.Field))
+	}
+	if condition.ValueField != "" && !validField(condition.ValueField) {
+		return errors.NewToUser(fmt.Sprintf("Unknown condition field '%v'.", condition.ValueField))
+	}
+
+	switch condition.Operator {
+	case policy_model.OPERATOR_EQ, policy_model.OPERATOR_NE, policy_model.OPERATOR_CONTAINS, policy_model.OPERATOR_EXISTS:
+	case policy_model.OPERATOR_IN:
+		if condition.ValueField == "" && list(condition.Value) == nil {
+			return errors.NewToUser("The in operator needs a list value.")
+		}
+	case policy_model.OPERATOR_GT, policy_model.OPERATOR_GTE, policy_model.OPERATOR_LT, policy_model.OPERATOR_LTE:
+		if _, ok := number(condition.Value); condition.ValueField == "" && !ok {
+			return errors.NewToUser(fmt.Sprintf("The %v operator needs a number value.", condition.Operator))
+		}
+	default:
+		return errors.NewToUser(fmt.Sprintf("Unknown condition operator '%v'.", condition.Operator))
+	}
+	return nil
+}
+
+func validField(field string) bool {
+	return userFields[field] || (strings.HasPrefix(field, resourcePrefix) && len(field) > len(resourcePrefix))
+}
+
+// number converts json and go numbers, and numeric strings, to float64 so they compare regardless of type.
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func equal(a interface{}, b interface{}) bool {
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			return x == y
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// list returns the items of a slice of any type, or nil when value isn't a slice.
+func list(value interface{}) []interface{} {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice {
+		return nil
+	}
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items
+}
+
+func contains(haystack interface{}, needle interface{}) bool {
+	for _, item := range list(haystack) {
+		if equal(item, needle) {
+			return true
+		}
+	}
+	return false
+}
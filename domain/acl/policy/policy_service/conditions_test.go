@@ -0,0 +1,145 @@
+package policy_service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cqlcorp/gocms/domain/acl/policy/policy_model"
+)
+
+// loadedSubject has every user field loaded so conditions are evaluated without a database
+func loadedSubject() *subject {
+	email := "jane@example.com"
+	return &subject{
+		userId:       4,
+		groupsLoaded: true,
+		groupIds:     []int64{2, 7},
+		groupNames:   []string{"editors", "staff"},
+		email:        &email,
+		permissions:  []string{"blog.edit"},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	resource := &policy_model.Resource{
+		Type:    "post",
+		Id:      "12",
+		OwnerId: 4,
+		Attributes: map[string]interface{}{
+			"teamId":   json.Number("7"),
+			"status":   "draft",
+			"title":    "Release notes",
+			"words":    float64(1200),
+			"editors":  []interface{}{json.Number("4"), json.Number("9")},
+			"reviewer": nil,
+		},
+	}
+	tests := []struct {
+		name      string
+		condition policy_model.Condition
+		want      bool
+	}{
+		{"eq string", policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_EQ, Value: "draft"}, true},
+		{"eq string mismatch", policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_EQ, Value: "published"}, false},
+		{"eq number types", policy_model.Condition{Field: "resource.teamId", Operator: policy_model.OPERATOR_EQ, Value: float64(7)}, true},
+		{"eq numeric string", policy_model.Condition{Field: "resource.id", Operator: policy_model.OPERATOR_EQ, Value: float64(12)}, true},
+		{"eq user field", policy_model.Condition{Field: "resource.ownerId", Operator: policy_model.OPERATOR_EQ, ValueField: "user.id"}, true},
+		{"eq user email", policy_model.Condition{Field: "user.email", Operator: policy_model.OPERATOR_EQ, Value: "jane@example.com"}, true},
+		{"ne", policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_NE, Value: "published"}, true},
+		{"ne missing field", policy_model.Condition{Field: "resource.missing", Operator: policy_model.OPERATOR_NE, Value: "x"}, false},
+		{"in literal list", policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_IN, Value: []interface{}{"draft", "review"}}, true},
+		{"in literal list mismatch", policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_IN, Value: []interface{}{"review"}}, false},
+		{"in user groups", policy_model.Condition{Field: "resource.teamId", Operator: policy_model.OPERATOR_IN, ValueField: "user.groups"}, true},
+		{"user in resource list", policy_model.Condition{Field: "user.id", Operator: policy_model.OPERATOR_IN, ValueField: "resource.editors"}, true},
+		{"contains list", policy_model.Condition{Field: "user.groupNames", Operator: policy_model.OPERATOR_CONTAINS, Value: "editors"}, true},
+		{"contains list mismatch", policy_model.Condition{Field: "user.groupNames", Operator: policy_model.OPERATOR_CONTAINS, Value: "admins"}, false},
+		{"contains permission", policy_model.Condition{Field: "user.permissions", Operator: policy_model.OPERATOR_CONTAINS, Value: "blog.edit"}, true},
+		{"contains string", policy_model.Condition{Field: "resource.title", Operator: policy_model.OPERATOR_CONTAINS, Value: "notes"}, true},
+		{"gt", policy_model.Condition{Field: "resource.words", Operator: policy_model.OPERATOR_GT, Value: float64(1000)}, true},
+		{"gt equal", policy_model.Condition{Field: "resource.words", Operator: policy_model.OPERATOR_GT, Value: float64(1200)}, false},
+		{"gte equal", policy_model.Condition{Field: "resource.words", Operator: policy_model.OPERATOR_GTE, Value: float64(1200)}, true},
+		{"lt", policy_model.Condition{Field: "resource.teamId", Operator: policy_model.OPERATOR_LT, Value: json.Number("10")}, true},
+		{"lte", policy_model.Condition{Field: "resource.teamId", Operator: policy_model.OPERATOR_LTE, Value: float64(6)}, false},
+		{"gt not a number", policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_GT, Value: float64(1)}, false},
+		{"exists", policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_EXISTS}, true},
+		{"exists null", policy_model.Condition{Field: "resource.reviewer", Operator: policy_model.OPERATOR_EXISTS}, false},
+		{"exists false", policy_model.Condition{Field: "resource.reviewer", Operator: policy_model.OPERATOR_EXISTS, Value: false}, true},
+		{"exists false when set", policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_EXISTS, Value: false}, false},
+		{"missing value field", policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_EQ, ValueField: "resource.missing"}, false},
+		{"unknown operator", policy_model.Condition{Field: "resource.status", Operator: "like", Value: "draft"}, false},
+	}
+	for _, test := range tests {
+		condition := test.condition
+		if got := loadedSubject().evaluate(&condition, resource); got != test.want {
+			t.Errorf("%v: evaluate() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestOwnerIdUnset(t *testing.T) {
+	resource := &policy_model.Resource{Type: "post", Id: "12"}
+	condition := &policy_model.Condition{Field: "resource.ownerId", Operator: policy_model.OPERATOR_EQ, ValueField: "user.id"}
+	if loadedSubject().evaluate(condition, resource) {
+		t.Error("a resource without an owner matched the user")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	resource := &policy_model.Resource{Type: "post", Id: "12", Attributes: map[string]interface{}{"status": "draft"}}
+	draft := &policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_EQ, Value: "draft"}
+	editor := &policy_model.Condition{Field: "user.groupNames", Operator: policy_model.OPERATOR_CONTAINS, Value: "editors"}
+	admin := &policy_model.Condition{Field: "user.groupNames", Operator: policy_model.OPERATOR_CONTAINS, Value: "admins"}
+	unpublished := &policy_model.Condition{Field: "resource.published", Operator: policy_model.OPERATOR_NE, Value: true}
+	reviewedBy := &policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_EQ, ValueField: "resource.reviewer"}
+	missing := &policy_model.Condition{Field: "resource.reviewer", Operator: policy_model.OPERATOR_EXISTS, Value: false}
+	tests := []struct {
+		name       string
+		effect     string
+		conditions []*policy_model.Condition
+		want       bool
+	}{
+		{"no conditions", policy_model.EFFECT_ALLOW, nil, true},
+		{"every condition holds", policy_model.EFFECT_ALLOW, []*policy_model.Condition{draft, editor}, true},
+		{"one condition fails", policy_model.EFFECT_ALLOW, []*policy_model.Condition{draft, admin}, false},
+		{"allow with missing field", policy_model.EFFECT_ALLOW, []*policy_model.Condition{unpublished}, false},
+		{"deny with missing field", policy_model.EFFECT_DENY, []*policy_model.Condition{unpublished}, true},
+		{"deny with missing value field", policy_model.EFFECT_DENY, []*policy_model.Condition{reviewedBy}, true},
+		{"deny with missing field and failing condition", policy_model.EFFECT_DENY, []*policy_model.Condition{unpublished, admin}, false},
+		{"deny with exists false", policy_model.EFFECT_DENY, []*policy_model.Condition{missing}, true},
+		{"deny with failing condition", policy_model.EFFECT_DENY, []*policy_model.Condition{admin}, false},
+	}
+	for _, test := range tests {
+		rule := &policy_model.Rule{Effect: test.effect, Conditions: test.conditions}
+		if got := loadedSubject().matches(rule, resource); got != test.want {
+			t.Errorf("%v: matches() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestValidateCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition *policy_model.Condition
+		valid     bool
+	}{
+		{"nil", nil, false},
+		{"eq", &policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_EQ, Value: "draft"}, true},
+		{"user field", &policy_model.Condition{Field: "user.groups", Operator: policy_model.OPERATOR_CONTAINS, Value: float64(2)}, true},
+		{"unknown user field", &policy_model.Condition{Field: "user.password", Operator: policy_model.OPERATOR_EQ, Value: "x"}, false},
+		{"bare resource prefix", &policy_model.Condition{Field: "resource.", Operator: policy_model.OPERATOR_EQ, Value: "x"}, false},
+		{"unknown value field", &policy_model.Condition{Field: "resource.ownerId", Operator: policy_model.OPERATOR_EQ, ValueField: "user.name"}, false},
+		{"in with list", &policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_IN, Value: []interface{}{"draft"}}, true},
+		{"in without list", &policy_model.Condition{Field: "resource.status", Operator: policy_model.OPERATOR_IN, Value: "draft"}, false},
+		{"in with value field", &policy_model.Condition{Field: "resource.teamId", Operator: policy_model.OPERATOR_IN, ValueField: "user.groups"}, true},
+		{"gt with number", &policy_model.Condition{Field: "resource.words", Operator: policy_model.OPERATOR_GT, Value: float64(1)}, true},
+		{"gt with numeric string", &policy_model.Condition{Field: "resource.words", Operator: policy_model.OPERATOR_GT, Value: "1"}, true},
+		{"gt without number", &policy_model.Condition{Field: "resource.words", Operator: policy_model.OPERATOR_GT, Value: "many"}, false},
+		{"exists", &policy_model.Condition{Field: "resource.reviewer", Operator: policy_model.OPERATOR_EXISTS}, true},
+		{"unknown operator", &policy_model.Condition{Field: "resource.status", Operator: "like", Value: "draft"}, false},
+	}
+	for _, test := range tests {
+		if err := validateCondition(test.condition); (err == nil) != test.valid {
+			t.Errorf("%v: validateCondition() = %v, want valid %v", test.name, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package policy_service
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_service"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/domain/acl/policy/policy_model"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/log"
+)
+
+const maxResourceTypeLength = 100
+
+type IPolicyService interface {
+	RegisterResourceType(*policy_model.ResourceTypeInput) (*policy_model.ResourceType, error)
+	GetResourceTypes() ([]*policy_model.ResourceType, error)
+
+	Check(user *user_model.User, action string, resource *policy_model.Resource) (*policy_model.Decision, error)
+	Can(user *user_model.User, action string, resource *policy_model.Resource) bool
+
+	Grant(*policy_model.GrantInput) (*policy_model.Grant, error)
+	GetGrant(int64) (*policy_model.Grant, error)
+	Revoke(grantId int64) error
+	GetGrants(resourceType string, resourceId string) ([]*policy_model.Grant, error)
+
+	AddRule(*policy_model.RuleInput) (*policy_model.Rule, error)
+	DeleteRule(int64) error
+	GetRules() ([]*policy_model.Rule, error)
+}
+
+type PolicyService struct {
+	RepositoriesGroup *repository.RepositoriesGroup
+	AclService        access_control_service.IAclService
+}
+
+func DefaultPolicyService(rg *repository.RepositoriesGroup, aclService access_control_service.IAclService) *PolicyService {
+	policyService := &PolicyService{
+		RepositoriesGroup: rg,
+		AclService:        aclService,
+	}
+
+	return policyService
+}
+
+// RegisterResourceType adds a resource type or updates the description and owner actions of an existing one.
+func (ps *PolicyService) RegisterResourceType(input *policy_model.ResourceTypeInput) (*policy_model.ResourceType, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > maxResourceTypeLength || strings.ContainsAny(name, " *,") {
+		return nil, errors.NewToUser(fmt.Sprintf("Resource type names must be 1-%v characters without spaces, commas or *.", maxResourceTypeLength))
+	}
+
+	resourceType := &policy_model.ResourceType{
+		Name:         name,
+		Description:  input.Description,
+		OwnerActions: strings.Join(input.OwnerActions, ","),
+	}
+	err := ps.RepositoriesGroup.PolicyRepository.UpsertResourceType(resourceType)
+	if err != nil {
+		return nil, err
+	}
+	return resourceType, nil
+}
+
+func (ps *PolicyService) GetResourceTypes() ([]*policy_model.ResourceType, error) {
+	return ps.RepositoriesGroup.PolicyRepository.GetResourceTypes()
+}
+
+// Check decides if the user may perform the action on the resource. Super admins can do anything, unless they
+// authenticated with an api key whose scope doesn't cover super_admin. Otherwise deny
+// rules are applied first, then ownership, grants to the user or any of their groups and finally allow rules. An error
+// loading the user fields conditions use is returned instead of a decision, so deny rules never fail open.
+func (ps *PolicyService) Check(user *user_model.User, action string, resource *policy_model.Resource) (*policy_model.Decision, error) {
+	if resource == nil || resource.Type == "" {
+		return nil, errors.NewToUser("A resource type is required.")
+	}
+	userId := user.Id
+
+	if user.InApiKeyScope(permissions.SUPER_ADMIN) && ps.AclService.IsAuthorized(permissions.SUPER_ADMIN, userId) {
+		return allow(permissions.SUPER_ADMIN), nil
+	}
+
+	resourceType, err := ps.RepositoriesGroup.PolicyRepository.GetResourceType(resource.Type)
+	if err == sql.ErrNoRows {
+		return deny(fmt.Sprintf("unknown resource type %v", resource.Type)), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	rules, err := ps.RepositoriesGroup.PolicyRepository.GetRulesForResourceType(resourceType.Name)
+	if err != nil {
+		return nil, err
+	}
+	s := &subject{userId: userId, rg: ps.RepositoriesGroup}
+
+	// deny rules win over everything else
+	for _, rule := range rules {
+		if rule.Effect == policy_model.EFFECT_DENY && policy_model.MatchesAction(rule.Action, action) && s.matches(rule, resource) {
+			return deny(fmt.Sprintf("rule:%v", rule.Id)), nil
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	// owners
+	if resource.OwnerId != 0 && resource.OwnerId == userId {
+		for _, ownerAction := range resourceType.GetOwnerActions() {
+			if policy_model.MatchesAction(ownerAction, action) {
+				return allow("owner"), nil
+			}
+		}
+	}
+
+	// grants on the object or on every object of the type
+	grants, err := ps.RepositoriesGroup.PolicyRepository.GetGrantsForResource(resourceType.Name, resource.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, grant := range grants {
+		if !policy_model.MatchesAction(grant.Action, action) {
+			continue
+		}
+		if grant.SubjectType == policy_model.SUBJECT_USER && grant.SubjectId == userId {
+			return allow(fmt.Sprintf("grant:%v", grant.Id)), nil
+		}
+		if grant.SubjectType == policy_model.SUBJECT_GROUP && s.inGroup(grant.SubjectId) {
+			return allow(fmt.Sprintf("grant:%v", grant.Id)), nil
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	for _, rule := range rules {
+		if rule.Effect == policy_model.EFFECT_ALLOW && policy_model.MatchesAction(rule.Action, action) && s.matches(rule, resource) {
+			// an exists false condition matches when the field failed to load
+			if s.err != nil {
+				return nil, s.err
+			}
+			return allow(fmt.Sprintf("rule:%v", rule.Id)), nil
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return deny("no matching policy"), nil
+}
+
+// Can is Check for callers that only need the outcome. Errors are logged and treated as a denial.
+func (ps *PolicyService) Can(user *user_model.User, action string, resource *policy_model.Resource) bool {
+	decision, err := ps.Check(user, action, resource)
+	if err != nil {
+		log.Errorf("Error checking policy for user %v: %s\n", user.Id, err.Error())
+		return false
+	}
+	return decision.Allowed
+}
+
+func (ps *PolicyService) Grant(input *policy_model.GrantInput) (*policy_model.Grant, error) {
+	_, err := ps.getResourceType(input.ResourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	switch input.SubjectType {
+	case policy_model.SUBJECT_USER:
+		_, err = ps.RepositoriesGroup.UsersRepository.Get(input.SubjectId)
+	case policy_model.SUBJECT_GROUP:
+		_, err = ps.RepositoriesGroup.GroupsRepository.Get(input.SubjectId)
+	default:
+		return nil, errors.NewToUser("Subject type must be user or group.")
+	}
+	if err != nil {
+		return nil, errors.NewToUser(fmt.Sprintf("No %v with id %v.", input.SubjectType, input.SubjectId))
+	}
+
+	grant := &policy_model.Grant{
+		ResourceType: input.ResourceType,
+		ResourceId:   input.ResourceId,
+		SubjectType:  input.SubjectType,
+		SubjectId:    input.SubjectId,
+		Action:       input.Action,
+	}
+	err = ps.RepositoriesGroup.PolicyRepository.AddGrant(grant)
+	if err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+func (ps *PolicyService) GetGrant(grantId int64) (*policy_model.Grant, error) {
+	return ps.RepositoriesGroup.PolicyRepository.GetGrant(grantId)
+}
+
+func (ps *PolicyService) Revoke(grantId int64) error {
+	return ps.RepositoriesGroup.PolicyRepository.DeleteGrant(grantId)
+}
+
+func (ps *PolicyService) GetGrants(resourceType string, resourceId string) ([]*policy_model.Grant, error) {
+	return ps.RepositoriesGroup.PolicyRepository.GetGrantsForResource(resourceType, resourceId)
+}
+
+// AddRule validates the conditions of a rule and stores it.
+func (ps *PolicyService) AddRule(input *policy_model.RuleInput) (*policy_model.Rule, error) {
+	_, err := ps.getResourceType(input.ResourceType)
+	if err != nil {
+		return nil, err
+	}
+	if input.Effect != policy_model.EFFECT_ALLOW && input.Effect != policy_model.EFFECT_DENY {
+		return nil, errors.NewToUser("Effect must be allow or deny.")
+	}
+	for _, condition := range input.Conditions {
+		err = validateCondition(condition)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rule := &policy_model.Rule{
+		ResourceType: input.ResourceType,
+		Action:       input.Action,
+		Effect:       input.Effect,
+		Description:  input.Description,
+		Conditions:   input.Conditions,
+	}
+	err = ps.RepositoriesGroup.PolicyRepository.AddRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (ps *PolicyService) DeleteRule(ruleId int64) error {
+	return ps.RepositoriesGroup.PolicyRepository.DeleteRule(ruleId)
+}
+
+func (ps *PolicyService) GetRules() ([]*policy_model.Rule, error) {
+	return ps.RepositoriesGroup.PolicyRepository.GetRules()
+}
+
+func (ps *PolicyService) getResourceType(name string) (*policy_model.ResourceType, error) {
+	resourceType, err := ps.RepositoriesGroup.PolicyRepository.GetResourceType(name)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewToUser(fmt.Sprintf("Resource type %v isn't registered.", name))
+	}
+	return resourceType, err
+}
+
+func allow(reason string) *policy_model.Decision {
+	return &policy_model.Decision{Allowed: true, Reason: reason}
+}
+
+func deny(reason string) *policy_model.Decision {
+	return &policy_model.Decision{Allowed: false, Reason: reason}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/security/security_middleware"
 	"github.com/cqlcorp/gocms/routes"
 	"html/template"
 	"net/http"
@@ -42,6 +43,7 @@ func (rc *ReactController) Default() {
 func (rc *ReactController) ServeReact(c *gin.Context) {
 
 	activePlugins := rc.getActivePlugins(false)
+	nonce := security_middleware.ContentSecurityPolicy(c, rc.serviceGroup, activePlugins.Scripts, activePlugins.Styles)
 
 	c.HTML(http.StatusOK, "react.tmpl", gin.H{
 		"Theme":                context.Config.DbVars.ActiveTheme,
@@ -52,12 +54,14 @@ func (rc *ReactController) ServeReact(c *gin.Context) {
 		"PluginScripts":        activePlugins.Scripts,
 		"PluginStyles":         activePlugins.Styles,
 		"ActivePlugins":        template.JS(activePlugins.Ids),
+		"CspNonce":             nonce,
 	})
 }
 
 func (rc *ReactController) serveReactAdmin(c *gin.Context) {
 
 	activePlugins := rc.getActivePlugins(true)
+	nonce := security_middleware.ContentSecurityPolicy(c, rc.serviceGroup, activePlugins.Scripts, activePlugins.Styles)
 
 	c.HTML(http.StatusOK, "react.tmpl", gin.H{
 		"Theme":                context.Config.DbVars.ActiveTheme,
@@ -69,6 +73,7 @@ func (rc *ReactController) serveReactAdmin(c *gin.Context) {
 		"PluginStyles":         activePlugins.Styles,
 		"ActivePlugins":        template.JS(activePlugins.Ids),
 		"ActiveAdminPlugins":   template.JS(activePlugins.AdminIds),
+		"CspNonce":             nonce,
 	})
 }
 
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/impersonation/impersonation_middleware"
 	"github.com/cqlcorp/gocms/domain/email/email_model"
 	"github.com/cqlcorp/gocms/init/service"
 	"github.com/cqlcorp/gocms/routes"
@@ -27,10 +29,10 @@ func DefaultEmailController(routes *routes.Routes, sg *service.ServicesGroup) *E
 }
 
 func (ec *EmailController) Default() {
-	ec.routes.Auth.POST("/user/email", ec.addEmail)
+	ec.routes.Auth.POST("/user/email", api_key_middleware.RequireUserToken(), impersonation_middleware.BlockWhileImpersonating(), ec.addEmail)
 	ec.routes.Auth.GET("/user/email", ec.getEmails)
-	ec.routes.Auth.PUT("/user/email/promote", ec.promoteEmail)
-	ec.routes.Auth.DELETE("/user/email", ec.deleteEmail)
+	ec.routes.Auth.PUT("/user/email/promote", api_key_middleware.RequireUserToken(), impersonation_middleware.BlockWhileImpersonating(), ec.promoteEmail)
+	ec.routes.Auth.DELETE("/user/email", api_key_middleware.RequireUserToken(), impersonation_middleware.BlockWhileImpersonating(), ec.deleteEmail)
 	ec.routes.Public.GET("/user/email/activate", ec.activateEmail)
 	ec.routes.Public.POST("/user/email/activate", ec.requestActivationLink)
 }
@@ -78,7 +80,7 @@ func (ec *EmailController) activateEmail(c *gin.Context) {
 	}
 
 	// get user
-	user, err := ec.ServicesGroup.UserService.GetByEmail(email)
+	user, err := ec.ServicesGroup.UserService.GetActiveByEmail(email)
 	if err != nil {
 		c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("%v/activateEmail/error.html", context.Config.DbVars.RedirectRootUrl))
 		return
@@ -1,10 +1,12 @@
 package email_service
 
 import (
+	"database/sql"
 	"fmt"
 	"github.com/cqlcorp/gocms/context"
 	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_service"
 	"github.com/cqlcorp/gocms/domain/email/email_model"
+	"github.com/cqlcorp/gocms/domain/mail/mail_model"
 	"github.com/cqlcorp/gocms/domain/mail/mail_service"
 	"github.com/cqlcorp/gocms/domain/secure_code/security_code_model"
 	"github.com/cqlcorp/gocms/init/repository"
@@ -82,13 +84,9 @@ func (es *EmailService) AddEmail(e *email_model.Email) error {
 
 	// send email to primary email about addition of email
 	if primaryEmail, err := es.RepositoriesGroup.EmailRepository.GetPrimaryByUserId(e.UserId); err == nil {
-		mail := mail_service.Mail{
-			To:       primaryEmail.Email,
-			Subject:  "New Email Added To Your Account",
-			Body:     "A new alternative email address, " + e.Email + ", was added to your account.\n\n If you believe this to be a mistake please contact support.",
-			BodyHTML: fmt.Sprintf("<h1>Alternative Email Added</h1><h3>%v</h3><p>If you believe this to be a mistake please contact support.</p>", e.Email),
-		}
-		es.MailService.Send(&mail)
+		es.MailService.SendTemplate(primaryEmail.Email, mail_model.Template_Email_Added, es.userLocale(e.UserId), map[string]interface{}{
+			"email": e.Email,
+		})
 	}
 
 	return nil
@@ -103,6 +101,15 @@ func (es *EmailService) SendEmailActivationCode(emailAddress string) error {
 		return err
 	}
 
+	// deleted users aren't sent codes
+	if user, err := es.RepositoriesGroup.UsersRepository.Get(email.UserId); err != nil || user.IsDeleted() {
+		if err == nil {
+			err = sql.ErrNoRows
+		}
+		log.Errorf("Error sending email activation code, get user: %s\n", err.Error())
+		return err
+	}
+
 	if email.IsVerified {
 		err = errors.NewToUser("Email already activated.")
 		log.Errorf("Error sending email activation code, %s\n", err.Error())
@@ -130,13 +137,9 @@ func (es *EmailService) SendEmailActivationCode(emailAddress string) error {
 	expTimeStr := time.Now().Add(time.Minute * time.Duration(context.Config.DbVars.EmailActivationTimeout)).Format("01/02/2006 03:04 pm")
 	activationLink := fmt.Sprintf("%v/user/email/activate?code=%v&email=%v", context.Config.DbVars.PublicApiUrl, code, emailAddress)
 	// send email
-	es.MailService.Send(&mail_service.Mail{
-		To:      emailAddress,
-		Subject: "Account Verification Required",
-		Body: "Click on the link below to activate your account:\n" +
-			activationLink + "\n\nThe link will expire at: " +
-			expTimeStr + ".",
-		BodyHTML: fmt.Sprintf("<h1>Account Verification Required</h1><h2>Click on the link below to activate your account:</h2><p><a href='%v'>Activate Link</a></h3></p><p>The link will expire at: <b>%v</b></p>", activationLink, expTimeStr),
+	err = es.MailService.SendTemplate(emailAddress, mail_model.Template_Email_Activation, es.userLocale(email.UserId), map[string]interface{}{
+		"link":    activationLink,
+		"expires": expTimeStr,
 	})
 	if err != nil {
 		log.Errorf("Error sending email activation code, sending mail: " + err.Error())
@@ -210,13 +213,9 @@ func (es *EmailService) PromoteEmail(email *email_model.Email) error {
 
 	// send notification
 	// send email to primary email about addition of email
-	mail := mail_service.Mail{
-		To:       oldPrimaryEmail.Email,
-		Subject:  "New Primary Email",
-		Body:     "A new primary email address, " + email.Email + ", has been set on your account.\n\n If you believe this to be a mistake please contact support.",
-		BodyHTML: fmt.Sprintf("<h1>New Primary Email</h1><p>A new primary email address has been set for your account:</p><h3>%v</h3><p>If you believe this to be a mistake please contact support.</p>", email.Email),
-	}
-	es.MailService.Send(&mail)
+	es.MailService.SendTemplate(oldPrimaryEmail.Email, mail_model.Template_Primary_Email, es.userLocale(email.UserId), map[string]interface{}{
+		"email": email.Email,
+	})
 
 	return nil
 }
@@ -272,13 +271,18 @@ func (es *EmailService) DeleteEmail(email *email_model.Email) error {
 
 	// send notification
 	// send email to primary email about addition of email
-	mail := mail_service.Mail{
-		To:       primaryEmail.Email,
-		Subject:  "Alternative Email Deleted",
-		Body:     "An alternative email, " + email.Email + ", has been deleted from your account.\n\n If you believe this to be a mistake please contact support.",
-		BodyHTML: fmt.Sprintf("<h1>Alternative Email Deleted</h1><p>An alternative email address has been deleted from your account:</p><h3>%v</h3><p>If you believe this to be a mistake please contact support.</p>", email.Email),
-	}
-	es.MailService.Send(&mail)
+	es.MailService.SendTemplate(primaryEmail.Email, mail_model.Template_Email_Deleted, es.userLocale(email.UserId), map[string]interface{}{
+		"email": email.Email,
+	})
 
 	return nil
 }
+
+// userLocale returns the locale emails to the user are sent in, empty for the default.
+func (es *EmailService) userLocale(userId int64) string {
+	user, err := es.RepositoriesGroup.UsersRepository.Get(userId)
+	if err != nil {
+		return ""
+	}
+	return user.Locale
+}
@@ -2,16 +2,16 @@ package health_middleware
 
 import (
 	"bytes"
-	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/cqlcorp/gocms/domain/logs/log_model"
 
 	"github.com/cqlcorp/gocms/context"
-	"github.com/cqlcorp/gocms/domain/mail/mail_service"
+	"github.com/cqlcorp/gocms/domain/mail/mail_model"
 	"github.com/cqlcorp/gocms/init/service"
 	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/log"
 	"github.com/gin-gonic/gin"
 )
 
@@ -79,32 +79,14 @@ func (hm *HealthMiddleware) errorMiddleware(c *gin.Context) {
 		}
 
 		if RecentError {
-			//write email
-			emailBody := (`
-				<!DOCTYPE html>
-				<html>
-				<h2> Error Report :    ` + context.Config.DbVars.LoginTitle + ` </h2>
-				<ul>
-					<li> Route:  		  ` + errorReport.Route + `</li>
-					<li> Status: 		  ` + errorReport.Status + `</li>
-					<li> Body:   		  ` + errorReport.Body + `</li>
-					<li> Time of Incident ` + errorReport.Time.String() + `</li>
-				</ul>
-				</html>
-				`)
-
-			//setup mail
-			ms := mail_service.DefaultMailService()
-			mail := &mail_service.Mail{
-				To:      context.Config.DbVars.ErrorReportAddress,
-				Subject: "GoCms - Health Monitor",
-				Body:    emailBody,
-			}
-
-			err := ms.Send(mail)
-
+			err := hm.ServicesGroup.MailService.SendTemplate(context.Config.DbVars.ErrorReportAddress, mail_model.Template_Health_Error_Report, "", map[string]interface{}{
+				"route":  errorReport.Route,
+				"status": errorReport.Status,
+				"body":   errorReport.Body,
+				"time":   errorReport.Time.String(),
+			})
 			if err != nil {
-				fmt.Println("\nThere was an error in sending email\n")
+				log.Errorf("There was an error in sending the error report email: %s\n", err.Error())
 			}
 		}
 	}
@@ -0,0 +1,257 @@
+package invitation_controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_model"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/domain/invitation/invitation_model"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/api_utility"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"net/http"
+	"strconv"
+)
+
+type InvitationController struct {
+	routes           *routes.Routes
+	ServicesGroup    *service.ServicesGroup
+	invitationRoutes *access_control_middleware.PermissionGroup
+}
+
+func DefaultInvitationController(routes *routes.Routes, sg *service.ServicesGroup) *InvitationController {
+	invitationController := &InvitationController{
+		routes:           routes,
+		ServicesGroup:    sg,
+		invitationRoutes: access_control_middleware.NewPermissionGroup(routes.Auth, "/invitation", sg.AclService, permissions.SUPER_ADMIN, permissions.INVITE_USERS),
+	}
+
+	invitationController.Default()
+	return invitationController
+}
+
+/**
+* @apiDefine InviteUsers Invite Users
+* User must be a super admin or have the invite_users permission. Users who are not super admins can only manage their
+* own invitations and only assign groups they belong to.
+ */
+
+func (ic *InvitationController) Default() {
+	ic.invitationRoutes.POST("", ic.invite)
+	ic.invitationRoutes.GET("", ic.getAll)
+	ic.invitationRoutes.PUT("/:invitationId/resend", ic.resend)
+	ic.invitationRoutes.DELETE("/:invitationId", ic.revoke)
+	ic.routes.Public.GET("/invitation/accept", ic.getForAccept)
+	ic.routes.Public.POST("/invitation/accept", ic.accept)
+}
+
+/**
+* @api {post} /invitation Invite User
+* @apiDescription Send an invitation to register to an email address.
+* @apiName InviteUser
+* @apiGroup Invitation
+*
+* @apiUse AuthHeader
+* @apiUse InvitationInput
+* @apiUse InvitationDisplay
+* @apiPermission InviteUsers
+ */
+func (ic *InvitationController) invite(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	var invitationInput invitation_model.InvitationInput
+	err := c.BindJSON(&invitationInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// users who aren't super admins can only give away groups they are in
+	if !ic.isSuperAdmin(authUser) {
+		userGroups := make(map[int64]bool, len(authUser.Groups))
+		for _, group := range authUser.Groups {
+			userGroups[group.Id] = true
+		}
+		for _, groupId := range invitationInput.Groups {
+			if !userGroups[groupId] {
+				errors.Response(c, http.StatusForbidden, "You can only invite users to groups you belong to.", nil)
+				return
+			}
+		}
+	}
+
+	invitation, err := ic.ServicesGroup.InvitationService.Invite(authUser.Id, invitationInput.Email, invitationInput.Groups, invitationInput.ExpiresIn)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't send invitation.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invitation.GetInvitationDisplay())
+}
+
+/**
+* @api {get} /invitation Get Invitations
+* @apiDescription Super admins get all invitations. Other users get the invitations they sent.
+* @apiName GetInvitations
+* @apiGroup Invitation
+*
+* @apiUse AuthHeader
+* @apiUse InvitationDisplay
+* @apiPermission InviteUsers
+ */
+func (ic *InvitationController) getAll(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	var invitations []*invitation_model.Invitation
+	var err error
+	if ic.isSuperAdmin(authUser) {
+		invitations, err = ic.ServicesGroup.InvitationService.GetAll()
+	} else {
+		invitations, err = ic.ServicesGroup.InvitationService.GetByInvitedBy(authUser.Id)
+	}
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get invitations.", err)
+		return
+	}
+
+	invitationDisplays := make([]*invitation_model.InvitationDisplay, len(invitations))
+	for i, invitation := range invitations {
+		invitationDisplays[i] = invitation.GetInvitationDisplay()
+	}
+
+	c.JSON(http.StatusOK, invitationDisplays)
+}
+
+/**
+* @api {put} /invitation/:invitationId/resend Resend Invitation
+* @apiDescription Send a new link for a pending invitation. The expiry restarts from now.
+* @apiName ResendInvitation
+* @apiGroup Invitation
+*
+* @apiUse AuthHeader
+* @apiUse InvitationDisplay
+* @apiPermission InviteUsers
+ */
+func (ic *InvitationController) resend(c *gin.Context) {
+
+	invitation, ok := ic.getInvitationForUser(c)
+	if !ok {
+		return
+	}
+
+	err := ic.ServicesGroup.InvitationService.Resend(invitation)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't resend invitation.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invitation.GetInvitationDisplay())
+}
+
+/**
+* @api {delete} /invitation/:invitationId Revoke Invitation
+* @apiName RevokeInvitation
+* @apiGroup Invitation
+*
+* @apiUse AuthHeader
+* @apiPermission InviteUsers
+ */
+func (ic *InvitationController) revoke(c *gin.Context) {
+
+	invitation, ok := ic.getInvitationForUser(c)
+	if !ok {
+		return
+	}
+
+	err := ic.ServicesGroup.InvitationService.Revoke(invitation)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't revoke invitation.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {get} /invitation/accept Get Invitation For Link
+* @apiDescription Check an invitation link before showing the registration form.
+* @apiName GetInvitationForAccept
+* @apiGroup Invitation
+*
+* @apiParam (Query) {string} token The token from the invitation link.
+* @apiUse InvitationDisplay
+ */
+func (ic *InvitationController) getForAccept(c *gin.Context) {
+
+	invitation, err := ic.ServicesGroup.InvitationService.VerifyToken(c.Query("token"))
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Invalid invitation.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invitation.GetInvitationDisplay())
+}
+
+/**
+* @api {post} /invitation/accept Accept Invitation
+* @apiDescription Creates the account for the invited email. The email is already verified so the user can login right away.
+* @apiName AcceptInvitation
+* @apiGroup Invitation
+*
+* @apiUse AcceptInvitationInput
+* @apiUse UserDisplay
+ */
+func (ic *InvitationController) accept(c *gin.Context) {
+
+	var acceptInvitationInput invitation_model.AcceptInvitationInput
+	err := c.BindJSON(&acceptInvitationInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	user, err := ic.ServicesGroup.InvitationService.Accept(acceptInvitationInput.Token, acceptInvitationInput.FullName, acceptInvitationInput.Password)
+	if fieldErrors, ok := err.(errors.FieldErrors); ok {
+		errors.ResponseWithFieldErrors(c, http.StatusBadRequest, password_policy_model.ApiError_Password_Policy, fieldErrors)
+		return
+	}
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't accept invitation.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user.GetUserDisplay())
+}
+
+// getInvitationForUser gets the invitation from the route and verifies the user may manage it
+func (ic *InvitationController) getInvitationForUser(c *gin.Context) (*invitation_model.Invitation, bool) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	invitationId, err := strconv.ParseInt(c.Param("invitationId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Id Field", err)
+		return nil, false
+	}
+
+	invitation, err := ic.ServicesGroup.InvitationService.Get(invitationId)
+	if err != nil {
+		errors.Response(c, http.StatusNotFound, "Couldn't find invitation.", err)
+		return nil, false
+	}
+
+	if invitation.InvitedBy != authUser.Id && !ic.isSuperAdmin(authUser) {
+		errors.Response(c, http.StatusForbidden, errors.ApiError_Permissions, nil)
+		return nil, false
+	}
+
+	return invitation, true
+}
+
+func (ic *InvitationController) isSuperAdmin(user *user_model.User) bool {
+	return user.InApiKeyScope(permissions.SUPER_ADMIN) && ic.ServicesGroup.AclService.IsAuthorized(permissions.SUPER_ADMIN, user.Id)
+}
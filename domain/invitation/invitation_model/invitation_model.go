@@ -0,0 +1,116 @@
+package invitation_model
+
+import (
+	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"time"
+)
+
+type InvitationStatus int64
+
+const (
+	Invitation_Pending  InvitationStatus = 0
+	Invitation_Accepted InvitationStatus = 1
+	Invitation_Revoked  InvitationStatus = 2
+)
+
+type Invitation struct {
+	Id           int64            `db:"id"`
+	Email        string           `db:"email"`
+	InvitedBy    int64            `db:"invitedBy"`
+	Status       InvitationStatus `db:"status"`
+	Expires      time.Time        `db:"expires"`
+	Created      time.Time        `db:"created"`
+	LastModified time.Time        `db:"lastModified"`
+	Groups       []*group_model.Group
+}
+
+// IsExpired reports if a pending invitation can no longer be accepted.
+func (invitation *Invitation) IsExpired() bool {
+	return time.Now().After(invitation.Expires)
+}
+
+/**
+* @apiDefine InvitationDisplay
+* @apiSuccess (Response) {number} id
+* @apiSuccess (Response) {string} email
+* @apiSuccess (Response) {number} invitedBy Id of the user who sent the invitation.
+* @apiSuccess (Response) {string} status pending, accepted, revoked or expired
+* @apiSuccess (Response) {string} expires
+* @apiSuccess (Response) {string} created
+* @apiSuccess (Response) {Object[]} groups Groups the user will be added to.
+* @apiSuccess (Response) {number} groups.id
+* @apiSuccess (Response) {string} groups.name
+ */
+type InvitationDisplay struct {
+	Id        int64                     `json:"id"`
+	Email     string                    `json:"email"`
+	InvitedBy int64                     `json:"invitedBy"`
+	Status    string                    `json:"status"`
+	Expires   time.Time                 `json:"expires"`
+	Created   time.Time                 `json:"created"`
+	Groups    []*InvitationGroupDisplay `json:"groups"`
+}
+
+type InvitationGroupDisplay struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func (invitation *Invitation) GetInvitationDisplay() *InvitationDisplay {
+
+	status := "pending"
+	switch invitation.Status {
+	case Invitation_Accepted:
+		status = "accepted"
+	case Invitation_Revoked:
+		status = "revoked"
+	default:
+		if invitation.IsExpired() {
+			status = "expired"
+		}
+	}
+
+	groups := []*InvitationGroupDisplay{}
+	for _, group := range invitation.Groups {
+		groups = append(groups, &InvitationGroupDisplay{
+			Id:   group.Id,
+			Name: group.Name,
+		})
+	}
+
+	invitationDisplay := InvitationDisplay{
+		Id:        invitation.Id,
+		Email:     invitation.Email,
+		InvitedBy: invitation.InvitedBy,
+		Status:    status,
+		Expires:   invitation.Expires,
+		Created:   invitation.Created,
+		Groups:    groups,
+	}
+
+	return &invitationDisplay
+}
+
+/**
+* @apiDefine InvitationInput
+* @apiParam (Request) {string} email Address to send the invitation to.
+* @apiParam (Request) {number[]} [groups] Ids of groups the user will be added to when they accept.
+* @apiParam (Request) {number} [expiresIn] Minutes until the invitation expires. Defaults to the INVITATION_TIMEOUT setting.
+ */
+type InvitationInput struct {
+	Email     string  `json:"email" binding:"required"`
+	Groups    []int64 `json:"groups"`
+	ExpiresIn int64   `json:"expiresIn"`
+}
+
+/**
+* @apiDefine AcceptInvitationInput
+* @apiParam (Request) {string} token The token from the invitation link.
+* @apiParam (Request) {string} fullName
+* @apiParam (Request) {string} password
+ */
+type AcceptInvitationInput struct {
+	Token    string `json:"token" binding:"required"`
+	FullName string `json:"fullName" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
@@ -0,0 +1,150 @@
+package invitation_repository
+
+import (
+	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"github.com/cqlcorp/gocms/domain/invitation/invitation_model"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type IInvitationRepository interface {
+	Add(*invitation_model.Invitation) error
+	Get(int64) (*invitation_model.Invitation, error)
+	GetAll() ([]*invitation_model.Invitation, error)
+	GetByInvitedBy(int64) ([]*invitation_model.Invitation, error)
+	GetPendingByEmail(string) (*invitation_model.Invitation, error)
+	Update(*invitation_model.Invitation) error
+	Delete(int64) error
+	AddGroupById(int64, int64) error
+	GetGroups(int64) ([]*group_model.Group, error)
+}
+
+type InvitationRepository struct {
+	database *sqlx.DB
+}
+
+func DefaultInvitationRepository(dbx *sqlx.DB) *InvitationRepository {
+	invitationRepository := &InvitationRepository{
+		database: dbx,
+	}
+
+	return invitationRepository
+}
+
+func (ir *InvitationRepository) Add(invitation *invitation_model.Invitation) error {
+	invitation.Created = time.Now()
+	// insert row
+	result, err := ir.database.NamedExec(`
+	INSERT INTO gocms_invitations (email, invitedBy, status, expires) VALUES (:email, :invitedBy, :status, :expires)
+	`, invitation)
+	if err != nil {
+		log.Errorf("Error adding invitation to database: %s\n", err.Error())
+		return err
+	}
+
+	// add id to invitation object
+	id, _ := result.LastInsertId()
+	invitation.Id = id
+
+	return nil
+}
+
+func (ir *InvitationRepository) Get(id int64) (*invitation_model.Invitation, error) {
+	var invitation invitation_model.Invitation
+	err := ir.database.Get(&invitation, `
+	SELECT * FROM gocms_invitations WHERE id=?
+	`, id)
+	if err != nil {
+		log.Errorf("Error getting invitation %v from database: %s\n", id, err.Error())
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (ir *InvitationRepository) GetAll() ([]*invitation_model.Invitation, error) {
+	var invitations []*invitation_model.Invitation
+	err := ir.database.Select(&invitations, `
+	SELECT * FROM gocms_invitations ORDER BY created DESC
+	`)
+	if err != nil {
+		log.Errorf("Error getting invitations from database: %s\n", err.Error())
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (ir *InvitationRepository) GetByInvitedBy(userId int64) ([]*invitation_model.Invitation, error) {
+	var invitations []*invitation_model.Invitation
+	err := ir.database.Select(&invitations, `
+	SELECT * FROM gocms_invitations WHERE invitedBy=? ORDER BY created DESC
+	`, userId)
+	if err != nil {
+		log.Errorf("Error getting invitations sent by user %v from database: %s\n", userId, err.Error())
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (ir *InvitationRepository) GetPendingByEmail(email string) (*invitation_model.Invitation, error) {
+	var invitation invitation_model.Invitation
+	err := ir.database.Get(&invitation, `
+	SELECT * FROM gocms_invitations WHERE email=? AND status=? ORDER BY created DESC LIMIT 1
+	`, email, invitation_model.Invitation_Pending)
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (ir *InvitationRepository) Update(invitation *invitation_model.Invitation) error {
+	_, err := ir.database.NamedExec(`
+	UPDATE gocms_invitations SET status=:status, expires=:expires WHERE id=:id
+	`, invitation)
+	if err != nil {
+		log.Errorf("Error updating invitation %v in database: %s\n", invitation.Id, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ir *InvitationRepository) Delete(id int64) error {
+	_, err := ir.database.Exec(`
+	DELETE FROM gocms_invitations WHERE id=?
+	`, id)
+	if err != nil {
+		log.Errorf("Error deleting invitation %v from database: %s\n", id, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ir *InvitationRepository) AddGroupById(invitationId int64, groupId int64) error {
+	_, err := ir.database.NamedExec(`
+	INSERT INTO gocms_invitations_to_groups (invitationId, groupId) VALUES (:invitationId, :groupId)
+	`, map[string]interface{}{"invitationId": invitationId, "groupId": groupId})
+	if err != nil {
+		log.Errorf("Error adding group %v to invitation %v: %s\n", groupId, invitationId, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ir *InvitationRepository) GetGroups(invitationId int64) ([]*group_model.Group, error) {
+	var groups []*group_model.Group
+	err := ir.database.Select(&groups, `
+	SELECT grps.id, grps.name, grps.description
+	FROM gocms_invitations_to_groups as itg
+	JOIN gocms_groups as grps
+	ON itg.groupId = grps.id
+	WHERE itg.invitationId = ?
+	`, invitationId)
+	if err != nil {
+		log.Errorf("Error getting groups for invitation %v from database: %s\n", invitationId, err.Error())
+		return nil, err
+	}
+	return groups, nil
+}
@@ -0,0 +1,308 @@
+package invitation_service
+
+import (
+	"fmt"
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/context/consts"
+	"github.com/cqlcorp/gocms/domain/email/email_service"
+	"github.com/cqlcorp/gocms/domain/invitation/invitation_model"
+	"github.com/cqlcorp/gocms/domain/mail/mail_model"
+	"github.com/cqlcorp/gocms/domain/mail/mail_service"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/domain/user/user_service"
+	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/dgrijalva/jwt-go"
+	"net/url"
+	"time"
+)
+
+type IInvitationService interface {
+	Invite(invitedBy int64, email string, groupIds []int64, expiresIn int64) (*invitation_model.Invitation, error)
+	Get(int64) (*invitation_model.Invitation, error)
+	GetAll() ([]*invitation_model.Invitation, error)
+	GetByInvitedBy(int64) ([]*invitation_model.Invitation, error)
+	Resend(*invitation_model.Invitation) error
+	Revoke(*invitation_model.Invitation) error
+	VerifyToken(string) (*invitation_model.Invitation, error)
+	Accept(token string, fullName string, password string) (*user_model.User, error)
+}
+
+type InvitationService struct {
+	UserService       user_service.IUserService
+	EmailService      email_service.IEmailService
+	MailService       mail_service.IMailService
+	RepositoriesGroup *repository.RepositoriesGroup
+}
+
+func DefaultInvitationService(rg *repository.RepositoriesGroup, userService *user_service.UserService, emailService *email_service.EmailService, mailService *mail_service.MailService) *InvitationService {
+	invitationService := &InvitationService{
+		UserService:       userService,
+		EmailService:      emailService,
+		MailService:       mailService,
+		RepositoriesGroup: rg,
+	}
+
+	return invitationService
+}
+
+// Invite creates an invitation for the email address and sends it. expiresIn is in minutes, 0 uses the default timeout.
+func (is *InvitationService) Invite(invitedBy int64, email string, groupIds []int64, expiresIn int64) (*invitation_model.Invitation, error) {
+
+	// email can't already belong to a user
+	if existingEmail, _ := is.RepositoriesGroup.EmailRepository.GetByAddress(email); existingEmail != nil {
+		return nil, errors.NewToUser(errors.ApiError_UserAlreadyExists)
+	}
+
+	// only one pending invitation per email, it can be resent instead
+	if pending, _ := is.RepositoriesGroup.InvitationRepository.GetPendingByEmail(email); pending != nil && !pending.IsExpired() {
+		return nil, errors.NewToUser("An invitation is already pending for this email.")
+	}
+
+	// verify groups exist
+	allGroups, err := is.RepositoriesGroup.GroupsRepository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	groupIdsExist := make(map[int64]bool, len(*allGroups))
+	for _, group := range *allGroups {
+		groupIdsExist[group.Id] = true
+	}
+	for _, groupId := range groupIds {
+		if !groupIdsExist[groupId] {
+			return nil, errors.NewToUser(fmt.Sprintf("Group %v doesn't exist.", groupId))
+		}
+	}
+
+	if expiresIn <= 0 {
+		expiresIn = context.Config.DbVars.InvitationTimeout
+	}
+
+	// add invitation
+	invitation := &invitation_model.Invitation{
+		Email:     email,
+		InvitedBy: invitedBy,
+		Status:    invitation_model.Invitation_Pending,
+		Expires:   time.Now().Add(time.Minute * time.Duration(expiresIn)),
+	}
+	err = is.RepositoriesGroup.InvitationRepository.Add(invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	err = is.addGroupsAndSend(invitation, groupIds)
+	if err != nil {
+		// remove the invitation so it doesn't block inviting the email again
+		is.RepositoriesGroup.InvitationRepository.Delete(invitation.Id)
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (is *InvitationService) addGroupsAndSend(invitation *invitation_model.Invitation, groupIds []int64) error {
+	var err error
+	for _, groupId := range groupIds {
+		err = is.RepositoriesGroup.InvitationRepository.AddGroupById(invitation.Id, groupId)
+		if err != nil {
+			return err
+		}
+	}
+
+	invitation.Groups, err = is.RepositoriesGroup.InvitationRepository.GetGroups(invitation.Id)
+	if err != nil {
+		return err
+	}
+
+	return is.send(invitation)
+}
+
+func (is *InvitationService) Get(id int64) (*invitation_model.Invitation, error) {
+	invitation, err := is.RepositoriesGroup.InvitationRepository.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.Groups, err = is.RepositoriesGroup.InvitationRepository.GetGroups(invitation.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (is *InvitationService) GetAll() ([]*invitation_model.Invitation, error) {
+	invitations, err := is.RepositoriesGroup.InvitationRepository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return is.addGroups(invitations)
+}
+
+func (is *InvitationService) GetByInvitedBy(userId int64) ([]*invitation_model.Invitation, error) {
+	invitations, err := is.RepositoriesGroup.InvitationRepository.GetByInvitedBy(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return is.addGroups(invitations)
+}
+
+// Resend sends a new link for a pending invitation and restarts the default timeout.
+func (is *InvitationService) Resend(invitation *invitation_model.Invitation) error {
+	if invitation.Status != invitation_model.Invitation_Pending {
+		return errors.NewToUser("Only pending invitations can be resent.")
+	}
+
+	invitation.Expires = time.Now().Add(time.Minute * time.Duration(context.Config.DbVars.InvitationTimeout))
+	err := is.RepositoriesGroup.InvitationRepository.Update(invitation)
+	if err != nil {
+		return err
+	}
+
+	return is.send(invitation)
+}
+
+// Revoke stops a pending invitation from being accepted. Links already sent become invalid.
+func (is *InvitationService) Revoke(invitation *invitation_model.Invitation) error {
+	if invitation.Status != invitation_model.Invitation_Pending {
+		return errors.NewToUser("Only pending invitations can be revoked.")
+	}
+
+	invitation.Status = invitation_model.Invitation_Revoked
+	return is.RepositoriesGroup.InvitationRepository.Update(invitation)
+}
+
+// VerifyToken checks the signature of an invitation link and returns the invitation if it can still be accepted.
+func (is *InvitationService) VerifyToken(tokenString string) (*invitation_model.Invitation, error) {
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+		return context.Config.DbVars.RSAPub, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.NewToUser("The invitation link is not valid or has expired.")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims[consts.TOKEN_TYPE_CLAIM] != consts.TOKEN_TYPE_INVITATION {
+		return nil, errors.NewToUser("The invitation link is not valid or has expired.")
+	}
+	invitationId, _ := claims["invitationId"].(float64)
+	email, _ := claims["email"].(string)
+
+	invitation, err := is.Get(int64(invitationId))
+	if err != nil || invitation.Email != email {
+		return nil, errors.NewToUser("The invitation link is not valid or has expired.")
+	}
+
+	if invitation.Status != invitation_model.Invitation_Pending || invitation.IsExpired() {
+		return nil, errors.NewToUser("The invitation is no longer available.")
+	}
+
+	return invitation, nil
+}
+
+// Accept creates the invited user with a verified email and adds them to the invitation groups.
+func (is *InvitationService) Accept(token string, fullName string, password string) (*user_model.User, error) {
+
+	invitation, err := is.VerifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// email could have been added since the invitation was sent
+	if existingEmail, _ := is.RepositoriesGroup.EmailRepository.GetByAddress(invitation.Email); existingEmail != nil {
+		return nil, errors.NewToUser(errors.ApiError_UserAlreadyExists)
+	}
+
+	user := &user_model.User{
+		FullName: fullName,
+		Email:    invitation.Email,
+		Password: password,
+		Enabled:  true,
+	}
+	err = is.UserService.Add(user)
+	if err != nil {
+		return nil, err
+	}
+
+	// the invitation link proves ownership of the email
+	err = is.EmailService.SetVerified(invitation.Email)
+	if err != nil {
+		log.Errorf("Error verifying email for invited user %v: %s\n", user.Id, err.Error())
+	} else {
+		user.Verified = true
+	}
+
+	for _, group := range invitation.Groups {
+		err = is.RepositoriesGroup.GroupsRepository.AddUserToGroupById(user.Id, group.Id)
+		if err != nil {
+			log.Errorf("Error adding invited user %v to group %v: %s\n", user.Id, group.Id, err.Error())
+		}
+	}
+
+	invitation.Status = invitation_model.Invitation_Accepted
+	err = is.RepositoriesGroup.InvitationRepository.Update(invitation)
+	if err != nil {
+		log.Errorf("Error marking invitation %v accepted: %s\n", invitation.Id, err.Error())
+	}
+
+	return user, nil
+}
+
+func (is *InvitationService) addGroups(invitations []*invitation_model.Invitation) ([]*invitation_model.Invitation, error) {
+	for _, invitation := range invitations {
+		groups, err := is.RepositoriesGroup.InvitationRepository.GetGroups(invitation.Id)
+		if err != nil {
+			return nil, err
+		}
+		invitation.Groups = groups
+	}
+
+	return invitations, nil
+}
+
+func (is *InvitationService) createToken(invitation *invitation_model.Invitation) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"invitationId": invitation.Id,
+		"email":        invitation.Email,
+		consts.TOKEN_TYPE_CLAIM: consts.TOKEN_TYPE_INVITATION,
+		"iat":          time.Now().Unix(),
+		"exp":          invitation.Expires.Unix(),
+	})
+	tokenString, err := token.SignedString(context.Config.DbVars.GetRsaPrivateKey(true))
+	if err != nil {
+		log.Errorf("Error signing token for invitation %v: %v\n", invitation.Id, err.Error())
+		return "", err
+	}
+
+	return tokenString, nil
+}
+
+func (is *InvitationService) send(invitation *invitation_model.Invitation) error {
+
+	token, err := is.createToken(invitation)
+	if err != nil {
+		return err
+	}
+
+	expTimeStr := invitation.Expires.Format("01/02/2006 03:04 pm")
+	invitationLink := fmt.Sprintf("%v?token=%v", context.Config.DbVars.InvitationAcceptUrl, url.QueryEscape(token))
+
+	// send email
+	err = is.MailService.SendTemplate(invitation.Email, mail_model.Template_Invitation, "", map[string]interface{}{
+		"link":    invitationLink,
+		"expires": expTimeStr,
+	})
+	if err != nil {
+		log.Errorf("Error sending invitation %v: %s\n", invitation.Id, err.Error())
+		return err
+	}
+
+	return nil
+}
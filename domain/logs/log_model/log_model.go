@@ -16,4 +16,38 @@ type ErrorLog struct {
 	Status string  `json:"status" db:"status"`
 	Body   string  `json:"body" db:"body"`
 	Time   time.Time  `json:"date" db:"date"`
-}
\ No newline at end of file
+}
+
+const (
+	Audit_Impersonation_Start = "impersonation.start"
+	Audit_Impersonation_Stop  = "impersonation.stop"
+	Audit_Permission_Add      = "acl.permission.add"
+	Audit_Permission_Remove   = "acl.permission.remove"
+	Audit_Group_Add           = "acl.group.add"
+	Audit_Group_Remove        = "acl.group.remove"
+	Audit_Data_Export         = "privacy.export"
+	Audit_Deletion_Request    = "privacy.deletion.request"
+	Audit_Deletion_Cancel     = "privacy.deletion.cancel"
+	Audit_Deletion_Complete   = "privacy.deletion.complete"
+	Audit_User_Delete         = "user.delete"
+	Audit_User_Restore        = "user.restore"
+	Audit_User_Ban            = "user.ban"
+	Audit_User_Unban          = "user.unban"
+)
+
+// group audits have no user, the detail names the group
+const (
+	Audit_Group_Permission_Add    = "acl.group.permission.add"
+	Audit_Group_Permission_Remove = "acl.group.permission.remove"
+)
+
+// AuditLog records a security related action. UserId is the user the action affects, ActorId the user who performed it.
+// UserId is 0 for actions on a group, the detail names the group.
+type AuditLog struct {
+	Id      int64     `json:"id" db:"id"`
+	UserId  int64     `json:"userId" db:"userId"`
+	ActorId int64     `json:"actorId" db:"actorId"`
+	Action  string    `json:"action" db:"action"`
+	Detail  string    `json:"detail" db:"detail"`
+	Created time.Time `json:"created" db:"created"`
+}
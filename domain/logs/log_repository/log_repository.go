@@ -6,12 +6,15 @@ import (
 
 	"github.com/cqlcorp/gocms/domain/logs/log_model"
 	"github.com/jmoiron/sqlx"
+	"time"
 )
 
 type ILogRepository interface {
 	RecordError(*log_model.ErrorLog) error
 	GetLastError() (*log_model.ErrorLog, error)
 	RecentError(string) (*log_model.ErrorLog, error)
+	RecordAudit(*log_model.AuditLog) error
+	GetAuditForUser(int64) ([]log_model.AuditLog, error)
 }
 
 type LogRepository struct {
@@ -64,3 +67,30 @@ func (lr *LogRepository) GetLastError() (*log_model.ErrorLog, error) {
 	}
 	return &lastLog, nil
 }
+
+func (lr *LogRepository) RecordAudit(record *log_model.AuditLog) error {
+	record.Created = time.Now()
+	result, err := lr.database.NamedExec(`
+	INSERT INTO gocms_audit_logs (userId, actorId, action, detail, created)
+		VALUES (:userId, :actorId, :action, :detail, :created)
+	`, record)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	record.Id = id
+	return nil
+}
+
+func (lr *LogRepository) GetAuditForUser(userId int64) ([]log_model.AuditLog, error) {
+	var auditLogs []log_model.AuditLog
+	err := lr.database.Select(&auditLogs, `
+	SELECT * FROM gocms_audit_logs
+	WHERE userId = ? OR actorId = ?
+	ORDER BY created desc;
+	`, userId, userId)
+	if err != nil {
+		return nil, err
+	}
+	return auditLogs, nil
+}
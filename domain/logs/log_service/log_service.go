@@ -8,12 +8,15 @@ import (
 
 	"github.com/cqlcorp/gocms/context"
 	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/log"
 )
 
 type ILogService interface {
 	RecordError(*log_model.ErrorLog) error
 	GetLastError() (*log_model.ErrorLog, error)
 	RecentError(*log_model.ErrorLog) (bool, error)
+	RecordAudit(userId int64, actorId int64, action string, detail string) error
+	GetAuditForUser(int64) ([]log_model.AuditLog, error)
 }
 
 type LogService struct {
@@ -64,3 +67,23 @@ func (ls *LogService) RecentError(record *log_model.ErrorLog) (bool, error) {
 	}
 	return false, nil
 }
+
+// RecordAudit writes a security related action to the audit log as well as the application log.
+func (ls *LogService) RecordAudit(userId int64, actorId int64, action string, detail string) error {
+	log.Infof("Audit %v: user %v, actor %v, %v\n", action, userId, actorId, detail)
+	err := ls.RepositoriesGroup.LogRepository.RecordAudit(&log_model.AuditLog{
+		UserId:  userId,
+		ActorId: actorId,
+		Action:  action,
+		Detail:  detail,
+	})
+	if err != nil {
+		log.Errorf("Error recording audit %v for user %v: %s\n", action, userId, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (ls *LogService) GetAuditForUser(userId int64) ([]log_model.AuditLog, error) {
+	return ls.RepositoriesGroup.LogRepository.GetAuditForUser(userId)
+}
@@ -0,0 +1,130 @@
+package mail_controller
+
+import (
+	"net/http"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/domain/mail/mail_model"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/localeUtil"
+	"github.com/gin-gonic/gin"
+)
+
+type EmailTemplateAdminController struct {
+	routes        *routes.Routes
+	servicesGroup *service.ServicesGroup
+	adminRoutes   *access_control_middleware.PermissionGroup
+}
+
+func DefaultEmailTemplateAdminController(routes *routes.Routes, sg *service.ServicesGroup) *EmailTemplateAdminController {
+	emailTemplateAdminController := &EmailTemplateAdminController{
+		routes:        routes,
+		servicesGroup: sg,
+		adminRoutes:   access_control_middleware.NewPermissionGroup(routes.Auth, "/admin/email", sg.AclService, permissions.SUPER_ADMIN),
+	}
+
+	emailTemplateAdminController.Default()
+	return emailTemplateAdminController
+}
+
+func (etac *EmailTemplateAdminController) Default() {
+	etac.adminRoutes.GET("/template", etac.getTemplates)
+	etac.adminRoutes.GET("/template/:name", etac.getTemplate)
+	etac.adminRoutes.POST("/template/:name/preview", etac.preview)
+}
+
+/**
+* @api {get} /admin/email/template Get Email Templates
+* @apiName GetEmailTemplates
+* @apiGroup Admin Email
+* @apiDescription Every email template, including templates registered by plugins. Template files are in the emails
+* directory of the theme and named <name>[.<locale>].<subject|txt|html>.tmpl.
+*
+* @apiUse AuthHeader
+* @apiSuccess (Response) {EmailTemplateDisplay[]} templates See EmailTemplateDisplay.
+* @apiPermission Admin
+ */
+func (etac *EmailTemplateAdminController) getTemplates(c *gin.Context) {
+
+	templates := etac.servicesGroup.MailService.GetTemplates()
+	displays := make([]*mail_model.EmailTemplateDisplay, len(templates))
+	for i, emailTemplate := range templates {
+		displays[i] = emailTemplate.GetDisplay(etac.servicesGroup.MailService.GetTemplateLocales(emailTemplate))
+	}
+
+	c.JSON(http.StatusOK, displays)
+}
+
+/**
+* @api {get} /admin/email/template/:name Get Email Template
+* @apiName GetEmailTemplate
+* @apiGroup Admin Email
+*
+* @apiUse AuthHeader
+* @apiUse EmailTemplateDisplay
+* @apiPermission Admin
+ */
+func (etac *EmailTemplateAdminController) getTemplate(c *gin.Context) {
+
+	emailTemplate, ok := etac.servicesGroup.MailService.GetTemplate(c.Param("name"))
+	if !ok {
+		errors.Response(c, http.StatusNotFound, "Email template doesn't exist.", nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, emailTemplate.GetDisplay(etac.servicesGroup.MailService.GetTemplateLocales(emailTemplate)))
+}
+
+/**
+* @api {post} /admin/email/template/:name/preview Preview Email Template
+* @apiName PreviewEmailTemplate
+* @apiGroup Admin Email
+* @apiDescription Render the template the way it would be sent, without sending it. Template errors are returned so
+* theme files can be checked before they are used.
+*
+* @apiUse AuthHeader
+* @apiUse EmailPreviewInput
+* @apiUse RenderedEmail
+* @apiPermission Admin
+ */
+func (etac *EmailTemplateAdminController) preview(c *gin.Context) {
+
+	emailTemplate, ok := etac.servicesGroup.MailService.GetTemplate(c.Param("name"))
+	if !ok {
+		errors.Response(c, http.StatusNotFound, "Email template doesn't exist.", nil)
+		return
+	}
+
+	// the body is optional
+	var previewInput mail_model.EmailPreviewInput
+	if c.Request.ContentLength != 0 {
+		err := c.BindJSON(&previewInput)
+		if err != nil {
+			errors.Response(c, http.StatusBadRequest, errors.ApiError_Json, err)
+			return
+		}
+	}
+
+	if previewInput.Locale != "" {
+		if _, ok := localeUtil.Normalize(previewInput.Locale); !ok {
+			errors.Response(c, http.StatusBadRequest, errors.ApiError_Locale, nil)
+			return
+		}
+	}
+
+	data := previewInput.Data
+	if len(data) == 0 {
+		data = emailTemplate.SampleData
+	}
+
+	rendered, err := etac.servicesGroup.MailService.Render(emailTemplate.Name, previewInput.Locale, data)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't render email template: "+err.Error(), nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, rendered)
+}
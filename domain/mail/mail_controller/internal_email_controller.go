@@ -0,0 +1,93 @@
+package mail_controller
+
+import (
+	"net/http"
+
+	"github.com/cqlcorp/gocms/domain/mail/mail_model"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_auth/plugin_auth_middleware"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_auth/plugin_auth_model"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/localeUtil"
+	"github.com/gin-gonic/gin"
+)
+
+type InternalEmailController struct {
+	internalRoutes *routes.InternalRoutes
+	servicesGroup  *service.ServicesGroup
+}
+
+func DefaultInternalEmailController(iRoutes *routes.InternalRoutes, sg *service.ServicesGroup) *InternalEmailController {
+	internalEmailController := &InternalEmailController{
+		internalRoutes: iRoutes,
+		servicesGroup:  sg,
+	}
+	internalEmailController.InternalDefault()
+	return internalEmailController
+}
+
+func (iec *InternalEmailController) InternalDefault() {
+	iec.internalRoutes.InternalRoot.POST("/email/send", plugin_auth_middleware.RequireScope(plugin_auth_model.SCOPE_EMAIL_SEND), iec.send)
+}
+
+/**
+* @api {post} (internal)/email/send (Internal) Send Email Template
+* @apiName SendEmailTemplate
+* @apiGroup (Internal) Email
+* @apiDescription (Internal) send one of the email templates declared in the plugin manifest, in the locale of the user
+* when a userId is given. Requires the email.send plugin scope.
+*
+* @apiUse EmailSendInput
+ */
+func (iec *InternalEmailController) send(c *gin.Context) {
+
+	plugin, _ := plugin_auth_middleware.GetPluginFromContext(c)
+
+	var sendInput mail_model.EmailSendInput
+	err := c.BindJSON(&sendInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	// plugins can only send their own templates
+	name := plugin.PluginId + "." + sendInput.Template
+	if _, ok := iec.servicesGroup.MailService.GetTemplate(name); !ok {
+		errors.Response(c, http.StatusNotFound, "Email template doesn't exist.", nil)
+		return
+	}
+
+	to := sendInput.To
+	locale := sendInput.Locale
+	if sendInput.UserId != 0 {
+		user, err := iec.servicesGroup.UserService.GetActive(sendInput.UserId)
+		if err != nil {
+			errors.Response(c, http.StatusNotFound, errors.ApiError_UserDoesntExist, err)
+			return
+		}
+		to = user.Email
+		if locale == "" {
+			locale = user.Locale
+		}
+	}
+	if to == "" {
+		errors.Response(c, http.StatusBadRequest, "userId or to is required.", nil)
+		return
+	}
+
+	if locale != "" {
+		if _, ok := localeUtil.Normalize(locale); !ok {
+			errors.Response(c, http.StatusBadRequest, errors.ApiError_Locale, nil)
+			return
+		}
+	}
+
+	err = iec.servicesGroup.MailService.SendTemplate(to, name, locale, sendInput.Data)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't send email.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
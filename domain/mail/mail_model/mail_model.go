@@ -0,0 +1,104 @@
+package mail_model
+
+// Parts of an email template. Each part is a file in the emails directory of a theme named
+// <template name>[.<locale>].<part>.tmpl. The html part is optional, the text part is used instead when it's missing.
+const (
+	PART_SUBJECT = "subject"
+	PART_TEXT    = "txt"
+	PART_HTML    = "html"
+)
+
+// Source of the templates GoCMS sends, plugin templates have the plugin id as their source.
+const SOURCE_GOCMS = "gocms"
+
+// Templates GoCMS sends.
+const (
+	Template_Password_Reset      = "password_reset"
+	Template_Password_Set        = "password_set"
+	Template_Account_Activated   = "account_activated"
+	Template_Device_Code         = "device_code"
+	Template_Email_Activation    = "email_activation"
+	Template_Email_Added         = "email_added"
+	Template_Email_Deleted       = "email_deleted"
+	Template_Primary_Email       = "primary_email_changed"
+	Template_Invitation          = "invitation"
+	Template_Deletion_Scheduled  = "deletion_scheduled"
+	Template_Health_Error_Report = "health_error_report"
+)
+
+type EmailTemplate struct {
+	// Name is unique, plugin templates are named <plugin id>.<name>.
+	Name        string
+	Description string
+	Source      string
+	// Dir holds the files of templates shipped outside the themes, like plugin templates. Their files are named with
+	// FileName instead of Name. Files in a theme override them.
+	Dir      string
+	FileName string
+	// SampleData is rendered by the preview when no data is given.
+	SampleData map[string]interface{}
+}
+
+/**
+* @apiDefine EmailTemplateDisplay
+* @apiSuccess (Response) {string} name
+* @apiSuccess (Response) {string} description
+* @apiSuccess (Response) {string} source gocms or the id of the plugin that registered the template.
+* @apiSuccess (Response) {string[]} locales Locales with their own files, the default files are listed as "".
+* @apiSuccess (Response) {Object} [sampleData] Data the preview renders when none is given.
+ */
+type EmailTemplateDisplay struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Source      string                 `json:"source"`
+	Locales     []string               `json:"locales"`
+	SampleData  map[string]interface{} `json:"sampleData,omitempty"`
+}
+
+func (emailTemplate *EmailTemplate) GetDisplay(locales []string) *EmailTemplateDisplay {
+	return &EmailTemplateDisplay{
+		Name:        emailTemplate.Name,
+		Description: emailTemplate.Description,
+		Source:      emailTemplate.Source,
+		Locales:     locales,
+		SampleData:  emailTemplate.SampleData,
+	}
+}
+
+/**
+* @apiDefine EmailPreviewInput
+* @apiParam (Request) {string} [locale] Defaults to DEFAULT_LOCALE.
+* @apiParam (Request) {Object} [data] Values the template uses, the sample data when empty.
+ */
+type EmailPreviewInput struct {
+	Locale string                 `json:"locale"`
+	Data   map[string]interface{} `json:"data"`
+}
+
+/**
+* @apiDefine EmailSendInput
+* @apiParam (Request) {string} template Name of the template without the plugin id.
+* @apiParam (Request) {number} [userId] Sends to the primary email of the user in their locale.
+* @apiParam (Request) {string} [to] Address to send to when there is no userId.
+* @apiParam (Request) {string} [locale] Overrides the locale of the user.
+* @apiParam (Request) {Object} [data] Values the template uses.
+ */
+type EmailSendInput struct {
+	Template string                 `json:"template" binding:"required"`
+	UserId   int64                  `json:"userId"`
+	To       string                 `json:"to"`
+	Locale   string                 `json:"locale"`
+	Data     map[string]interface{} `json:"data"`
+}
+
+/**
+* @apiDefine RenderedEmail
+* @apiSuccess (Response) {string} subject
+* @apiSuccess (Response) {string} text The plain text body.
+* @apiSuccess (Response) {string} html The html body inside the email layout of the theme.
+ */
+type RenderedEmail struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	Html    string `json:"html"`
+}
@@ -0,0 +1,75 @@
+package mail_service
+
+import (
+	"github.com/cqlcorp/gocms/domain/mail/mail_model"
+)
+
+// registerDefaultTemplates adds the templates GoCMS sends. Their files are in the emails directory of the default theme.
+func (ms *MailService) registerDefaultTemplates() {
+	defaults := []*mail_model.EmailTemplate{
+		{
+			Name:        mail_model.Template_Password_Reset,
+			Description: "Code to reset a forgotten password.",
+			SampleData:  map[string]interface{}{"code": "A1B2C3", "expires": "03:04 pm"},
+		},
+		{
+			Name:        mail_model.Template_Password_Set,
+			Description: "Code to choose a password, sent to users created by an import.",
+			SampleData:  map[string]interface{}{"code": "A1B2C3D4E5F6", "expires": "01/02/2006 03:04 pm"},
+		},
+		{
+			Name:        mail_model.Template_Account_Activated,
+			Description: "Sent when resetting the password also activated the account.",
+		},
+		{
+			Name:        mail_model.Template_Device_Code,
+			Description: "Two factor code to verify a new device.",
+			SampleData:  map[string]interface{}{"code": "A1B2C3D4", "expires": "03:04 pm"},
+		},
+		{
+			Name:        mail_model.Template_Email_Activation,
+			Description: "Link to verify an email address.",
+			SampleData:  map[string]interface{}{"link": "https://example.com/user/email/activate?code=sample&email=user@example.com", "expires": "01/02/2006 03:04 pm"},
+		},
+		{
+			Name:        mail_model.Template_Email_Added,
+			Description: "Sent to the primary email when an alternative email is added.",
+			SampleData:  map[string]interface{}{"email": "user@example.com"},
+		},
+		{
+			Name:        mail_model.Template_Email_Deleted,
+			Description: "Sent to the primary email when an alternative email is deleted.",
+			SampleData:  map[string]interface{}{"email": "user@example.com"},
+		},
+		{
+			Name:        mail_model.Template_Primary_Email,
+			Description: "Sent to the old primary email when another email is promoted.",
+			SampleData:  map[string]interface{}{"email": "user@example.com"},
+		},
+		{
+			Name:        mail_model.Template_Invitation,
+			Description: "Invitation to create an account.",
+			SampleData:  map[string]interface{}{"link": "https://example.com/invitation?token=sample", "expires": "01/02/2006 03:04 pm"},
+		},
+		{
+			Name:        mail_model.Template_Deletion_Scheduled,
+			Description: "Sent when a user asks to delete their account.",
+			SampleData:  map[string]interface{}{"scheduled": "01/02/2006 03:04 pm"},
+		},
+		{
+			Name:        mail_model.Template_Health_Error_Report,
+			Description: "Error report sent to ERROR_REPORT_ADDRESS by the health monitor.",
+			SampleData: map[string]interface{}{
+				"route":  "/api/user",
+				"status": "500",
+				"body":   "{\"message\":\"Something went wrong. Please try again.\"}",
+				"time":   "2006-01-02 15:04:05 -0700 MST",
+			},
+		},
+	}
+
+	for _, emailTemplate := range defaults {
+		emailTemplate.Source = mail_model.SOURCE_GOCMS
+		ms.RegisterTemplate(emailTemplate)
+	}
+}
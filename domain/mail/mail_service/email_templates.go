@@ -0,0 +1,214 @@
+package mail_service
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	textTemplate "text/template"
+	"time"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/mail/mail_model"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/localeUtil"
+)
+
+const (
+	themesDir        = "./content/themes"
+	defaultTheme     = "default"
+	emailTemplateDir = "emails"
+)
+
+// names may be namespaced with dots, like plugin templates
+var templateNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_\-]+(\.[a-zA-Z0-9_\-]+)*$`)
+var fileNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
+
+// RegisterTemplate adds a template or replaces the one with the same name.
+func (ms *MailService) RegisterTemplate(emailTemplate *mail_model.EmailTemplate) error {
+	if !templateNameRegex.MatchString(emailTemplate.Name) {
+		return errors.NewToUser(fmt.Sprintf("Email template name %v may only contain letters, numbers, dashes, underscores and dots.", emailTemplate.Name))
+	}
+	if emailTemplate.Dir != "" && !fileNameRegex.MatchString(emailTemplate.FileName) {
+		return errors.NewToUser(fmt.Sprintf("Email template file name %v may only contain letters, numbers, dashes and underscores.", emailTemplate.FileName))
+	}
+
+	ms.templatesLock.Lock()
+	defer ms.templatesLock.Unlock()
+	ms.templates[emailTemplate.Name] = emailTemplate
+	return nil
+}
+
+func (ms *MailService) GetTemplate(name string) (*mail_model.EmailTemplate, bool) {
+	ms.templatesLock.RLock()
+	defer ms.templatesLock.RUnlock()
+	emailTemplate, ok := ms.templates[name]
+	return emailTemplate, ok
+}
+
+// GetTemplates returns every registered template ordered by name.
+func (ms *MailService) GetTemplates() []*mail_model.EmailTemplate {
+	ms.templatesLock.RLock()
+	defer ms.templatesLock.RUnlock()
+
+	templates := make([]*mail_model.EmailTemplate, 0, len(ms.templates))
+	for _, emailTemplate := range ms.templates {
+		templates = append(templates, emailTemplate)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates
+}
+
+// GetTemplateLocales lists the locales the template has a subject for in the themes or its own directory. The files
+// without a locale are listed as "".
+func (ms *MailService) GetTemplateLocales(emailTemplate *mail_model.EmailTemplate) []string {
+	found := map[string]bool{}
+	for _, dir := range templateDirs(emailTemplate) {
+		suffix := "." + mail_model.PART_SUBJECT + ".tmpl"
+		matches, _ := filepath.Glob(filepath.Join(dir.path, dir.fileName+".*"))
+		for _, match := range matches {
+			base := filepath.Base(match)
+			if !strings.HasSuffix(base, suffix) {
+				continue
+			}
+			locale := strings.TrimPrefix(strings.TrimSuffix(base, suffix), dir.fileName)
+			if locale == "" {
+				found[""] = true
+			} else if normalized, ok := localeUtil.Normalize(strings.TrimPrefix(locale, ".")); ok {
+				found[normalized] = true
+			}
+		}
+	}
+
+	locales := make([]string, 0, len(found))
+	for locale := range found {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Render builds the email from the template files closest to the locale. Each part is looked up in the locale, its
+// parents, then DEFAULT_LOCALE and finally the files without a locale. Within a locale the active theme comes first,
+// then the default theme and the template's own directory. Files are read on every render so themes can be edited
+// without a restart.
+func (ms *MailService) Render(name string, locale string, data map[string]interface{}) (*mail_model.RenderedEmail, error) {
+	emailTemplate, ok := ms.GetTemplate(name)
+	if !ok {
+		return nil, errors.NewToUser(fmt.Sprintf("Email template %v doesn't exist.", name))
+	}
+
+	data = templateData(data)
+
+	subject, err := renderTextPart(emailTemplate, mail_model.PART_SUBJECT, locale, data)
+	if err != nil {
+		return nil, err
+	}
+	// headers are a single line
+	subject = strings.Join(strings.Fields(subject), " ")
+	data["subject"] = subject
+
+	text, err := renderTextPart(emailTemplate, mail_model.PART_TEXT, locale, data)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyHTML := textToHTML(text)
+	path, ok := findPart(emailTemplate, mail_model.PART_HTML, locale)
+	if ok {
+		htmlPart, err := template.ParseFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		var buffer bytes.Buffer
+		if err := htmlPart.Execute(&buffer, data); err != nil {
+			return nil, err
+		}
+		bodyHTML = template.HTML(buffer.String())
+	}
+
+	html, err := ms.wrap(subject, bodyHTML)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mail_model.RenderedEmail{
+		Subject: subject,
+		Text:    text,
+		Html:    html,
+	}, nil
+}
+
+// templateData copies the data and adds the values every template can use.
+func templateData(data map[string]interface{}) map[string]interface{} {
+	withDefaults := map[string]interface{}{
+		"siteName":   context.Config.DbVars.LoginTitle,
+		"publicUrl":  context.Config.DbVars.PublicApiUrl,
+		"assetsBase": context.Config.DbVars.ActiveThemeAssetsBase,
+		"year":       time.Now().Format("2006"),
+	}
+	for key, value := range data {
+		withDefaults[key] = value
+	}
+	return withDefaults
+}
+
+func renderTextPart(emailTemplate *mail_model.EmailTemplate, part string, locale string, data map[string]interface{}) (string, error) {
+	path, ok := findPart(emailTemplate, part, locale)
+	if !ok {
+		return "", errors.New(fmt.Sprintf("email template %v has no %v file", emailTemplate.Name, part))
+	}
+
+	textPart, err := textTemplate.ParseFiles(path)
+	if err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	if err := textPart.Execute(&buffer, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+type templateDir struct {
+	path     string
+	fileName string
+}
+
+// templateDirs lists where the files of the template are looked for, in order.
+func templateDirs(emailTemplate *mail_model.EmailTemplate) []templateDir {
+	dirs := []templateDir{
+		{filepath.Join(themesDir, context.Config.DbVars.ActiveTheme, emailTemplateDir), emailTemplate.Name},
+	}
+	if context.Config.DbVars.ActiveTheme != defaultTheme {
+		dirs = append(dirs, templateDir{filepath.Join(themesDir, defaultTheme, emailTemplateDir), emailTemplate.Name})
+	}
+	if emailTemplate.Dir != "" {
+		dirs = append(dirs, templateDir{emailTemplate.Dir, emailTemplate.FileName})
+	}
+	return dirs
+}
+
+// findPart returns the path of the file closest to the locale for the part.
+func findPart(emailTemplate *mail_model.EmailTemplate, part string, locale string) (string, bool) {
+	dirs := templateDirs(emailTemplate)
+	for _, fallback := range localeUtil.Fallbacks(locale, context.Config.DbVars.DefaultLocale) {
+		for _, dir := range dirs {
+			name := dir.fileName
+			if fallback != "" {
+				name += "." + fallback
+			}
+			path := filepath.Join(dir.path, name+"."+part+".tmpl")
+			if stat, err := os.Stat(path); err == nil && stat.Mode().IsRegular() {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
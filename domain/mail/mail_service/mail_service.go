@@ -1,25 +1,37 @@
 package mail_service
 
 import (
-	"fmt"
+	"bytes"
 	"html/template"
 	"io"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/mail/mail_model"
 	"github.com/cqlcorp/gocms/utility/log"
 	"gopkg.in/gomail.v2"
 )
 
 type IMailService interface {
 	Send(*Mail) error
+	SendTemplate(to string, name string, locale string, data map[string]interface{}) error
+	Render(name string, locale string, data map[string]interface{}) (*mail_model.RenderedEmail, error)
+	RegisterTemplate(*mail_model.EmailTemplate) error
+	GetTemplate(string) (*mail_model.EmailTemplate, bool)
+	GetTemplates() []*mail_model.EmailTemplate
+	GetTemplateLocales(*mail_model.EmailTemplate) []string
 }
 
 type MailService struct {
 	Dialer          *gomail.Dialer
 	From            string
 	DefaultTemplate *template.Template
+
+	templates     map[string]*mail_model.EmailTemplate
+	templatesLock sync.RWMutex
 }
 
 type Mail struct {
@@ -37,32 +49,50 @@ func DefaultMailService() *MailService {
 		Dialer:          gomail.NewDialer(context.Config.DbVars.SMTPServer, int(context.Config.DbVars.SMTPPort), context.Config.DbVars.SMTPUser, context.Config.DbVars.SMTPPassword),
 		From:            context.Config.DbVars.SMTPFromAddress,
 		DefaultTemplate: defaultTemplate,
+		templates:       make(map[string]*mail_model.EmailTemplate),
 	}
+	mailService.registerDefaultTemplates()
 
 	return mailService
 
 }
 
+// Send emails a message built by the caller. BodyHTML is trusted html, the text body is used when it's empty.
 func (ms *MailService) Send(mail *Mail) error {
 
+	bodyHTML := template.HTML(mail.BodyHTML)
 	if mail.BodyHTML == "" {
-		mail.BodyHTML = mail.Body
+		bodyHTML = textToHTML(mail.Body)
 	}
 
-	htmlData := map[string]string{
-		"subject":     mail.Subject,
-		"message":     mail.BodyHTML,
-		"year":        time.Now().Format("2006"),
-		"headerImage": fmt.Sprintf("http://static.gocms.io/default_assets/default_email_img.jpg"),
+	html, err := ms.wrap(mail.Subject, bodyHTML)
+	if err != nil {
+		return err
 	}
 
+	return ms.send(mail.To, mail.Subject, mail.Body, html)
+}
+
+// SendTemplate renders the named template in the locale and emails it. An empty locale uses DEFAULT_LOCALE.
+func (ms *MailService) SendTemplate(to string, name string, locale string, data map[string]interface{}) error {
+	rendered, err := ms.Render(name, locale, data)
+	if err != nil {
+		log.Errorf("Error rendering email template %v: %s\n", name, err.Error())
+		return err
+	}
+
+	return ms.send(to, rendered.Subject, rendered.Text, rendered.Html)
+}
+
+func (ms *MailService) send(to string, subject string, body string, bodyHTML string) error {
+
 	m := gomail.NewMessage()
 	m.SetHeader("From", ms.From)
-	m.SetHeader("To", mail.To)
-	m.SetHeader("Subject", mail.Subject)
-	m.SetBody("text/plain", mail.Body)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/plain", body)
 	m.AddAlternativeWriter("text/html", func(w io.Writer) error {
-		err := ms.DefaultTemplate.Execute(w, htmlData)
+		_, err := io.WriteString(w, bodyHTML)
 		if err != nil {
 			log.Errorf("Error adding alt writter to html email: %v\n", err.Error())
 		}
@@ -76,8 +106,41 @@ func (ms *MailService) Send(mail *Mail) error {
 			log.Errorf("Error sending mail: " + err.Error())
 		}
 	} else {
-		log.Debugf("Email simulated: " + mail.Body)
+		log.Debugf("Email simulated: " + body)
 	}
 
 	return nil
 }
+
+// wrap places the html body in the email layout of the theme.
+func (ms *MailService) wrap(subject string, bodyHTML template.HTML) (string, error) {
+	htmlData := map[string]interface{}{
+		"subject":     subject,
+		"message":     bodyHTML,
+		"year":        time.Now().Format("2006"),
+		"headerImage": headerImage(),
+	}
+
+	var html bytes.Buffer
+	err := ms.DefaultTemplate.Execute(&html, htmlData)
+	if err != nil {
+		log.Errorf("Error executing email layout: %v\n", err.Error())
+		return "", err
+	}
+	return html.String(), nil
+}
+
+// headerImage is EMAIL_HEADER_IMAGE, relative paths are in the assets of the active theme.
+func headerImage() string {
+	image := context.Config.DbVars.EmailHeaderImage
+	if image == "" || strings.Contains(image, "://") || strings.HasPrefix(image, "//") {
+		return image
+	}
+	return strings.TrimSuffix(context.Config.DbVars.ActiveThemeAssetsBase, "/") + "/" + strings.TrimPrefix(image, "/")
+}
+
+// textToHTML escapes a plain text body and keeps its line breaks.
+func textToHTML(text string) template.HTML {
+	escaped := template.HTMLEscapeString(text)
+	return template.HTML(strings.Replace(escaped, "\n", "<br/>\n", -1))
+}
@@ -0,0 +1,102 @@
+package photo_service
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// tiffWithOrientation builds exif tiff data with a single image directory holding the tags
+func tiffWithOrientation(order binary.ByteOrder, tags map[uint16]uint16) []byte {
+	tiff := make([]byte, 8, 8+2+len(tags)*12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+
+	count := make([]byte, 2)
+	order.PutUint16(count, uint16(len(tags)))
+	tiff = append(tiff, count...)
+	for _, tag := range []uint16{0x0100, exifOrientationTag, 0x0110} {
+		value, ok := tags[tag]
+		if !ok {
+			continue
+		}
+		entry := make([]byte, 12)
+		order.PutUint16(entry[0:], tag)
+		order.PutUint16(entry[2:], 3) // short
+		order.PutUint32(entry[4:], 1)
+		order.PutUint16(entry[8:], value)
+		tiff = append(tiff, entry...)
+	}
+	return append(tiff, 0, 0, 0, 0)
+}
+
+// jpegWithSegments builds the start of a jpeg with the segments before the image data
+func jpegWithSegments(segments ...[]byte) []byte {
+	data := []byte{0xFF, 0xD8}
+	for _, segment := range segments {
+		data = append(data, segment...)
+	}
+	return append(data, 0xFF, 0xDA, 0x00, 0x02)
+}
+
+func segment(marker byte, payload []byte) []byte {
+	length := len(payload) + 2
+	return append([]byte{0xFF, marker, byte(length >> 8), byte(length)}, payload...)
+}
+
+func exifSegment(tiff []byte) []byte {
+	return segment(0xE1, append([]byte("Exif\x00\x00"), tiff...))
+}
+
+func TestTiffOrientation(t *testing.T) {
+	tests := []struct {
+		name string
+		tiff []byte
+		want int
+	}{
+		{"little endian", tiffWithOrientation(binary.LittleEndian, map[uint16]uint16{exifOrientationTag: 6}), 6},
+		{"big endian", tiffWithOrientation(binary.BigEndian, map[uint16]uint16{exifOrientationTag: 8}), 8},
+		{"after other tags", tiffWithOrientation(binary.BigEndian, map[uint16]uint16{0x0100: 640, exifOrientationTag: 3, 0x0110: 1}), 3},
+		{"no orientation", tiffWithOrientation(binary.LittleEndian, map[uint16]uint16{0x0100: 640}), 1},
+		{"orientation too large", tiffWithOrientation(binary.LittleEndian, map[uint16]uint16{exifOrientationTag: 9}), 1},
+		{"orientation zero", tiffWithOrientation(binary.LittleEndian, map[uint16]uint16{exifOrientationTag: 0}), 1},
+		{"unknown byte order", append([]byte("XX"), tiffWithOrientation(binary.LittleEndian, map[uint16]uint16{exifOrientationTag: 6})[2:]...), 1},
+		{"too short", []byte("II*\x00"), 1},
+		{"offset past the end", []byte("II*\x00\xff\x00\x00\x00"), 1},
+		{"entries past the end", []byte("II*\x00\x08\x00\x00\x00\x05\x00"), 1},
+	}
+	for _, test := range tests {
+		if got := tiffOrientation(test.tiff); got != test.want {
+			t.Errorf("%v: tiffOrientation() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestJpegOrientation(t *testing.T) {
+	rotated := tiffWithOrientation(binary.BigEndian, map[uint16]uint16{exifOrientationTag: 6})
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"exif", jpegWithSegments(exifSegment(rotated)), 6},
+		{"exif after app0", jpegWithSegments(segment(0xE0, []byte("JFIF\x00\x01\x01")), exifSegment(rotated)), 6},
+		{"no exif", jpegWithSegments(segment(0xE0, []byte("JFIF\x00\x01\x01"))), 1},
+		{"app1 that isn't exif", jpegWithSegments(segment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00"))), 1},
+		{"exif after the image data", append(jpegWithSegments(), exifSegment(rotated)...), 1},
+		{"not a jpeg", append([]byte{0x89, 'P', 'N', 'G'}, exifSegment(rotated)...), 1},
+		{"segment length past the end", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF, 'E'}, 1},
+		{"segment length too small", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01, 0xFF, 0xDA}, 1},
+		{"missing marker", []byte{0xFF, 0xD8, 0x00, 0xE1, 0x00, 0x02}, 1},
+		{"empty", []byte{}, 1},
+	}
+	for _, test := range tests {
+		if got := jpegOrientation(test.data); got != test.want {
+			t.Errorf("%v: jpegOrientation() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package photo_service
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+var (
+	red   = color.RGBA{255, 0, 0, 255}
+	green = color.RGBA{0, 255, 0, 255}
+	blue  = color.RGBA{0, 0, 255, 255}
+	white = color.RGBA{255, 255, 255, 255}
+)
+
+// square builds a square image from rows of pixels
+func square(rows ...[]color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, len(rows), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestOrient(t *testing.T) {
+	// A B
+	// C D
+	a, b, c, d := red, green, blue, white
+	src := square([]color.RGBA{a, b}, []color.RGBA{c, d})
+	tests := []struct {
+		orientation int
+		want        *image.RGBA
+	}{
+		{0, square([]color.RGBA{a, b}, []color.RGBA{c, d})},
+		{1, square([]color.RGBA{a, b}, []color.RGBA{c, d})},
+		{2, square([]color.RGBA{b, a}, []color.RGBA{d, c})},
+		{3, square([]color.RGBA{d, c}, []color.RGBA{b, a})},
+		{4, square([]color.RGBA{c, d}, []color.RGBA{a, b})},
+		{5, square([]color.RGBA{a, c}, []color.RGBA{b, d})},
+		{6, square([]color.RGBA{c, a}, []color.RGBA{d, b})},
+		{7, square([]color.RGBA{d, b}, []color.RGBA{c, a})},
+		{8, square([]color.RGBA{b, d}, []color.RGBA{a, c})},
+		{9, square([]color.RGBA{a, b}, []color.RGBA{c, d})},
+	}
+	for _, test := range tests {
+		if got := orient(src, test.orientation); !reflect.DeepEqual(got.Pix, test.want.Pix) {
+			t.Errorf("orient(%v) = %v, want %v", test.orientation, got.Pix, test.want.Pix)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	quadrants := square(
+		[]color.RGBA{red, red, green, green},
+		[]color.RGBA{red, red, green, green},
+		[]color.RGBA{blue, blue, white, white},
+		[]color.RGBA{blue, blue, white, white},
+	)
+	tests := []struct {
+		name string
+		src  *image.RGBA
+		size int
+		want *image.RGBA
+	}{
+		{"same size", quadrants, 4, quadrants},
+		{"half size", quadrants, 2, square([]color.RGBA{red, green}, []color.RGBA{blue, white})},
+		{"averaged", quadrants, 1, square([]color.RGBA{{127, 127, 127, 255}})},
+		{"upscaled", square([]color.RGBA{red}), 2, square([]color.RGBA{red, red}, []color.RGBA{red, red})},
+		// the second pixel of each row covers two source pixels, the first only one
+		{"uneven", square([]color.RGBA{red, red, green}, []color.RGBA{red, blue, white}, []color.RGBA{red, green, white}), 2,
+			square([]color.RGBA{red, {127, 127, 0, 255}}, []color.RGBA{red, {127, 191, 191, 255}})},
+	}
+	for _, test := range tests {
+		got := resize(test.src, test.size)
+		if got.Bounds() != test.want.Bounds() || !reflect.DeepEqual(got.Pix, test.want.Pix) {
+			t.Errorf("%v: resize(%v) = %v, want %v", test.name, test.size, got.Pix, test.want.Pix)
+		}
+	}
+}
+
+func TestCropSquare(t *testing.T) {
+	wide := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		wide.SetRGBA(0, y, red)
+		wide.SetRGBA(1, y, green)
+		wide.SetRGBA(2, y, blue)
+		wide.SetRGBA(3, y, red)
+	}
+	got := cropSquare(wide)
+	want := square([]color.RGBA{green, blue}, []color.RGBA{green, blue})
+	if !reflect.DeepEqual(got.Pix, want.Pix) {
+		t.Errorf("cropSquare() = %v, want %v", got.Pix, want.Pix)
+	}
+
+	// transparent pixels end up white
+	transparent := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	if got := cropSquare(transparent); got.RGBAAt(0, 0) != white {
+		t.Errorf("cropSquare() of a transparent pixel = %v, want white", got.RGBAAt(0, 0))
+	}
+}
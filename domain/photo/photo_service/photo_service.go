@@ -0,0 +1,169 @@
+package photo_service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/storage/storage_service"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/log"
+)
+
+// PHOTO_FIELD is the multipart field of the upload, used in field errors
+const PHOTO_FIELD = "photo"
+
+const (
+	minVariantSize = 16
+	maxVariantSize = 2048
+)
+
+type IPhotoService interface {
+	SetUserPhoto(user *user_model.User, data []byte) error
+	DeleteUserPhoto(user *user_model.User) error
+}
+
+type PhotoService struct {
+	RepositoriesGroup *repository.RepositoriesGroup
+	StorageService    storage_service.IStorageService
+}
+
+func DefaultPhotoService(rg *repository.RepositoriesGroup, storageService storage_service.IStorageService) *PhotoService {
+	photoService := &PhotoService{
+		RepositoriesGroup: rg,
+		StorageService:    storageService,
+	}
+	return photoService
+}
+
+// SetUserPhoto stores square variants of the uploaded image for each of USER_PHOTO_SIZES and makes the largest the
+// users photo. The previous upload is deleted.
+func (ps *PhotoService) SetUserPhoto(user *user_model.User, data []byte) error {
+	if int64(len(data)) > context.Config.DbVars.UserPhotoMaxSize {
+		return invalidPhoto(fmt.Sprintf("Photo must be smaller than %v KB.", context.Config.DbVars.UserPhotoMaxSize/1024))
+	}
+
+	sizes, err := variantSizes()
+	if err != nil {
+		return err
+	}
+
+	img, orientation, err := decodePhoto(data)
+	if err != nil {
+		return err
+	}
+	square := cropSquare(img)
+
+	// a new key for every upload so cached variants of the old photo are never served
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return err
+	}
+	photoKey := fmt.Sprintf("users/%v/%v", user.Id, hex.EncodeToString(random))
+
+	variants := make(map[string]string)
+	var stored []string
+	for _, size := range sizes {
+		variant, err := encodeJpeg(orient(resize(square, size), orientation))
+		if err != nil {
+			ps.deleteKeys(stored)
+			return err
+		}
+		key := variantKey(photoKey, size)
+		err = ps.StorageService.Put(key, "image/jpeg", variant)
+		if err != nil {
+			ps.deleteKeys(stored)
+			return err
+		}
+		stored = append(stored, key)
+		variants[strconv.Itoa(size)] = ps.StorageService.Url(key)
+	}
+
+	variantsData, err := json.Marshal(variants)
+	if err != nil {
+		ps.deleteKeys(stored)
+		return err
+	}
+
+	previous := previousKeys(user)
+	user.Photo = variants[strconv.Itoa(sizes[len(sizes)-1])]
+	user.PhotoKey = photoKey
+	user.PhotoVariants = string(variantsData)
+	err = ps.RepositoriesGroup.UsersRepository.UpdatePhoto(user.Id, user)
+	if err != nil {
+		ps.deleteKeys(stored)
+		return err
+	}
+
+	ps.deleteKeys(previous)
+	return nil
+}
+
+// DeleteUserPhoto removes the uploaded photo. Photos from social logins are only cleared.
+func (ps *PhotoService) DeleteUserPhoto(user *user_model.User) error {
+	previous := previousKeys(user)
+	user.Photo = ""
+	user.PhotoKey = ""
+	user.PhotoVariants = ""
+	err := ps.RepositoriesGroup.UsersRepository.UpdatePhoto(user.Id, user)
+	if err != nil {
+		return err
+	}
+
+	ps.deleteKeys(previous)
+	return nil
+}
+
+// deleteKeys removes stored files. Failures only leave orphaned files so they are logged.
+func (ps *PhotoService) deleteKeys(keys []string) {
+	for _, key := range keys {
+		if err := ps.StorageService.Delete(key); err != nil {
+			log.Errorf("Error deleting photo %v: %s\n", key, err.Error())
+		}
+	}
+}
+
+// invalidPhoto is returned for uploads the user has to replace
+func invalidPhoto(message string) errors.FieldErrors {
+	var fieldErrors errors.FieldErrors
+	fieldErrors.Add(PHOTO_FIELD, message)
+	return fieldErrors
+}
+
+func previousKeys(user *user_model.User) []string {
+	if user.PhotoKey == "" {
+		return nil
+	}
+	var keys []string
+	for size := range user.GetPhotoVariants() {
+		if n, err := strconv.Atoi(size); err == nil {
+			keys = append(keys, variantKey(user.PhotoKey, n))
+		}
+	}
+	return keys
+}
+
+func variantKey(photoKey string, size int) string {
+	return fmt.Sprintf("%v-%v.jpg", photoKey, size)
+}
+
+// variantSizes parses USER_PHOTO_SIZES, smallest first.
+func variantSizes() ([]int, error) {
+	var sizes []int
+	for _, s := range strings.Split(context.Config.DbVars.UserPhotoSizes, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || size < minVariantSize || size > maxVariantSize {
+			return nil, errors.New(fmt.Sprintf("USER_PHOTO_SIZES has an invalid size '%v'", s))
+		}
+		sizes = append(sizes, size)
+	}
+	sort.Ints(sizes)
+	return sizes, nil
+}
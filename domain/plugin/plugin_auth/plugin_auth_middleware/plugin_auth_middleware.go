@@ -0,0 +1,78 @@
+package plugin_auth_middleware
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/context/consts"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_auth/plugin_auth_model"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_auth/plugin_auth_service"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireSignedRequest only lets through internal api requests signed with a plugin credential. Requests carrying
+// the shared microservice secret are still accepted, but hold no scopes so can only reach routes that don't declare any.
+func RequireSignedRequest(pluginAuthService plugin_auth_service.IPluginAuthService) gin.HandlerFunc {
+	log.Debugf("Adding Signed Plugin Request Middleware\n")
+	return func(c *gin.Context) {
+		if c.Request.Header.Get(consts.GOCMS_HEADER_PLUGIN_ID) == "" {
+			msSecret := c.Request.Header.Get(consts.GOCMS_HEADER_MICROSERVICE_SECRET)
+			if msSecret == "" || subtle.ConstantTimeCompare([]byte(msSecret), []byte(context.Config.DbVars.MicroserviceSecret)) != 1 {
+				c.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// read the body for the signature and put it back for the handler
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			errors.Response(c, http.StatusBadRequest, "Couldn't read request body.", err)
+			return
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		credential, err := pluginAuthService.VerifyRequest(c.Request, body)
+		if err != nil {
+			log.Warningf("Rejected internal api request from plugin %v: %v\n", c.Request.Header.Get(consts.GOCMS_HEADER_PLUGIN_ID), err.Error())
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		c.Set(plugin_auth_model.PLUGIN_KEY_FOR_GIN_CONTEXT, credential)
+		c.Next()
+	}
+}
+
+// RequireScope declares the plugin scopes allowed to call a route. The plugin must hold at least one of them.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		credential, ok := GetPluginFromContext(c)
+		if ok {
+			for _, scope := range scopes {
+				if credential.HasScope(scope) {
+					c.Next()
+					return
+				}
+			}
+			log.Warningf("Plugin %v does not have scope for %v\n", credential.PluginId, c.Request.URL.Path)
+		}
+		errors.Response(c, http.StatusForbidden, "Plugin is not allowed to access this resource.", nil)
+	}
+}
+
+// GetPluginFromContext returns the credential of the plugin that signed the request.
+func GetPluginFromContext(c *gin.Context) (*plugin_auth_model.PluginCredential, bool) {
+	value, exists := c.Get(plugin_auth_model.PLUGIN_KEY_FOR_GIN_CONTEXT)
+	if !exists {
+		return nil, false
+	}
+	credential, ok := value.(*plugin_auth_model.PluginCredential)
+	return credential, ok
+}
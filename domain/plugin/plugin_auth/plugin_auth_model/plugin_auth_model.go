@@ -0,0 +1,29 @@
+package plugin_auth_model
+
+// PLUGIN_KEY_FOR_GIN_CONTEXT holds the credential of the plugin that signed an internal api request.
+const PLUGIN_KEY_FOR_GIN_CONTEXT = "plugin"
+
+// Scopes internal routes can require. Plugins request them with "internalScopes" in the services section of their manifest.
+const (
+	SCOPE_ACL_GROUPS = "acl.groups"
+	SCOPE_ACL_CHECK  = "acl.check"
+	SCOPE_ACL_GRANTS = "acl.grants"
+	SCOPE_EMAIL_SEND = "email.send"
+)
+
+// PluginCredential is issued to each plugin and used to sign its requests to the internal api.
+type PluginCredential struct {
+	PluginId string
+	Secret   string
+	Scopes   []string
+}
+
+// HasScope reports if the plugin was granted the scope.
+func (pc *PluginCredential) HasScope(scope string) bool {
+	for _, s := range pc.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
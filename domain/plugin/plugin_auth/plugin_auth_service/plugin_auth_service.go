@@ -0,0 +1,133 @@
+package plugin_auth_service
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/context/consts"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_auth/plugin_auth_model"
+	"github.com/cqlcorp/gocms/utility"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/gocms_plugin_util/request_signing"
+	"github.com/cqlcorp/gocms/utility/log"
+)
+
+const credentialLength = 48
+
+type IPluginAuthService interface {
+	Issue(pluginId string, scopes []string) (*plugin_auth_model.PluginCredential, error)
+	Register(pluginId string, secret string, scopes []string) *plugin_auth_model.PluginCredential
+	Revoke(pluginId string)
+	VerifyRequest(req *http.Request, body []byte) (*plugin_auth_model.PluginCredential, error)
+}
+
+type PluginAuthService struct {
+	credentials map[string]*plugin_auth_model.PluginCredential
+	nonces      map[string]time.Time
+	mu          sync.RWMutex
+}
+
+func DefaultPluginAuthService() *PluginAuthService {
+	pluginAuthService := &PluginAuthService{
+		credentials: make(map[string]*plugin_auth_model.PluginCredential),
+		nonces:      make(map[string]time.Time),
+	}
+
+	// clear out nonces that are too old to be replayed
+	context.Schedule.AddTicker(time.Minute, pluginAuthService.pruneNonces)
+
+	return pluginAuthService
+}
+
+// Issue creates a new random credential for the plugin, replacing any it had before.
+func (pas *PluginAuthService) Issue(pluginId string, scopes []string) (*plugin_auth_model.PluginCredential, error) {
+	secret, err := utility.GenerateRandomString(credentialLength)
+	if err != nil {
+		log.Errorf("Error generating credential for plugin %v: %v\n", pluginId, err.Error())
+		return nil, err
+	}
+
+	return pas.Register(pluginId, secret, scopes), nil
+}
+
+// Register stores a credential that was provisioned outside of gocms, such as for an external plugin.
+func (pas *PluginAuthService) Register(pluginId string, secret string, scopes []string) *plugin_auth_model.PluginCredential {
+	credential := &plugin_auth_model.PluginCredential{
+		PluginId: pluginId,
+		Secret:   secret,
+		Scopes:   scopes,
+	}
+
+	pas.mu.Lock()
+	pas.credentials[pluginId] = credential
+	pas.mu.Unlock()
+
+	return credential
+}
+
+// Revoke removes the plugins credential so it can no longer call the internal api.
+func (pas *PluginAuthService) Revoke(pluginId string) {
+	pas.mu.Lock()
+	delete(pas.credentials, pluginId)
+	pas.mu.Unlock()
+}
+
+// VerifyRequest checks the signature headers of an internal api request against the credential of the plugin that
+// sent it. Requests outside the allowed clock skew or reusing a nonce are rejected.
+func (pas *PluginAuthService) VerifyRequest(req *http.Request, body []byte) (*plugin_auth_model.PluginCredential, error) {
+	pluginId := req.Header.Get(consts.GOCMS_HEADER_PLUGIN_ID)
+	timestamp := req.Header.Get(consts.GOCMS_HEADER_TIMESTAMP)
+	nonce := req.Header.Get(consts.GOCMS_HEADER_NONCE)
+	signature := req.Header.Get(consts.GOCMS_HEADER_SIGNATURE)
+	if pluginId == "" || timestamp == "" || nonce == "" || signature == "" {
+		return nil, errors.New("request is missing signature headers")
+	}
+
+	pas.mu.RLock()
+	credential, ok := pas.credentials[pluginId]
+	pas.mu.RUnlock()
+	if !ok {
+		return nil, errors.New("no credential issued for plugin " + pluginId)
+	}
+
+	// check the request is recent
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.New("timestamp is not an integer")
+	}
+	maxAge := time.Duration(context.Config.DbVars.PluginRequestMaxAge) * time.Second
+	signedAt := time.Unix(ts, 0)
+	if time.Since(signedAt) > maxAge || time.Until(signedAt) > maxAge {
+		return nil, errors.New("request timestamp is outside the allowed window")
+	}
+
+	bodyHash := request_signing.BodyHash(body)
+	if !request_signing.ValidSignature(credential.Secret, req.Method, req.URL.RequestURI(), timestamp, nonce, bodyHash, signature) {
+		return nil, errors.New("signature doesn't match")
+	}
+
+	// only remember nonces from valid requests so they can't be used to fill the cache
+	nonceKey := pluginId + ":" + nonce
+	pas.mu.Lock()
+	defer pas.mu.Unlock()
+	if _, seen := pas.nonces[nonceKey]; seen {
+		return nil, errors.New("nonce has already been used")
+	}
+	pas.nonces[nonceKey] = signedAt.Add(maxAge)
+
+	return credential, nil
+}
+
+func (pas *PluginAuthService) pruneNonces() {
+	now := time.Now()
+	pas.mu.Lock()
+	for key, expires := range pas.nonces {
+		if now.After(expires) {
+			delete(pas.nonces, key)
+		}
+	}
+	pas.mu.Unlock()
+}
@@ -0,0 +1,157 @@
+package plugin_auth_service
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/context/consts"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_auth/plugin_auth_model"
+	"github.com/cqlcorp/gocms/utility/gocms_plugin_util/request_signing"
+)
+
+func testService() *PluginAuthService {
+	context.Config.DbVars.PluginRequestMaxAge = 60
+	pas := &PluginAuthService{
+		credentials: make(map[string]*plugin_auth_model.PluginCredential),
+		nonces:      make(map[string]time.Time),
+	}
+	pas.Register("plugin", "secret", nil)
+	pas.Register("other", "other-secret", nil)
+	return pas
+}
+
+func signedRequest(t *testing.T, pluginId string, secret string, body string) *http.Request {
+	req := httptest.NewRequest("POST", "/internal/api/user/4?x=1", bytes.NewBufferString(body))
+	if err := request_signing.SignRequest(req, pluginId, secret); err != nil {
+		t.Fatalf("SignRequest() error: %v", err)
+	}
+	return req
+}
+
+// resign replaces the timestamp and signature as a plugin holding the secret would
+func resign(req *http.Request, secret string, body string, timestamp time.Time) {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	req.Header.Set(consts.GOCMS_HEADER_TIMESTAMP, ts)
+	req.Header.Set(consts.GOCMS_HEADER_SIGNATURE, request_signing.Signature(secret, req.Method, req.URL.RequestURI(), ts, req.Header.Get(consts.GOCMS_HEADER_NONCE), request_signing.BodyHash([]byte(body))))
+}
+
+func TestVerifyRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   func() (*http.Request, []byte)
+		valid bool
+	}{
+		{"valid", func() (*http.Request, []byte) {
+			return signedRequest(t, "plugin", "secret", `{"a":1}`), []byte(`{"a":1}`)
+		}, true},
+		{"empty body", func() (*http.Request, []byte) {
+			return signedRequest(t, "plugin", "secret", ""), nil
+		}, true},
+		{"wrong secret", func() (*http.Request, []byte) {
+			return signedRequest(t, "plugin", "other-secret", ""), nil
+		}, false},
+		{"signed as another plugin", func() (*http.Request, []byte) {
+			req := signedRequest(t, "other", "other-secret", "")
+			req.Header.Set(consts.GOCMS_HEADER_PLUGIN_ID, "plugin")
+			return req, nil
+		}, false},
+		{"unknown plugin", func() (*http.Request, []byte) {
+			return signedRequest(t, "missing", "secret", ""), nil
+		}, false},
+		{"changed body", func() (*http.Request, []byte) {
+			return signedRequest(t, "plugin", "secret", `{"a":1}`), []byte(`{"a":2}`)
+		}, false},
+		{"changed path", func() (*http.Request, []byte) {
+			req := signedRequest(t, "plugin", "secret", "")
+			req.URL.RawQuery = "x=2"
+			return req, nil
+		}, false},
+		{"changed method", func() (*http.Request, []byte) {
+			req := signedRequest(t, "plugin", "secret", "")
+			req.Method = "DELETE"
+			return req, nil
+		}, false},
+		{"missing signature", func() (*http.Request, []byte) {
+			req := signedRequest(t, "plugin", "secret", "")
+			req.Header.Del(consts.GOCMS_HEADER_SIGNATURE)
+			return req, nil
+		}, false},
+		{"missing nonce", func() (*http.Request, []byte) {
+			req := signedRequest(t, "plugin", "secret", "")
+			req.Header.Del(consts.GOCMS_HEADER_NONCE)
+			return req, nil
+		}, false},
+		{"old timestamp", func() (*http.Request, []byte) {
+			req := signedRequest(t, "plugin", "secret", "")
+			resign(req, "secret", "", time.Now().Add(-2*time.Minute))
+			return req, nil
+		}, false},
+		{"future timestamp", func() (*http.Request, []byte) {
+			req := signedRequest(t, "plugin", "secret", "")
+			resign(req, "secret", "", time.Now().Add(2*time.Minute))
+			return req, nil
+		}, false},
+		{"timestamp not a number", func() (*http.Request, []byte) {
+			req := signedRequest(t, "plugin", "secret", "")
+			req.Header.Set(consts.GOCMS_HEADER_TIMESTAMP, "now")
+			return req, nil
+		}, false},
+	}
+	for _, test := range tests {
+		pas := testService()
+		req, body := test.req()
+		credential, err := pas.VerifyRequest(req, body)
+		if (err == nil) != test.valid {
+			t.Errorf("%v: VerifyRequest() error = %v, want valid %v", test.name, err, test.valid)
+		}
+		if test.valid && credential.PluginId != "plugin" {
+			t.Errorf("%v: VerifyRequest() = credential for %v, want plugin", test.name, credential.PluginId)
+		}
+	}
+}
+
+func TestVerifyRequestReplay(t *testing.T) {
+	pas := testService()
+	req := signedRequest(t, "plugin", "secret", "")
+	if _, err := pas.VerifyRequest(req, nil); err != nil {
+		t.Fatalf("VerifyRequest() error: %v", err)
+	}
+	if _, err := pas.VerifyRequest(req, nil); err == nil {
+		t.Error("VerifyRequest() accepted a replayed request")
+	}
+
+	// a rejected request doesn't use up its nonce
+	req = signedRequest(t, "plugin", "secret", "")
+	if _, err := pas.VerifyRequest(req, []byte("changed")); err == nil {
+		t.Fatal("VerifyRequest() accepted a changed body")
+	}
+	if _, err := pas.VerifyRequest(req, nil); err != nil {
+		t.Errorf("VerifyRequest() error after a rejected request with the same nonce: %v", err)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	pas := testService()
+	pas.Revoke("plugin")
+	if _, err := pas.VerifyRequest(signedRequest(t, "plugin", "secret", ""), nil); err == nil {
+		t.Error("VerifyRequest() accepted a request from a revoked plugin")
+	}
+}
+
+func TestPruneNonces(t *testing.T) {
+	pas := testService()
+	pas.nonces["plugin:old"] = time.Now().Add(-time.Second)
+	pas.nonces["plugin:new"] = time.Now().Add(time.Minute)
+	pas.pruneNonces()
+	if _, ok := pas.nonces["plugin:old"]; ok {
+		t.Error("pruneNonces() kept an expired nonce")
+	}
+	if _, ok := pas.nonces["plugin:new"]; !ok {
+		t.Error("pruneNonces() removed a nonce that can still be replayed")
+	}
+}
@@ -24,6 +24,7 @@ type Plugin struct {
 	ExternalSchema sql.NullString `db:"externalSchema"`
 	ExternalHost   sql.NullString `db:"externalHost"`
 	ExternalPort   sql.NullInt64 `db:"externalPort"`
+	ExternalCredential sql.NullString `db:"externalCredential"`
 }
 
 // PluginManifest is the root manifest object.
@@ -48,6 +49,14 @@ type PluginManifest struct {
 	Services PluginServices `json:"services"`
 	// Interface see "Plugin Interface"
 	Interface PluginInterface `json:"interface"`
+	// Permissions see "PluginManifestPermission"
+	Permissions []*PluginManifestPermission `json:"permissions,omitempty"`
+	// Groups see "PluginManifestGroup"
+	Groups []*PluginManifestGroup `json:"groups,omitempty"`
+	// ProfileFields see "PluginManifestProfileField"
+	ProfileFields []*PluginManifestProfileField `json:"profileFields,omitempty"`
+	// EmailTemplates see "PluginManifestEmailTemplate"
+	EmailTemplates []*PluginManifestEmailTemplate `json:"emailTemplates,omitempty"`
 }
 
 // PluginServices should the plugin provide backend services, like an API, that configuration is done in this section.
@@ -58,6 +67,34 @@ type PluginServices struct {
 	Bin         string                      `json:"bin"`
 	Docs        string                      `json:"docs"`
 	HealthCheck bool                        `json:"healthCheck"`
+	// InternalScopes the internal api scopes the plugin needs. Internal routes only accept signed requests from plugins
+	// holding one of the scopes the route declares. For a list of scopes look here:
+	// github.com/cqlcorp//gocms/tree/alpha-release/domain/plugin/plugin_auth/plugin_auth_model/plugin_auth_model.go
+	InternalScopes []string `json:"internalScopes,omitempty"`
+	// Cors see "PluginManifestCors"
+	Cors *PluginManifestCors `json:"cors,omitempty"`
+	// UserData see "PluginManifestUserData"
+	UserData *PluginManifestUserData `json:"userData,omitempty"`
+}
+
+// PluginManifestUserData hooks GoCMS calls so plugins can take part in user data exports and account deletion. Hooks
+// are POST requests to the plugin with the user context header of the user the request is for.
+type PluginManifestUserData struct {
+	// Export url that responds with a JSON document of the data the plugin stores for the user. It is added to the
+	// users export archive as plugins/<plugin id>.json.
+	Export string `json:"export,omitempty"`
+	// Delete url called before the user is deleted. The plugin must erase the users data and respond with a 2xx status,
+	// otherwise the deletion is retried later.
+	Delete string `json:"delete,omitempty"`
+}
+
+// PluginManifestCors extra headers the plugin routes need browsers to send or read on cross origin requests.
+// They are added to the headers GoCMS allows for every route.
+type PluginManifestCors struct {
+	// AllowedHeaders request headers added to Access-Control-Allow-Headers. Ex: X-Upload-Id
+	AllowedHeaders []string `json:"allowedHeaders,omitempty"`
+	// ExposedHeaders response headers added to Access-Control-Expose-Headers.
+	ExposedHeaders []string `json:"exposedHeaders,omitempty"`
 }
 
 // PluginManifestRoute routes for the api services are defined here. Currently only HTTP Request are supported through a reverse proxy provided by the GoCMS Parent Service
@@ -75,10 +112,17 @@ type PluginManifestRoute struct {
 	// this functionality can be disabled. When disabled the URL will be only what is specified. If there is a conflict with another plugin GoCMS will crash.
 	DisableNamespace bool `json:"disableNamespace"`
 	// Permissions required for access to this endpoint. This requires "Route=Auth". If no permissions are specified the assumption is that the user must simply be authenticated.
-	// Plugin specific permissions can be specified. Additionally, default GoCMS permissions can be specified. For a list of GoCMS provided permissions
-	// look here:
+	// Plugin specific permissions can be specified, these must be declared in the manifest permissions section and are referenced
+	// without the plugin id. Additionally, default GoCMS permissions can be specified. GoCMS will not start if a permission doesn't exist.
+	// For a list of GoCMS provided permissions look here:
 	// github.com/cqlcorp//gocms/tree/alpha-release/domain/acl/permissions/permissions.go
 	Permissions []string `json:"permissions,omitempty"`
+	// RequireAllPermissions when set the user needs every permission in Permissions instead of any one of them.
+	RequireAllPermissions bool `json:"requireAllPermissions,omitempty"`
+	// PermissionExpression a boolean expression of permissions joined with AND, OR and parentheses, used instead of Permissions.
+	// Ex: "(edit AND publish) OR super_admin". Permissions are referenced the same way as in Permissions. GoCMS will not start
+	// if the expression is invalid.
+	PermissionExpression string `json:"permissionExpression,omitempty"`
 }
 
 // PluginManifestRoute manifest for the api services are defined here. Currently only HTTP Request are supported through a reverse proxy provided by the GoCMS Parent Service
@@ -107,6 +151,55 @@ type PluginManifestMiddleware struct {
 	DisableNamespace bool `json:"disableNamespace"`
 }
 
+// PluginManifestPermission permissions the plugin needs are declared here and created when the plugin is activated.
+// They are namespaced by the plugin id, so a permission "edit" in plugin "blog" is stored as "blog.edit".
+type PluginManifestPermission struct {
+	// Name of the permission without the plugin id.
+	Name string `json:"name"`
+	// Description displayed in the GoCMS settings.
+	Description string `json:"description"`
+}
+
+// PluginManifestGroup groups the plugin provides are declared here and created when the plugin is activated. Like
+// permissions they are namespaced by the plugin id.
+type PluginManifestGroup struct {
+	// Name of the group without the plugin id.
+	Name string `json:"name"`
+	// Description displayed in the GoCMS settings.
+	Description string `json:"description"`
+	// Permissions given to the group. Either permissions declared by this plugin or default GoCMS permissions.
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// PluginManifestProfileField custom user profile fields the plugin stores are declared here and created when the plugin
+// is activated. They are namespaced by the plugin id and can only be changed through the manifest.
+type PluginManifestProfileField struct {
+	// Name of the field without the plugin id. Letters, numbers, dashes and underscores.
+	Name string `json:"name"`
+	// Label displayed next to the input.
+	Label string `json:"label"`
+	// Description displayed in the GoCMS settings.
+	Description string `json:"description"`
+	// Type is one of string, number, boolean, date or select.
+	Type string `json:"type"`
+	// Required fields can't be cleared once set.
+	Required bool `json:"required"`
+	// Options are the allowed values of a select field.
+	Options []string `json:"options,omitempty"`
+	// Min is the minimum number, or the minimum length of a string.
+	Min *float64 `json:"min,omitempty"`
+	// Max is the maximum number, or the maximum length of a string.
+	Max *float64 `json:"max,omitempty"`
+	// Pattern is a regular expression string values must match.
+	Pattern string `json:"pattern,omitempty"`
+	// Visibility is user or admin. Admin fields are never shown to the user.
+	Visibility string `json:"visibility,omitempty"`
+	// EditableBy is user or admin.
+	EditableBy string `json:"editableBy,omitempty"`
+	// IncludeInContext sends the value to plugins in the user context header.
+	IncludeInContext bool `json:"includeInContext"`
+}
+
 // PluginInterface when plugins provide front-end functionality they must serve specific files. More details on this later. For now see the Contact Form Plugin Example:
 // github.com/cqlcorp//plugin-contact-form
 type PluginInterface struct {
@@ -129,8 +222,21 @@ type PluginDatabaseRecord struct {
 	ExternalSchema sql.NullString `db:"externalSchema"`
 	ExternalHost   sql.NullString `db:"externalHost"`
 	ExternalPort   sql.NullInt64 `db:"externalPort"`
+	ExternalCredential sql.NullString `db:"externalCredential"`
 	ManifestData   sql.NullString `db:"manifest"`
 	Manifest       *PluginManifest `db:"-"`
 	Created        time.Time      `db:"created"`
 	LastModified   time.Time      `db:"lastModified"`
 }
+
+// PluginManifestEmailTemplate emails the plugin sends through GoCMS are declared here. The files are read from the emails
+// directory of the plugin and named <name>[.<locale>].<subject|txt|html>.tmpl, the html part is optional. Themes can
+// override them with files named <plugin id>.<name> in their emails directory. Send them with the email.send internal scope.
+type PluginManifestEmailTemplate struct {
+	// Name of the template without the plugin id. Letters, numbers, dashes and underscores.
+	Name string `json:"name"`
+	// Description displayed in the GoCMS settings.
+	Description string `json:"description"`
+	// SampleData is rendered when previewing the template.
+	SampleData map[string]interface{} `json:"sampleData,omitempty"`
+}
@@ -3,9 +3,7 @@ package plugin_middleware_proxy
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/cqlcorp/gocms/context/consts"
-	"github.com/cqlcorp/gocms/domain/user/user_middleware"
-	"github.com/cqlcorp/gocms/utility/api_utility"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_proxies/plugin_user_context"
 	"github.com/cqlcorp/gocms/utility/errors"
 	"github.com/cqlcorp/gocms/utility/log"
 	"net/http"
@@ -26,6 +24,9 @@ type PluginMiddlewareProxy struct {
 	PassAlongError     bool
 	ContinueOnError  bool
 	CopyBody bool
+
+	// loads the profile values sent to the plugin in the user context
+	ProfileLoader plugin_user_context.IProfileLoader
 }
 
 func (ppm *PluginMiddlewareProxy) MiddlewareProxy() gin.HandlerFunc {
@@ -45,9 +46,6 @@ func (ppm *PluginMiddlewareProxy) middlewareProxy(c *gin.Context) {
 		return
 	}
 
-	// transfer headers and user context as needed
-	ppm.handleHeadersAndUserContext(c)
-
 	// take namespace away from app unless it asks for it in the manifest
 	// todo actually check for namespace. Right now, it just assumes and strips.
 	nonNamespacedRequestUrl := strings.Replace(c.Request.URL.Path, fmt.Sprintf("%v/", ppm.PluginId), "", 1)
@@ -74,6 +72,9 @@ func (ppm *PluginMiddlewareProxy) middlewareProxy(c *gin.Context) {
 		}
 	}
 
+	// transfer headers and user context as needed, the original request keeps its credentials for GoCMS
+	plugin_user_context.SetHeaders(c, proxyReq, ppm.PluginId, ppm.ProfileLoader)
+
 	client := &http.Client{}
 	proxyRes, err := client.Do(proxyReq)
 	if err != nil {
@@ -157,16 +158,6 @@ func (ppm *PluginMiddlewareProxy) handleProxyUpdate(c *gin.Context) {
 	}
 }
 
-func (ppm *PluginMiddlewareProxy) handleHeadersAndUserContext(c *gin.Context) {
-	authUser, _ := api_utility.GetUserFromContext(c)
-	timezone, _ := user_middleware.GetTimezoneFromContext(c)
-	if authUser != nil {
-		userHeaderContext := authUser.GetUserContextHeader().Marshal()
-		c.Request.Header.Set(consts.GOCMS_HEADER_USER_CONTEXT_KEY, userHeaderContext)
-	}
-	c.Request.Header.Set(consts.GOCMS_HEADER_TIMEZONE_KEY, timezone.String())
-}
-
 func singleJoiningSlash(a, b string) string {
 	aslash := strings.HasSuffix(a, "/")
 	bslash := strings.HasPrefix(b, "/")
@@ -3,9 +3,7 @@ package plugin_routes_proxy
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/cqlcorp/gocms/context/consts"
-	"github.com/cqlcorp/gocms/domain/user/user_middleware"
-	"github.com/cqlcorp/gocms/utility/api_utility"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_proxies/plugin_user_context"
 	"github.com/cqlcorp/gocms/utility/errors"
 	"github.com/cqlcorp/gocms/utility/log"
 	"net/http"
@@ -21,6 +19,9 @@ type PluginRoutesProxy struct {
 	UpdateProxyChan chan (*PluginRoutesProxy)
 	Disabled        bool
 	IsExternal bool
+
+	// loads the profile values sent to the plugin in the user context
+	ProfileLoader plugin_user_context.IProfileLoader
 }
 
 func (ppm *PluginRoutesProxy) ReverseProxy() gin.HandlerFunc {
@@ -40,11 +41,11 @@ func (ppm *PluginRoutesProxy) reverseProxy(c *gin.Context) {
 		return
 	}
 
-	// transfer headers and user context as needed
-	ppm.handleHeadersAndUserContext(c)
-
 	// do actual request directing
 	director := func(req *http.Request) {
+		// transfer headers and user context as needed, the proxy works on a copy of the request headers
+		plugin_user_context.SetHeaders(c, req, ppm.PluginId, ppm.ProfileLoader)
+
 		// check new port channel in case the plugin has moved ports
 		req.URL.Scheme = ppm.Schema
 		req.URL.Host = fmt.Sprintf("%v:%v", ppm.Host, ppm.Port)
@@ -79,16 +80,6 @@ func (ppm *PluginRoutesProxy) handleProxyUpdate(c *gin.Context) {
 	}
 }
 
-func (ppm *PluginRoutesProxy) handleHeadersAndUserContext(c *gin.Context) {
-	authUser, _ := api_utility.GetUserFromContext(c)
-	timezone, _ := user_middleware.GetTimezoneFromContext(c)
-	if authUser != nil {
-		userHeaderContext := authUser.GetUserContextHeader().Marshal()
-		c.Request.Header.Set(consts.GOCMS_HEADER_USER_CONTEXT_KEY, userHeaderContext)
-	}
-	c.Request.Header.Set(consts.GOCMS_HEADER_TIMEZONE_KEY, timezone.String())
-}
-
 func singleJoiningSlash(a, b string) string {
 	aslash := strings.HasSuffix(a, "/")
 	bslash := strings.HasPrefix(b, "/")
@@ -0,0 +1,94 @@
+package plugin_user_context
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/context/consts"
+	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_cookie"
+	"github.com/cqlcorp/gocms/domain/profile/profile_model"
+	"github.com/cqlcorp/gocms/domain/user/user_middleware"
+	"github.com/cqlcorp/gocms/utility/api_utility"
+	"github.com/cqlcorp/gocms/utility/gocms_plugin_util/user_context"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+// cookies holding GoCMS credentials, plugins never get them
+var credentialCookies = map[string]bool{
+	authentication_cookie.AUTH_COOKIE:         true,
+	authentication_cookie.DEVICE_COOKIE:       true,
+	authentication_cookie.IMPERSONATOR_COOKIE: true,
+	authentication_cookie.CSRF_COOKIE:         true,
+}
+
+// IProfileLoader loads the profile values included in the user context, implemented by the profile service.
+type IProfileLoader interface {
+	GetContextProfile(userId int64) (profile_model.Profile, error)
+}
+
+// SetHeaders adds the timezone and, for authenticated requests, a signed user context token for the plugin to the
+// outgoing proxy request. The credentials of the user are removed so a plugin can't act as the user, or as the admin
+// impersonating them, outside of the user context it is given. The profile values are only loaded here so requests
+// that never reach a plugin don't pay for them.
+func SetHeaders(c *gin.Context, req *http.Request, pluginId string, profileLoader IProfileLoader) {
+	authUser, _ := api_utility.GetUserFromContext(c)
+	timezone, _ := user_middleware.GetTimezoneFromContext(c)
+
+	removeCredentials(req)
+
+	req.Header.Del(consts.GOCMS_HEADER_USER_CONTEXT_KEY)
+	if authUser != nil {
+		if authUser.Profile == nil && profileLoader != nil {
+			profile, err := profileLoader.GetContextProfile(authUser.Id)
+			if err != nil {
+				log.Errorf("Error loading context profile of user %v for plugin %v: %v\n", authUser.Id, pluginId, err.Error())
+			}
+			authUser.Profile = profile
+		}
+		token, err := CreateToken(authUser.GetUserContextHeader(), pluginId)
+		if err != nil {
+			log.Errorf("Error creating user context token for plugin %v: %v\n", pluginId, err.Error())
+		} else {
+			req.Header.Set(consts.GOCMS_HEADER_USER_CONTEXT_KEY, token)
+		}
+	}
+	req.Header.Set(consts.GOCMS_HEADER_TIMEZONE_KEY, timezone.String())
+}
+
+// removeCredentials deletes the auth, device, impersonator and csrf tokens from the headers and cookies of the request.
+// Other cookies are kept.
+func removeCredentials(req *http.Request) {
+	req.Header.Del("Authorization")
+	req.Header.Del(authentication_cookie.AUTH_HEADER)
+	req.Header.Del(authentication_cookie.DEVICE_HEADER)
+	req.Header.Del(authentication_cookie.CSRF_HEADER)
+
+	cookies := req.Cookies()
+	req.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if !credentialCookies[cookie.Name] {
+			req.AddCookie(cookie)
+		}
+	}
+}
+
+// CreateToken signs the user context for a plugin. Also used when GoCMS calls a plugin on behalf of a user.
+func CreateToken(userContextHeader interface{}, pluginId string) (string, error) {
+	now := time.Now()
+	expire := now.Add(time.Duration(context.Config.DbVars.UserContextTimeout) * time.Second)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"user": userContextHeader,
+		"iss":  user_context.ISSUER,
+		"aud":  pluginId,
+		consts.TOKEN_TYPE_CLAIM: consts.TOKEN_TYPE_USER_CONTEXT,
+		"iat":  now.Unix(),
+		"exp":  expire.Unix(),
+	})
+	token.Header["kid"] = user_context.KeyId(context.Config.DbVars.RSAPub)
+
+	return token.SignedString(context.Config.DbVars.GetRsaPrivateKey(true))
+}
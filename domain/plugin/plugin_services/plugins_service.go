@@ -3,7 +3,10 @@ package plugin_services
 import (
 	"database/sql"
 	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_service"
+	"github.com/cqlcorp/gocms/domain/mail/mail_service"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_auth/plugin_auth_service"
 	"github.com/cqlcorp/gocms/domain/plugin/plugin_model"
+	"github.com/cqlcorp/gocms/domain/profile/profile_service"
 	"github.com/cqlcorp/gocms/init/repository"
 	"github.com/cqlcorp/gocms/routes"
 	"github.com/cqlcorp/gocms/utility/log"
@@ -24,15 +27,21 @@ type PluginsService struct {
 	installedPlugins  map[string]*plugin_model.Plugin
 	activePlugins     map[string]*plugin_model.Plugin
 	aclService        access_control_service.IAclService
+	pluginAuthService plugin_auth_service.IPluginAuthService
+	profileService    profile_service.IProfileService
+	mailService       mail_service.IMailService
 }
 
-func DefaultPluginsService(rg *repository.RepositoriesGroup, aclService access_control_service.IAclService) *PluginsService {
+func DefaultPluginsService(rg *repository.RepositoriesGroup, aclService access_control_service.IAclService, pluginAuthService plugin_auth_service.IPluginAuthService, profileService profile_service.IProfileService, mailService mail_service.IMailService) *PluginsService {
 
 	pluginsService := &PluginsService{
 		repositoriesGroup: rg,
 		installedPlugins:  make(map[string]*plugin_model.Plugin),
 		activePlugins:     make(map[string]*plugin_model.Plugin),
 		aclService:        aclService,
+		pluginAuthService: pluginAuthService,
+		profileService:    profileService,
+		mailService:       mailService,
 	}
 
 	return pluginsService
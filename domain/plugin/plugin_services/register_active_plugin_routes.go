@@ -2,6 +2,7 @@ package plugin_services
 
 import (
 	"fmt"
+	"path"
 	"github.com/gin-gonic/gin"
 	"github.com/cqlcorp/gocms/domain/acl/permissions"
 	"github.com/cqlcorp/gocms/routes"
@@ -17,20 +18,39 @@ type ProxyRoute struct {
 	Port   string
 }
 
+// RegisterActivePluginRoutes registers the manifest routes of every active plugin. A plugin with an invalid route gets
+// none of its routes registered, the other plugins are still registered and the last error is returned.
 func (ps *PluginsService) RegisterActivePluginRoutes(routes *routes.Routes) error {
+	var lastErr error
 	for _, plugin := range ps.GetActivePlugins() {
 
-		// loop through each manifest and apply each route to the middleware proxy
-		for _, routeManifest := range plugin.Manifest.Services.Routes {
-			routerGroup, err := ps.getRouteGroup(routeManifest.Route, routes)
+		// resolve every route first so a plugin is registered completely or not at all
+		routerGroups := make([]*gin.RouterGroup, len(plugin.Manifest.Services.Routes))
+		requirements := make([]permissions.Expression, len(plugin.Manifest.Services.Routes))
+		var err error
+		for i, routeManifest := range plugin.Manifest.Services.Routes {
+			routerGroups[i], err = ps.getRouteGroup(routeManifest.Route, routes)
 			if err != nil {
-				es := fmt.Sprintf("Plugin %s -> Route %s -> Method %s, Url %s, Error: %s\n", plugin.Manifest.Id, routeManifest.Route, routeManifest.Method, routeManifest.Url, err.Error())
-				log.Errorf(es)
-				return err
+				log.Errorf("Plugin %s -> Route %s -> Method %s, Url %s, Error: %s\n", plugin.Manifest.Id, routeManifest.Route, routeManifest.Method, routeManifest.Url, err.Error())
+				break
 			}
 
-			// register route and permissions within GoCMS
-			ps.registerPluginProxyOnRoute(routerGroup, plugin, routeManifest)
+			// the requirement must be valid and every permission must exist, otherwise the route could never be authorized
+			requirements[i], err = ps.resolveRouteRequirement(plugin.Manifest, routeManifest)
+			if err != nil {
+				log.Errorf("Plugin %s -> Route %s -> Method %s, Url %s, Error: %s\n", plugin.Manifest.Id, routeManifest.Route, routeManifest.Method, routeManifest.Url, err.Error())
+				break
+			}
+		}
+		if err != nil {
+			log.Errorf("Skipping the routes of plugin %v\n", plugin.Manifest.Id)
+			lastErr = err
+			continue
+		}
+
+		// register route and permissions within GoCMS
+		for i, routeManifest := range plugin.Manifest.Services.Routes {
+			ps.registerPluginProxyOnRoute(routerGroups[i], plugin, routeManifest, requirements[i])
 		}
 
 		// check if there is interface routes that need to be registered
@@ -44,19 +64,53 @@ func (ps *PluginsService) RegisterActivePluginRoutes(routes *routes.Routes) erro
 		}
 
 	}
-	return nil
+	return lastErr
 }
 
-func (ps *PluginsService) registerPluginProxyOnRoute(route *gin.RouterGroup, plugin *plugin_model.Plugin, routeManifest *plugin_model.PluginManifestRoute) {
+// resolveRouteRequirement builds the permission requirement of a manifest route with plugin permission names resolved.
+// Routes without permissions return nil.
+func (ps *PluginsService) resolveRouteRequirement(manifest *plugin_model.PluginManifest, routeManifest *plugin_model.PluginManifestRoute) (permissions.Expression, error) {
+	var requirement permissions.Expression
+	switch {
+	case routeManifest.PermissionExpression != "" && len(routeManifest.Permissions) > 0:
+		return nil, errors.New("use either permissions or permissionExpression, not both")
+	case routeManifest.PermissionExpression != "":
+		parsed, err := permissions.ParseExpression(routeManifest.PermissionExpression)
+		if err != nil {
+			return nil, err
+		}
+		requirement = parsed
+	case len(routeManifest.Permissions) > 0 && routeManifest.RequireAllPermissions:
+		requirement = permissions.AllOf(routeManifest.Permissions...)
+	case len(routeManifest.Permissions) > 0:
+		requirement = permissions.AnyOf(routeManifest.Permissions...)
+	default:
+		return nil, nil
+	}
+
+	if routeManifest.Route != routes.AUTH {
+		log.Warningf("Plugin %v -> Url %v: permissions are only applied to %v routes\n", manifest.Id, routeManifest.Url, routes.AUTH)
+	}
+
+	return permissions.MapPermissions(requirement, func(name string) (string, error) {
+		permission, err := ps.resolvePluginPermission(manifest, name)
+		if err != nil {
+			return "", err
+		}
+		return permission.Name, nil
+	})
+}
+
+func (ps *PluginsService) registerPluginProxyOnRoute(route *gin.RouterGroup, plugin *plugin_model.Plugin, routeManifest *plugin_model.PluginManifestRoute, requirement permissions.Expression) {
 
 	// middlewares
 	var handlers []gin.HandlerFunc
 	url := routeManifest.Url
 
 	// add acl middleware if needed
-	if routeManifest.Route == routes.AUTH && len(routeManifest.Permissions) > 0 {
+	if routeManifest.Route == routes.AUTH && requirement != nil {
 		log.Debugf("Adding ACL Middleware for %v\n", routeManifest.Url)
-		handlers = append(handlers, access_control_middleware.RequirePermission(ps.aclService, routeManifest.Permissions...))
+		handlers = append(handlers, access_control_middleware.RequireExpression(ps.aclService, requirement))
 	}
 
 	// if the namespace is not disabled then we should inject
@@ -70,6 +124,11 @@ func (ps *PluginsService) registerPluginProxyOnRoute(route *gin.RouterGroup, plu
 
 	// register route
 	route.Handle(routeManifest.Method, url, handlers...)
+
+	// record what the route requires so authorization can be explained
+	if routeManifest.Route == routes.AUTH {
+		ps.aclService.RegisterRoute(routeManifest.Method, path.Join(route.BasePath(), url), plugin.Manifest.Id, requirement)
+	}
 }
 
 func (ps *PluginsService) getRouteGroup(pluginRoute string, r *routes.Routes) (*gin.RouterGroup, error) {
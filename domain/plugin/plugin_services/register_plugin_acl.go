@@ -0,0 +1,118 @@
+package plugin_services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_model"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_model"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/cqlcorp/gocms/utility/sqlUtl"
+)
+
+// NamespacedName returns the name a plugin permission or group is stored under in gocms.
+func NamespacedName(pluginId string, name string) string {
+	return fmt.Sprintf("%v.%v", pluginId, name)
+}
+
+// registerPluginAcl upserts the permissions and groups declared in the plugin manifest.
+func (ps *PluginsService) registerPluginAcl(plugin *plugin_model.Plugin) error {
+	manifest := plugin.Manifest
+	if len(manifest.Permissions) == 0 && len(manifest.Groups) == 0 {
+		return nil
+	}
+
+	for _, manifestPermission := range manifest.Permissions {
+		if strings.TrimSpace(manifestPermission.Name) == "" {
+			return errors.New(fmt.Sprintf("plugin %v declares a permission without a name", manifest.Id))
+		}
+		permission := &permission_model.Permission{
+			Name:        NamespacedName(manifest.Id, manifestPermission.Name),
+			Description: manifestPermission.Description,
+		}
+		err := permissions.ValidateName(permission.Name)
+		if err != nil {
+			return errors.New(fmt.Sprintf("plugin %v declares an invalid permission: %v", manifest.Id, err.Error()))
+		}
+		err = ps.repositoriesGroup.PermissionsRepository.Upsert(permission)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Registered permission %v for plugin %v\n", permission.Name, manifest.Id)
+	}
+
+	// refresh so group permissions can be looked up
+	err := ps.aclService.RefreshPermissionsCache()
+	if err != nil {
+		return err
+	}
+
+	for _, manifestGroup := range manifest.Groups {
+		if strings.TrimSpace(manifestGroup.Name) == "" {
+			return errors.New(fmt.Sprintf("plugin %v declares a group without a name", manifest.Id))
+		}
+		group := &group_model.Group{
+			Name:        NamespacedName(manifest.Id, manifestGroup.Name),
+			Description: manifestGroup.Description,
+		}
+		err := ps.repositoriesGroup.GroupsRepository.Upsert(group)
+		if err != nil {
+			return err
+		}
+
+		for _, permissionName := range manifestGroup.Permissions {
+			permission, err := ps.resolvePluginGroupPermission(manifest, permissionName)
+			if err != nil {
+				return err
+			}
+			err = ps.repositoriesGroup.PermissionsRepository.AddGroupToPermission(group.Id, permission.Id)
+			if err != nil && !sqlUtl.ErrDupEtry(err) {
+				return err
+			}
+		}
+		log.Debugf("Registered group %v for plugin %v\n", group.Name, manifest.Id)
+	}
+
+	// members of existing groups may have gained permissions
+	if len(manifest.Groups) > 0 {
+		ps.aclService.InvalidateAll()
+	}
+
+	return nil
+}
+
+// resolvePluginGroupPermission finds a permission a manifest group grants. Groups can only grant the plugin's own
+// permissions, otherwise activating a plugin could hand out super_admin or acl management to whoever it adds.
+func (ps *PluginsService) resolvePluginGroupPermission(manifest *plugin_model.PluginManifest, name string) (*permission_model.Permission, error) {
+	permission, err := ps.resolvePluginPermission(manifest, name)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(permission.Name, NamespacedName(manifest.Id, "")) {
+		return nil, errors.New(fmt.Sprintf("plugin %v groups can only grant the plugin's own permissions, not %v", manifest.Id, permission.Name))
+	}
+	return permission, nil
+}
+
+// resolvePluginPermission finds a permission referenced in a manifest. Names declared by the plugin are looked up
+// with the plugin namespace, anything else must be an existing gocms permission.
+func (ps *PluginsService) resolvePluginPermission(manifest *plugin_model.PluginManifest, name string) (*permission_model.Permission, error) {
+	allPermissions := ps.aclService.GetPermissions()
+
+	for _, manifestPermission := range manifest.Permissions {
+		if manifestPermission.Name == name {
+			name = NamespacedName(manifest.Id, name)
+			break
+		}
+	}
+
+	permission, ok := allPermissions[name]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("plugin %v references unknown permission %v", manifest.Id, name))
+	}
+
+	return &permission, nil
+}
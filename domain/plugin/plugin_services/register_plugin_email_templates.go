@@ -0,0 +1,28 @@
+package plugin_services
+
+import (
+	"path/filepath"
+
+	"github.com/cqlcorp/gocms/domain/mail/mail_model"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_model"
+)
+
+// registerPluginEmailTemplates adds the email templates declared in the plugin manifest as <plugin id>.<name>.
+func (ps *PluginsService) registerPluginEmailTemplates(plugin *plugin_model.Plugin) error {
+	manifest := plugin.Manifest
+	for _, manifestTemplate := range manifest.EmailTemplates {
+		err := ps.mailService.RegisterTemplate(&mail_model.EmailTemplate{
+			Name:        manifest.Id + "." + manifestTemplate.Name,
+			Description: manifestTemplate.Description,
+			Source:      manifest.Id,
+			Dir:         filepath.Join(plugin.PluginRoot, "emails"),
+			FileName:    manifestTemplate.Name,
+			SampleData:  manifestTemplate.SampleData,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package plugin_services
+
+import (
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_model"
+	"github.com/cqlcorp/gocms/domain/profile/profile_model"
+)
+
+// registerPluginProfileFields upserts the profile fields declared in the plugin manifest.
+func (ps *PluginsService) registerPluginProfileFields(plugin *plugin_model.Plugin) error {
+	manifest := plugin.Manifest
+	if len(manifest.ProfileFields) == 0 {
+		return nil
+	}
+
+	fields := make([]*profile_model.ProfileField, len(manifest.ProfileFields))
+	for i, manifestField := range manifest.ProfileFields {
+		fields[i] = &profile_model.ProfileField{
+			Name:             manifestField.Name,
+			Label:            manifestField.Label,
+			Description:      manifestField.Description,
+			Type:             manifestField.Type,
+			Required:         manifestField.Required,
+			Options:          manifestField.Options,
+			Min:              manifestField.Min,
+			Max:              manifestField.Max,
+			Pattern:          manifestField.Pattern,
+			Visibility:       manifestField.Visibility,
+			EditableBy:       manifestField.EditableBy,
+			IncludeInContext: manifestField.IncludeInContext,
+		}
+	}
+
+	return ps.profileService.RegisterPluginFields(manifest.Id, fields)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/cqlcorp/gocms/domain/plugin/plugin_proxies/plugin_routes_proxy"
 	"github.com/cqlcorp/gocms/domain/plugin/plugin_proxies/plugin_middleware_proxy"
 	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/gocms_plugin_util"
 )
 
 func (ps *PluginsService) StartPluginsService() (err error) {
@@ -25,6 +26,30 @@ func (ps *PluginsService) StartPluginsService() (err error) {
 	}
 
 	for _, plugin := range activePlugins {
+		// create the permissions and groups the plugin declares
+		newErr := ps.registerPluginAcl(plugin)
+		if newErr != nil {
+			log.Errorf("Error registering permissions and groups for plugin %v: %v\n", plugin.Manifest.Id, newErr.Error())
+			err = newErr
+			continue
+		}
+
+		// create the profile fields the plugin declares
+		newErr = ps.registerPluginProfileFields(plugin)
+		if newErr != nil {
+			log.Errorf("Error registering profile fields for plugin %v: %v\n", plugin.Manifest.Id, newErr.Error())
+			err = newErr
+			continue
+		}
+
+		// add the email templates the plugin declares
+		newErr = ps.registerPluginEmailTemplates(plugin)
+		if newErr != nil {
+			log.Errorf("Error registering email templates for plugin %v: %v\n", plugin.Manifest.Id, newErr.Error())
+			err = newErr
+			continue
+		}
+
 		// handle external plugins
 		if plugin.IsExternal {
 			newErr := ps.registerExternalPlugin(plugin)
@@ -69,6 +94,7 @@ func (ps *PluginsService) registerExternalPlugin(plugin *plugin_model.Plugin) er
 		Host:     plugin.ExternalHost.String,
 		PluginId: plugin.Manifest.Id,
 		Disabled: false,
+		ProfileLoader: ps.profileService,
 	}
 
 	// create proxies for middleware use
@@ -84,6 +110,7 @@ func (ps *PluginsService) registerExternalPlugin(plugin *plugin_model.Plugin) er
 			Schema:           plugin.ExternalSchema.String,
 			Host:             plugin.ExternalHost.String,
 			Disabled:         false,
+			ProfileLoader:    ps.profileService,
 		}
 
 		// add middleware to slice
@@ -91,6 +118,13 @@ func (ps *PluginsService) registerExternalPlugin(plugin *plugin_model.Plugin) er
 
 	}
 
+	// external plugins are given their credential by whoever deploys them
+	if plugin.ExternalCredential.String != "" {
+		ps.pluginAuthService.Register(plugin.Manifest.Id, plugin.ExternalCredential.String, plugin.Manifest.Services.InternalScopes)
+	} else {
+		log.Warningf("Plugin %v has no external credential and won't be able to call the internal api\n", plugin.Manifest.Id)
+	}
+
 	log.Infof("Microservice External: %v\n", plugin.Manifest.Id)
 
 	// add plugin to active list for monitoring and other things
@@ -108,10 +142,20 @@ func (ps *PluginsService) startLocalPlugin(plugin *plugin_model.Plugin) error {
 		return err
 	}
 
+	// issue a fresh credential each start for signing internal api requests
+	credential, err := ps.pluginAuthService.Issue(plugin.Manifest.Id, plugin.Manifest.Services.InternalScopes)
+	if err != nil {
+		log.Errorf("Couldn't start plugin %v, error: %v", plugin.Manifest.Name, err.Error())
+		return err
+	}
+
 	// build command
 	cmd := exec.Command(filepath.FromSlash("./"+plugin.BinaryFile), fmt.Sprintf("-port=%d", pluginPort))
 	cmd.Dir = plugin.PluginRoot
 
+	// pass the credential in the environment, arguments are visible to every user in the process list
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", gocms_plugin_util.CredentialEnv, credential.Secret))
+
 	// set stdout to pipe
 	cmdStdoutReader, err := cmd.StdoutPipe()
 	if err != nil {
@@ -179,6 +223,7 @@ func (ps *PluginsService) startLocalPlugin(plugin *plugin_model.Plugin) error {
 		PluginId:        plugin.Manifest.Id,
 		UpdateProxyChan: newPpmRouteChan,
 		Disabled:        false,
+		ProfileLoader:   ps.profileService,
 	}
 
 	// create proxies for middleware use
@@ -195,6 +240,7 @@ func (ps *PluginsService) startLocalPlugin(plugin *plugin_model.Plugin) error {
 			Host:             "localhost",
 			Schema:           "http",
 			Disabled:         false,
+			ProfileLoader:    ps.profileService,
 		}
 
 		// add middleware to slice
@@ -256,6 +302,7 @@ func (ps *PluginsService) getActivePlugins() (map[string]*plugin_model.Plugin, e
 					ExternalSchema: dbPlugin.ExternalSchema,
 					ExternalHost:   dbPlugin.ExternalHost,
 					ExternalPort:   dbPlugin.ExternalPort,
+					ExternalCredential: dbPlugin.ExternalCredential,
 				}
 			} else { // plugin is not installed locally, but it is active in the database, and its set to internal. WARN!
 				log.Debugf("Skipping %v, plugin active in database but not installed locally. Should plugin be set to run in 'External Mode'?\n", dbPlugin.PluginId)
@@ -0,0 +1,132 @@
+package privacy_controller
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/domain/privacy/privacy_model"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/api_utility"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyAdminController struct {
+	routes        *routes.Routes
+	ServicesGroup *service.ServicesGroup
+	adminRoutes   *access_control_middleware.PermissionGroup
+}
+
+func DefaultPrivacyAdminController(routes *routes.Routes, sg *service.ServicesGroup) *PrivacyAdminController {
+	privacyAdminController := &PrivacyAdminController{
+		routes:        routes,
+		ServicesGroup: sg,
+		adminRoutes:   access_control_middleware.NewPermissionGroup(routes.Auth, "/admin", sg.AclService, permissions.SUPER_ADMIN),
+	}
+
+	privacyAdminController.Default()
+	return privacyAdminController
+}
+
+func (pac *PrivacyAdminController) Default() {
+	pac.adminRoutes.GET("/user/:userId/export", pac.export)
+	pac.adminRoutes.GET("/user-deletion/failed", pac.getFailedDeletions)
+	pac.adminRoutes.POST("/user/:userId/deletion/retry", pac.retryDeletion)
+}
+
+/**
+* @api {get} /admin/user/:userId/export Export User Data
+* @apiDescription Download the data export archive of a user, to answer a request made outside of GoCMS. The export is
+* recorded in the users audit log.
+* @apiName AdminExportUserData
+* @apiGroup Admin
+*
+* @apiUse AuthHeader
+* @apiSuccess (Response) {File} archive application/zip attachment.
+* @apiPermission Admin
+ */
+func (pac *PrivacyAdminController) export(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	userId, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Id Field", err)
+		return
+	}
+
+	user, err := pac.ServicesGroup.UserService.Get(userId)
+	if err != nil {
+		errors.Response(c, http.StatusNotFound, errors.ApiError_UserDoesntExist, err)
+		return
+	}
+
+	archive, err := pac.ServicesGroup.PrivacyService.Export(user, authUser.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't export user data.", err)
+		return
+	}
+
+	sendArchive(c, user, archive)
+}
+
+/**
+* @api {get} /admin/user-deletion/failed Get Failed Account Deletions
+* @apiDescription Account deletions that failed on every attempt for a day, usually because a plugin couldn't delete the
+* users data. They aren't retried until an admin retries them.
+* @apiName AdminGetFailedDeletions
+* @apiGroup Admin
+*
+* @apiUse AuthHeader
+* @apiUse DeletionRequestAdminDisplay
+* @apiPermission Admin
+ */
+func (pac *PrivacyAdminController) getFailedDeletions(c *gin.Context) {
+
+	requests, err := pac.ServicesGroup.PrivacyService.GetFailedDeletions()
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get failed deletions.", err)
+		return
+	}
+
+	displays := make([]*privacy_model.DeletionRequestAdminDisplay, len(requests))
+	for i, request := range requests {
+		displays[i] = request.GetDeletionRequestAdminDisplay()
+	}
+
+	c.JSON(http.StatusOK, displays)
+}
+
+/**
+* @api {post} /admin/user/:userId/deletion/retry Retry Account Deletion
+* @apiDescription Try a failed account deletion again on the next hourly run.
+* @apiName AdminRetryDeletion
+* @apiGroup Admin
+*
+* @apiUse AuthHeader
+* @apiPermission Admin
+ */
+func (pac *PrivacyAdminController) retryDeletion(c *gin.Context) {
+
+	userId, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Id Field", err)
+		return
+	}
+
+	err = pac.ServicesGroup.PrivacyService.RetryDeletion(userId)
+	if err == sql.ErrNoRows {
+		errors.Response(c, http.StatusNotFound, "No deletion scheduled.", err)
+		return
+	}
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't retry deletion.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
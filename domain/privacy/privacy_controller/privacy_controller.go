@@ -0,0 +1,162 @@
+package privacy_controller
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/impersonation/impersonation_middleware"
+	"github.com/cqlcorp/gocms/domain/privacy/privacy_model"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/api_utility"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyController struct {
+	routes        *routes.Routes
+	ServicesGroup *service.ServicesGroup
+}
+
+func DefaultPrivacyController(routes *routes.Routes, sg *service.ServicesGroup) *PrivacyController {
+	privacyController := &PrivacyController{
+		routes:        routes,
+		ServicesGroup: sg,
+	}
+
+	privacyController.Default()
+	return privacyController
+}
+
+func (pc *PrivacyController) Default() {
+	pc.routes.Auth.GET("/user/export", api_key_middleware.RequireUserToken(), impersonation_middleware.BlockWhileImpersonating(), pc.export)
+	pc.routes.Auth.GET("/user/deletion", pc.getDeletion)
+	pc.routes.Auth.POST("/user/deletion", api_key_middleware.RequireUserToken(), impersonation_middleware.BlockWhileImpersonating(), pc.requestDeletion)
+	pc.routes.Auth.DELETE("/user/deletion", api_key_middleware.RequireUserToken(), impersonation_middleware.BlockWhileImpersonating(), pc.cancelDeletion)
+}
+
+/**
+* @api {get} /user/export Export User Data
+* @apiDescription Download a zip archive of the data stored about the user: profile, emails, groups, api keys and
+* impersonations, audit log entries and the data of plugins with a user data export hook. A plugin that fails is noted
+* in plugin_errors.json.
+* @apiName ExportUserData
+* @apiGroup User
+*
+* @apiUse AuthHeader
+* @apiSuccess (Response) {File} archive application/zip attachment.
+* @apiPermission Authenticated
+ */
+func (pc *PrivacyController) export(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	archive, err := pc.ServicesGroup.PrivacyService.Export(authUser, authUser.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't export user data.", err)
+		return
+	}
+
+	sendArchive(c, authUser, archive)
+}
+
+/**
+* @api {get} /user/deletion Get Account Deletion
+* @apiDescription Get the pending deletion of the account. Responds 404 if no deletion is scheduled.
+* @apiName GetAccountDeletion
+* @apiGroup User
+*
+* @apiUse AuthHeader
+* @apiUse DeletionRequestDisplay
+* @apiPermission Authenticated
+ */
+func (pc *PrivacyController) getDeletion(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	request, err := pc.ServicesGroup.PrivacyService.GetDeletion(authUser.Id)
+	if err == sql.ErrNoRows {
+		errors.Response(c, http.StatusNotFound, "No deletion scheduled.", err)
+		return
+	}
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get deletion.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, request.GetDeletionRequestDisplay())
+}
+
+/**
+* @api {post} /user/deletion Request Account Deletion
+* @apiDescription Schedule the account and its data, including plugin data, to be deleted after
+* ACCOUNT_DELETION_GRACE_DAYS. The user is emailed and can cancel until then. Requesting again restarts the grace period.
+* @apiName RequestAccountDeletion
+* @apiGroup User
+*
+* @apiUse AuthHeader
+* @apiUse UserPasswordInput
+* @apiUse DeletionRequestDisplay
+* @apiPermission Authenticated
+ */
+func (pc *PrivacyController) requestDeletion(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	var userPasswordInput user_model.UserPasswordInput
+	err := c.BindJSON(&userPasswordInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// verify password
+	if ok := pc.ServicesGroup.AuthService.VerifyPassword(authUser.Password, userPasswordInput.Password); !ok {
+		errors.Response(c, http.StatusUnauthorized, "Bad Password.", err)
+		return
+	}
+
+	request, err := pc.ServicesGroup.PrivacyService.RequestDeletion(authUser)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't schedule deletion.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, request.GetDeletionRequestDisplay())
+}
+
+/**
+* @api {delete} /user/deletion Cancel Account Deletion
+* @apiName CancelAccountDeletion
+* @apiGroup User
+*
+* @apiUse AuthHeader
+* @apiPermission Authenticated
+ */
+func (pc *PrivacyController) cancelDeletion(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	err := pc.ServicesGroup.PrivacyService.CancelDeletion(authUser.Id)
+	if err == sql.ErrNoRows {
+		errors.Response(c, http.StatusNotFound, "No deletion scheduled.", err)
+		return
+	}
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't cancel deletion.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func sendArchive(c *gin.Context, user *user_model.User, archive []byte) {
+	filename := fmt.Sprintf("user-%v-%v.zip", user.Id, time.Now().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%v\"", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, privacy_model.EXPORT_CONTENT_TYPE, archive)
+}
@@ -0,0 +1,102 @@
+package privacy_model
+
+import (
+	"time"
+
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_model"
+	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"github.com/cqlcorp/gocms/domain/acl/impersonation/impersonation_model"
+	"github.com/cqlcorp/gocms/domain/email/email_model"
+	"github.com/cqlcorp/gocms/domain/logs/log_model"
+	"github.com/cqlcorp/gocms/domain/profile/profile_model"
+)
+
+// EXPORT_CONTENT_TYPE of the export archive
+const EXPORT_CONTENT_TYPE = "application/zip"
+
+// DeletionRequest schedules the user to be deleted once the grace period is over. The request is removed with the user.
+type DeletionRequest struct {
+	UserId       int64     `db:"userId"`
+	Requested    time.Time `db:"requested"`
+	ScheduledFor time.Time `db:"scheduledFor"`
+	Attempts     int64     `db:"attempts"`
+	LastError    string    `db:"lastError"`
+}
+
+/**
+* @apiDefine DeletionRequestDisplay
+* @apiSuccess (Response) {string} requested
+* @apiSuccess (Response) {string} scheduledFor When the account and its data will be deleted.
+ */
+type DeletionRequestDisplay struct {
+	Requested    time.Time `json:"requested"`
+	ScheduledFor time.Time `json:"scheduledFor"`
+}
+
+func (request *DeletionRequest) GetDeletionRequestDisplay() *DeletionRequestDisplay {
+	return &DeletionRequestDisplay{
+		Requested:    request.Requested,
+		ScheduledFor: request.ScheduledFor,
+	}
+}
+
+/**
+* @apiDefine DeletionRequestAdminDisplay
+* @apiSuccess (Response) {number} userId
+* @apiSuccess (Response) {string} requested
+* @apiSuccess (Response) {string} scheduledFor
+* @apiSuccess (Response) {number} attempts
+* @apiSuccess (Response) {string} lastError Why the last attempt to delete the user failed.
+ */
+type DeletionRequestAdminDisplay struct {
+	UserId       int64     `json:"userId"`
+	Requested    time.Time `json:"requested"`
+	ScheduledFor time.Time `json:"scheduledFor"`
+	Attempts     int64     `json:"attempts"`
+	LastError    string    `json:"lastError"`
+}
+
+func (request *DeletionRequest) GetDeletionRequestAdminDisplay() *DeletionRequestAdminDisplay {
+	return &DeletionRequestAdminDisplay{
+		UserId:       request.UserId,
+		Requested:    request.Requested,
+		ScheduledFor: request.ScheduledFor,
+		Attempts:     request.Attempts,
+		LastError:    request.LastError,
+	}
+}
+
+// ProfileExport is profile.json in the export archive.
+type ProfileExport struct {
+	Id           int64                 `json:"id"`
+	FullName     string                `json:"fullName"`
+	Gender       int64                 `json:"gender"`
+	Photo        string                `json:"photo,omitempty"`
+	Photos       map[string]string     `json:"photos,omitempty"`
+	MinAge       int64                 `json:"minAge"`
+	MaxAge       int64                 `json:"maxAge"`
+	Enabled      bool                  `json:"enabled"`
+	Created      time.Time             `json:"created"`
+	LastModified time.Time             `json:"lastModified"`
+	Profile      profile_model.Profile `json:"profile"`
+}
+
+// SessionsExport is sessions.json in the export archive. GoCMS tokens aren't stored, so it lists the long lived
+// credentials and impersonations instead.
+type SessionsExport struct {
+	ApiKeys        []*api_key_model.ApiKeyDisplay       `json:"apiKeys"`
+	Impersonations []*impersonation_model.Impersonation `json:"impersonations"`
+}
+
+// Export is everything in the export archive.
+type Export struct {
+	Profile  *ProfileExport
+	Emails   []*email_model.EmailDisplay
+	Groups   []*group_model.GroupDisplay
+	Sessions *SessionsExport
+	Audit    []log_model.AuditLog
+	// Plugins are the raw JSON documents returned by the plugin export hooks by plugin id
+	Plugins map[string][]byte
+	// PluginErrors by plugin id, when a plugin export hook failed
+	PluginErrors map[string]string
+}
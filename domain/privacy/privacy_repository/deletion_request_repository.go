@@ -0,0 +1,109 @@
+package privacy_repository
+
+import (
+	"time"
+
+	"github.com/cqlcorp/gocms/domain/privacy/privacy_model"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/jmoiron/sqlx"
+)
+
+type IDeletionRequestRepository interface {
+	Upsert(*privacy_model.DeletionRequest) error
+	Get(userId int64) (*privacy_model.DeletionRequest, error)
+	Delete(userId int64) error
+	GetDue(before time.Time, maxAttempts int64) ([]*privacy_model.DeletionRequest, error)
+	GetFailed(maxAttempts int64) ([]*privacy_model.DeletionRequest, error)
+	RecordFailure(userId int64, message string) error
+	ResetAttempts(userId int64) error
+}
+
+type DeletionRequestRepository struct {
+	database *sqlx.DB
+}
+
+func DefaultDeletionRequestRepository(dbx *sqlx.DB) *DeletionRequestRepository {
+	deletionRequestRepository := &DeletionRequestRepository{
+		database: dbx,
+	}
+	return deletionRequestRepository
+}
+
+// Upsert adds the request, or reschedules an existing one.
+func (drr *DeletionRequestRepository) Upsert(request *privacy_model.DeletionRequest) error {
+	_, err := drr.database.NamedExec(`
+	INSERT INTO gocms_deletion_requests (userId, requested, scheduledFor) VALUES (:userId, :requested, :scheduledFor)
+	ON DUPLICATE KEY UPDATE requested=VALUES(requested), scheduledFor=VALUES(scheduledFor), attempts=0, lastError=''
+	`, request)
+	if err != nil {
+		log.Errorf("Error adding deletion request for user %v: %s\n", request.UserId, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (drr *DeletionRequestRepository) Get(userId int64) (*privacy_model.DeletionRequest, error) {
+	var request privacy_model.DeletionRequest
+	err := drr.database.Get(&request, "SELECT * FROM gocms_deletion_requests WHERE userId=?", userId)
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (drr *DeletionRequestRepository) Delete(userId int64) error {
+	_, err := drr.database.Exec("DELETE FROM gocms_deletion_requests WHERE userId=?", userId)
+	if err != nil {
+		log.Errorf("Error deleting deletion request for user %v: %s\n", userId, err.Error())
+		return err
+	}
+	return nil
+}
+
+// GetDue returns the requests scheduled at or before the time that failed fewer than maxAttempts times, oldest first.
+func (drr *DeletionRequestRepository) GetDue(before time.Time, maxAttempts int64) ([]*privacy_model.DeletionRequest, error) {
+	var requests []*privacy_model.DeletionRequest
+	err := drr.database.Select(&requests, `
+	SELECT * FROM gocms_deletion_requests WHERE scheduledFor<=? AND attempts<? ORDER BY scheduledFor
+	`, before, maxAttempts)
+	if err != nil {
+		log.Errorf("Error getting due deletion requests: %s\n", err.Error())
+		return nil, err
+	}
+	return requests, nil
+}
+
+// GetFailed returns the requests that failed maxAttempts times or more, oldest first.
+func (drr *DeletionRequestRepository) GetFailed(maxAttempts int64) ([]*privacy_model.DeletionRequest, error) {
+	var requests []*privacy_model.DeletionRequest
+	err := drr.database.Select(&requests, `
+	SELECT * FROM gocms_deletion_requests WHERE attempts>=? ORDER BY scheduledFor
+	`, maxAttempts)
+	if err != nil {
+		log.Errorf("Error getting failed deletion requests: %s\n", err.Error())
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (drr *DeletionRequestRepository) RecordFailure(userId int64, message string) error {
+	_, err := drr.database.Exec(`
+	UPDATE gocms_deletion_requests SET attempts=attempts+1, lastError=? WHERE userId=?
+	`, message, userId)
+	if err != nil {
+		log.Errorf("Error recording failed deletion of user %v: %s\n", userId, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (drr *DeletionRequestRepository) ResetAttempts(userId int64) error {
+	_, err := drr.database.Exec(`
+	UPDATE gocms_deletion_requests SET attempts=0, lastError='' WHERE userId=?
+	`, userId)
+	if err != nil {
+		log.Errorf("Error resetting deletion attempts of user %v: %s\n", userId, err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package privacy_service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cqlcorp/gocms/domain/privacy/privacy_model"
+)
+
+const exportReadme = `This archive contains the data stored about your account.
+
+profile.json        your account details and custom profile fields
+emails.json         your email addresses
+groups.json         the groups you belong to
+sessions.json       your api keys and admin impersonations of your account
+audit.json          security related actions on your account
+plugins/            data stored by plugins, one file per plugin
+plugin_errors.json  plugins that couldn't export their data, if any
+`
+
+// buildArchive writes the export as a zip of JSON documents.
+func buildArchive(export *privacy_model.Export) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"emails.json", export.Emails},
+		{"groups.json", export.Groups},
+		{"sessions.json", export.Sessions},
+		{"audit.json", export.Audit},
+	}
+
+	if err := writeFile(archive, "README.txt", []byte(exportReadme)); err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFile(archive, file.name, data); err != nil {
+			return nil, err
+		}
+	}
+
+	// sorted so archives of the same data are identical
+	pluginIds := make([]string, 0, len(export.Plugins))
+	for pluginId := range export.Plugins {
+		pluginIds = append(pluginIds, pluginId)
+	}
+	sort.Strings(pluginIds)
+	for _, pluginId := range pluginIds {
+		if err := writeFile(archive, fmt.Sprintf("plugins/%v.json", pluginId), export.Plugins[pluginId]); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(export.PluginErrors) > 0 {
+		data, err := json.MarshalIndent(export.PluginErrors, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFile(archive, "plugin_errors.json", data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeFile(archive *zip.Writer, name string, data []byte) error {
+	w, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,72 @@
+package privacy_service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/cqlcorp/gocms/context/consts"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_model"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_proxies/plugin_user_context"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/utility/errors"
+)
+
+const (
+	hookTimeout = 30 * time.Second
+	// plugin exports larger than this are rejected to keep the archive in memory
+	maxHookResponse = 32 * 1024 * 1024
+)
+
+var hookClient = &http.Client{Timeout: hookTimeout}
+
+// callPluginHook posts the user id to a user data hook of the plugin, with the user context header so the plugin can
+// verify the request came from GoCMS. The response body is returned for export hooks and must be JSON.
+func callPluginHook(plugin *plugin_model.Plugin, hookUrl string, user *user_model.User) ([]byte, error) {
+	if plugin.RoutesProxy == nil {
+		return nil, errors.New("plugin isn't running")
+	}
+
+	token, err := plugin_user_context.CreateToken(user.GetUserContextHeader(), plugin.Manifest.Id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]int64{"userId": user.Id})
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := plugin.RoutesProxy
+	url := fmt.Sprintf("%v://%v:%v%v", proxy.Schema, proxy.Host, proxy.Port, hookUrl)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(consts.GOCMS_HEADER_USER_CONTEXT_KEY, token)
+
+	res, err := hookClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, maxHookResponse+1))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, errors.New(fmt.Sprintf("hook responded with status %v", res.StatusCode))
+	}
+	if len(data) > maxHookResponse {
+		return nil, errors.New("hook response is too large")
+	}
+	if len(data) > 0 && !json.Valid(data) {
+		return nil, errors.New("hook response isn't JSON")
+	}
+	return data, nil
+}
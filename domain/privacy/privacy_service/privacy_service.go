@@ -0,0 +1,313 @@
+package privacy_service
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_model"
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_service"
+	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"github.com/cqlcorp/gocms/domain/acl/group/group_service"
+	"github.com/cqlcorp/gocms/domain/email/email_model"
+	"github.com/cqlcorp/gocms/domain/email/email_service"
+	"github.com/cqlcorp/gocms/domain/logs/log_model"
+	"github.com/cqlcorp/gocms/domain/logs/log_service"
+	"github.com/cqlcorp/gocms/domain/mail/mail_model"
+	"github.com/cqlcorp/gocms/domain/mail/mail_service"
+	"github.com/cqlcorp/gocms/domain/photo/photo_service"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_services"
+	"github.com/cqlcorp/gocms/domain/privacy/privacy_model"
+	"github.com/cqlcorp/gocms/domain/profile/profile_service"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/domain/user/user_service"
+	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/log"
+)
+
+// the length of the lastError column
+const maxErrorLength = 255
+
+// deletions are retried hourly, after a day of failures they wait for an admin to fix the cause and retry them
+const maxDeletionAttempts = 24
+
+type IPrivacyService interface {
+	Export(user *user_model.User, actorId int64) ([]byte, error)
+	RequestDeletion(user *user_model.User) (*privacy_model.DeletionRequest, error)
+	GetDeletion(userId int64) (*privacy_model.DeletionRequest, error)
+	CancelDeletion(userId int64) error
+	DeleteUser(user *user_model.User, actorId int64) error
+	ProcessDueDeletions()
+	GetFailedDeletions() ([]*privacy_model.DeletionRequest, error)
+	RetryDeletion(userId int64) error
+	PurgeDeletedUsers()
+}
+
+type PrivacyService struct {
+	RepositoriesGroup *repository.RepositoriesGroup
+	UserService       user_service.IUserService
+	EmailService      email_service.IEmailService
+	GroupService      group_service.IGroupService
+	ApiKeyService     api_key_service.IApiKeyService
+	ProfileService    profile_service.IProfileService
+	PhotoService      photo_service.IPhotoService
+	LogService        log_service.ILogService
+	MailService       mail_service.IMailService
+	PluginsService    plugin_services.IPluginsService
+}
+
+func DefaultPrivacyService(rg *repository.RepositoriesGroup, userService user_service.IUserService, emailService email_service.IEmailService,
+	groupService group_service.IGroupService, apiKeyService api_key_service.IApiKeyService, profileService profile_service.IProfileService,
+	photoService photo_service.IPhotoService, logService log_service.ILogService, mailService mail_service.IMailService,
+	pluginsService plugin_services.IPluginsService) *PrivacyService {
+
+	privacyService := &PrivacyService{
+		RepositoriesGroup: rg,
+		UserService:       userService,
+		EmailService:      emailService,
+		GroupService:      groupService,
+		ApiKeyService:     apiKeyService,
+		ProfileService:    profileService,
+		PhotoService:      photoService,
+		LogService:        logService,
+		MailService:       mailService,
+		PluginsService:    pluginsService,
+	}
+	return privacyService
+}
+
+// Export builds a zip archive of everything GoCMS and the active plugins store about the user. ActorId is the user
+// requesting the export, for the audit log.
+func (ps *PrivacyService) Export(user *user_model.User, actorId int64) ([]byte, error) {
+	export := &privacy_model.Export{
+		Plugins:      make(map[string][]byte),
+		PluginErrors: make(map[string]string),
+	}
+
+	// every custom profile field, including admin only ones, is the users data
+	profile, err := ps.ProfileService.GetProfile(user.Id, true)
+	if err != nil {
+		return nil, err
+	}
+	export.Profile = &privacy_model.ProfileExport{
+		Id:           user.Id,
+		FullName:     user.FullName,
+		Gender:       user.Gender,
+		Photo:        user.Photo,
+		Photos:       user.GetPhotoVariants(),
+		MinAge:       user.MinAge,
+		MaxAge:       user.MaxAge,
+		Enabled:      user.Enabled,
+		Created:      user.Created,
+		LastModified: user.LastModified,
+		Profile:      profile,
+	}
+
+	emails, err := ps.EmailService.GetEmailsByUserId(user.Id)
+	if err != nil {
+		return nil, err
+	}
+	export.Emails = make([]*email_model.EmailDisplay, len(emails))
+	for i := range emails {
+		export.Emails[i] = emails[i].GetEmailDisplay()
+	}
+
+	groups, err := ps.GroupService.GetUserGroups(user.Id)
+	if err != nil {
+		return nil, err
+	}
+	export.Groups = make([]*group_model.GroupDisplay, len(groups))
+	for i, group := range groups {
+		export.Groups[i] = group.GetGroupDisplay()
+	}
+
+	apiKeys, err := ps.ApiKeyService.GetByUserId(user.Id)
+	if err != nil {
+		return nil, err
+	}
+	impersonations, err := ps.RepositoriesGroup.ImpersonationRepository.GetByUserId(user.Id)
+	if err != nil {
+		return nil, err
+	}
+	export.Sessions = &privacy_model.SessionsExport{
+		ApiKeys:        make([]*api_key_model.ApiKeyDisplay, len(apiKeys)),
+		Impersonations: impersonations,
+	}
+	for i, apiKey := range apiKeys {
+		export.Sessions.ApiKeys[i] = apiKey.GetApiKeyDisplay()
+	}
+
+	export.Audit, err = ps.LogService.GetAuditForUser(user.Id)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// a failing plugin is noted in the archive instead of failing the export
+	for pluginId, plugin := range ps.PluginsService.GetActivePlugins() {
+		hooks := plugin.Manifest.Services.UserData
+		if hooks == nil || hooks.Export == "" {
+			continue
+		}
+		data, err := callPluginHook(plugin, hooks.Export, user)
+		if err != nil {
+			export.PluginErrors[pluginId] = err.Error()
+			continue
+		}
+		export.Plugins[pluginId] = data
+	}
+
+	archive, err := buildArchive(export)
+	if err != nil {
+		return nil, err
+	}
+
+	ps.LogService.RecordAudit(user.Id, actorId, log_model.Audit_Data_Export, "")
+	return archive, nil
+}
+
+// RequestDeletion schedules the user to be deleted after ACCOUNT_DELETION_GRACE_DAYS and emails them how to cancel.
+func (ps *PrivacyService) RequestDeletion(user *user_model.User) (*privacy_model.DeletionRequest, error) {
+	now := time.Now()
+	request := &privacy_model.DeletionRequest{
+		UserId:       user.Id,
+		Requested:    now,
+		ScheduledFor: now.AddDate(0, 0, int(context.Config.DbVars.AccountDeletionGraceDays)),
+	}
+	err := ps.RepositoriesGroup.DeletionRequestRepository.Upsert(request)
+	if err != nil {
+		return nil, err
+	}
+	ps.LogService.RecordAudit(user.Id, user.Id, log_model.Audit_Deletion_Request, request.ScheduledFor.Format(time.RFC3339))
+
+	scheduledStr := request.ScheduledFor.Format("01/02/2006 03:04 pm")
+	err = ps.MailService.SendTemplate(user.Email, mail_model.Template_Deletion_Scheduled, user.Locale, map[string]interface{}{
+		"scheduled": scheduledStr,
+	})
+	if err != nil { // log error but don't fail, the request is already scheduled
+		log.Errorf("Error sending deletion notice to user %v: %s\n", user.Id, err.Error())
+	}
+
+	return request, nil
+}
+
+func (ps *PrivacyService) GetDeletion(userId int64) (*privacy_model.DeletionRequest, error) {
+	return ps.RepositoriesGroup.DeletionRequestRepository.Get(userId)
+}
+
+// CancelDeletion returns sql.ErrNoRows when no deletion is scheduled.
+func (ps *PrivacyService) CancelDeletion(userId int64) error {
+	_, err := ps.RepositoriesGroup.DeletionRequestRepository.Get(userId)
+	if err != nil {
+		return err
+	}
+	err = ps.RepositoriesGroup.DeletionRequestRepository.Delete(userId)
+	if err != nil {
+		return err
+	}
+	ps.LogService.RecordAudit(userId, userId, log_model.Audit_Deletion_Cancel, "")
+	return nil
+}
+
+// DeleteUser erases the user now. Plugins are asked to erase their data first and the user is kept if one fails, so
+// no plugin is left with data of a user that no longer exists. The audit log is kept, it only references the user id.
+func (ps *PrivacyService) DeleteUser(user *user_model.User, actorId int64) error {
+	for pluginId, plugin := range ps.PluginsService.GetActivePlugins() {
+		hooks := plugin.Manifest.Services.UserData
+		if hooks == nil || hooks.Delete == "" {
+			continue
+		}
+		if _, err := callPluginHook(plugin, hooks.Delete, user); err != nil {
+			return fmt.Errorf("plugin %v couldn't delete the users data: %v", pluginId, err.Error())
+		}
+	}
+
+	// uploaded files aren't removed with the database rows
+	if user.PhotoKey != "" {
+		if err := ps.PhotoService.DeleteUserPhoto(user); err != nil {
+			return err
+		}
+	}
+
+	err := ps.UserService.Delete(user.Id)
+	if err != nil {
+		return err
+	}
+
+	ps.LogService.RecordAudit(user.Id, actorId, log_model.Audit_Deletion_Complete, "")
+	return nil
+}
+
+// ProcessDueDeletions deletes the users whose grace period is over. Failed deletions are retried on the next run until
+// they failed maxDeletionAttempts times, then they are listed for admins by GetFailedDeletions.
+func (ps *PrivacyService) ProcessDueDeletions() {
+	requests, err := ps.RepositoriesGroup.DeletionRequestRepository.GetDue(time.Now(), maxDeletionAttempts)
+	if err != nil {
+		return
+	}
+
+	for _, request := range requests {
+		user, err := ps.UserService.Get(request.UserId)
+		if err != nil {
+			log.Errorf("Error getting user %v scheduled for deletion: %s\n", request.UserId, err.Error())
+			ps.RepositoriesGroup.DeletionRequestRepository.RecordFailure(request.UserId, truncate(err.Error()))
+			continue
+		}
+
+		err = ps.DeleteUser(user, user.Id)
+		if err != nil {
+			log.Errorf("Error deleting user %v, attempt %v: %s\n", user.Id, request.Attempts+1, err.Error())
+			ps.RepositoriesGroup.DeletionRequestRepository.RecordFailure(request.UserId, truncate(err.Error()))
+			if request.Attempts+1 >= maxDeletionAttempts {
+				log.Errorf("Giving up deleting user %v after %v attempts, an admin needs to retry the deletion\n", user.Id, maxDeletionAttempts)
+			}
+			continue
+		}
+		log.Infof("Deleted user %v as they requested on %v\n", user.Id, request.Requested.Format(time.RFC3339))
+	}
+}
+
+// GetFailedDeletions returns the deletions that are no longer retried because they failed too often.
+func (ps *PrivacyService) GetFailedDeletions() ([]*privacy_model.DeletionRequest, error) {
+	return ps.RepositoriesGroup.DeletionRequestRepository.GetFailed(maxDeletionAttempts)
+}
+
+// RetryDeletion resets the attempts of a failed deletion so it is tried again on the next run.
+func (ps *PrivacyService) RetryDeletion(userId int64) error {
+	_, err := ps.RepositoriesGroup.DeletionRequestRepository.Get(userId)
+	if err != nil {
+		return err
+	}
+	return ps.RepositoriesGroup.DeletionRequestRepository.ResetAttempts(userId)
+}
+
+// PurgeDeletedUsers permanently deletes the users an admin deleted more than USER_DELETED_RETENTION_DAYS ago. Users
+// that fail are retried on the next run.
+func (ps *PrivacyService) PurgeDeletedUsers() {
+	before := time.Now().AddDate(0, 0, -int(context.Config.DbVars.UserDeletedRetentionDays))
+	users, err := ps.RepositoriesGroup.UsersRepository.GetDeletedBefore(before)
+	if err != nil {
+		return
+	}
+
+	for _, user := range users {
+		err := ps.DeleteUser(user, user.DeletedBy)
+		if err != nil {
+			log.Errorf("Error purging deleted user %v: %s\n", user.Id, err.Error())
+			continue
+		}
+		log.Infof("Purged user %v deleted on %v\n", user.Id, user.Deleted.Format(time.RFC3339))
+	}
+}
+
+func truncate(message string) string {
+	if len(message) <= maxErrorLength {
+		return message
+	}
+	cut := maxErrorLength
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+	return message[:cut]
+}
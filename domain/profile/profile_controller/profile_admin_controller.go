@@ -0,0 +1,177 @@
+package profile_controller
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/domain/profile/profile_model"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/sqlUtl"
+	"github.com/gin-gonic/gin"
+)
+
+type ProfileAdminController struct {
+	routes        *routes.Routes
+	servicesGroup *service.ServicesGroup
+	adminRoutes   *access_control_middleware.PermissionGroup
+}
+
+func DefaultProfileAdminController(routes *routes.Routes, sg *service.ServicesGroup) *ProfileAdminController {
+	profileAdminController := &ProfileAdminController{
+		routes:        routes,
+		servicesGroup: sg,
+		adminRoutes:   access_control_middleware.NewPermissionGroup(routes.Auth, "/admin/profile", sg.AclService, permissions.SUPER_ADMIN),
+	}
+
+	profileAdminController.Default()
+	return profileAdminController
+}
+
+func (pac *ProfileAdminController) Default() {
+	pac.adminRoutes.GET("/field", pac.getFields)
+	pac.adminRoutes.POST("/field", pac.addField)
+	pac.adminRoutes.PUT("/field/:fieldId", pac.updateField)
+	pac.adminRoutes.DELETE("/field/:fieldId", pac.deleteField)
+}
+
+/**
+* @api {get} /admin/profile/field Get Profile Fields
+* @apiName GetProfileFields
+* @apiGroup Admin Profile
+* @apiDescription Every custom profile field, including admin only fields and fields declared by plugins.
+*
+* @apiUse AuthHeader
+* @apiSuccess (Response) {ProfileField[]} fields See ProfileField.
+* @apiPermission Admin
+ */
+func (pac *ProfileAdminController) getFields(c *gin.Context) {
+
+	fields, err := pac.servicesGroup.ProfileService.GetFields()
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get profile fields.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, fields)
+}
+
+/**
+* @api {post} /admin/profile/field Add Profile Field
+* @apiName AddProfileField
+* @apiGroup Admin Profile
+*
+* @apiUse AuthHeader
+* @apiUse ProfileFieldInput
+* @apiUse ProfileField
+* @apiPermission Admin
+ */
+func (pac *ProfileAdminController) addField(c *gin.Context) {
+
+	var fieldInput profile_model.ProfileFieldInput
+	err := c.BindJSON(&fieldInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	field := fieldInput.NewField()
+	err = pac.servicesGroup.ProfileService.AddField(field)
+	if err != nil {
+		if sqlUtl.ErrDupEtry(err) {
+			errors.Response(c, http.StatusBadRequest, "A profile field with this name already exists.", err)
+			return
+		}
+		errors.Response(c, http.StatusBadRequest, "Couldn't add profile field.", err)
+		return
+	}
+
+	field, err = pac.servicesGroup.ProfileService.GetField(field.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get profile field.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, field)
+}
+
+/**
+* @api {put} /admin/profile/field/:fieldId Update Profile Field
+* @apiName UpdateProfileField
+* @apiGroup Admin Profile
+* @apiDescription The name and type of a field can't be changed. Fields declared by plugins can only be changed in the
+* plugin manifest.
+*
+* @apiUse AuthHeader
+* @apiUse ProfileFieldInput
+* @apiUse ProfileField
+* @apiPermission Admin
+ */
+func (pac *ProfileAdminController) updateField(c *gin.Context) {
+
+	fieldId, err := strconv.ParseInt(c.Param("fieldId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "fieldId is missing or not an integer", err)
+		return
+	}
+
+	var fieldInput profile_model.ProfileFieldInput
+	err = c.BindJSON(&fieldInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Fields", err)
+		return
+	}
+
+	field := fieldInput.NewField()
+	err = pac.servicesGroup.ProfileService.UpdateField(fieldId, field)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errors.Response(c, http.StatusNotFound, "Profile field not found.", err)
+			return
+		}
+		errors.Response(c, http.StatusBadRequest, "Couldn't update profile field.", err)
+		return
+	}
+
+	field, err = pac.servicesGroup.ProfileService.GetField(fieldId)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get profile field.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, field)
+}
+
+/**
+* @api {delete} /admin/profile/field/:fieldId Delete Profile Field
+* @apiName DeleteProfileField
+* @apiGroup Admin Profile
+* @apiDescription Deletes the field and every users value for it.
+*
+* @apiUse AuthHeader
+* @apiPermission Admin
+ */
+func (pac *ProfileAdminController) deleteField(c *gin.Context) {
+
+	fieldId, err := strconv.ParseInt(c.Param("fieldId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "fieldId is missing or not an integer", err)
+		return
+	}
+
+	err = pac.servicesGroup.ProfileService.DeleteField(fieldId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errors.Response(c, http.StatusNotFound, "Profile field not found.", err)
+			return
+		}
+		errors.Response(c, http.StatusBadRequest, "Couldn't delete profile field.", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
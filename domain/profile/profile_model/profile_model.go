@@ -0,0 +1,141 @@
+package profile_model
+
+import (
+	"time"
+)
+
+const (
+	ApiError_Profile = "Some profile fields are invalid."
+)
+
+const (
+	FIELD_TYPE_STRING  = "string"
+	FIELD_TYPE_NUMBER  = "number"
+	FIELD_TYPE_BOOLEAN = "boolean"
+	FIELD_TYPE_DATE    = "date"
+	FIELD_TYPE_SELECT  = "select"
+)
+
+const (
+	// VISIBILITY_USER fields are shown to the user and admins.
+	VISIBILITY_USER = "user"
+	// VISIBILITY_ADMIN fields are only shown to admins.
+	VISIBILITY_ADMIN = "admin"
+)
+
+const (
+	EDITABLE_BY_USER  = "user"
+	EDITABLE_BY_ADMIN = "admin"
+)
+
+// DATE_LAYOUT is the format of date field values.
+const DATE_LAYOUT = "2006-01-02"
+
+/**
+* @apiDefine ProfileField
+* @apiSuccess (Response) {number} id
+* @apiSuccess (Response) {string} name Key of the value in the profile. Plugin fields are prefixed with the plugin id.
+* @apiSuccess (Response) {string} label
+* @apiSuccess (Response) {string} description
+* @apiSuccess (Response) {string} type string, number, boolean, date or select.
+* @apiSuccess (Response) {boolean} required
+* @apiSuccess (Response) {string[]} [options] Allowed values of a select field.
+* @apiSuccess (Response) {number} [min] Minimum number, or minimum length of a string.
+* @apiSuccess (Response) {number} [max] Maximum number, or maximum length of a string.
+* @apiSuccess (Response) {string} [pattern] Regular expression string values must match.
+* @apiSuccess (Response) {string} visibility user or admin.
+* @apiSuccess (Response) {string} editableBy user or admin.
+* @apiSuccess (Response) {boolean} includeInContext Sent to plugins in the user context.
+* @apiSuccess (Response) {string} [pluginId] Plugin that declared the field.
+* @apiSuccess (Response) {string} created
+ */
+type ProfileField struct {
+	Id               int64     `json:"id" db:"id"`
+	Name             string    `json:"name" db:"name"`
+	Label            string    `json:"label" db:"label"`
+	Description      string    `json:"description" db:"description"`
+	Type             string    `json:"type" db:"type"`
+	Required         bool      `json:"required" db:"required"`
+	Options          []string  `json:"options,omitempty" db:"-"`
+	OptionsData      string    `json:"-" db:"options"`
+	Min              *float64  `json:"min,omitempty" db:"min"`
+	Max              *float64  `json:"max,omitempty" db:"max"`
+	Pattern          string    `json:"pattern,omitempty" db:"pattern"`
+	Visibility       string    `json:"visibility" db:"visibility"`
+	EditableBy       string    `json:"editableBy" db:"editableBy"`
+	IncludeInContext bool      `json:"includeInContext" db:"includeInContext"`
+	PluginId         string    `json:"pluginId,omitempty" db:"pluginId"`
+	Created          time.Time `json:"created" db:"created"`
+}
+
+/**
+* @apiDefine ProfileFieldInput
+* @apiParam (Request) {string} name Letters, numbers, dashes and underscores.
+* @apiParam (Request) {string} label
+* @apiParam (Request) {string} [description]
+* @apiParam (Request) {string} type string, number, boolean, date or select.
+* @apiParam (Request) {boolean} [required=false]
+* @apiParam (Request) {string[]} [options] Required for select fields.
+* @apiParam (Request) {number} [min] Minimum number, or minimum length of a string.
+* @apiParam (Request) {number} [max] Maximum number, or maximum length of a string.
+* @apiParam (Request) {string} [pattern] Regular expression string values must match.
+* @apiParam (Request) {string} [visibility=user] user or admin.
+* @apiParam (Request) {string} [editableBy=user] user or admin. Admin only fields can't be edited by the user.
+* @apiParam (Request) {boolean} [includeInContext=false] Send the value to plugins in the user context.
+ */
+type ProfileFieldInput struct {
+	Name             string   `json:"name"`
+	Label            string   `json:"label"`
+	Description      string   `json:"description"`
+	Type             string   `json:"type"`
+	Required         bool     `json:"required"`
+	Options          []string `json:"options"`
+	Min              *float64 `json:"min"`
+	Max              *float64 `json:"max"`
+	Pattern          string   `json:"pattern"`
+	Visibility       string   `json:"visibility"`
+	EditableBy       string   `json:"editableBy"`
+	IncludeInContext bool     `json:"includeInContext"`
+}
+
+// ProfileValue is the stored value of a field for a user. Values are stored as text and converted by field type.
+type ProfileValue struct {
+	UserId  int64  `db:"userId"`
+	FieldId int64  `db:"fieldId"`
+	Value   string `db:"value"`
+}
+
+/**
+* @apiDefine Profile
+* @apiSuccess (Response) {Object} profile Custom profile field values by field name. Numbers and booleans are typed,
+* dates are strings like 2006-01-02. Fields without a value are left out.
+ */
+type Profile map[string]interface{}
+
+// NewField builds a field from the input.
+func (input *ProfileFieldInput) NewField() *ProfileField {
+	return &ProfileField{
+		Name:             input.Name,
+		Label:            input.Label,
+		Description:      input.Description,
+		Type:             input.Type,
+		Required:         input.Required,
+		Options:          input.Options,
+		Min:              input.Min,
+		Max:              input.Max,
+		Pattern:          input.Pattern,
+		Visibility:       input.Visibility,
+		EditableBy:       input.EditableBy,
+		IncludeInContext: input.IncludeInContext,
+	}
+}
+
+// VisibleTo reports if the field is shown to the user or only to admins.
+func (field *ProfileField) VisibleTo(admin bool) bool {
+	return admin || field.Visibility != VISIBILITY_ADMIN
+}
+
+// EditableByUser reports if the user can change their own value.
+func (field *ProfileField) EditableByUser() bool {
+	return field.EditableBy != EDITABLE_BY_ADMIN && field.Visibility != VISIBILITY_ADMIN
+}
@@ -0,0 +1,182 @@
+package profile_repository
+
+import (
+	"encoding/json"
+
+	"github.com/cqlcorp/gocms/domain/profile/profile_model"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/jmoiron/sqlx"
+)
+
+type IProfileRepository interface {
+	GetFields() ([]*profile_model.ProfileField, error)
+	GetField(int64) (*profile_model.ProfileField, error)
+	AddField(*profile_model.ProfileField) error
+	UpsertField(*profile_model.ProfileField) error
+	UpdateField(*profile_model.ProfileField) error
+	DeleteField(int64) error
+
+	GetValues(userId int64) ([]*profile_model.ProfileValue, error)
+	UpdateValues(userId int64, toSet map[int64]string, toDelete []int64) error
+}
+
+type ProfileRepository struct {
+	database *sqlx.DB
+}
+
+func DefaultProfileRepository(dbx *sqlx.DB) *ProfileRepository {
+	profileRepository := &ProfileRepository{
+		database: dbx,
+	}
+	return profileRepository
+}
+
+func (pr *ProfileRepository) GetFields() ([]*profile_model.ProfileField, error) {
+	var fields []*profile_model.ProfileField
+	err := pr.database.Select(&fields, "SELECT * FROM gocms_profile_fields ORDER BY id")
+	if err != nil {
+		log.Errorf("Error getting profile fields from database: %s\n", err.Error())
+		return nil, err
+	}
+	return parseOptions(fields), nil
+}
+
+func (pr *ProfileRepository) GetField(id int64) (*profile_model.ProfileField, error) {
+	var field profile_model.ProfileField
+	err := pr.database.Get(&field, "SELECT * FROM gocms_profile_fields WHERE id=?", id)
+	if err != nil {
+		return nil, err
+	}
+	return parseOptions([]*profile_model.ProfileField{&field})[0], nil
+}
+
+func (pr *ProfileRepository) AddField(field *profile_model.ProfileField) error {
+	if err := marshalOptions(field); err != nil {
+		return err
+	}
+	result, err := pr.database.NamedExec(`
+	INSERT INTO gocms_profile_fields (name, label, description, type, required, options, min, max, pattern, visibility, editableBy, includeInContext, pluginId)
+	VALUES (:name, :label, :description, :type, :required, :options, :min, :max, :pattern, :visibility, :editableBy, :includeInContext, :pluginId)
+	`, field)
+	if err != nil {
+		log.Errorf("Error adding profile field %v to database: %s\n", field.Name, err.Error())
+		return err
+	}
+	id, _ := result.LastInsertId()
+	field.Id = id
+	return nil
+}
+
+// UpsertField adds the field or updates the definition if one with the same name exists
+func (pr *ProfileRepository) UpsertField(field *profile_model.ProfileField) error {
+	if err := marshalOptions(field); err != nil {
+		return err
+	}
+	result, err := pr.database.NamedExec(`
+	INSERT INTO gocms_profile_fields (name, label, description, type, required, options, min, max, pattern, visibility, editableBy, includeInContext, pluginId)
+	VALUES (:name, :label, :description, :type, :required, :options, :min, :max, :pattern, :visibility, :editableBy, :includeInContext, :pluginId)
+	ON DUPLICATE KEY UPDATE label=VALUES(label), description=VALUES(description), type=VALUES(type), required=VALUES(required),
+		options=VALUES(options), min=VALUES(min), max=VALUES(max), pattern=VALUES(pattern), visibility=VALUES(visibility),
+		editableBy=VALUES(editableBy), includeInContext=VALUES(includeInContext), pluginId=VALUES(pluginId), id=LAST_INSERT_ID(id)
+	`, field)
+	if err != nil {
+		log.Errorf("Error upserting profile field %v: %s\n", field.Name, err.Error())
+		return err
+	}
+	id, _ := result.LastInsertId()
+	field.Id = id
+	return nil
+}
+
+func (pr *ProfileRepository) UpdateField(field *profile_model.ProfileField) error {
+	if err := marshalOptions(field); err != nil {
+		return err
+	}
+	_, err := pr.database.NamedExec(`
+	UPDATE gocms_profile_fields SET label=:label, description=:description, required=:required, options=:options, min=:min,
+		max=:max, pattern=:pattern, visibility=:visibility, editableBy=:editableBy, includeInContext=:includeInContext
+	WHERE id=:id
+	`, field)
+	if err != nil {
+		log.Errorf("Error updating profile field %v in database: %s\n", field.Id, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (pr *ProfileRepository) DeleteField(id int64) error {
+	_, err := pr.database.Exec("DELETE FROM gocms_profile_fields WHERE id=?", id)
+	if err != nil {
+		log.Errorf("Error deleting profile field %v from database: %s\n", id, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (pr *ProfileRepository) GetValues(userId int64) ([]*profile_model.ProfileValue, error) {
+	var values []*profile_model.ProfileValue
+	err := pr.database.Select(&values, "SELECT userId, fieldId, value FROM gocms_profile_values WHERE userId=?", userId)
+	if err != nil {
+		log.Errorf("Error getting profile values for user %v from database: %s\n", userId, err.Error())
+		return nil, err
+	}
+	return values, nil
+}
+
+// UpdateValues sets and deletes profile values by field id in one transaction, so either every change is stored or none.
+func (pr *ProfileRepository) UpdateValues(userId int64, toSet map[int64]string, toDelete []int64) error {
+	tx, err := pr.database.Beginx()
+	if err != nil {
+		log.Errorf("Error starting transaction to update profile values for user %v: %s\n", userId, err.Error())
+		return err
+	}
+	defer tx.Rollback()
+
+	for fieldId, value := range toSet {
+		_, err = tx.Exec(`
+		INSERT INTO gocms_profile_values (userId, fieldId, value) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE value=VALUES(value)
+		`, userId, fieldId, value)
+		if err != nil {
+			log.Errorf("Error setting profile value %v for user %v: %s\n", fieldId, userId, err.Error())
+			return err
+		}
+	}
+	for _, fieldId := range toDelete {
+		_, err = tx.Exec("DELETE FROM gocms_profile_values WHERE userId=? AND fieldId=?", userId, fieldId)
+		if err != nil {
+			log.Errorf("Error deleting profile value %v for user %v: %s\n", fieldId, userId, err.Error())
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Errorf("Error committing profile values for user %v: %s\n", userId, err.Error())
+		return err
+	}
+	return nil
+}
+
+func marshalOptions(field *profile_model.ProfileField) error {
+	options := field.Options
+	if options == nil {
+		options = []string{}
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	field.OptionsData = string(data)
+	return nil
+}
+
+func parseOptions(fields []*profile_model.ProfileField) []*profile_model.ProfileField {
+	for _, field := range fields {
+		err := json.Unmarshal([]byte(field.OptionsData), &field.Options)
+		if err != nil {
+			log.Errorf("Error parsing options of profile field %v: %v\n", field.Id, err.Error())
+		}
+	}
+	return fields
+}
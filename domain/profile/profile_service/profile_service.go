@@ -0,0 +1,354 @@
+package profile_service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cqlcorp/gocms/domain/profile/profile_model"
+	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/log"
+)
+
+// fields are cached since they are needed on every authenticated request that goes to a plugin
+const fieldsCacheLife = time.Minute
+
+var fieldNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type IProfileService interface {
+	GetFields() ([]*profile_model.ProfileField, error)
+	GetField(id int64) (*profile_model.ProfileField, error)
+	AddField(*profile_model.ProfileField) error
+	UpdateField(id int64, field *profile_model.ProfileField) error
+	DeleteField(id int64) error
+	RegisterPluginFields(pluginId string, fields []*profile_model.ProfileField) error
+
+	GetProfile(userId int64, admin bool) (profile_model.Profile, error)
+	UpdateProfile(userId int64, values map[string]interface{}, admin bool) error
+	ValidateProfile(userId int64, values map[string]interface{}, admin bool) error
+	GetContextProfile(userId int64) (profile_model.Profile, error)
+}
+
+type ProfileService struct {
+	RepositoriesGroup *repository.RepositoriesGroup
+	fields            []*profile_model.ProfileField
+	fieldsLoaded      time.Time
+	fieldsMutex       sync.RWMutex
+}
+
+func DefaultProfileService(rg *repository.RepositoriesGroup) *ProfileService {
+	profileService := &ProfileService{
+		RepositoriesGroup: rg,
+	}
+	return profileService
+}
+
+// GetFields returns every profile field, cached for a minute so changes made by other instances are picked up.
+func (ps *ProfileService) GetFields() ([]*profile_model.ProfileField, error) {
+	ps.fieldsMutex.RLock()
+	if time.Since(ps.fieldsLoaded) < fieldsCacheLife {
+		fields := ps.fields
+		ps.fieldsMutex.RUnlock()
+		return fields, nil
+	}
+	ps.fieldsMutex.RUnlock()
+
+	return ps.refreshFields()
+}
+
+func (ps *ProfileService) GetField(id int64) (*profile_model.ProfileField, error) {
+	return ps.RepositoriesGroup.ProfileRepository.GetField(id)
+}
+
+func (ps *ProfileService) AddField(field *profile_model.ProfileField) error {
+	if !fieldNameRegex.MatchString(field.Name) {
+		return errors.NewToUser("Field names can only contain letters, numbers, dashes and underscores.")
+	}
+	field.PluginId = ""
+	if err := validateField(field); err != nil {
+		return err
+	}
+
+	err := ps.RepositoriesGroup.ProfileRepository.AddField(field)
+	if err != nil {
+		return err
+	}
+	ps.refreshFields()
+	return nil
+}
+
+// UpdateField changes a field definition. The name and type can't change since stored values depend on them.
+func (ps *ProfileService) UpdateField(id int64, field *profile_model.ProfileField) error {
+	existing, err := ps.RepositoriesGroup.ProfileRepository.GetField(id)
+	if err != nil {
+		return err
+	}
+	if existing.PluginId != "" {
+		return errors.NewToUser(fmt.Sprintf("This field is managed by the %v plugin manifest.", existing.PluginId))
+	}
+
+	field.Id = id
+	field.Name = existing.Name
+	field.Type = existing.Type
+	if err := validateField(field); err != nil {
+		return err
+	}
+
+	err = ps.RepositoriesGroup.ProfileRepository.UpdateField(field)
+	if err != nil {
+		return err
+	}
+	ps.refreshFields()
+	return nil
+}
+
+// DeleteField removes a field along with every users value for it.
+func (ps *ProfileService) DeleteField(id int64) error {
+	existing, err := ps.RepositoriesGroup.ProfileRepository.GetField(id)
+	if err != nil {
+		return err
+	}
+	if existing.PluginId != "" {
+		return errors.NewToUser(fmt.Sprintf("This field is managed by the %v plugin manifest.", existing.PluginId))
+	}
+
+	err = ps.RepositoriesGroup.ProfileRepository.DeleteField(id)
+	if err != nil {
+		return err
+	}
+	ps.refreshFields()
+	return nil
+}
+
+// RegisterPluginFields upserts the fields a plugin declares. Names are namespaced by the plugin id.
+func (ps *ProfileService) RegisterPluginFields(pluginId string, fields []*profile_model.ProfileField) error {
+	for _, field := range fields {
+		if !fieldNameRegex.MatchString(field.Name) {
+			return errors.New(fmt.Sprintf("plugin %v declares an invalid profile field name '%v'", pluginId, field.Name))
+		}
+		field.Name = fmt.Sprintf("%v.%v", pluginId, field.Name)
+		field.PluginId = pluginId
+		if err := validateField(field); err != nil {
+			return errors.New(fmt.Sprintf("plugin %v declares an invalid profile field %v: %v", pluginId, field.Name, err.Error()))
+		}
+		err := ps.RepositoriesGroup.ProfileRepository.UpsertField(field)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Registered profile field %v for plugin %v\n", field.Name, pluginId)
+	}
+
+	ps.refreshFields()
+	return nil
+}
+
+// GetProfile returns the users values for the fields visible to them, or every field for admins.
+func (ps *ProfileService) GetProfile(userId int64, admin bool) (profile_model.Profile, error) {
+	return ps.getProfile(userId, func(field *profile_model.ProfileField) bool {
+		return field.VisibleTo(admin)
+	})
+}
+
+// GetContextProfile returns the values of the fields included in the user context sent to plugins. Nil without
+// querying when no field is included.
+func (ps *ProfileService) GetContextProfile(userId int64) (profile_model.Profile, error) {
+	fields, err := ps.GetFields()
+	if err != nil {
+		return nil, err
+	}
+	included := false
+	for _, field := range fields {
+		included = included || field.IncludeInContext
+	}
+	if !included {
+		return nil, nil
+	}
+
+	return ps.getProfile(userId, func(field *profile_model.ProfileField) bool {
+		return field.IncludeInContext
+	})
+}
+
+// UpdateProfile validates and stores the values by field name. Null or empty values remove the value. Nothing is
+// stored unless every value is valid and every required field the caller can edit has a value once the values are
+// merged with the stored ones.
+func (ps *ProfileService) UpdateProfile(userId int64, values map[string]interface{}, admin bool) error {
+	toSet, toDelete, err := ps.validateProfile(userId, values, admin)
+	if err != nil {
+		return err
+	}
+
+	return ps.RepositoriesGroup.ProfileRepository.UpdateValues(userId, toSet, toDelete)
+}
+
+// ValidateProfile checks the values like UpdateProfile without storing them. A userId of 0 checks the values alone,
+// for a user that doesn't exist yet, so required fields that aren't in the values aren't reported.
+func (ps *ProfileService) ValidateProfile(userId int64, values map[string]interface{}, admin bool) error {
+	_, _, err := ps.validateProfile(userId, values, admin)
+	return err
+}
+
+// validateProfile returns the values to store by field id and the ids of the fields to clear.
+func (ps *ProfileService) validateProfile(userId int64, values map[string]interface{}, admin bool) (map[int64]string, []int64, error) {
+	fields, err := ps.GetFields()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// required fields missing from the values must already have a stored value
+	stored := make(map[int64]bool)
+	if userId != 0 {
+		storedValues, err := ps.RepositoriesGroup.ProfileRepository.GetValues(userId)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, value := range storedValues {
+			stored[value.FieldId] = true
+		}
+	}
+	fieldsByName := make(map[string]*profile_model.ProfileField)
+	for _, field := range fields {
+		fieldsByName[field.Name] = field
+	}
+
+	var fieldErrors errors.FieldErrors
+	toSet := make(map[int64]string)
+	var toDelete []int64
+	for name, raw := range values {
+		key := "profile." + name
+		field, ok := fieldsByName[name]
+		if !ok || !field.VisibleTo(admin) {
+			fieldErrors.Add(key, "Unknown profile field.")
+			continue
+		}
+		if !admin && !field.EditableByUser() {
+			fieldErrors.Add(key, fmt.Sprintf("%v can only be changed by an admin.", field.Label))
+			continue
+		}
+
+		if raw == nil || raw == "" {
+			if field.Required {
+				fieldErrors.Add(key, fmt.Sprintf("%v is required.", field.Label))
+				continue
+			}
+			toDelete = append(toDelete, field.Id)
+			continue
+		}
+
+		value, message := convertValue(field, raw)
+		if message != "" {
+			fieldErrors.Add(key, message)
+			continue
+		}
+		toSet[field.Id] = value
+	}
+	if userId != 0 {
+		for _, field := range fields {
+			if !field.Required || !field.VisibleTo(admin) || (!admin && !field.EditableByUser()) {
+				continue
+			}
+			if _, ok := values[field.Name]; !ok && !stored[field.Id] {
+				fieldErrors.Add("profile."+field.Name, fmt.Sprintf("%v is required.", field.Label))
+			}
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return nil, nil, fieldErrors
+	}
+	return toSet, toDelete, nil
+}
+
+func (ps *ProfileService) getProfile(userId int64, include func(*profile_model.ProfileField) bool) (profile_model.Profile, error) {
+	fields, err := ps.GetFields()
+	if err != nil {
+		return nil, err
+	}
+	fieldsById := make(map[int64]*profile_model.ProfileField)
+	for _, field := range fields {
+		if include(field) {
+			fieldsById[field.Id] = field
+		}
+	}
+
+	values, err := ps.RepositoriesGroup.ProfileRepository.GetValues(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := profile_model.Profile{}
+	for _, value := range values {
+		if field, ok := fieldsById[value.FieldId]; ok {
+			profile[field.Name] = typedValue(field, value.Value)
+		}
+	}
+	return profile, nil
+}
+
+func (ps *ProfileService) refreshFields() ([]*profile_model.ProfileField, error) {
+	fields, err := ps.RepositoriesGroup.ProfileRepository.GetFields()
+	if err != nil {
+		return nil, err
+	}
+
+	ps.fieldsMutex.Lock()
+	ps.fields = fields
+	ps.fieldsLoaded = time.Now()
+	ps.fieldsMutex.Unlock()
+
+	return fields, nil
+}
+
+func validateField(field *profile_model.ProfileField) error {
+	switch field.Type {
+	case profile_model.FIELD_TYPE_STRING, profile_model.FIELD_TYPE_NUMBER, profile_model.FIELD_TYPE_BOOLEAN,
+		profile_model.FIELD_TYPE_DATE, profile_model.FIELD_TYPE_SELECT:
+	default:
+		return errors.NewToUser("Field type must be string, number, boolean, date or select.")
+	}
+
+	if strings.TrimSpace(field.Label) == "" {
+		field.Label = field.Name
+	}
+	if field.Visibility == "" {
+		field.Visibility = profile_model.VISIBILITY_USER
+	}
+	if field.Visibility != profile_model.VISIBILITY_USER && field.Visibility != profile_model.VISIBILITY_ADMIN {
+		return errors.NewToUser("Field visibility must be user or admin.")
+	}
+	if field.EditableBy == "" {
+		field.EditableBy = profile_model.EDITABLE_BY_USER
+	}
+	if field.EditableBy != profile_model.EDITABLE_BY_USER && field.EditableBy != profile_model.EDITABLE_BY_ADMIN {
+		return errors.NewToUser("Field editableBy must be user or admin.")
+	}
+
+	if field.Type == profile_model.FIELD_TYPE_SELECT {
+		if len(field.Options) == 0 {
+			return errors.NewToUser("Select fields need at least one option.")
+		}
+	} else {
+		field.Options = nil
+	}
+
+	if field.Type != profile_model.FIELD_TYPE_STRING && field.Type != profile_model.FIELD_TYPE_NUMBER {
+		field.Min = nil
+		field.Max = nil
+	}
+	if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+		return errors.NewToUser("Field min can't be greater than max.")
+	}
+
+	if field.Type != profile_model.FIELD_TYPE_STRING {
+		field.Pattern = ""
+	}
+	if field.Pattern != "" {
+		if _, err := regexp.Compile(field.Pattern); err != nil {
+			return errors.NewToUser(fmt.Sprintf("Field pattern isn't a valid regular expression: %v", err.Error()))
+		}
+	}
+
+	return nil
+}
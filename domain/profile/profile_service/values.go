@@ -0,0 +1,112 @@
+package profile_service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cqlcorp/gocms/domain/profile/profile_model"
+)
+
+// values are stored in a text column
+const maxValueLength = 4000
+
+// convertValue validates a value sent in a request and returns it in its stored form, or a message for the user.
+func convertValue(field *profile_model.ProfileField, raw interface{}) (string, string) {
+	switch field.Type {
+	case profile_model.FIELD_TYPE_NUMBER:
+		var n float64
+		switch v := raw.(type) {
+		case float64:
+			n = v
+		case string:
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return "", fmt.Sprintf("%v must be a number.", field.Label)
+			}
+			n = parsed
+		default:
+			return "", fmt.Sprintf("%v must be a number.", field.Label)
+		}
+		if field.Min != nil && n < *field.Min {
+			return "", fmt.Sprintf("%v must be at least %v.", field.Label, *field.Min)
+		}
+		if field.Max != nil && n > *field.Max {
+			return "", fmt.Sprintf("%v must be at most %v.", field.Label, *field.Max)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), ""
+
+	case profile_model.FIELD_TYPE_BOOLEAN:
+		var b bool
+		switch v := raw.(type) {
+		case bool:
+			b = v
+		case string: // imported files only have text
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				return "", fmt.Sprintf("%v must be true or false.", field.Label)
+			}
+			b = parsed
+		default:
+			return "", fmt.Sprintf("%v must be true or false.", field.Label)
+		}
+		return strconv.FormatBool(b), ""
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		return "", fmt.Sprintf("%v must be text.", field.Label)
+	}
+
+	switch field.Type {
+	case profile_model.FIELD_TYPE_DATE:
+		if _, err := time.Parse(profile_model.DATE_LAYOUT, s); err != nil {
+			return "", fmt.Sprintf("%v must be a date like 2006-01-02.", field.Label)
+		}
+
+	case profile_model.FIELD_TYPE_SELECT:
+		for _, option := range field.Options {
+			if option == s {
+				return s, ""
+			}
+		}
+		return "", fmt.Sprintf("%v must be one of the available options.", field.Label)
+
+	case profile_model.FIELD_TYPE_STRING:
+		length := utf8.RuneCountInString(s)
+		if len(s) > maxValueLength {
+			return "", fmt.Sprintf("%v is too long.", field.Label)
+		}
+		if field.Min != nil && float64(length) < *field.Min {
+			return "", fmt.Sprintf("%v must be at least %v characters.", field.Label, *field.Min)
+		}
+		if field.Max != nil && float64(length) > *field.Max {
+			return "", fmt.Sprintf("%v must be at most %v characters.", field.Label, *field.Max)
+		}
+		if field.Pattern != "" {
+			pattern, err := regexp.Compile(field.Pattern)
+			if err != nil || !pattern.MatchString(s) {
+				return "", fmt.Sprintf("%v isn't in the expected format.", field.Label)
+			}
+		}
+	}
+
+	return s, ""
+}
+
+// typedValue converts a stored value to the type sent in responses.
+func typedValue(field *profile_model.ProfileField, value string) interface{} {
+	switch field.Type {
+	case profile_model.FIELD_TYPE_NUMBER:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case profile_model.FIELD_TYPE_BOOLEAN:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
@@ -9,6 +9,7 @@ import (
 type IRuntimeRepository interface {
 	GetByName(name string) (*runtime_model.Runtime, error)
 	UpdateValue(id int, value string) error
+	Increment(name string) (int64, error)
 }
 
 type RuntimeRepository struct {
@@ -43,3 +44,13 @@ func (ur *RuntimeRepository) UpdateValue(id int, value string) error {
 	}
 	return nil
 }
+
+// Increment adds one to a numeric runtime value and returns the new value
+func (ur *RuntimeRepository) Increment(name string) (int64, error) {
+	result, err := ur.database.Exec("UPDATE gocms_runtime SET value=LAST_INSERT_ID(value + 1) WHERE name = ?", name)
+	if err != nil {
+		log.Errorf("Error incrementing runtime %v: %s", name, err.Error())
+		return 0, err
+	}
+	return result.LastInsertId()
+}
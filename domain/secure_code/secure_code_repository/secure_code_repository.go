@@ -1,6 +1,7 @@
 package secure_code_repository
 
 import (
+	"database/sql"
 	"github.com/cqlcorp/gocms/domain/secure_code/security_code_model"
 	"github.com/cqlcorp/gocms/utility/log"
 	"github.com/jmoiron/sqlx"
@@ -63,7 +64,9 @@ func (scr *SecureCodeRepository) GetLatestForUserByType(id int64, codeType secur
 	SELECT * from gocms_secure_codes WHERE userId=? AND type=? ORDER BY created DESC LIMIT 1
 	`, id, codeType)
 	if err != nil {
-		log.Errorf("Error getting getting latest security code for user from database: %s", err.Error())
+		if err != sql.ErrNoRows {
+			log.Errorf("Error getting getting latest security code for user from database: %s", err.Error())
+		}
 		return nil, err
 	}
 	return &secureCode, nil
@@ -8,6 +8,8 @@ const (
 	Code_VerifyEmail   SecureCodeType = 1
 	Code_VerifyDevice  SecureCodeType = 2
 	Code_ResetPassword SecureCodeType = 3
+	// sent to users created by an admin, used like a reset code but valid for INVITATION_TIMEOUT
+	Code_SetPassword SecureCodeType = 4
 )
 
 type SecureCode struct {
@@ -0,0 +1,73 @@
+package security_controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultCspReportLimit = 100
+const maxCspReportLimit = 1000
+
+type SecurityAdminController struct {
+	routes        *routes.Routes
+	servicesGroup *service.ServicesGroup
+	adminRoutes   *access_control_middleware.PermissionGroup
+}
+
+func DefaultSecurityAdminController(routes *routes.Routes, sg *service.ServicesGroup) *SecurityAdminController {
+	securityAdminController := &SecurityAdminController{
+		routes:        routes,
+		servicesGroup: sg,
+		adminRoutes:   access_control_middleware.NewPermissionGroup(routes.Auth, "/admin/security", sg.AclService, permissions.SUPER_ADMIN),
+	}
+
+	securityAdminController.Default()
+	return securityAdminController
+}
+
+func (sac *SecurityAdminController) Default() {
+	sac.adminRoutes.GET("/cspReport", sac.getCspReports)
+}
+
+/**
+* @api {get} /admin/security/cspReport?limit=:limit Get CSP Reports
+* @apiName GetCspReports
+* @apiGroup Admin Security
+* @apiDescription Most recent content security policy violation reports. Use these to tune CSP_EXTRA_SOURCES while
+* CSP_MODE is report-only, before switching it to enforce.
+*
+* @apiParam (Request) {number} [limit=100] Max 1000.
+*
+* @apiUse AuthHeader
+* @apiUse CspReports
+* @apiPermission Admin
+ */
+func (sac *SecurityAdminController) getCspReports(c *gin.Context) {
+	limit := int64(defaultCspReportLimit)
+	if l := c.Query("limit"); l != "" {
+		parsed, err := strconv.ParseInt(l, 10, 64)
+		if err != nil || parsed <= 0 {
+			errors.Response(c, http.StatusBadRequest, "Invalid limit.", err)
+			return
+		}
+		limit = parsed
+	}
+	if limit > maxCspReportLimit {
+		limit = maxCspReportLimit
+	}
+
+	reports, err := sac.servicesGroup.SecurityService.GetCspReports(limit)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get csp reports.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
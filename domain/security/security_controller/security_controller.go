@@ -0,0 +1,70 @@
+package security_controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/cqlcorp/gocms/domain/security/security_model"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// reports are small, anything bigger isn't from a browser
+const maxCspReportSize = 16 * 1024
+
+type SecurityController struct {
+	routes        *routes.Routes
+	servicesGroup *service.ServicesGroup
+}
+
+func DefaultSecurityController(routes *routes.Routes, sg *service.ServicesGroup) *SecurityController {
+	securityController := &SecurityController{
+		routes:        routes,
+		servicesGroup: sg,
+	}
+
+	securityController.Default()
+	return securityController
+}
+
+func (sc *SecurityController) Default() {
+	sc.routes.Public.POST("/csp/report", sc.cspReport)
+}
+
+/**
+* @api {post} /csp/report Content Security Policy Report
+* @apiName CspReport
+* @apiGroup Security
+* @apiDescription Collects content security policy violation reports sent by browsers. Set as the report-uri of the
+* policy on pages served by GoCMS. Reports about pages on another host, repeats of a report within a minute and
+* reports past the per ip limit are dropped.
+*
+* @apiParam (Request) {Object} csp-report
+* @apiParam (Request) {string} csp-report.document-uri
+* @apiParam (Request) {string} csp-report.violated-directive
+* @apiParam (Request) {string} [csp-report.effective-directive]
+* @apiParam (Request) {string} csp-report.blocked-uri
+* @apiParam (Request) {string} [csp-report.source-file]
+* @apiParam (Request) {number} [csp-report.line-number]
+* @apiParam (Request) {string} [csp-report.disposition]
+ */
+func (sc *SecurityController) cspReport(c *gin.Context) {
+	// browsers post with the application/csp-report content type so decode directly instead of binding
+	var body security_model.CspReportBody
+	err := json.NewDecoder(io.LimitReader(c.Request.Body, maxCspReportSize)).Decode(&body)
+	if err != nil || body.Report == nil {
+		errors.Response(c, http.StatusBadRequest, "Invalid csp report.", err)
+		return
+	}
+
+	err = sc.servicesGroup.SecurityService.RecordCspReport(body.Report, c.Request.UserAgent(), c.ClientIP(), c.Request.Host)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't record csp report.", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
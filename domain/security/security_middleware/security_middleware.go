@@ -0,0 +1,99 @@
+package security_middleware
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/security/security_model"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/gin-gonic/gin"
+)
+
+var hostRegex = regexp.MustCompile(`^[A-Za-z0-9.\-]+(:[0-9]+)?$`)
+
+type SecurityMiddleware struct {
+	ServicesGroup *service.ServicesGroup
+}
+
+func DefaultSecurityMiddleware(sg *service.ServicesGroup) *SecurityMiddleware {
+
+	securityMiddleware := &SecurityMiddleware{
+		ServicesGroup: sg,
+	}
+
+	return securityMiddleware
+}
+
+// SecurityHeaders adds the headers every response should carry. The content security policy is added by the pages that
+// need it with ContentSecurityPolicy since it depends on the assets of the page.
+func (sm *SecurityMiddleware) SecurityHeaders() gin.HandlerFunc {
+	log.Debugf("Adding Security Headers Middleware\n")
+	return sm.securityHeaders
+}
+
+func (sm *SecurityMiddleware) securityHeaders(c *gin.Context) {
+	c.Writer.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if frameOptions := strings.ToUpper(context.Config.DbVars.FrameOptions); frameOptions != "" {
+		c.Writer.Header().Set("X-Frame-Options", frameOptions)
+	}
+	if context.Config.DbVars.ReferrerPolicy != "" {
+		c.Writer.Header().Set("Referrer-Policy", context.Config.DbVars.ReferrerPolicy)
+	}
+
+	// browsers ignore hsts over plain http
+	if context.Config.DbVars.HstsMaxAge > 0 && isHttps(c) {
+		hsts := fmt.Sprintf("max-age=%v", context.Config.DbVars.HstsMaxAge)
+		if context.Config.DbVars.HstsIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		c.Writer.Header().Set("Strict-Transport-Security", hsts)
+	}
+
+	c.Next()
+}
+
+// ContentSecurityPolicy sets the content security policy for a page loading the given scripts and styles according
+// to CSP_MODE. It returns the nonce inline scripts and styles of the page must carry, empty when the policy is off.
+func ContentSecurityPolicy(c *gin.Context, sg *service.ServicesGroup, scripts []string, styles []string) string {
+	mode := strings.ToLower(context.Config.DbVars.CspMode)
+	if mode == security_model.CSP_MODE_OFF {
+		return ""
+	}
+
+	nonce, err := sg.SecurityService.NewNonce()
+	if err != nil {
+		log.Errorf("Error creating csp nonce: %s\n", err.Error())
+		return ""
+	}
+	c.Set(security_model.CSP_NONCE_KEY, nonce)
+
+	policy := sg.SecurityService.ContentSecurityPolicy(requestOrigin(c), nonce, scripts, styles)
+	if mode == security_model.CSP_MODE_ENFORCE {
+		c.Writer.Header().Set("Content-Security-Policy", policy)
+	} else {
+		c.Writer.Header().Set("Content-Security-Policy-Report-Only", policy)
+	}
+
+	return nonce
+}
+
+// requestOrigin is the origin the browser loaded the page from. Falls back to an empty origin, which limits the
+// policy to the nonce and configured sources, when the host header isn't a plain host.
+func requestOrigin(c *gin.Context) string {
+	if !hostRegex.MatchString(c.Request.Host) {
+		return ""
+	}
+	scheme := "http"
+	if isHttps(c) {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+func isHttps(c *gin.Context) bool {
+	return c.Request.TLS != nil || strings.EqualFold(c.Request.Header.Get("X-Forwarded-Proto"), "https")
+}
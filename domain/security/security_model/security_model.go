@@ -0,0 +1,58 @@
+package security_model
+
+import (
+	"time"
+)
+
+const (
+	CSP_MODE_OFF         = "off"
+	CSP_MODE_REPORT_ONLY = "report-only"
+	CSP_MODE_ENFORCE     = "enforce"
+)
+
+// CSP_NONCE_KEY is the gin context key of the nonce generated for the current page.
+const CSP_NONCE_KEY = "GOCMS-CSP-NONCE"
+
+// CSP_REPORT_URI is where browsers send violation reports.
+const CSP_REPORT_URI = "/api/csp/report"
+
+// CspReportBody is the report a browser posts for a content security policy violation.
+type CspReportBody struct {
+	Report *CspViolation `json:"csp-report"`
+}
+
+type CspViolation struct {
+	DocumentUri        string `json:"document-uri"`
+	Referrer           string `json:"referrer"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	BlockedUri         string `json:"blocked-uri"`
+	SourceFile         string `json:"source-file"`
+	LineNumber         int64  `json:"line-number"`
+	ColumnNumber       int64  `json:"column-number"`
+	Disposition        string `json:"disposition"`
+}
+
+/**
+* @apiDefine CspReports
+* @apiSuccess (Response) {number} id
+* @apiSuccess (Response) {string} documentUri Page the violation happened on.
+* @apiSuccess (Response) {string} directive Directive that was violated.
+* @apiSuccess (Response) {string} blockedUri Resource that was blocked, or inline / eval.
+* @apiSuccess (Response) {string} sourceFile
+* @apiSuccess (Response) {number} lineNumber
+* @apiSuccess (Response) {string} disposition enforce or report.
+* @apiSuccess (Response) {string} userAgent
+* @apiSuccess (Response) {string} created
+**/
+type CspReport struct {
+	Id          int64     `json:"id" db:"id"`
+	DocumentUri string    `json:"documentUri" db:"documentUri"`
+	Directive   string    `json:"directive" db:"directive"`
+	BlockedUri  string    `json:"blockedUri" db:"blockedUri"`
+	SourceFile  string    `json:"sourceFile" db:"sourceFile"`
+	LineNumber  int64     `json:"lineNumber" db:"lineNumber"`
+	Disposition string    `json:"disposition" db:"disposition"`
+	UserAgent   string    `json:"userAgent" db:"userAgent"`
+	Created     time.Time `json:"created" db:"created"`
+}
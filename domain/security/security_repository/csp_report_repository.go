@@ -0,0 +1,71 @@
+package security_repository
+
+import (
+	"time"
+
+	"github.com/cqlcorp/gocms/domain/security/security_model"
+	"github.com/jmoiron/sqlx"
+)
+
+type ICspReportRepository interface {
+	Add(*security_model.CspReport) error
+	GetRecent(limit int64) ([]*security_model.CspReport, error)
+	DeleteOlderThan(time.Time) error
+	Count() (int64, error)
+}
+
+type CspReportRepository struct {
+	database *sqlx.DB
+}
+
+func DefaultCspReportRepository(dbx *sqlx.DB) *CspReportRepository {
+	cspReportRepository := &CspReportRepository{
+		database: dbx,
+	}
+	return cspReportRepository
+}
+
+func (cr *CspReportRepository) Add(report *security_model.CspReport) error {
+	report.Created = time.Now()
+	result, err := cr.database.NamedExec(`
+	INSERT INTO gocms_csp_reports (documentUri, directive, blockedUri, sourceFile, lineNumber, disposition, userAgent, created)
+		VALUES (:documentUri, :directive, :blockedUri, :sourceFile, :lineNumber, :disposition, :userAgent, :created)
+	`, report)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	report.Id = id
+	return nil
+}
+
+func (cr *CspReportRepository) GetRecent(limit int64) ([]*security_model.CspReport, error) {
+	var reports []*security_model.CspReport
+	err := cr.database.Select(&reports, `
+	SELECT * FROM gocms_csp_reports
+	ORDER BY created DESC, id DESC
+	LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+func (cr *CspReportRepository) DeleteOlderThan(before time.Time) error {
+	_, err := cr.database.Exec(`
+	DELETE FROM gocms_csp_reports WHERE created < ?
+	`, before)
+	return err
+}
+
+func (cr *CspReportRepository) Count() (int64, error) {
+	var count int64
+	err := cr.database.Get(&count, `
+	SELECT COUNT(*) FROM gocms_csp_reports
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,302 @@
+package security_service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/security/security_model"
+	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/log"
+)
+
+// reports with the same directive, blocked uri and source seen within this window are only recorded once
+const cspReportWindow = time.Minute
+const cspReportWindowMax = 1000
+const cspReportFieldMax = 255
+
+// each client ip can send this many reports per window, the rest are dropped
+const cspReportsPerIp = 20
+
+// once the table holds this many reports new ones are dropped until old ones are purged
+const cspReportRowMax = 10000
+
+type ISecurityService interface {
+	NewNonce() (string, error)
+	ContentSecurityPolicy(origin string, nonce string, scripts []string, styles []string) string
+	RecordCspReport(violation *security_model.CspViolation, userAgent string, clientIp string, host string) error
+	GetCspReports(limit int64) ([]*security_model.CspReport, error)
+	PurgeCspReports() error
+}
+
+type SecurityService struct {
+	RepositoriesGroup *repository.RepositoriesGroup
+	recentReports     map[string]time.Time
+	reportsByIp       map[string]*reportCounter
+	reportsMutex      sync.Mutex
+}
+
+// reportCounter counts the reports from one client ip since start
+type reportCounter struct {
+	start time.Time
+	count int
+}
+
+func DefaultSecurityService(rg *repository.RepositoriesGroup) *SecurityService {
+	securityService := &SecurityService{
+		RepositoriesGroup: rg,
+		recentReports:     make(map[string]time.Time),
+		reportsByIp:       make(map[string]*reportCounter),
+	}
+	return securityService
+}
+
+// NewNonce returns a random value for the nonce-source of a single response.
+func (ss *SecurityService) NewNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// ContentSecurityPolicy builds the policy for a page served from origin. Scripts and styles are the paths of the assets
+// the page loads, the policy allows the directories they live in along with the gocms and active theme assets.
+func (ss *SecurityService) ContentSecurityPolicy(origin string, nonce string, scripts []string, styles []string) string {
+	theme := fmt.Sprintf("/themes/%v/", context.Config.DbVars.ActiveTheme)
+	extra := extraSources()
+	assetBase := sourceFromUrl(context.Config.DbVars.ActiveThemeAssetsBase)
+	apiOrigin := originFromUrl(context.Config.DbVars.PublicApiUrl)
+
+	scriptSources := appendSources([]string{fmt.Sprintf("'nonce-%v'", nonce)}, local(origin, "/gocms/"), local(origin, theme))
+	scriptSources = appendDirectories(scriptSources, origin, scripts)
+	scriptSources = appendSources(scriptSources, assetBase)
+	scriptSources = appendSources(scriptSources, extra...)
+
+	styleSources := appendSources([]string{fmt.Sprintf("'nonce-%v'", nonce)}, local(origin, "/gocms/"), local(origin, theme))
+	styleSources = appendDirectories(styleSources, origin, styles)
+	styleSources = appendSources(styleSources, assetBase)
+	styleSources = appendSources(styleSources, extra...)
+
+	imgSources := appendSources([]string{"'self'", "data:"}, assetBase)
+	imgSources = appendSources(imgSources, extra...)
+
+	fontSources := appendSources([]string{"'self'", "data:"}, assetBase)
+	fontSources = appendSources(fontSources, extra...)
+
+	connectSources := appendSources([]string{"'self'"}, apiOrigin)
+	connectSources = appendSources(connectSources, extra...)
+
+	directives := []string{
+		"default-src 'self'",
+		"script-src " + strings.Join(scriptSources, " "),
+		"style-src " + strings.Join(styleSources, " "),
+		"img-src " + strings.Join(imgSources, " "),
+		"font-src " + strings.Join(fontSources, " "),
+		"connect-src " + strings.Join(connectSources, " "),
+		"object-src 'none'",
+		"base-uri 'self'",
+	}
+
+	switch strings.ToUpper(context.Config.DbVars.FrameOptions) {
+	case "DENY":
+		directives = append(directives, "frame-ancestors 'none'")
+	case "SAMEORIGIN":
+		directives = append(directives, "frame-ancestors 'self'")
+	}
+
+	directives = append(directives, "report-uri "+security_model.CSP_REPORT_URI)
+
+	return strings.Join(directives, "; ")
+}
+
+// RecordCspReport stores a violation report. The endpoint is public so reports are dropped when they are about a page
+// that isn't on the host the report was sent to, when the client ip sent too many recently, when they repeat a recent
+// report or when the table is full.
+func (ss *SecurityService) RecordCspReport(violation *security_model.CspViolation, userAgent string, clientIp string, host string) error {
+	if !onHost(violation.DocumentUri, host) || !ss.underIpLimit(clientIp) {
+		return nil
+	}
+
+	directive := violation.EffectiveDirective
+	if directive == "" {
+		directive = violation.ViolatedDirective
+	}
+
+	report := &security_model.CspReport{
+		DocumentUri: truncate(violation.DocumentUri),
+		Directive:   truncate(directive),
+		BlockedUri:  truncate(violation.BlockedUri),
+		SourceFile:  truncate(violation.SourceFile),
+		LineNumber:  violation.LineNumber,
+		Disposition: truncate(violation.Disposition),
+		UserAgent:   truncate(userAgent),
+	}
+
+	key := fmt.Sprintf("%v|%v|%v|%v|%v", report.DocumentUri, report.Directive, report.BlockedUri, report.SourceFile, report.LineNumber)
+	if !ss.firstReportInWindow(key) {
+		return nil
+	}
+
+	count, err := ss.RepositoriesGroup.CspReportRepository.Count()
+	if err != nil {
+		log.Errorf("Error counting csp reports: %s\n", err.Error())
+		return err
+	}
+	if count >= cspReportRowMax {
+		log.Warningf("Dropping csp report, there are already %v reports stored\n", count)
+		return nil
+	}
+
+	err = ss.RepositoriesGroup.CspReportRepository.Add(report)
+	if err != nil {
+		log.Errorf("Error recording csp report: %s\n", err.Error())
+		return err
+	}
+	return nil
+}
+
+func (ss *SecurityService) GetCspReports(limit int64) ([]*security_model.CspReport, error) {
+	return ss.RepositoriesGroup.CspReportRepository.GetRecent(limit)
+}
+
+// PurgeCspReports removes reports older than CSP_REPORT_RETENTION days.
+func (ss *SecurityService) PurgeCspReports() error {
+	if context.Config.DbVars.CspReportRetention <= 0 {
+		return nil
+	}
+	before := time.Now().AddDate(0, 0, -int(context.Config.DbVars.CspReportRetention))
+	err := ss.RepositoriesGroup.CspReportRepository.DeleteOlderThan(before)
+	if err != nil {
+		log.Errorf("Error purging csp reports: %s\n", err.Error())
+		return err
+	}
+	return nil
+}
+
+func (ss *SecurityService) firstReportInWindow(key string) bool {
+	ss.reportsMutex.Lock()
+	defer ss.reportsMutex.Unlock()
+
+	now := time.Now()
+	if seen, ok := ss.recentReports[key]; ok && now.Sub(seen) < cspReportWindow {
+		return false
+	}
+	if len(ss.recentReports) >= cspReportWindowMax {
+		ss.recentReports = make(map[string]time.Time)
+	}
+	ss.recentReports[key] = now
+	return true
+}
+
+func (ss *SecurityService) underIpLimit(clientIp string) bool {
+	ss.reportsMutex.Lock()
+	defer ss.reportsMutex.Unlock()
+
+	now := time.Now()
+	counter, ok := ss.reportsByIp[clientIp]
+	if !ok || now.Sub(counter.start) >= cspReportWindow {
+		if len(ss.reportsByIp) >= cspReportWindowMax {
+			ss.reportsByIp = make(map[string]*reportCounter)
+		}
+		counter = &reportCounter{start: now}
+		ss.reportsByIp[clientIp] = counter
+	}
+	counter.count++
+	return counter.count <= cspReportsPerIp
+}
+
+// onHost reports if the document uri of a report is a page on host, the host the report was posted to. The report-uri
+// is relative so browsers always send reports to the host of the page.
+func onHost(documentUri string, host string) bool {
+	u, err := url.Parse(documentUri)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return host != "" && strings.EqualFold(u.Host, host)
+}
+
+// appendDirectories adds the directory of each asset path as a source.
+func appendDirectories(sources []string, origin string, assets []string) []string {
+	for _, asset := range assets {
+		sources = appendSources(sources, local(origin, path.Dir(asset)+"/"))
+	}
+	return sources
+}
+
+// local is a source for a path on the page origin. Empty when the origin is unknown since a path alone isn't a valid source.
+func local(origin string, p string) string {
+	if origin == "" {
+		return ""
+	}
+	return origin + p
+}
+
+// appendSources adds sources that aren't already in the list, skipping empty ones.
+func appendSources(sources []string, add ...string) []string {
+	for _, source := range add {
+		if source == "" {
+			continue
+		}
+		exists := false
+		for _, s := range sources {
+			if s == source {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// extraSources are the sources from CSP_EXTRA_SOURCES, separated by spaces.
+func extraSources() []string {
+	var sources []string
+	for _, source := range strings.Fields(context.Config.DbVars.CspExtraSources) {
+		if strings.ContainsAny(source, ";,") {
+			log.Warningf("Ignoring invalid CSP_EXTRA_SOURCES source %v\n", source)
+			continue
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// sourceFromUrl turns an absolute url into a source, keeping its path.
+func sourceFromUrl(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + u.EscapedPath()
+}
+
+func originFromUrl(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func truncate(s string) string {
+	if len(s) <= cspReportFieldMax {
+		return s
+	}
+	// don't cut a multi byte character in half
+	end := cspReportFieldMax
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end]
+}
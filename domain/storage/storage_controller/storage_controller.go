@@ -0,0 +1,30 @@
+package storage_controller
+
+import (
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/storage/storage_service"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+)
+
+type StorageController struct {
+	routes        *routes.Routes
+	servicesGroup *service.ServicesGroup
+}
+
+func DefaultStorageController(routes *routes.Routes, sg *service.ServicesGroup) *StorageController {
+	storageController := &StorageController{
+		routes:        routes,
+		servicesGroup: sg,
+	}
+
+	storageController.Default()
+	return storageController
+}
+
+func (sc *StorageController) Default() {
+	// files in s3 are served by the bucket
+	if context.Config.DbVars.StorageBackend == storage_service.BACKEND_LOCAL {
+		sc.routes.Root.Static(storage_service.LOCAL_ROUTE, context.Config.DbVars.StorageLocalDir)
+	}
+}
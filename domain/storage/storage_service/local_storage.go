@@ -0,0 +1,58 @@
+package storage_service
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/utility/log"
+)
+
+// LocalStorageBackend stores files in STORAGE_LOCAL_DIR. They are served by the storage controller.
+type LocalStorageBackend struct{}
+
+func DefaultLocalStorageBackend() *LocalStorageBackend {
+	return &LocalStorageBackend{}
+}
+
+func (lsb *LocalStorageBackend) Put(key string, contentType string, data []byte) error {
+	path := lsb.path(key)
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		log.Errorf("Error creating storage directory for %v: %s\n", key, err.Error())
+		return err
+	}
+
+	// write to a temp file first so a partial file is never served
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		log.Errorf("Error writing %v to local storage: %s\n", key, err.Error())
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (lsb *LocalStorageBackend) Delete(key string) error {
+	err := os.Remove(lsb.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorf("Error deleting %v from local storage: %s\n", key, err.Error())
+		return err
+	}
+	return nil
+}
+
+// BaseUrl serves files from the host of the public api.
+func (lsb *LocalStorageBackend) BaseUrl() string {
+	apiUrl, err := url.Parse(context.Config.DbVars.PublicApiUrl)
+	if err != nil || apiUrl.Host == "" {
+		return LOCAL_ROUTE
+	}
+	return apiUrl.Scheme + "://" + apiUrl.Host + LOCAL_ROUTE
+}
+
+func (lsb *LocalStorageBackend) path(key string) string {
+	return filepath.Join(context.Config.DbVars.StorageLocalDir, filepath.FromSlash(key))
+}
@@ -0,0 +1,94 @@
+package storage_service
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/log"
+)
+
+const s3Timeout = 30 * time.Second
+
+// S3StorageBackend stores files in a bucket of any s3 compatible service. Buckets are addressed by path so services
+// without virtual host buckets work too.
+type S3StorageBackend struct {
+	client *http.Client
+}
+
+func DefaultS3StorageBackend() *S3StorageBackend {
+	return &S3StorageBackend{
+		client: &http.Client{Timeout: s3Timeout},
+	}
+}
+
+func (ssb *S3StorageBackend) Put(key string, contentType string, data []byte) error {
+	req, err := http.NewRequest(http.MethodPut, ssb.objectUrl(key), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Cache-Control", "public, max-age=31536000, immutable")
+
+	return ssb.do(req, bytes.NewReader(data), key)
+}
+
+func (ssb *S3StorageBackend) Delete(key string) error {
+	req, err := http.NewRequest(http.MethodDelete, ssb.objectUrl(key), nil)
+	if err != nil {
+		return err
+	}
+
+	return ssb.do(req, nil, key)
+}
+
+func (ssb *S3StorageBackend) BaseUrl() string {
+	return ssb.bucketUrl()
+}
+
+func (ssb *S3StorageBackend) do(req *http.Request, body *bytes.Reader, key string) error {
+	dbVars := context.Config.DbVars
+	if dbVars.StorageS3Endpoint == "" || dbVars.StorageS3Bucket == "" {
+		return errors.New("STORAGE_S3_ENDPOINT and STORAGE_S3_BUCKET are required for s3 storage")
+	}
+
+	signer := v4.NewSigner(credentials.NewStaticCredentials(dbVars.StorageS3AccessKey, dbVars.StorageS3SecretKey, ""))
+	var err error
+	if body != nil {
+		_, err = signer.Sign(req, body, "s3", dbVars.StorageS3Region, time.Now())
+	} else {
+		_, err = signer.Sign(req, nil, "s3", dbVars.StorageS3Region, time.Now())
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := ssb.client.Do(req)
+	if err != nil {
+		log.Errorf("Error sending %v %v to s3: %s\n", req.Method, key, err.Error())
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(res.Body)
+		log.Errorf("Error from s3 for %v %v: %v %s\n", req.Method, key, res.StatusCode, message)
+		return errors.New(fmt.Sprintf("s3 responded %v to %v %v", res.StatusCode, req.Method, key))
+	}
+	return nil
+}
+
+func (ssb *S3StorageBackend) bucketUrl() string {
+	return strings.TrimRight(context.Config.DbVars.StorageS3Endpoint, "/") + "/" + context.Config.DbVars.StorageS3Bucket
+}
+
+func (ssb *S3StorageBackend) objectUrl(key string) string {
+	return ssb.bucketUrl() + "/" + escapeKey(key)
+}
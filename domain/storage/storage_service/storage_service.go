@@ -0,0 +1,109 @@
+package storage_service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/utility/errors"
+)
+
+const (
+	BACKEND_LOCAL = "local"
+	BACKEND_S3    = "s3"
+)
+
+// LOCAL_ROUTE is where files in the local storage directory are served.
+const LOCAL_ROUTE = "/uploads"
+
+// IStorageBackend stores files by key. Keys are slash separated paths like users/1/photo.jpg.
+type IStorageBackend interface {
+	Put(key string, contentType string, data []byte) error
+	Delete(key string) error
+	// BaseUrl is the url files are publicly served from when STORAGE_PUBLIC_URL isn't set.
+	BaseUrl() string
+}
+
+type IStorageService interface {
+	Put(key string, contentType string, data []byte) error
+	Delete(key string) error
+	Url(key string) string
+}
+
+type StorageService struct {
+	local IStorageBackend
+	s3    IStorageBackend
+}
+
+func DefaultStorageService() *StorageService {
+	storageService := &StorageService{
+		local: DefaultLocalStorageBackend(),
+		s3:    DefaultS3StorageBackend(),
+	}
+	return storageService
+}
+
+func (ss *StorageService) Put(key string, contentType string, data []byte) error {
+	backend, err := ss.backend()
+	if err != nil {
+		return err
+	}
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	return backend.Put(key, contentType, data)
+}
+
+func (ss *StorageService) Delete(key string) error {
+	backend, err := ss.backend()
+	if err != nil {
+		return err
+	}
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	return backend.Delete(key)
+}
+
+// Url returns the public url of a stored file.
+func (ss *StorageService) Url(key string) string {
+	base := strings.TrimRight(context.Config.DbVars.StoragePublicUrl, "/")
+	if base == "" {
+		if backend, err := ss.backend(); err == nil {
+			base = strings.TrimRight(backend.BaseUrl(), "/")
+		}
+	}
+	return base + "/" + escapeKey(key)
+}
+
+// backend is picked on each call so settings refreshes are applied.
+func (ss *StorageService) backend() (IStorageBackend, error) {
+	switch context.Config.DbVars.StorageBackend {
+	case BACKEND_LOCAL:
+		return ss.local, nil
+	case BACKEND_S3:
+		return ss.s3, nil
+	}
+	return nil, errors.New(fmt.Sprintf("unknown storage backend '%v'", context.Config.DbVars.StorageBackend))
+}
+
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return errors.New(fmt.Sprintf("invalid storage key '%v'", key))
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return errors.New(fmt.Sprintf("invalid storage key '%v'", key))
+		}
+	}
+	return nil
+}
+
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
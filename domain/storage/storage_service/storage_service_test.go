@@ -0,0 +1,44 @@
+package storage_service
+
+import "testing"
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"photo.jpg", true},
+		{"users/4/photo.jpg", true},
+		{"users/4/.photo.jpg", true},
+		{"users/4/..photo.jpg", true},
+		{"", false},
+		{"/etc/passwd", false},
+		{"../photo.jpg", false},
+		{"users/../../photo.jpg", false},
+		{"users/..", false},
+		{"users/./photo.jpg", false},
+		{"users//photo.jpg", false},
+		{"users/4/", false},
+	}
+	for _, test := range tests {
+		if err := validateKey(test.key); (err == nil) != test.valid {
+			t.Errorf("validateKey(%q) = %v, want valid %v", test.key, err, test.valid)
+		}
+	}
+}
+
+func TestEscapeKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"users/4/photo.jpg", "users/4/photo.jpg"},
+		{"users/4/my photo.jpg", "users/4/my%20photo.jpg"},
+		{"users/4/a?b#c.jpg", "users/4/a%3Fb%23c.jpg"},
+	}
+	for _, test := range tests {
+		if got := escapeKey(test.key); got != test.want {
+			t.Errorf("escapeKey(%q) = %q, want %q", test.key, got, test.want)
+		}
+	}
+}
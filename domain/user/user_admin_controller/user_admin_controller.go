@@ -4,18 +4,25 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_middleware"
 	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_model"
+	"github.com/cqlcorp/gocms/domain/profile/profile_model"
+	"github.com/cqlcorp/gocms/domain/logs/log_model"
 	"github.com/cqlcorp/gocms/domain/user/user_model"
 	"github.com/cqlcorp/gocms/init/service"
 	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/api_utility"
 	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/list_utility"
+	"github.com/cqlcorp/gocms/utility/localeUtil"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type UserAdminController struct {
 	routes        *routes.Routes
 	ServicesGroup *service.ServicesGroup
-	adminRoutes   *gin.RouterGroup
+	adminRoutes   *access_control_middleware.PermissionGroup
 }
 
 func DefaultUserAdminController(routes *routes.Routes, sg *service.ServicesGroup) *UserAdminController {
@@ -25,7 +32,7 @@ func DefaultUserAdminController(routes *routes.Routes, sg *service.ServicesGroup
 	}
 
 	// add acl rules to route
-	adminUserController.adminRoutes = routes.Auth.Group("/admin", access_control_middleware.RequirePermission(sg.AclService, permissions.SUPER_ADMIN))
+	adminUserController.adminRoutes = access_control_middleware.NewPermissionGroup(routes.Auth, "/admin", sg.AclService, permissions.SUPER_ADMIN)
 
 	adminUserController.Default()
 	return adminUserController
@@ -43,6 +50,9 @@ func (auc *UserAdminController) Default() {
 	auc.adminRoutes.PUT("/user/:userId", auc.update)
 	auc.adminRoutes.POST("/user", auc.add)
 	auc.adminRoutes.DELETE("/user/:userId", auc.delete)
+	auc.adminRoutes.POST("/user/:userId/restore", auc.restore)
+	auc.adminRoutes.PUT("/user/:userId/ban", auc.ban)
+	auc.adminRoutes.DELETE("/user/:userId/ban", auc.unban)
 }
 
 func (auc *UserAdminController) add(c *gin.Context) {
@@ -63,6 +73,10 @@ func (auc *UserAdminController) add(c *gin.Context) {
 
 	// add user
 	err = auc.ServicesGroup.UserService.Add(user)
+	if fieldErrors, ok := err.(errors.FieldErrors); ok {
+		errors.ResponseWithFieldErrors(c, http.StatusBadRequest, password_policy_model.ApiError_Password_Policy, fieldErrors)
+		return
+	}
 	if err != nil {
 		errors.Response(c, http.StatusInternalServerError, err.Error(), err)
 		return
@@ -78,7 +92,7 @@ func (auc *UserAdminController) add(c *gin.Context) {
 * @apiGroup Admin
 *
 * @apiUse UserAuthHeader
-* @apiUse UserDisplay
+* @apiUse UserAdminDisplay
 * @apiPermission Admin
  */
 func (auc *UserAdminController) get(c *gin.Context) {
@@ -94,35 +108,120 @@ func (auc *UserAdminController) get(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, user.GetUserAdminDisplay())
+	profile, err := auc.ServicesGroup.ProfileService.GetProfile(userId, true)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get profile.", err)
+		return
+	}
+
+	userAdminDisplay := user.GetUserAdminDisplay()
+	userAdminDisplay.Profile = profile
+	c.JSON(http.StatusOK, userAdminDisplay)
 }
 
 /**
-* @api {get} /admin/user Get All Users
-* @apiDescription Used to get a list of all users.
+* @api {get} /admin/user Get Users
+* @apiDescription Used to get a page of users. The search matches the full name or any of the users emails, including
+* alternate emails. Sortable fields are id, fullName, email, created and lastModified. Without any list or filter
+* parameter every user that isn't deleted is returned as a plain UserAdminDisplay array instead of a page.
 * @apiName GetAllUsers
 * @apiGroup Admin
 *
 * @apiUse UserAuthHeader
-* @apiUse UserDisplay
+* @apiUse ListQuery
+* @apiUse UserListFilter
+* @apiUse ListPage
+* @apiSuccess (Response) {UserAdminDisplay[]} items See UserAdminDisplay.
 * @apiPermission Admin
  */
 func (auc *UserAdminController) getAll(c *gin.Context) {
-	users, err := auc.ServicesGroup.UserService.GetAll()
+	if !list_utility.HasParams(c, list_utility.QUERY_PARAMS...) && !list_utility.HasParams(c, userListFilterParams...) {
+		auc.getAllUnpaged(c)
+		return
+	}
+
+	query, err := list_utility.ParseQuery(c, user_model.UserListSortColumns, "id")
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	filter, err := getUserListFilter(c)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	users, total, err := auc.ServicesGroup.UserService.List(query, filter)
 	if err != nil {
 		errors.Response(c, http.StatusInternalServerError, "Couldn't get users", err)
 		return
 	}
 
 	// create list of users to sent out
-	usersAdminDisplays := make([]user_model.UserAdminDisplay, len(*users))
-	for i, user := range *users {
+	usersAdminDisplays := make([]user_model.UserAdminDisplay, len(users))
+	for i, user := range users {
 		usersAdminDisplays[i] = *user.GetUserAdminDisplay()
 	}
 
+	list_utility.Respond(c, query.NewPage(usersAdminDisplays, total))
+}
+
+// getAllUnpaged responds with the array GET /admin/user returned before it was paged.
+func (auc *UserAdminController) getAllUnpaged(c *gin.Context) {
+	users, err := auc.ServicesGroup.UserService.GetAll()
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get users", err)
+		return
+	}
+
+	usersAdminDisplays := []user_model.UserAdminDisplay{}
+	for _, user := range *users {
+		if user.IsDeleted() {
+			continue
+		}
+		usersAdminDisplays = append(usersAdminDisplays, *user.GetUserAdminDisplay())
+	}
+
 	c.JSON(http.StatusOK, usersAdminDisplays)
 }
 
+// userListFilterParams are the parameters read by getUserListFilter
+var userListFilterParams = []string{"enabled", "verified", "groupId", "createdAfter", "createdBefore", "banned", "deleted"}
+
+func getUserListFilter(c *gin.Context) (*user_model.UserListFilter, error) {
+	var err error
+	filter := &user_model.UserListFilter{}
+
+	if filter.Enabled, err = list_utility.ParseBool(c, "enabled"); err != nil {
+		return nil, err
+	}
+	if filter.Verified, err = list_utility.ParseBool(c, "verified"); err != nil {
+		return nil, err
+	}
+	if groupId := c.Query("groupId"); groupId != "" {
+		if filter.GroupId, err = strconv.ParseInt(groupId, 10, 64); err != nil {
+			return nil, errors.NewToUser("groupId must be a number.")
+		}
+	}
+	if filter.CreatedAfter, err = list_utility.ParseTime(c, "createdAfter"); err != nil {
+		return nil, err
+	}
+	if filter.CreatedBefore, err = list_utility.ParseTime(c, "createdBefore"); err != nil {
+		return nil, err
+	}
+	if filter.Banned, err = list_utility.ParseBool(c, "banned"); err != nil {
+		return nil, err
+	}
+	deleted, err := list_utility.ParseBool(c, "deleted")
+	if err != nil {
+		return nil, err
+	}
+	filter.Deleted = deleted != nil && *deleted
+
+	return filter, nil
+}
+
 func (auc *UserAdminController) update(c *gin.Context) {
 	// get user to update
 	userId, err := strconv.ParseInt(c.Param("userId"), 10, 64)
@@ -140,6 +239,29 @@ func (auc *UserAdminController) update(c *gin.Context) {
 		return
 	}
 
+	if user.Locale != "" {
+		locale, ok := localeUtil.Normalize(user.Locale)
+		if !ok {
+			errors.Response(c, http.StatusBadRequest, errors.ApiError_Locale, nil)
+			return
+		}
+		user.Locale = locale
+	}
+
+	// admins can change any profile field, validated before storing anything so an invalid value doesn't leave a
+	// partial update
+	if len(user.Profile) > 0 {
+		err = auc.ServicesGroup.ProfileService.ValidateProfile(userId, user.Profile, true)
+		if fieldErrors, ok := err.(errors.FieldErrors); ok {
+			errors.ResponseWithFieldErrors(c, http.StatusBadRequest, profile_model.ApiError_Profile, fieldErrors)
+			return
+		}
+		if err != nil {
+			errors.Response(c, http.StatusInternalServerError, "Couldn't validate profile.", err)
+			return
+		}
+	}
+
 	// do update
 	err = auc.ServicesGroup.UserService.Update(userId, user)
 	if err != nil {
@@ -147,22 +269,219 @@ func (auc *UserAdminController) update(c *gin.Context) {
 		return
 	}
 
+	// profile values are stored after the user row so a failed user update doesn't leave new profile values behind
+	if len(user.Profile) > 0 {
+		err = auc.ServicesGroup.ProfileService.UpdateProfile(userId, user.Profile, true)
+		if err != nil {
+			errors.Response(c, http.StatusInternalServerError, "Couldn't update profile.", err)
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, user)
 }
 
+/**
+* @api {delete} /admin/user/:userId Delete User
+* @apiDescription Delete the user. They can't log in and are left out of the user list, but can be restored until they
+* are permanently deleted USER_DELETED_RETENTION_DAYS later. Permanently deleting asks plugins with a user data delete
+* hook to erase their data first, the user is kept if one of them fails.
+* @apiName DeleteUser
+* @apiGroup Admin
+*
+* @apiUse AuthHeader
+* @apiParam (Query) {boolean} [permanent=false] Delete the user and their data now, without the chance to restore them.
+* @apiPermission Admin
+ */
 func (auc *UserAdminController) delete(c *gin.Context) {
+	authUser, _ := api_utility.GetUserFromContext(c)
+
 	userId, err := strconv.ParseInt(c.Param("userId"), 10, 64)
 	if err != nil {
 		errors.Response(c, http.StatusBadRequest, "Missing Id Field", err)
 		return
 	}
 
-	// delete user
-	err = auc.ServicesGroup.UserService.Delete(userId)
+	if userId == authUser.Id {
+		errors.Response(c, http.StatusBadRequest, "You can't delete yourself.", nil)
+		return
+	}
+
+	permanent, err := list_utility.ParseBool(c, "permanent")
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	user, err := auc.ServicesGroup.UserService.Get(userId)
+	if err != nil {
+		errors.Response(c, http.StatusNotFound, errors.ApiError_UserDoesntExist, err)
+		return
+	}
+
+	if permanent != nil && *permanent {
+		// delete user, their plugin data and uploaded files
+		err = auc.ServicesGroup.PrivacyService.DeleteUser(user, authUser.Id)
+		if err != nil {
+			errors.Response(c, http.StatusInternalServerError, "Couldn't delete user.", err)
+			return
+		}
+		c.Status(http.StatusOK)
+		return
+	}
+
+	if user.IsDeleted() {
+		errors.Response(c, http.StatusBadRequest, "User is already deleted.", nil)
+		return
+	}
+
+	err = auc.ServicesGroup.UserService.SoftDelete(user.Id, authUser.Id)
 	if err != nil {
 		errors.Response(c, http.StatusInternalServerError, "Couldn't delete user.", err)
 		return
 	}
+	auc.ServicesGroup.LogService.RecordAudit(user.Id, authUser.Id, log_model.Audit_User_Delete, "")
 
 	c.Status(http.StatusOK)
 }
+
+/**
+* @api {post} /admin/user/:userId/restore Restore User
+* @apiDescription Restore a deleted user that hasn't been permanently deleted yet.
+* @apiName RestoreUser
+* @apiGroup Admin
+*
+* @apiUse AuthHeader
+* @apiUse UserAdminDisplay
+* @apiPermission Admin
+ */
+func (auc *UserAdminController) restore(c *gin.Context) {
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	user, ok := auc.getUser(c)
+	if !ok {
+		return
+	}
+
+	if !user.IsDeleted() {
+		errors.Response(c, http.StatusBadRequest, "User isn't deleted.", nil)
+		return
+	}
+
+	err := auc.ServicesGroup.UserService.Restore(user.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't restore user.", err)
+		return
+	}
+	auc.ServicesGroup.LogService.RecordAudit(user.Id, authUser.Id, log_model.Audit_User_Restore, "")
+
+	user.Deleted = nil
+	user.DeletedBy = 0
+	c.JSON(http.StatusOK, user.GetUserAdminDisplay())
+}
+
+/**
+* @api {put} /admin/user/:userId/ban Ban User
+* @apiDescription Block the user from logging in and using their existing tokens and api keys until the ban expires or
+* is lifted. They are told the reason. Unlike disabling, a ban can't be undone by the user and is kept apart from
+* enabled. Banning a banned user replaces the ban.
+* @apiName BanUser
+* @apiGroup Admin
+*
+* @apiUse AuthHeader
+* @apiUse UserBanInput
+* @apiUse UserAdminDisplay
+* @apiPermission Admin
+ */
+func (auc *UserAdminController) ban(c *gin.Context) {
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	user, ok := auc.getUser(c)
+	if !ok {
+		return
+	}
+
+	if user.Id == authUser.Id {
+		errors.Response(c, http.StatusBadRequest, "You can't ban yourself.", nil)
+		return
+	}
+
+	var banInput user_model.UserBanInput
+	err := c.BindJSON(&banInput)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	err = auc.ServicesGroup.UserService.Ban(user.Id, &banInput, authUser.Id)
+	if fieldErrors, ok := err.(errors.FieldErrors); ok {
+		errors.ResponseWithFieldErrors(c, http.StatusBadRequest, fieldErrors.Error(), fieldErrors)
+		return
+	}
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't ban user.", err)
+		return
+	}
+
+	detail := "until lifted"
+	if banInput.Expires != nil {
+		detail = "until " + banInput.Expires.Format(time.RFC3339)
+	}
+	auc.ServicesGroup.LogService.RecordAudit(user.Id, authUser.Id, log_model.Audit_User_Ban, detail+": "+banInput.Reason)
+
+	user, err = auc.ServicesGroup.UserService.Get(user.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get user.", err)
+		return
+	}
+	c.JSON(http.StatusOK, user.GetUserAdminDisplay())
+}
+
+/**
+* @api {delete} /admin/user/:userId/ban Lift Ban
+* @apiName UnbanUser
+* @apiGroup Admin
+*
+* @apiUse AuthHeader
+* @apiUse UserAdminDisplay
+* @apiPermission Admin
+ */
+func (auc *UserAdminController) unban(c *gin.Context) {
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	user, ok := auc.getUser(c)
+	if !ok {
+		return
+	}
+
+	if user.Banned == nil {
+		errors.Response(c, http.StatusBadRequest, "User isn't banned.", nil)
+		return
+	}
+
+	err := auc.ServicesGroup.UserService.Unban(user.Id)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't lift ban.", err)
+		return
+	}
+	auc.ServicesGroup.LogService.RecordAudit(user.Id, authUser.Id, log_model.Audit_User_Unban, "")
+
+	user.Banned = nil
+	c.JSON(http.StatusOK, user.GetUserAdminDisplay())
+}
+
+// getUser gets the user of the userId route parameter, or responds with an error.
+func (auc *UserAdminController) getUser(c *gin.Context) (*user_model.User, bool) {
+	userId, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Id Field", err)
+		return nil, false
+	}
+
+	user, err := auc.ServicesGroup.UserService.Get(userId)
+	if err != nil {
+		errors.Response(c, http.StatusNotFound, errors.ApiError_UserDoesntExist, err)
+		return nil, false
+	}
+	return user, true
+}
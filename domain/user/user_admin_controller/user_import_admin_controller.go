@@ -0,0 +1,275 @@
+package user_admin_controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
+	"github.com/cqlcorp/gocms/domain/user/user_import/user_import_model"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/init/service"
+	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/api_utility"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/list_utility"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IMPORT_FILE_FIELD = "file"
+	// max size of an import file
+	maxImportSize = 20 * 1024 * 1024
+	// room for the multipart boundaries and other fields around the file
+	importUploadOverhead = 64 * 1024
+	// jobs listed by GET /admin/user-import
+	recentImportJobs = 50
+)
+
+type UserImportAdminController struct {
+	routes        *routes.Routes
+	ServicesGroup *service.ServicesGroup
+	adminRoutes   *access_control_middleware.PermissionGroup
+}
+
+func DefaultUserImportAdminController(routes *routes.Routes, sg *service.ServicesGroup) *UserImportAdminController {
+	userImportAdminController := &UserImportAdminController{
+		routes:        routes,
+		ServicesGroup: sg,
+		adminRoutes:   access_control_middleware.NewPermissionGroup(routes.Auth, "/admin", sg.AclService, permissions.SUPER_ADMIN),
+	}
+
+	userImportAdminController.Default()
+	return userImportAdminController
+}
+
+func (uiac *UserImportAdminController) Default() {
+	uiac.adminRoutes.POST("/user-import", uiac.startImport)
+	uiac.adminRoutes.GET("/user-import", uiac.getJobs)
+	uiac.adminRoutes.GET("/user-import/:jobId", uiac.getJob)
+	uiac.adminRoutes.GET("/user-export", uiac.export)
+}
+
+/**
+* @api {post} /admin/user-import Import Users
+* @apiDescription Start importing users from a csv or json file in the background, poll the returned job for progress.
+* Users are matched by email: existing users are updated and missing users are created without a password, so running
+* the same import again changes nothing. Empty cells leave a value unchanged and users are only ever added to groups.
+* Rows with errors are skipped and listed on the job. Fields are email, fullName, gender (0-2, male or female), enabled,
+* groups (names separated by ;) and profile.<name>. Files made by Export Users can be imported as is.
+* @apiName ImportUsers
+* @apiGroup Admin
+*
+* @apiUse AuthHeader
+* @apiParam (Request) {File} file Csv with a header row or a json array of objects, max 20MB and USER_IMPORT_MAX_ROWS rows.
+* @apiParam (Request) {string} [format] csv or json. Defaults to the file extension.
+* @apiParam (Request) {string} [mapping] Json object of column name to field. Columns named like a field don't need to
+* be mapped, map a column to "" to ignore it. Ex: {"E-mail": "email", "Name": "fullName", "Company": "profile.company"}
+* @apiParam (Request) {string} [groupIds] Comma separated ids of groups to add every user to.
+* @apiParam (Request) {boolean} [dryRun=false] Only validate the rows and count the users that would be added or updated.
+* @apiParam (Request) {boolean} [invite=false] Email new users a code to choose their password, valid for INVITATION_TIMEOUT.
+* @apiUse ImportJobDisplay
+* @apiPermission Admin
+ */
+func (uiac *UserImportAdminController) startImport(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize+importUploadOverhead)
+	file, header, err := c.Request.FormFile(IMPORT_FILE_FIELD)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing file or file is too large.", err)
+		return
+	}
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(file, maxImportSize+1))
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't read file.", err)
+		return
+	}
+	if len(data) > maxImportSize {
+		errors.Response(c, http.StatusBadRequest, "File is too large.", nil)
+		return
+	}
+
+	options, err := getImportOptions(c, header.Filename)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	job, err := uiac.ServicesGroup.UserImportService.StartImport(authUser.Id, data, options)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't start import.", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job.GetImportJobDisplay())
+}
+
+/**
+* @api {get} /admin/user-import Get Imports
+* @apiDescription Get the 50 latest user imports, newest first.
+* @apiName GetUserImports
+* @apiGroup Admin
+*
+* @apiUse AuthHeader
+* @apiSuccess (Response) {ImportJobDisplay[]} jobs See ImportJobDisplay.
+* @apiPermission Admin
+ */
+func (uiac *UserImportAdminController) getJobs(c *gin.Context) {
+	jobs, err := uiac.ServicesGroup.UserImportService.GetRecentJobs(recentImportJobs)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get imports.", err)
+		return
+	}
+
+	jobDisplays := make([]*user_import_model.ImportJobDisplay, len(jobs))
+	for i, job := range jobs {
+		jobDisplays[i] = job.GetImportJobDisplay()
+	}
+	c.JSON(http.StatusOK, jobDisplays)
+}
+
+/**
+* @api {get} /admin/user-import/:jobId Get Import
+* @apiDescription Get the progress and row errors of a user import.
+* @apiName GetUserImport
+* @apiGroup Admin
+*
+* @apiUse AuthHeader
+* @apiUse ImportJobDisplay
+* @apiPermission Admin
+ */
+func (uiac *UserImportAdminController) getJob(c *gin.Context) {
+	jobId, err := strconv.ParseInt(c.Param("jobId"), 10, 64)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing Id Field", err)
+		return
+	}
+
+	job, err := uiac.ServicesGroup.UserImportService.GetJob(jobId)
+	if err == sql.ErrNoRows {
+		errors.Response(c, http.StatusNotFound, "Import doesn't exist.", err)
+		return
+	}
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get import.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job.GetImportJobDisplay())
+}
+
+/**
+* @api {get} /admin/user-export Export Users
+* @apiDescription Download the users matching the search and filters in the format read by Import Users, along with
+* their id, isVerified and created which the import ignores. Csv cells starting with =, +, - or @ are prefixed with ' so
+* spreadsheets don't run them as formulas, the import removes the prefix.
+* @apiName ExportUsers
+* @apiGroup Admin
+*
+* @apiUse AuthHeader
+* @apiParam (Query) {string} [format=csv] csv or json.
+* @apiParam (Query) {string} [search] Case insensitive text to search for.
+* @apiParam (Query) {string} [sort=id] Field to sort by, prefix with - for descending.
+* @apiUse UserListFilter
+* @apiSuccess (Response) {File} file csv or json attachment.
+* @apiPermission Admin
+ */
+func (uiac *UserImportAdminController) export(c *gin.Context) {
+	query, err := list_utility.ParseQuery(c, user_model.UserListSortColumns, "id")
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	filter, err := getUserListFilter(c)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	format := c.DefaultQuery("format", user_import_model.FORMAT_CSV)
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case user_import_model.FORMAT_CSV:
+	case user_import_model.FORMAT_JSON:
+		contentType = "application/json; charset=utf-8"
+	default:
+		errors.Response(c, http.StatusBadRequest, "Format must be csv or json.", nil)
+		return
+	}
+
+	filename := fmt.Sprintf("users-%v.%v", time.Now().Format("20060102"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%v\"", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	// the response has started, errors can only end it early
+	err = uiac.ServicesGroup.UserImportService.Export(c.Writer, format, query, filter)
+	if err != nil {
+		c.Error(err)
+	}
+}
+
+func getImportOptions(c *gin.Context, filename string) (*user_import_model.ImportOptions, error) {
+	var err error
+	options := &user_import_model.ImportOptions{
+		Filename: filepath.Base(filename),
+		Format:   strings.ToLower(c.PostForm("format")),
+	}
+	if len(options.Filename) > 255 {
+		options.Filename = options.Filename[:255]
+	}
+	if options.Format == "" {
+		options.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+
+	if mapping := c.PostForm("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &options.Mapping); err != nil {
+			return nil, errors.NewToUser("mapping must be a json object of column name to field.")
+		}
+	}
+
+	for _, groupId := range strings.Split(c.PostForm("groupIds"), ",") {
+		if groupId = strings.TrimSpace(groupId); groupId == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(groupId, 10, 64)
+		if err != nil {
+			return nil, errors.NewToUser("groupIds must be comma separated numbers.")
+		}
+		options.GroupIds = append(options.GroupIds, id)
+	}
+
+	if options.DryRun, err = parseFormBool(c, "dryRun"); err != nil {
+		return nil, err
+	}
+	if options.Invite, err = parseFormBool(c, "invite"); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+func parseFormBool(c *gin.Context, name string) (bool, error) {
+	value := c.PostForm(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.NewToUser(fmt.Sprintf("%v must be true or false.", name))
+	}
+	return b, nil
+}
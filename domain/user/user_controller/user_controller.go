@@ -2,14 +2,27 @@ package user_controller
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/cqlcorp/gocms/context"
 	"github.com/cqlcorp/gocms/utility/api_utility"
 	"github.com/cqlcorp/gocms/domain/user/user_model"
 	"github.com/cqlcorp/gocms/init/service"
 	"github.com/cqlcorp/gocms/routes"
 	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/localeUtil"
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/impersonation/impersonation_middleware"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_model"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_service"
+	"github.com/cqlcorp/gocms/domain/profile/profile_model"
+	"github.com/cqlcorp/gocms/domain/photo/photo_service"
+	"io"
+	"io/ioutil"
 	"net/http"
 )
 
+// room for the multipart boundaries and headers around the photo
+const photoUploadOverhead = 64 * 1024
+
 type UserController struct {
 	routes        *routes.Routes
 	ServicesGroup *service.ServicesGroup
@@ -27,9 +40,12 @@ func DefaultUserController(routes *routes.Routes, sg *service.ServicesGroup) *Us
 func (uc *UserController) Default() {
 
 	uc.routes.Auth.GET("/user", uc.get)
-	uc.routes.Auth.PUT("/user", uc.update)
-	uc.routes.Auth.PUT("/user/deactivate", uc.deactivateUser)
-	uc.routes.Auth.PUT("/user/changePassword", uc.changePassword)
+	uc.routes.Auth.PUT("/user", api_key_middleware.RequireUserToken(), impersonation_middleware.BlockWhileImpersonating(), uc.update)
+	uc.routes.Auth.GET("/user/profileField", uc.getProfileFields)
+	uc.routes.Auth.POST("/user/photo", impersonation_middleware.BlockWhileImpersonating(), uc.uploadPhoto)
+	uc.routes.Auth.DELETE("/user/photo", impersonation_middleware.BlockWhileImpersonating(), uc.deletePhoto)
+	uc.routes.Auth.PUT("/user/deactivate", api_key_middleware.RequireUserToken(), impersonation_middleware.BlockWhileImpersonating(), uc.deactivateUser)
+	uc.routes.Auth.PUT("/user/changePassword", api_key_middleware.RequireUserToken(), impersonation_middleware.BlockWhileImpersonating(), uc.changePassword)
 
 }
 
@@ -46,7 +62,43 @@ func (uc *UserController) get(c *gin.Context) {
 
 	authUser, _ := api_utility.GetUserFromContext(c)
 
-	c.JSON(http.StatusOK, authUser.GetUserDisplay())
+	profile, err := uc.ServicesGroup.ProfileService.GetProfile(authUser.Id, false)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get profile.", err)
+		return
+	}
+
+	userDisplay := authUser.GetUserDisplay()
+	userDisplay.Profile = profile
+	c.JSON(http.StatusOK, userDisplay)
+}
+
+/**
+* @api {get} /user/profileField Get Profile Fields
+* @apiDescription Get the custom profile fields the user can see, to build the profile form. Fields the user can't
+* change have editableBy admin.
+* @apiName GetUserProfileFields
+* @apiGroup User
+*
+* @apiUse AuthHeader
+* @apiSuccess (Response) {ProfileField[]} fields See ProfileField.
+* @apiPermission Authenticated
+ */
+func (uc *UserController) getProfileFields(c *gin.Context) {
+	fields, err := uc.ServicesGroup.ProfileService.GetFields()
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't get profile fields.", err)
+		return
+	}
+
+	visible := make([]*profile_model.ProfileField, 0, len(fields))
+	for _, field := range fields {
+		if field.VisibleTo(false) {
+			visible = append(visible, field)
+		}
+	}
+
+	c.JSON(http.StatusOK, visible)
 }
 
 /**
@@ -86,6 +138,32 @@ func (uc *UserController) update(c *gin.Context) {
 		break
 	}
 
+	// an empty locale goes back to the default
+	if userForUpdate.Locale != nil {
+		authUser.Locale = ""
+		if *userForUpdate.Locale != "" {
+			locale, ok := localeUtil.Normalize(*userForUpdate.Locale)
+			if !ok {
+				errors.Response(c, http.StatusBadRequest, errors.ApiError_Locale, nil)
+				return
+			}
+			authUser.Locale = locale
+		}
+	}
+
+	// validate profile fields before storing anything so an invalid value doesn't leave a partial update
+	if len(userForUpdate.Profile) > 0 {
+		err = uc.ServicesGroup.ProfileService.ValidateProfile(authUser.Id, userForUpdate.Profile, false)
+		if fieldErrors, ok := err.(errors.FieldErrors); ok {
+			errors.ResponseWithFieldErrors(c, http.StatusBadRequest, profile_model.ApiError_Profile, fieldErrors)
+			return
+		}
+		if err != nil {
+			errors.Response(c, http.StatusInternalServerError, "Couldn't validate profile.", err)
+			return
+		}
+	}
+
 	// do update
 	err = uc.ServicesGroup.UserService.Update(authUser.Id, authUser)
 	if err != nil {
@@ -93,6 +171,83 @@ func (uc *UserController) update(c *gin.Context) {
 		return
 	}
 
+	// profile values are stored after the user row so a failed user update doesn't leave new profile values behind
+	if len(userForUpdate.Profile) > 0 {
+		err = uc.ServicesGroup.ProfileService.UpdateProfile(authUser.Id, userForUpdate.Profile, false)
+		if err != nil {
+			errors.Response(c, http.StatusInternalServerError, "Couldn't update profile.", err)
+			return
+		}
+	}
+
+	c.Status(http.StatusOK)
+}
+
+/**
+* @api {post} /user/photo Upload Photo
+* @apiDescription Upload a jpeg, png or gif as multipart/form-data. The image is cropped to a centered square and
+* resized to each of USER_PHOTO_SIZES. Exif orientation is applied and all metadata is removed. The largest variant
+* becomes the users photo.
+* @apiName UploadUserPhoto
+* @apiGroup User
+*
+* @apiUse AuthHeader
+* @apiParam (Request) {File} photo Max USER_PHOTO_MAX_SIZE bytes.
+* @apiUse UserDisplay
+* @apiPermission Authenticated
+ */
+func (uc *UserController) uploadPhoto(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	maxSize := context.Config.DbVars.UserPhotoMaxSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+photoUploadOverhead)
+	file, _, err := c.Request.FormFile(photo_service.PHOTO_FIELD)
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Missing photo or photo is too large.", err)
+		return
+	}
+	defer file.Close()
+
+	// read one byte more than allowed so the service can reject it
+	data, err := ioutil.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		errors.Response(c, http.StatusBadRequest, "Couldn't read photo.", err)
+		return
+	}
+
+	err = uc.ServicesGroup.PhotoService.SetUserPhoto(authUser, data)
+	if fieldErrors, ok := err.(errors.FieldErrors); ok {
+		errors.ResponseWithFieldErrors(c, http.StatusBadRequest, fieldErrors.Error(), fieldErrors)
+		return
+	}
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't save photo.", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, authUser.GetUserDisplay())
+}
+
+/**
+* @api {delete} /user/photo Delete Photo
+* @apiDescription Removes the uploaded photo and its variants, or clears the photo from a social login.
+* @apiName DeleteUserPhoto
+* @apiGroup User
+*
+* @apiUse AuthHeader
+* @apiPermission Authenticated
+ */
+func (uc *UserController) deletePhoto(c *gin.Context) {
+
+	authUser, _ := api_utility.GetUserFromContext(c)
+
+	err := uc.ServicesGroup.PhotoService.DeleteUserPhoto(authUser)
+	if err != nil {
+		errors.Response(c, http.StatusInternalServerError, "Couldn't delete photo.", err)
+		return
+	}
+
 	c.Status(http.StatusOK)
 }
 
@@ -126,6 +281,10 @@ func (uc *UserController) changePassword(c *gin.Context) {
 
 	// do update
 	err = uc.ServicesGroup.UserService.UpdatePassword(authUser.Id, changePasswordInput.NewPassword)
+	if fieldErrors, ok := err.(errors.FieldErrors); ok {
+		errors.ResponseWithFieldErrors(c, http.StatusBadRequest, password_policy_model.ApiError_Password_Policy, fieldErrors.Rename(password_policy_service.PASSWORD_FIELD, "newPassword"))
+		return
+	}
 	if err != nil {
 		errors.Response(c, http.StatusInternalServerError, "Couldn't update user.", err)
 		return
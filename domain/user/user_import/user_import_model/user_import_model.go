@@ -0,0 +1,160 @@
+package user_import_model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cqlcorp/gocms/utility/errors"
+)
+
+// file formats for imports and exports
+const (
+	FORMAT_CSV  = "csv"
+	FORMAT_JSON = "json"
+)
+
+const (
+	Job_Pending  = "pending"
+	Job_Running  = "running"
+	Job_Complete = "complete"
+	Job_Failed   = "failed"
+)
+
+// fields a column can be mapped to, along with profile.<name> for custom profile fields
+const (
+	FIELD_EMAIL          = "email"
+	FIELD_FULL_NAME      = "fullName"
+	FIELD_GENDER         = "gender"
+	FIELD_ENABLED        = "enabled"
+	FIELD_GROUPS         = "groups"
+	FIELD_PROFILE_PREFIX = "profile."
+)
+
+// exported only, ignored when imported so an export can be imported again
+const (
+	FIELD_ID       = "id"
+	FIELD_VERIFIED = "isVerified"
+	FIELD_CREATED  = "created"
+)
+
+// GROUP_SEPARATOR separates group names in the groups column
+const GROUP_SEPARATOR = ";"
+
+// ImportJob is an import running in the background. Rows are validated and applied one at a time, progress is saved
+// as it goes.
+type ImportJob struct {
+	Id           int64     `db:"id"`
+	CreatedBy    int64     `db:"createdBy"`
+	Filename     string    `db:"filename"`
+	Format       string    `db:"format"`
+	DryRun       bool      `db:"dryRun"`
+	Invite       bool      `db:"invite"`
+	Status       string    `db:"status"`
+	Total        int64     `db:"total"`
+	Processed    int64     `db:"processed"`
+	Added        int64     `db:"added"`
+	Updated      int64     `db:"updated"`
+	Failed       int64     `db:"failed"`
+	RowErrors    string    `db:"rowErrors"`
+	Error        string    `db:"error"`
+	Created      time.Time `db:"created"`
+	LastModified time.Time `db:"lastModified"`
+}
+
+// RowError lists the problems with a row of the file. Row 1 is the first row after the csv header, or the first
+// object of a json file.
+type RowError struct {
+	Row    int64              `json:"row"`
+	Email  string             `json:"email,omitempty"`
+	Fields errors.FieldErrors `json:"fields"`
+}
+
+/**
+* @apiDefine ImportJobDisplay
+* @apiSuccess (Response) {number} id
+* @apiSuccess (Response) {number} createdBy Id of the admin that started the import.
+* @apiSuccess (Response) {string} filename
+* @apiSuccess (Response) {string} format csv or json.
+* @apiSuccess (Response) {boolean} dryRun Rows were only validated, nothing was changed.
+* @apiSuccess (Response) {boolean} invite New users are emailed a code to choose their password.
+* @apiSuccess (Response) {string} status pending, running, complete or failed.
+* @apiSuccess (Response) {number} total Rows in the file.
+* @apiSuccess (Response) {number} processed Rows done so far.
+* @apiSuccess (Response) {number} added Users created, or that would be created by a dry run.
+* @apiSuccess (Response) {number} updated Existing users updated, or that would be updated by a dry run.
+* @apiSuccess (Response) {number} failed Rows with errors, they are skipped.
+* @apiSuccess (Response) {Object[]} rowErrors The first 1000 rows with errors: row, email and fields, a list of field
+* and message.
+* @apiSuccess (Response) {string} [error] Why the job failed.
+* @apiSuccess (Response) {string} created
+* @apiSuccess (Response) {string} lastModified
+ */
+type ImportJobDisplay struct {
+	Id           int64       `json:"id"`
+	CreatedBy    int64       `json:"createdBy"`
+	Filename     string      `json:"filename"`
+	Format       string      `json:"format"`
+	DryRun       bool        `json:"dryRun"`
+	Invite       bool        `json:"invite"`
+	Status       string      `json:"status"`
+	Total        int64       `json:"total"`
+	Processed    int64       `json:"processed"`
+	Added        int64       `json:"added"`
+	Updated      int64       `json:"updated"`
+	Failed       int64       `json:"failed"`
+	RowErrors    []*RowError `json:"rowErrors"`
+	Error        string      `json:"error,omitempty"`
+	Created      time.Time   `json:"created"`
+	LastModified time.Time   `json:"lastModified"`
+}
+
+func (job *ImportJob) GetImportJobDisplay() *ImportJobDisplay {
+	rowErrors := []*RowError{}
+	if job.RowErrors != "" {
+		json.Unmarshal([]byte(job.RowErrors), &rowErrors)
+	}
+
+	return &ImportJobDisplay{
+		Id:           job.Id,
+		CreatedBy:    job.CreatedBy,
+		Filename:     job.Filename,
+		Format:       job.Format,
+		DryRun:       job.DryRun,
+		Invite:       job.Invite,
+		Status:       job.Status,
+		Total:        job.Total,
+		Processed:    job.Processed,
+		Added:        job.Added,
+		Updated:      job.Updated,
+		Failed:       job.Failed,
+		RowErrors:    rowErrors,
+		Error:        job.Error,
+		Created:      job.Created,
+		LastModified: job.LastModified,
+	}
+}
+
+// ImportOptions are chosen by the admin when starting an import.
+type ImportOptions struct {
+	Filename string
+	Format   string
+	// Mapping maps the columns of the file to fields. Columns named like a field are mapped to it unless mapped to
+	// something else, other columns are ignored.
+	Mapping map[string]string
+	// GroupIds every imported user is added to
+	GroupIds []int64
+	DryRun   bool
+	// Invite emails new users a code to choose their password
+	Invite bool
+}
+
+// ImportRow is a row of the file mapped to fields. Fields that aren't mapped are nil and left unchanged.
+type ImportRow struct {
+	Row      int64
+	Email    string
+	FullName *string
+	Gender   *string
+	Enabled  *string
+	Groups   *string
+	Profile  map[string]interface{}
+}
@@ -0,0 +1,98 @@
+package user_import_repository
+
+import (
+	"time"
+
+	"github.com/cqlcorp/gocms/domain/user/user_import/user_import_model"
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/jmoiron/sqlx"
+)
+
+type IUserImportRepository interface {
+	Add(*user_import_model.ImportJob) error
+	Get(id int64) (*user_import_model.ImportJob, error)
+	GetRecent(limit int64) ([]*user_import_model.ImportJob, error)
+	UpdateProgress(*user_import_model.ImportJob) error
+	FailStale(before time.Time, message string) error
+}
+
+type UserImportRepository struct {
+	database *sqlx.DB
+}
+
+func DefaultUserImportRepository(dbx *sqlx.DB) *UserImportRepository {
+	userImportRepository := &UserImportRepository{
+		database: dbx,
+	}
+	return userImportRepository
+}
+
+func (uir *UserImportRepository) Add(job *user_import_model.ImportJob) error {
+	job.Created = time.Now()
+	job.LastModified = job.Created
+	result, err := uir.database.NamedExec(`
+	INSERT INTO gocms_user_import_jobs (createdBy, filename, format, dryRun, invite, status, total, rowErrors, created, lastModified)
+		VALUES (:createdBy, :filename, :format, :dryRun, :invite, :status, :total, :rowErrors, :created, :lastModified)
+	`, job)
+	if err != nil {
+		log.Errorf("Error adding user import job: %s\n", err.Error())
+		return err
+	}
+	id, _ := result.LastInsertId()
+	job.Id = id
+	return nil
+}
+
+func (uir *UserImportRepository) Get(id int64) (*user_import_model.ImportJob, error) {
+	var job user_import_model.ImportJob
+	err := uir.database.Get(&job, `
+	SELECT * FROM gocms_user_import_jobs WHERE id=?
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetRecent returns the latest jobs, newest first.
+func (uir *UserImportRepository) GetRecent(limit int64) ([]*user_import_model.ImportJob, error) {
+	jobs := []*user_import_model.ImportJob{}
+	err := uir.database.Select(&jobs, `
+	SELECT * FROM gocms_user_import_jobs
+	ORDER BY created DESC, id DESC
+	LIMIT ?
+	`, limit)
+	if err != nil {
+		log.Errorf("Error getting user import jobs: %s\n", err.Error())
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// UpdateProgress saves the status, counts and errors of the job.
+func (uir *UserImportRepository) UpdateProgress(job *user_import_model.ImportJob) error {
+	job.LastModified = time.Now()
+	_, err := uir.database.NamedExec(`
+	UPDATE gocms_user_import_jobs SET status=:status, processed=:processed, added=:added, updated=:updated, failed=:failed,
+		rowErrors=:rowErrors, error=:error, lastModified=:lastModified
+	WHERE id=:id
+	`, job)
+	if err != nil {
+		log.Errorf("Error updating user import job %v: %s\n", job.Id, err.Error())
+		return err
+	}
+	return nil
+}
+
+// FailStale fails the unfinished jobs that haven't progressed since before, their instance stopped while running them.
+func (uir *UserImportRepository) FailStale(before time.Time, message string) error {
+	_, err := uir.database.Exec(`
+	UPDATE gocms_user_import_jobs SET status=?, error=?, lastModified=?
+	WHERE status IN (?, ?) AND lastModified<?
+	`, user_import_model.Job_Failed, message, time.Now(), user_import_model.Job_Pending, user_import_model.Job_Running, before)
+	if err != nil {
+		log.Errorf("Error failing stale user import jobs: %s\n", err.Error())
+		return err
+	}
+	return nil
+}
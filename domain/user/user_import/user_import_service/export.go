@@ -0,0 +1,218 @@
+package user_import_service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cqlcorp/gocms/domain/profile/profile_model"
+	"github.com/cqlcorp/gocms/domain/user/user_import/user_import_model"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/list_utility"
+)
+
+type IExportService interface {
+	Export(w io.Writer, format string, query *list_utility.Query, filter *user_model.UserListFilter) error
+}
+
+// exportUser is a user in a json export. The field names match the import so an export can be imported again.
+type exportUser struct {
+	Id         int64                 `json:"id"`
+	Email      string                `json:"email"`
+	FullName   string                `json:"fullName"`
+	Gender     int64                 `json:"gender"`
+	Enabled    bool                  `json:"enabled"`
+	IsVerified bool                  `json:"isVerified"`
+	Created    time.Time             `json:"created"`
+	Groups     []string              `json:"groups"`
+	Profile    profile_model.Profile `json:"profile"`
+}
+
+// Export writes every user matching the search and filters, in pages so the whole list isn't held in memory. The
+// limit and offset of the query are ignored.
+func (uis *UserImportService) Export(w io.Writer, format string, query *list_utility.Query, filter *user_model.UserListFilter) error {
+	var writer exportWriter
+	switch format {
+	case user_import_model.FORMAT_CSV:
+		writer = &csvExportWriter{writer: csv.NewWriter(w)}
+	case user_import_model.FORMAT_JSON:
+		writer = &jsonExportWriter{w: w}
+	default:
+		return errors.NewToUser("Format must be csv or json.")
+	}
+
+	fields, err := uis.ProfileService.GetFields()
+	if err != nil {
+		return err
+	}
+	if err := writer.start(fields); err != nil {
+		return err
+	}
+
+	page := *query
+	page.Limit = list_utility.MAX_LIMIT
+	page.Offset = 0
+	for {
+		users, total, err := uis.UserService.List(&page, filter)
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			exported, err := uis.exportUser(&user)
+			if err != nil {
+				return err
+			}
+			if err := writer.write(exported); err != nil {
+				return err
+			}
+		}
+
+		page.Offset += page.Limit
+		if len(users) == 0 || page.Offset >= total {
+			break
+		}
+	}
+
+	return writer.end()
+}
+
+func (uis *UserImportService) exportUser(user *user_model.User) (*exportUser, error) {
+	groups, err := uis.GroupService.GetUserGroups(user.Id)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := uis.ProfileService.GetProfile(user.Id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	exported := &exportUser{
+		Id:         user.Id,
+		Email:      user.Email,
+		FullName:   user.FullName,
+		Gender:     user.Gender,
+		Enabled:    user.Enabled,
+		IsVerified: user.Verified,
+		Created:    user.Created,
+		Groups:     make([]string, len(groups)),
+		Profile:    profile,
+	}
+	for i, group := range groups {
+		exported.Groups[i] = group.Name
+	}
+	return exported, nil
+}
+
+type exportWriter interface {
+	start(fields []*profile_model.ProfileField) error
+	write(*exportUser) error
+	end() error
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+	fields []*profile_model.ProfileField
+}
+
+func (cw *csvExportWriter) start(fields []*profile_model.ProfileField) error {
+	cw.fields = fields
+	header := []string{
+		user_import_model.FIELD_ID,
+		user_import_model.FIELD_EMAIL,
+		user_import_model.FIELD_FULL_NAME,
+		user_import_model.FIELD_GENDER,
+		user_import_model.FIELD_ENABLED,
+		user_import_model.FIELD_VERIFIED,
+		user_import_model.FIELD_CREATED,
+		user_import_model.FIELD_GROUPS,
+	}
+	for _, field := range fields {
+		header = append(header, user_import_model.FIELD_PROFILE_PREFIX+field.Name)
+	}
+	return cw.writer.Write(header)
+}
+
+func (cw *csvExportWriter) write(user *exportUser) error {
+	cells := []string{
+		strconv.FormatInt(user.Id, 10),
+		user.Email,
+		user.FullName,
+		strconv.FormatInt(user.Gender, 10),
+		strconv.FormatBool(user.Enabled),
+		strconv.FormatBool(user.IsVerified),
+		user.Created.Format(time.RFC3339),
+		strings.Join(user.Groups, user_import_model.GROUP_SEPARATOR),
+	}
+	for _, field := range cw.fields {
+		cells = append(cells, formatValue(user.Profile[field.Name]))
+	}
+	for i, cell := range cells {
+		cells[i] = escapeFormula(cell)
+	}
+	return cw.writer.Write(cells)
+}
+
+func (cw *csvExportWriter) end() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+type jsonExportWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (jw *jsonExportWriter) start(fields []*profile_model.ProfileField) error {
+	_, err := io.WriteString(jw.w, "[")
+	return err
+}
+
+func (jw *jsonExportWriter) write(user *exportUser) error {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	separator := "\n"
+	if jw.count > 0 {
+		separator = ",\n"
+	}
+	jw.count++
+	if _, err := io.WriteString(jw.w, separator); err != nil {
+		return err
+	}
+	_, err = jw.w.Write(data)
+	return err
+}
+
+func (jw *jsonExportWriter) end() error {
+	_, err := io.WriteString(jw.w, "\n]\n")
+	return err
+}
+
+// formatValue writes a profile value the way the import reads it.
+// escapeFormula prefixes cells spreadsheets would run as a formula with a quote so they are shown as text. The import
+// removes the quote again, see unescapeFormula.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return formulaEscape + cell
+	}
+	return cell
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,28 @@
+package user_import_service
+
+import "testing"
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{"", ""},
+		{"Jane Doe", "Jane Doe"},
+		{"=HYPERLINK(\"http://evil.com\")", "'=HYPERLINK(\"http://evil.com\")"},
+		{"+1", "'+1"},
+		{"-5", "'-5"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"'quoted", "'quoted"},
+		{"a=b", "a=b"},
+	}
+	for _, test := range tests {
+		got := escapeFormula(test.cell)
+		if got != test.want {
+			t.Errorf("escapeFormula(%q) = %q, want %q", test.cell, got, test.want)
+		}
+		if unescaped := unescapeFormula(got); unescaped != test.cell {
+			t.Errorf("unescapeFormula(%q) = %q, want %q", got, unescaped, test.cell)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package user_import_service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cqlcorp/gocms/domain/user/user_import/user_import_model"
+	"github.com/cqlcorp/gocms/utility/errors"
+)
+
+var utf8Bom = []byte("\xef\xbb\xbf")
+
+const (
+	// first characters that make spreadsheets treat a csv cell as a formula
+	formulaPrefixes = "=+-@"
+	// prefix the export adds to those cells
+	formulaEscape = "'"
+)
+
+// record is a row of the file by column name. Empty cells are left out.
+type record map[string]string
+
+// parseFile reads the rows of a csv or json file. Csv files need a header row, json files an array of objects. Nested
+// profile objects become profile.<name> columns and arrays are joined with the group separator, like the export.
+func parseFile(data []byte, format string, maxRows int64) ([]string, []record, error) {
+	data = bytes.TrimPrefix(data, utf8Bom)
+	switch format {
+	case user_import_model.FORMAT_CSV:
+		return parseCsv(data, maxRows)
+	case user_import_model.FORMAT_JSON:
+		return parseJson(data, maxRows)
+	}
+	return nil, nil, errors.NewToUser("Format must be csv or json.")
+}
+
+func parseCsv(data []byte, maxRows int64) ([]string, []record, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, errors.NewToUser("The file must start with a header row.")
+	}
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = strings.TrimSpace(column)
+	}
+
+	var records []record
+	for {
+		cells, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, errors.NewToUser(fmt.Sprintf("The file isn't valid csv: %v", err.Error()))
+		}
+		if int64(len(records)) >= maxRows {
+			return nil, nil, errors.NewToUser(fmt.Sprintf("The file has more than %v rows.", maxRows))
+		}
+
+		r := make(record)
+		for i, cell := range cells {
+			if cell = strings.TrimSpace(cell); cell != "" {
+				r[columns[i]] = unescapeFormula(cell)
+			}
+		}
+		records = append(records, r)
+	}
+	return columns, records, nil
+}
+
+// unescapeFormula removes the quote the export adds in front of cells that look like a formula. Other cells starting with
+// a quote are kept as they are.
+func unescapeFormula(cell string) string {
+	if len(cell) > 1 && strings.HasPrefix(cell, formulaEscape) && strings.ContainsRune(formulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
+
+func parseJson(data []byte, maxRows int64) ([]string, []record, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var objects []map[string]interface{}
+	if err := decoder.Decode(&objects); err != nil {
+		return nil, nil, errors.NewToUser("The file must be a json array of objects.")
+	}
+	if int64(len(objects)) > maxRows {
+		return nil, nil, errors.NewToUser(fmt.Sprintf("The file has more than %v rows.", maxRows))
+	}
+
+	seen := make(map[string]bool)
+	var columns []string
+	records := make([]record, len(objects))
+	for i, object := range objects {
+		r := make(record)
+		if err := flatten(r, "", object); err != nil {
+			return nil, nil, errors.NewToUser(fmt.Sprintf("Row %v: %v", i+1, err.Error()))
+		}
+		for column := range r {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+		records[i] = r
+	}
+	sort.Strings(columns)
+	return columns, records, nil
+}
+
+func flatten(r record, prefix string, object map[string]interface{}) error {
+	for key, value := range object {
+		column := prefix + key
+		switch v := value.(type) {
+		case nil:
+		case string:
+			if v = strings.TrimSpace(v); v != "" {
+				r[column] = v
+			}
+		case json.Number:
+			r[column] = v.String()
+		case bool:
+			r[column] = strconv.FormatBool(v)
+		case []interface{}:
+			values := make([]string, len(v))
+			for i, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("%v must be a list of text.", column)
+				}
+				values[i] = s
+			}
+			if len(values) > 0 {
+				r[column] = strings.Join(values, user_import_model.GROUP_SEPARATOR)
+			}
+		case map[string]interface{}:
+			if prefix != "" {
+				return fmt.Errorf("%v can't be an object.", column)
+			}
+			if err := flatten(r, column+".", v); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%v has an unsupported value.", column)
+		}
+	}
+	return nil
+}
+
+// resolveMapping returns the field of every column that is imported. Columns named like a field are mapped to it
+// unless the mapping says otherwise, a column mapped to "" is ignored.
+func resolveMapping(columns []string, mapping map[string]string, profileFields map[string]bool) (map[string]string, error) {
+	inFile := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		inFile[column] = true
+	}
+	for column := range mapping {
+		if !inFile[column] {
+			return nil, errors.NewToUser(fmt.Sprintf("Column '%v' isn't in the file.", column))
+		}
+	}
+
+	resolved := make(map[string]string)
+	columnByField := make(map[string]string)
+	for _, column := range columns {
+		field, mapped := mapping[column]
+		if !mapped {
+			if !isField(column, profileFields) {
+				// likely a field that was renamed or deleted since the export
+				if strings.HasPrefix(column, user_import_model.FIELD_PROFILE_PREFIX) {
+					return nil, errors.NewToUser(fmt.Sprintf("Column '%v' isn't a profile field, map it to \"\" to ignore it.", column))
+				}
+				continue
+			}
+			field = column
+		}
+		if field == "" {
+			continue
+		}
+		if !isField(field, profileFields) {
+			return nil, errors.NewToUser(fmt.Sprintf("Column '%v' is mapped to '%v', which isn't a field.", column, field))
+		}
+		if other, ok := columnByField[field]; ok {
+			return nil, errors.NewToUser(fmt.Sprintf("Columns '%v' and '%v' are both mapped to '%v'.", other, column, field))
+		}
+		columnByField[field] = column
+		resolved[column] = field
+	}
+
+	if _, ok := columnByField[user_import_model.FIELD_EMAIL]; !ok {
+		return nil, errors.NewToUser("No column is mapped to email.")
+	}
+	return resolved, nil
+}
+
+func isField(name string, profileFields map[string]bool) bool {
+	switch name {
+	case user_import_model.FIELD_EMAIL, user_import_model.FIELD_FULL_NAME, user_import_model.FIELD_GENDER,
+		user_import_model.FIELD_ENABLED, user_import_model.FIELD_GROUPS:
+		return true
+	}
+	if strings.HasPrefix(name, user_import_model.FIELD_PROFILE_PREFIX) {
+		return profileFields[strings.TrimPrefix(name, user_import_model.FIELD_PROFILE_PREFIX)]
+	}
+	return false
+}
+
+// mapRecords converts the records to rows using the resolved mapping.
+func mapRecords(records []record, resolved map[string]string) []*user_import_model.ImportRow {
+	rows := make([]*user_import_model.ImportRow, len(records))
+	for i, r := range records {
+		row := &user_import_model.ImportRow{
+			Row:     int64(i + 1),
+			Profile: make(map[string]interface{}),
+		}
+		for column, field := range resolved {
+			value, ok := r[column]
+			if !ok {
+				continue
+			}
+			switch field {
+			case user_import_model.FIELD_EMAIL:
+				row.Email = value
+			case user_import_model.FIELD_FULL_NAME:
+				row.FullName = &value
+			case user_import_model.FIELD_GENDER:
+				row.Gender = &value
+			case user_import_model.FIELD_ENABLED:
+				row.Enabled = &value
+			case user_import_model.FIELD_GROUPS:
+				row.Groups = &value
+			default:
+				row.Profile[strings.TrimPrefix(field, user_import_model.FIELD_PROFILE_PREFIX)] = value
+			}
+		}
+		rows[i] = row
+	}
+	return rows
+}
@@ -0,0 +1,178 @@
+package user_import_service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cqlcorp/gocms/domain/user/user_import/user_import_model"
+)
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		columns []string
+		records []record
+	}{
+		{
+			name:    "csv",
+			format:  user_import_model.FORMAT_CSV,
+			data:    "email,fullName,groups\njane@example.com, Jane Doe ,editors;authors\njohn@example.com,,\n",
+			columns: []string{"email", "fullName", "groups"},
+			records: []record{
+				{"email": "jane@example.com", "fullName": "Jane Doe", "groups": "editors;authors"},
+				{"email": "john@example.com"},
+			},
+		},
+		{
+			name:    "csv with a byte order mark",
+			format:  user_import_model.FORMAT_CSV,
+			data:    "\xef\xbb\xbfemail\njane@example.com\n",
+			columns: []string{"email"},
+			records: []record{{"email": "jane@example.com"}},
+		},
+		{
+			name:    "csv with escaped formulas",
+			format:  user_import_model.FORMAT_CSV,
+			data:    "email,fullName,profile.note\njane@example.com,'=SUM(A1),'-5\njohn@example.com,'Quoted,'\n",
+			columns: []string{"email", "fullName", "profile.note"},
+			records: []record{
+				{"email": "jane@example.com", "fullName": "=SUM(A1)", "profile.note": "-5"},
+				{"email": "john@example.com", "fullName": "'Quoted", "profile.note": "'"},
+			},
+		},
+		{
+			name:    "json",
+			format:  user_import_model.FORMAT_JSON,
+			data:    `[{"email":"jane@example.com","enabled":true,"groups":["editors","authors"],"profile":{"age":42,"city":" Paris "}},{"email":"john@example.com","fullName":null}]`,
+			columns: []string{"email", "enabled", "groups", "profile.age", "profile.city"},
+			records: []record{
+				{"email": "jane@example.com", "enabled": "true", "groups": "editors;authors", "profile.age": "42", "profile.city": "Paris"},
+				{"email": "john@example.com"},
+			},
+		},
+		{
+			name:    "json formulas are kept",
+			format:  user_import_model.FORMAT_JSON,
+			data:    `[{"email":"jane@example.com","fullName":"'=1+1"}]`,
+			columns: []string{"email", "fullName"},
+			records: []record{{"email": "jane@example.com", "fullName": "'=1+1"}},
+		},
+	}
+	for _, test := range tests {
+		columns, records, err := parseFile([]byte(test.data), test.format, 10)
+		if err != nil {
+			t.Errorf("%v: parseFile() failed: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(columns, test.columns) {
+			t.Errorf("%v: columns = %v, want %v", test.name, columns, test.columns)
+		}
+		if !reflect.DeepEqual(records, test.records) {
+			t.Errorf("%v: records = %v, want %v", test.name, records, test.records)
+		}
+	}
+}
+
+func TestParseFileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{"unknown format", "xml", "<users/>"},
+		{"csv without header", user_import_model.FORMAT_CSV, ""},
+		{"csv with too many rows", user_import_model.FORMAT_CSV, "email\na@example.com\nb@example.com\nc@example.com\n"},
+		{"csv with a wrong number of cells", user_import_model.FORMAT_CSV, "email,fullName\na@example.com\n"},
+		{"json that isn't an array", user_import_model.FORMAT_JSON, `{"email":"a@example.com"}`},
+		{"json with too many rows", user_import_model.FORMAT_JSON, `[{},{},{}]`},
+		{"json with a nested object", user_import_model.FORMAT_JSON, `[{"profile":{"address":{"city":"Paris"}}}]`},
+		{"json with a list of numbers", user_import_model.FORMAT_JSON, `[{"groups":[1,2]}]`},
+	}
+	for _, test := range tests {
+		if _, _, err := parseFile([]byte(test.data), test.format, 2); err == nil {
+			t.Errorf("%v: parseFile() succeeded, want an error", test.name)
+		}
+	}
+}
+
+func TestResolveMapping(t *testing.T) {
+	profileFields := map[string]bool{"city": true}
+	tests := []struct {
+		name    string
+		columns []string
+		mapping map[string]string
+		want    map[string]string
+		err     string
+	}{
+		{
+			name:    "columns named like fields",
+			columns: []string{"email", "fullName", "profile.city", "id", "created"},
+			want:    map[string]string{"email": "email", "fullName": "fullName", "profile.city": "profile.city"},
+		},
+		{
+			name:    "mapped columns",
+			columns: []string{"E-mail", "Name", "Town"},
+			mapping: map[string]string{"E-mail": "email", "Name": "fullName", "Town": "profile.city"},
+			want:    map[string]string{"E-mail": "email", "Name": "fullName", "Town": "profile.city"},
+		},
+		{
+			name:    "ignored column",
+			columns: []string{"email", "fullName"},
+			mapping: map[string]string{"fullName": ""},
+			want:    map[string]string{"email": "email"},
+		},
+		{
+			name:    "ignored unknown profile field",
+			columns: []string{"email", "profile.removed"},
+			mapping: map[string]string{"profile.removed": ""},
+			want:    map[string]string{"email": "email"},
+		},
+		{
+			name:    "mapped column not in the file",
+			columns: []string{"email"},
+			mapping: map[string]string{"Name": "fullName"},
+			err:     "isn't in the file",
+		},
+		{
+			name:    "unknown profile field",
+			columns: []string{"email", "profile.removed"},
+			err:     "isn't a profile field",
+		},
+		{
+			name:    "mapped to an unknown field",
+			columns: []string{"email", "Name"},
+			mapping: map[string]string{"Name": "nickname"},
+			err:     "which isn't a field",
+		},
+		{
+			name:    "two columns for one field",
+			columns: []string{"email", "E-mail"},
+			mapping: map[string]string{"E-mail": "email"},
+			err:     "are both mapped",
+		},
+		{
+			name:    "no email",
+			columns: []string{"fullName"},
+			err:     "No column is mapped to email",
+		},
+	}
+	for _, test := range tests {
+		got, err := resolveMapping(test.columns, test.mapping, profileFields)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%v: resolveMapping() error = %v, want %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: resolveMapping() failed: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: resolveMapping() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
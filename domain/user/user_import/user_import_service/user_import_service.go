@@ -0,0 +1,409 @@
+package user_import_service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_service"
+	"github.com/cqlcorp/gocms/domain/acl/group/group_service"
+	"github.com/cqlcorp/gocms/domain/profile/profile_service"
+	"github.com/cqlcorp/gocms/domain/user/user_import/user_import_model"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/domain/user/user_service"
+	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/cqlcorp/gocms/utility/log"
+)
+
+const (
+	// only the first row errors are kept so the job stays a reasonable size
+	maxRowErrors = 1000
+	// how often progress is saved while running
+	progressInterval = 2 * time.Second
+	// running jobs that haven't saved progress for this long were stopped with their instance
+	staleAfter = 10 * time.Minute
+)
+
+type IUserImportService interface {
+	StartImport(createdBy int64, data []byte, options *user_import_model.ImportOptions) (*user_import_model.ImportJob, error)
+	GetJob(id int64) (*user_import_model.ImportJob, error)
+	GetRecentJobs(limit int64) ([]*user_import_model.ImportJob, error)
+	FailStaleJobs()
+	IExportService
+}
+
+type UserImportService struct {
+	RepositoriesGroup *repository.RepositoriesGroup
+	UserService       user_service.IUserService
+	GroupService      group_service.IGroupService
+	ProfileService    profile_service.IProfileService
+	AuthService       authentication_service.IAuthService
+}
+
+func DefaultUserImportService(rg *repository.RepositoriesGroup, userService user_service.IUserService, groupService group_service.IGroupService,
+	profileService profile_service.IProfileService, authService authentication_service.IAuthService) *UserImportService {
+
+	userImportService := &UserImportService{
+		RepositoriesGroup: rg,
+		UserService:       userService,
+		GroupService:      groupService,
+		ProfileService:    profileService,
+		AuthService:       authService,
+	}
+	return userImportService
+}
+
+// importContext is shared by the rows of a job.
+type importContext struct {
+	options      *user_import_model.ImportOptions
+	groupsByName map[string]int64
+	// rows by lower case email, to find duplicates
+	emails map[string]int64
+}
+
+// validRow is an import row converted to the values to store. Nil values are left unchanged.
+type validRow struct {
+	email    string
+	fullName *string
+	gender   *int64
+	enabled  *bool
+	groupIds []int64
+	profile  map[string]interface{}
+}
+
+// StartImport checks the file and options, then imports the rows in the background. File level problems are returned
+// to the user, row problems are recorded on the job and the row is skipped.
+func (uis *UserImportService) StartImport(createdBy int64, data []byte, options *user_import_model.ImportOptions) (*user_import_model.ImportJob, error) {
+	columns, records, err := parseFile(data, options.Format, context.Config.DbVars.UserImportMaxRows)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.NewToUser("The file has no rows.")
+	}
+
+	fields, err := uis.ProfileService.GetFields()
+	if err != nil {
+		return nil, err
+	}
+	profileFields := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		profileFields[field.Name] = true
+	}
+	resolved, err := resolveMapping(columns, options.Mapping, profileFields)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := uis.RepositoriesGroup.GroupsRepository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	ctx := &importContext{
+		options:      options,
+		groupsByName: make(map[string]int64, len(*groups)),
+		emails:       make(map[string]int64, len(records)),
+	}
+	groupExists := make(map[int64]bool, len(*groups))
+	for _, group := range *groups {
+		ctx.groupsByName[strings.ToLower(group.Name)] = group.Id
+		groupExists[group.Id] = true
+	}
+	for _, groupId := range options.GroupIds {
+		if !groupExists[groupId] {
+			return nil, errors.NewToUser(fmt.Sprintf("Group %v doesn't exist.", groupId))
+		}
+	}
+
+	job := &user_import_model.ImportJob{
+		CreatedBy: createdBy,
+		Filename:  options.Filename,
+		Format:    options.Format,
+		DryRun:    options.DryRun,
+		Invite:    options.Invite,
+		Status:    user_import_model.Job_Pending,
+		Total:     int64(len(records)),
+		RowErrors: "[]",
+	}
+	err = uis.RepositoriesGroup.UserImportRepository.Add(job)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := mapRecords(records, resolved)
+	go uis.run(job, rows, ctx)
+
+	return job, nil
+}
+
+func (uis *UserImportService) GetJob(id int64) (*user_import_model.ImportJob, error) {
+	return uis.RepositoriesGroup.UserImportRepository.Get(id)
+}
+
+func (uis *UserImportService) GetRecentJobs(limit int64) ([]*user_import_model.ImportJob, error) {
+	return uis.RepositoriesGroup.UserImportRepository.GetRecent(limit)
+}
+
+// FailStaleJobs marks jobs left unfinished by a stopped instance as failed.
+func (uis *UserImportService) FailStaleJobs() {
+	uis.RepositoriesGroup.UserImportRepository.FailStale(time.Now().Add(-staleAfter), "The import was interrupted, import the file again to finish it.")
+}
+
+func (uis *UserImportService) run(job *user_import_model.ImportJob, rows []*user_import_model.ImportRow, ctx *importContext) {
+	var rowErrors []*user_import_model.RowError
+	save := func() {
+		data, _ := json.Marshal(rowErrors)
+		job.RowErrors = string(data)
+		uis.RepositoriesGroup.UserImportRepository.UpdateProgress(job)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("User import job %v panicked: %v\n", job.Id, r)
+			job.Status = user_import_model.Job_Failed
+			job.Error = "The import stopped unexpectedly."
+			save()
+		}
+	}()
+
+	job.Status = user_import_model.Job_Running
+	save()
+	lastSave := time.Now()
+
+	for _, row := range rows {
+		added, fieldErrors := uis.importRow(row, ctx)
+		job.Processed++
+		switch {
+		case fieldErrors != nil && added == nil:
+			job.Failed++
+		case *added:
+			job.Added++
+		default:
+			job.Updated++
+		}
+		if fieldErrors != nil && len(rowErrors) < maxRowErrors {
+			rowErrors = append(rowErrors, &user_import_model.RowError{
+				Row:    row.Row,
+				Email:  row.Email,
+				Fields: fieldErrors,
+			})
+		}
+
+		if time.Since(lastSave) >= progressInterval {
+			save()
+			lastSave = time.Now()
+		}
+	}
+
+	job.Status = user_import_model.Job_Complete
+	save()
+	log.Infof("User import job %v complete, %v added, %v updated, %v failed, dry run: %v\n", job.Id, job.Added, job.Updated, job.Failed, job.DryRun)
+}
+
+// importRow validates and applies a row. Added is nil when the row was skipped, otherwise it tells if the user is new.
+// Errors can be returned for a row that was applied, when only sending the invitation failed.
+func (uis *UserImportService) importRow(row *user_import_model.ImportRow, ctx *importContext) (*bool, errors.FieldErrors) {
+	valid, fieldErrors := uis.validateRow(row, ctx)
+	if fieldErrors != nil {
+		return nil, fieldErrors
+	}
+
+	user, err := uis.RepositoriesGroup.UsersRepository.GetByEmail(valid.email)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, internalError(row, err)
+	}
+	if user != nil && user.IsDeleted() {
+		var fieldErrors errors.FieldErrors
+		fieldErrors.Add(user_import_model.FIELD_EMAIL, "The user with this email is deleted, restore them to import the row.")
+		return nil, fieldErrors
+	}
+	added := user == nil
+
+	if ctx.options.DryRun {
+		return &added, nil
+	}
+
+	if added {
+		user = &user_model.User{
+			Email:   valid.email,
+			Enabled: true,
+		}
+		applyRow(user, valid)
+		if err := uis.UserService.Add(user); err != nil {
+			return nil, internalError(row, err)
+		}
+	} else {
+		enabled := user.Enabled
+		applyRow(user, valid)
+		if err := uis.UserService.Update(user.Id, user); err != nil {
+			return nil, internalError(row, err)
+		}
+		if user.Enabled != enabled {
+			if err := uis.UserService.SetEnabled(user.Id, user.Enabled); err != nil {
+				return nil, internalError(row, err)
+			}
+		}
+	}
+
+	if err := uis.addGroups(user.Id, valid.groupIds); err != nil {
+		return nil, internalError(row, err)
+	}
+
+	if len(valid.profile) > 0 {
+		// required fields are checked against the users stored values here, the row was only checked on its own
+		err := uis.ProfileService.UpdateProfile(user.Id, valid.profile, true)
+		if profileErrors, ok := err.(errors.FieldErrors); ok {
+			return nil, profileErrors
+		} else if err != nil {
+			return nil, internalError(row, err)
+		}
+	}
+
+	// only new users are invited so importing a file again doesn't email everyone
+	if added && ctx.options.Invite {
+		if err := uis.AuthService.SendPasswordSetCode(user); err != nil {
+			log.Errorf("Error sending password set code to imported user %v: %s\n", user.Id, err.Error())
+			var fieldErrors errors.FieldErrors
+			fieldErrors.Add(user_import_model.FIELD_EMAIL, "The user was added but the invitation couldn't be sent.")
+			return &added, fieldErrors
+		}
+	}
+
+	return &added, nil
+}
+
+func (uis *UserImportService) validateRow(row *user_import_model.ImportRow, ctx *importContext) (*validRow, errors.FieldErrors) {
+	var fieldErrors errors.FieldErrors
+	valid := &validRow{
+		fullName: row.FullName,
+		profile:  row.Profile,
+	}
+
+	if row.Email == "" {
+		fieldErrors.Add(user_import_model.FIELD_EMAIL, "Email is required.")
+	} else if address, err := mail.ParseAddress(row.Email); err != nil || address.Address != row.Email {
+		fieldErrors.Add(user_import_model.FIELD_EMAIL, "Email isn't a valid email address.")
+	} else if first, ok := ctx.emails[strings.ToLower(row.Email)]; ok {
+		fieldErrors.Add(user_import_model.FIELD_EMAIL, fmt.Sprintf("Email is already on row %v.", first))
+	} else {
+		ctx.emails[strings.ToLower(row.Email)] = row.Row
+		valid.email = row.Email
+	}
+
+	if row.Gender != nil {
+		switch strings.ToLower(*row.Gender) {
+		case "0", "unknown":
+			valid.gender = &user_model.GENDER_UNKNOWN
+		case "1", "male":
+			valid.gender = &user_model.GENDER_MALE
+		case "2", "female":
+			valid.gender = &user_model.GENDER_FEMALE
+		default:
+			fieldErrors.Add(user_import_model.FIELD_GENDER, "Gender must be 0, 1, 2, unknown, male or female.")
+		}
+	}
+
+	if row.Enabled != nil {
+		enabled, err := parseBool(*row.Enabled)
+		if err != nil {
+			fieldErrors.Add(user_import_model.FIELD_ENABLED, "Enabled must be true or false.")
+		}
+		valid.enabled = &enabled
+	}
+
+	valid.groupIds = append(valid.groupIds, ctx.options.GroupIds...)
+	if row.Groups != nil {
+		for _, name := range strings.Split(*row.Groups, user_import_model.GROUP_SEPARATOR) {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			groupId, ok := ctx.groupsByName[strings.ToLower(name)]
+			if !ok {
+				fieldErrors.Add(user_import_model.FIELD_GROUPS, fmt.Sprintf("Group '%v' doesn't exist.", name))
+				continue
+			}
+			valid.groupIds = append(valid.groupIds, groupId)
+		}
+	}
+
+	if len(row.Profile) > 0 {
+		err := uis.ProfileService.ValidateProfile(0, row.Profile, true)
+		if profileErrors, ok := err.(errors.FieldErrors); ok {
+			fieldErrors = append(fieldErrors, profileErrors...)
+		} else if err != nil {
+			return nil, internalError(row, err)
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, fieldErrors
+	}
+	return valid, nil
+}
+
+// addGroups adds the user to the groups they aren't in yet.
+func (uis *UserImportService) addGroups(userId int64, groupIds []int64) error {
+	if len(groupIds) == 0 {
+		return nil
+	}
+
+	current, err := uis.GroupService.GetUserGroups(userId)
+	if err != nil {
+		return err
+	}
+	inGroup := make(map[int64]bool, len(current))
+	for _, group := range current {
+		inGroup[group.Id] = true
+	}
+
+	for _, groupId := range groupIds {
+		if inGroup[groupId] {
+			continue
+		}
+		if err := uis.GroupService.AddUserToGroupById(userId, groupId); err != nil {
+			return err
+		}
+		inGroup[groupId] = true
+	}
+	return nil
+}
+
+func applyRow(user *user_model.User, valid *validRow) {
+	if valid.fullName != nil {
+		user.FullName = *valid.fullName
+	}
+	if valid.gender != nil {
+		user.Gender = *valid.gender
+	}
+	if valid.enabled != nil {
+		user.Enabled = *valid.enabled
+	}
+}
+
+// internalError logs an unexpected error and reports it on the row without the details.
+func internalError(row *user_import_model.ImportRow, err error) errors.FieldErrors {
+	log.Errorf("Error importing user on row %v: %s\n", row.Row, err.Error())
+	var fieldErrors errors.FieldErrors
+	message := "The user couldn't be imported."
+	if userErr, ok := err.(interface{ Include() bool }); ok && userErr.Include() {
+		message = err.Error()
+	}
+	fieldErrors.Add(user_import_model.FIELD_EMAIL, message)
+	return fieldErrors
+}
+
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
@@ -0,0 +1,27 @@
+package user_middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/cqlcorp/gocms/utility/log"
+	"github.com/gin-gonic/gin"
+)
+
+const gocmsHeaderPrefix = "X-Gocms-"
+
+// StripGocmsHeaders removes every X-GOCMS-* header sent by the client. Those headers are only ever set by gocms
+// itself, so a client supplied copy, such as a forged user context, must never reach a plugin.
+func StripGocmsHeaders() gin.HandlerFunc {
+	log.Debugf("Adding Strip GoCMS Headers Middleware\n")
+	return stripGocmsHeadersMiddleware
+}
+
+func stripGocmsHeadersMiddleware(c *gin.Context) {
+	for header := range c.Request.Header {
+		if strings.HasPrefix(http.CanonicalHeaderKey(header), gocmsHeaderPrefix) {
+			c.Request.Header.Del(header)
+		}
+	}
+	c.Next()
+}
@@ -10,6 +10,14 @@ type UserContextHeader struct {
 	FullName string   `json:"fullName"`
 	Email    string   `json:"email"`
 	ACL      *UserAcl `json:"acl"`
+	// ImpersonatedBy is the id of the admin acting as this user, 0 when not impersonated
+	ImpersonatedBy int64 `json:"impersonatedBy,omitempty"`
+	// Profile has the values of the custom profile fields included in the context
+	Profile map[string]interface{} `json:"profile,omitempty"`
+	// ApiKeyId is set when the user authenticated with an api key, only the permissions in ApiKeyScope can be used.
+	// Send both with policy checks made for the request.
+	ApiKeyId    int64    `json:"apiKeyId,omitempty"`
+	ApiKeyScope []string `json:"apiKeyScope,omitempty"`
 }
 
 type UserAcl struct {
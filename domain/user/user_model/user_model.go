@@ -1,9 +1,13 @@
 package user_model
 
 import (
+	"encoding/json"
 	"github.com/cqlcorp/gocms/domain/acl/group/group_model"
+	"github.com/cqlcorp/gocms/domain/acl/permissions"
 	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_model"
 	"github.com/cqlcorp/gocms/domain/email/email_model"
+	"github.com/cqlcorp/gocms/domain/profile/profile_model"
+	"github.com/cqlcorp/gocms/utility/errors"
 	"time"
 )
 
@@ -21,13 +25,71 @@ type User struct {
 	Password     string    `json:"password" db:"password"`
 	Gender       int64     `json:"gender" db:"gender"`
 	Photo        string    `json:"photo" db:"photo"`
+	// set when the user uploaded their photo, see GetPhotoVariants
+	PhotoKey      string `json:"-" db:"photoKey"`
+	PhotoVariants string `json:"-" db:"photoVariants"`
 	MinAge       int64     `json:"minAge" db:"minAge"`
 	MaxAge       int64     `json:"maxAge" db:"maxAge"`
+	// language tag emails are sent in, empty uses DEFAULT_LOCALE
+	Locale       string    `json:"locale" db:"locale"`
 	Created      time.Time `json:"created" db:"created"`
 	Enabled      bool      `json:"enabled" db:"enabled"`
 	LastModified time.Time `json:"lastModified" db:"lastModified"`
 	Permissions  []*permission_model.Permission
 	Groups       []*group_model.Group
+	// set from the token when an admin is impersonating the user
+	ImpersonatedBy  int64
+	ImpersonationId int64
+	// set when the user authenticated with an api key, ApiKeyScope limits which permissions can be used
+	ApiKeyId    int64
+	ApiKeyScope []string
+	// custom profile field values, only the fields needed by the request are loaded
+	Profile profile_model.Profile `json:"profile,omitempty"`
+	// set when an admin deleted the user, they can be restored until USER_DELETED_RETENTION_DAYS
+	Deleted   *time.Time `json:"-" db:"deleted"`
+	DeletedBy int64      `json:"-" db:"deletedBy"`
+	// set while the user is banned, see IsBanned
+	Banned     *time.Time `json:"-" db:"banned"`
+	BannedBy   int64      `json:"-" db:"bannedBy"`
+	BanReason  string     `json:"-" db:"banReason"`
+	BanNote    string     `json:"-" db:"banNote"`
+	BanExpires *time.Time `json:"-" db:"banExpires"`
+}
+
+// IsDeleted reports if an admin deleted the user. Deleted users can't authenticate.
+func (user *User) IsDeleted() bool {
+	return user.Deleted != nil
+}
+
+// IsBanned reports if the user is banned now. Bans without an expiry last until they are lifted.
+func (user *User) IsBanned() bool {
+	return user.Banned != nil && (user.BanExpires == nil || user.BanExpires.After(time.Now()))
+}
+
+// BanMessage explains the ban to the user. The admin note isn't included.
+func (user *User) BanMessage() string {
+	message := errors.ApiError_User_Banned
+	if user.BanReason != "" {
+		message += " Reason: " + user.BanReason + "."
+	}
+	if user.BanExpires != nil {
+		message += " The ban ends " + user.BanExpires.Format(time.RFC3339) + "."
+	}
+	return message
+}
+
+// InApiKeyScope reports if a permission can be used with the current authentication. Always true when the user didn't
+// authenticate with an api key. Wildcard scopes cover the permissions below them.
+func (user *User) InApiKeyScope(permission string) bool {
+	if user.ApiKeyId == 0 {
+		return true
+	}
+	for _, scope := range user.ApiKeyScope {
+		if permissions.Matches(scope, permission) {
+			return true
+		}
+	}
+	return false
 }
 
 /**
@@ -37,7 +99,10 @@ type User struct {
 * @apiSuccess (Response) {string} email
 * @apiSuccess (Response) {number} gender 1=male, 2=female
 * @apiSuccess (Response) {string} photo url string
+* @apiSuccess (Response) {Object} [photos] Urls of the uploaded photo resized to square variants, by width in pixels.
+* @apiSuccess (Response) {string} [locale] Language tag emails are sent in.
 * @apiSuccess (Response) {string} lastModified
+* @apiSuccess (Response) {Object} [profile] Custom profile field values visible to the user by field name.
  */
 type UserDisplay struct {
 	Id           int64     `json:"id,omitempty"`
@@ -45,17 +110,24 @@ type UserDisplay struct {
 	Email        string    `json:"email,omitempty"`
 	Gender       int64     `json:"gender,omitempty"`
 	Photo        string    `json:"photo,string,omitempty"`
+	Photos       map[string]string `json:"photos,omitempty"`
+	Locale       string    `json:"locale,omitempty"`
 	LastModified time.Time `json:"lastModified,omitempty"`
+	Profile      profile_model.Profile `json:"profile,omitempty"`
 }
 
 /**
 * @apiDefine UserUpdateInput
 * @apiParam (Request) {string} fullName
 * @apiParam (Request) {number} gender 1=male, 2=female
+* @apiParam (Request) {string} [locale] Language tag emails are sent in. Ex: en-US. Empty uses the site default.
+* @apiParam (Request) {Object} [profile] Custom profile field values to change by field name. Null clears a value.
  */
 type UserUpdateInput struct {
-	FullName string `json:"fullName,omitempty"`
-	Gender   int64  `json:"gender"`
+	FullName string                 `json:"fullName,omitempty"`
+	Gender   int64                  `json:"gender"`
+	Locale   *string                `json:"locale,omitempty"`
+	Profile  map[string]interface{} `json:"profile,omitempty"`
 }
 
 /**
@@ -84,11 +156,25 @@ func (user *User) GetUserDisplay() *UserDisplay {
 		FullName:     user.FullName,
 		Gender:       user.Gender,
 		Photo:        user.Photo,
+		Photos:       user.GetPhotoVariants(),
+		Locale:       user.Locale,
 		LastModified: user.LastModified,
 	}
 	return &userDisplay
 }
 
+// GetPhotoVariants returns the urls of the uploaded photo variants by width, nil if the user didn't upload a photo.
+func (user *User) GetPhotoVariants() map[string]string {
+	if user.PhotoVariants == "" {
+		return nil
+	}
+	var variants map[string]string
+	if err := json.Unmarshal([]byte(user.PhotoVariants), &variants); err != nil {
+		return nil
+	}
+	return variants
+}
+
 // helper function to get userContextHeader from user object
 func (user *User) GetUserContextHeader() *UserContextHeader {
 	userDisplay := UserContextHeader{
@@ -96,6 +182,10 @@ func (user *User) GetUserContextHeader() *UserContextHeader {
 		Email:    user.Email,
 		FullName: user.FullName,
 		ACL:      user.GetUserAclPermissionsAndGroups(),
+		ImpersonatedBy: user.ImpersonatedBy,
+		Profile:  user.Profile,
+		ApiKeyId:    user.ApiKeyId,
+		ApiKeyScope: user.ApiKeyScope,
 	}
 	return &userDisplay
 }
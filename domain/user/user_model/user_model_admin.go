@@ -1,6 +1,7 @@
 package user_model
 
 import (
+	"github.com/cqlcorp/gocms/domain/profile/profile_model"
 	"time"
 )
 
@@ -10,12 +11,25 @@ import (
 * @apiSuccess (Response) {string} fullName
 * @apiSuccess (Response) {string} email
 * @apiSuccess (Response) {number} gender 1=male, 2=female
+* @apiSuccess (Response) {string} photo url string
+* @apiSuccess (Response) {Object} [photos] Urls of the uploaded photo resized to square variants, by width in pixels.
 * @apiSuccess (Response) {boolean} enabled true is the user is enabled
 * @apiSuccess (Response) {boolean} verified true is the user has verified their primary email address
 * @apiSuccess (Response) {number} minAge
 * @apiSuccess (Response) {number} maxAge
+* @apiSuccess (Response) {string} [locale] Language tag emails are sent in.
 * @apiSuccess (Response) {string} created
 * @apiSuccess (Response) {string} lastModified
+* @apiSuccess (Response) {Object} [profile] Every custom profile field value by field name.
+* @apiSuccess (Response) {string} [deleted] When the user was deleted, they can be restored until
+* USER_DELETED_RETENTION_DAYS after.
+* @apiSuccess (Response) {number} [deletedBy] Id of the admin that deleted the user.
+* @apiSuccess (Response) {Object} [ban] Set while the user is banned.
+* @apiSuccess (Response) {string} ban.banned
+* @apiSuccess (Response) {number} ban.bannedBy Id of the admin that banned the user.
+* @apiSuccess (Response) {string} ban.reason Shown to the user.
+* @apiSuccess (Response) {string} ban.note Only shown to admins.
+* @apiSuccess (Response) {string} [ban.expires] The ban lasts until it's lifted when empty.
  */
 type UserAdminDisplay struct {
 	Id           int64     `json:"id,omitempty"`
@@ -24,11 +38,66 @@ type UserAdminDisplay struct {
 	Verified     bool      `json:"verified,omitempty"`
 	Gender       int64     `json:"gender,omitempty"`
 	Photo        string    `json:"photo,string,omitempty"`
+	Photos       map[string]string `json:"photos,omitempty"`
 	Enabled      bool      `json:"enabled,omitempty"`
 	MinAge       int64     `json:"minAge,omitempty"`
 	MaxAge       int64     `json:"maxAge,omitempty"`
+	Locale       string    `json:"locale,omitempty"`
 	Created      time.Time `json:"created,omitempty"`
 	LastModified time.Time `json:"lastModified,omitempty"`
+	Profile      profile_model.Profile `json:"profile,omitempty"`
+	Deleted      *time.Time            `json:"deleted,omitempty"`
+	DeletedBy    int64                 `json:"deletedBy,omitempty"`
+	Ban          *UserBanDisplay       `json:"ban,omitempty"`
+}
+
+type UserBanDisplay struct {
+	Banned   time.Time  `json:"banned"`
+	BannedBy int64      `json:"bannedBy"`
+	Reason   string     `json:"reason"`
+	Note     string     `json:"note"`
+	Expires  *time.Time `json:"expires,omitempty"`
+}
+
+/**
+* @apiDefine UserBanInput
+* @apiParam (Request) {string} reason Shown to the user when they try to log in, max 255 characters.
+* @apiParam (Request) {string} [note] Only shown to admins, max 1000 characters.
+* @apiParam (Request) {string} [expires] RFC3339 time the ban ends. The ban lasts until it's lifted when empty.
+ */
+type UserBanInput struct {
+	Reason  string     `json:"reason"`
+	Note    string     `json:"note"`
+	Expires *time.Time `json:"expires"`
+}
+
+// UserListSortColumns the fields the admin user list can be sorted by and their columns.
+var UserListSortColumns = map[string]string{
+	"id":           "gocms_users.id",
+	"fullName":     "gocms_users.fullName",
+	"email":        "gocms_emails.email",
+	"created":      "gocms_users.created",
+	"lastModified": "gocms_users.lastModified",
+}
+
+/**
+* @apiDefine UserListFilter
+* @apiParam (Query) {boolean} [enabled]
+* @apiParam (Query) {boolean} [verified] Primary email verified.
+* @apiParam (Query) {number} [groupId] Direct member of the group.
+* @apiParam (Query) {string} [createdAfter] Date like 2006-01-02 or an RFC3339 time, inclusive.
+* @apiParam (Query) {string} [createdBefore] Date like 2006-01-02 or an RFC3339 time, exclusive.
+* @apiParam (Query) {boolean} [banned] Currently banned.
+* @apiParam (Query) {boolean} [deleted=false] Deleted users that can still be restored. They are left out unless true.
+ */
+type UserListFilter struct {
+	Enabled       *bool
+	Verified      *bool
+	GroupId       int64
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Banned        *bool
+	Deleted       bool
 }
 
 // helper function to get userAdminDisplay from user object
@@ -39,12 +108,25 @@ func (user *User) GetUserAdminDisplay() *UserAdminDisplay {
 		FullName:     user.FullName,
 		Gender:       user.Gender,
 		Photo:        user.Photo,
+		Photos:       user.GetPhotoVariants(),
 		Enabled:      user.Enabled,
 		Verified:     user.Verified,
 		Created:      user.Created,
 		MaxAge:       user.MaxAge,
 		MinAge:       user.MinAge,
+		Locale:       user.Locale,
 		LastModified: user.LastModified,
+		Deleted:      user.Deleted,
+		DeletedBy:    user.DeletedBy,
+	}
+	if user.IsBanned() {
+		userAdminDisplay.Ban = &UserBanDisplay{
+			Banned:   *user.Banned,
+			BannedBy: user.BannedBy,
+			Reason:   user.BanReason,
+			Note:     user.BanNote,
+			Expires:  user.BanExpires,
+		}
 	}
 	return &userAdminDisplay
 }
@@ -0,0 +1,84 @@
+package user_model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInApiKeyScope(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       User
+		permission string
+		want       bool
+	}{
+		{"no api key", User{}, "content.write", true},
+		{"in scope", User{ApiKeyId: 1, ApiKeyScope: []string{"content.read", "content.write"}}, "content.write", true},
+		{"out of scope", User{ApiKeyId: 1, ApiKeyScope: []string{"content.read"}}, "content.write", false},
+		{"empty scope", User{ApiKeyId: 1}, "content.read", false},
+		{"super admin not in scope", User{ApiKeyId: 1, ApiKeyScope: []string{"content.read"}}, "super_admin", false},
+	}
+	for _, test := range tests {
+		if got := test.user.InApiKeyScope(test.permission); got != test.want {
+			t.Errorf("%v: InApiKeyScope(%v) = %v, want %v", test.name, test.permission, got, test.want)
+		}
+	}
+}
+
+// sort columns are put into the ORDER BY clause as is so they must be plain column names
+func TestUserListSortColumns(t *testing.T) {
+	for field, column := range UserListSortColumns {
+		for _, r := range column {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || r == '.') {
+				t.Errorf("sort column %q for %v isn't a plain column name", column, field)
+				break
+			}
+		}
+	}
+}
+
+func TestIsBanned(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		user User
+		want bool
+	}{
+		{"not banned", User{}, false},
+		{"banned", User{Banned: &past}, true},
+		{"banned until later", User{Banned: &past, BanExpires: &future}, true},
+		{"ban expired", User{Banned: &past, BanExpires: &past}, false},
+		{"expiry without a ban", User{BanExpires: &future}, false},
+	}
+	for _, test := range tests {
+		if got := test.user.IsBanned(); got != test.want {
+			t.Errorf("%v: IsBanned() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestIsDeleted(t *testing.T) {
+	now := time.Now()
+	if (&User{}).IsDeleted() {
+		t.Error("IsDeleted() = true for a user that wasn't deleted")
+	}
+	if !(&User{Deleted: &now}).IsDeleted() {
+		t.Error("IsDeleted() = false for a deleted user")
+	}
+}
+
+func TestBanMessage(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	user := &User{BanReason: "spam", BanNote: "internal note", BanExpires: &expires}
+	message := user.BanMessage()
+	if !strings.Contains(message, "spam") || !strings.Contains(message, expires.Format(time.RFC3339)) {
+		t.Errorf("BanMessage() = %q, want the reason and expiry", message)
+	}
+	if strings.Contains(message, "internal note") {
+		t.Errorf("BanMessage() = %q shows the admin note", message)
+	}
+}
@@ -2,6 +2,9 @@ package user_repository
 
 import (
 	"database/sql"
+	"strings"
+
+	"github.com/cqlcorp/gocms/utility/list_utility"
 	"github.com/cqlcorp/gocms/domain/user/user_model"
 	"github.com/cqlcorp/gocms/utility/errors"
 	"github.com/cqlcorp/gocms/utility/log"
@@ -13,11 +16,18 @@ type IUserRepository interface {
 	Get(int64) (*user_model.User, error)
 	GetByEmail(string) (*user_model.User, error)
 	GetAll() (*[]user_model.User, error)
+	List(*list_utility.Query, *user_model.UserListFilter) ([]user_model.User, int64, error)
 	Add(*user_model.User) error
 	Update(int64, *user_model.User) error
 	UpdatePassword(int64, string) error
+	UpdatePhoto(int64, *user_model.User) error
 	Delete(int64) error
 	SetEnabled(int64, bool) error
+	SoftDelete(id int64, deletedBy int64) error
+	Restore(int64) error
+	GetDeletedBefore(time.Time) ([]*user_model.User, error)
+	SetBan(id int64, user *user_model.User) error
+	ClearBan(int64) error
 }
 
 type UserRepository struct {
@@ -97,6 +107,86 @@ func (ur *UserRepository) GetAll() (*[]user_model.User, error) {
 	return &users, nil
 }
 
+// List a page of users matching the search and filters, along with the total number that match.
+// The search matches the full name or any of the users emails.
+func (ur *UserRepository) List(query *list_utility.Query, filter *user_model.UserListFilter) ([]user_model.User, int64, error) {
+	var conditions []string
+	var args []interface{}
+
+	if query.Search != "" {
+		pattern := query.SearchPattern()
+		conditions = append(conditions, `(gocms_users.fullName LIKE ? OR EXISTS (
+			SELECT 1 FROM gocms_emails AS alt WHERE alt.userId=gocms_users.id AND alt.email LIKE ?
+		))`)
+		args = append(args, pattern, pattern)
+	}
+	if filter.Enabled != nil {
+		conditions = append(conditions, "gocms_users.enabled=?")
+		args = append(args, *filter.Enabled)
+	}
+	if filter.Verified != nil {
+		conditions = append(conditions, "gocms_emails.isVerified=?")
+		args = append(args, *filter.Verified)
+	}
+	if filter.GroupId != 0 {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM gocms_users_to_groups WHERE gocms_users_to_groups.userId=gocms_users.id AND gocms_users_to_groups.groupId=?)")
+		args = append(args, filter.GroupId)
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, "gocms_users.created>=?")
+		args = append(args, *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, "gocms_users.created<?")
+		args = append(args, *filter.CreatedBefore)
+	}
+	if filter.Banned != nil {
+		banned := "gocms_users.banned IS NOT NULL AND (gocms_users.banExpires IS NULL OR gocms_users.banExpires>?)"
+		if !*filter.Banned {
+			banned = "NOT (" + banned + ")"
+		}
+		conditions = append(conditions, banned)
+		args = append(args, time.Now())
+	}
+	if filter.Deleted {
+		conditions = append(conditions, "gocms_users.deleted IS NOT NULL")
+	} else {
+		conditions = append(conditions, "gocms_users.deleted IS NULL")
+	}
+
+	from := `
+	FROM gocms_users
+	INNER JOIN gocms_emails
+	ON gocms_users.id=gocms_emails.userId AND gocms_emails.isPrimary=1`
+	if len(conditions) > 0 {
+		from += "\n\tWHERE " + strings.Join(conditions, "\n\tAND ")
+	}
+
+	var total int64
+	err := ur.database.Get(&total, "SELECT COUNT(*)"+from, args...)
+	if err != nil {
+		log.Errorf("Error counting users in database: %s", err.Error())
+		return nil, 0, err
+	}
+
+	users := []user_model.User{}
+	if total == 0 || query.Offset >= total {
+		return users, total, nil
+	}
+
+	err = ur.database.Select(&users, `
+	SELECT gocms_users.*, gocms_emails.email, gocms_emails.isVerified`+from+`
+	`+query.OrderBy(user_model.UserListSortColumns, "gocms_users.id")+`
+	LIMIT ? OFFSET ?
+	`, append(args, query.Limit, query.Offset)...)
+	if err != nil {
+		log.Errorf("Error listing users from database: %s", err.Error())
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
 func (ur *UserRepository) Add(user *user_model.User) error {
 
 	// check if user exists
@@ -124,7 +214,7 @@ func (ur *UserRepository) Update(id int64, user *user_model.User) error {
 	// insert row
 	user.Id = id
 	_, err := ur.database.NamedExec(`
-	UPDATE gocms_users SET fullName=:fullName, gender=:gender, photo=:photo, maxAge=:maxAge, minAge=:minAge WHERE id=:id
+	UPDATE gocms_users SET fullName=:fullName, gender=:gender, photo=:photo, maxAge=:maxAge, minAge=:minAge, locale=:locale WHERE id=:id
 	`, user)
 	if err != nil {
 		log.Errorf("Error updating user in database: %s", err.Error())
@@ -164,6 +254,20 @@ func (ur *UserRepository) UpdatePassword(id int64, hash string) error {
 	return nil
 }
 
+// UpdatePhoto sets the photo url and the uploaded photo variants
+func (ur *UserRepository) UpdatePhoto(id int64, user *user_model.User) error {
+	user.Id = id
+	_, err := ur.database.NamedExec(`
+	UPDATE gocms_users SET photo=:photo, photoKey=:photoKey, photoVariants=:photoVariants WHERE id=:id
+	`, user)
+	if err != nil {
+		log.Errorf("Error updating photo for user %v in database: %s", id, err.Error())
+		return err
+	}
+
+	return nil
+}
+
 func (ur *UserRepository) Delete(id int64) error {
 
 	if id == 0 {
@@ -181,6 +285,72 @@ func (ur *UserRepository) Delete(id int64) error {
 	return nil
 }
 
+// SoftDelete marks the user deleted, their data is kept until they are purged.
+func (ur *UserRepository) SoftDelete(id int64, deletedBy int64) error {
+	_, err := ur.database.Exec(`
+	UPDATE gocms_users SET deleted=?, deletedBy=? WHERE id=? AND deleted IS NULL
+	`, time.Now(), deletedBy, id)
+	if err != nil {
+		log.Errorf("Error soft deleting user %v in database: %s", id, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (ur *UserRepository) Restore(id int64) error {
+	_, err := ur.database.Exec(`
+	UPDATE gocms_users SET deleted=NULL, deletedBy=0 WHERE id=?
+	`, id)
+	if err != nil {
+		log.Errorf("Error restoring user %v in database: %s", id, err.Error())
+		return err
+	}
+	return nil
+}
+
+// GetDeletedBefore returns the users soft deleted before the time, oldest first.
+func (ur *UserRepository) GetDeletedBefore(before time.Time) ([]*user_model.User, error) {
+	var users []*user_model.User
+	err := ur.database.Select(&users, `
+	SELECT gocms_users.*, gocms_emails.email, gocms_emails.isVerified
+	FROM gocms_users
+	INNER JOIN gocms_emails
+	ON gocms_users.id=gocms_emails.userId AND gocms_emails.isPrimary=1
+	WHERE gocms_users.deleted<?
+	ORDER BY gocms_users.deleted
+	`, before)
+	if err != nil {
+		log.Errorf("Error getting deleted users from database: %s", err.Error())
+		return nil, err
+	}
+	return users, nil
+}
+
+// SetBan stores the ban fields of the user.
+func (ur *UserRepository) SetBan(id int64, user *user_model.User) error {
+	user.Id = id
+	_, err := ur.database.NamedExec(`
+	UPDATE gocms_users SET banned=:banned, bannedBy=:bannedBy, banReason=:banReason, banNote=:banNote, banExpires=:banExpires
+	WHERE id=:id
+	`, user)
+	if err != nil {
+		log.Errorf("Error banning user %v in database: %s", id, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (ur *UserRepository) ClearBan(id int64) error {
+	_, err := ur.database.Exec(`
+	UPDATE gocms_users SET banned=NULL, bannedBy=0, banReason='', banNote='', banExpires=NULL WHERE id=?
+	`, id)
+	if err != nil {
+		log.Errorf("Error lifting ban of user %v in database: %s", id, err.Error())
+		return err
+	}
+	return nil
+}
+
 func (ur *UserRepository) userExistsByEmail(email string) bool {
 	user := user_model.User{}
 	err := ur.database.QueryRowx(`
@@ -1,6 +1,7 @@
 package user_service
 
 import (
+	"database/sql"
 	"github.com/cqlcorp/gocms/utility"
 	"github.com/cqlcorp/gocms/utility/errors"
 	"github.com/cqlcorp/gocms/domain/user/user_model"
@@ -8,29 +9,43 @@ import (
 	"github.com/cqlcorp/gocms/domain/mail/mail_service"
 	"github.com/cqlcorp/gocms/domain/email/email_model"
 	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_service"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_service"
+	"github.com/cqlcorp/gocms/utility/list_utility"
+	"github.com/cqlcorp/gocms/utility/log"
+	"time"
+	"unicode/utf8"
 )
 
 type IUserService interface {
 	Add(*user_model.User) error
 	Get(int64) (*user_model.User, error)
 	GetByEmail(string) (*user_model.User, error)
+	GetActive(int64) (*user_model.User, error)
+	GetActiveByEmail(string) (*user_model.User, error)
 	GetAll() (*[]user_model.User, error)
+	List(*list_utility.Query, *user_model.UserListFilter) ([]user_model.User, int64, error)
 	Delete(int64) error
 	Update(int64, *user_model.User) error
 	UpdatePassword(int64, string) error
 	SetEnabled(int64, bool) error
+	SoftDelete(id int64, actorId int64) error
+	Restore(int64) error
+	Ban(id int64, ban *user_model.UserBanInput, actorId int64) error
+	Unban(int64) error
 }
 
 type UserService struct {
-	AuthService       authentication_service.IAuthService
-	MailService       mail_service.IMailService
-	RepositoriesGroup *repository.RepositoriesGroup
+	AuthService           authentication_service.IAuthService
+	PasswordPolicyService password_policy_service.IPasswordPolicyService
+	MailService           mail_service.IMailService
+	RepositoriesGroup     *repository.RepositoriesGroup
 }
 
-func DefaultUserService(rg *repository.RepositoriesGroup, authService *authentication_service.AuthService, mailService *mail_service.MailService) *UserService {
+func DefaultUserService(rg *repository.RepositoriesGroup, authService *authentication_service.AuthService, passwordPolicyService *password_policy_service.PasswordPolicyService, mailService *mail_service.MailService) *UserService {
 	userService := &UserService{
-		AuthService:       authService,
-		MailService:       mailService,
+		AuthService:           authService,
+		PasswordPolicyService: passwordPolicyService,
+		MailService:           mailService,
 		RepositoriesGroup: rg,
 	}
 
@@ -59,6 +74,31 @@ func (us *UserService) GetByEmail(email string) (*user_model.User, error) {
 	return user, nil
 }
 
+// GetActive gets a user that wasn't deleted by an admin. Use it for anything done for or sent to the user, deleted
+// users are only visible to admins through Get. Deleted users are reported as sql.ErrNoRows.
+func (us *UserService) GetActive(id int64) (*user_model.User, error) {
+	user, err := us.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsDeleted() {
+		return nil, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+// GetActiveByEmail gets a user that wasn't deleted by an admin by email, see GetActive.
+func (us *UserService) GetActiveByEmail(email string) (*user_model.User, error) {
+	user, err := us.GetByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsDeleted() {
+		return nil, sql.ErrNoRows
+	}
+	return user, nil
+}
+
 func (us *UserService) GetAll() (*[]user_model.User, error) {
 
 	users, err := us.RepositoriesGroup.UsersRepository.GetAll()
@@ -69,6 +109,14 @@ func (us *UserService) GetAll() (*[]user_model.User, error) {
 	return users, nil
 }
 
+// List a page of users along with the total matching the search and filters.
+func (us *UserService) List(query *list_utility.Query, filter *user_model.UserListFilter) ([]user_model.User, int64, error) {
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return nil, 0, errors.NewToUser("createdAfter must be before createdBefore.")
+	}
+	return us.RepositoriesGroup.UsersRepository.List(query, filter)
+}
+
 func (us *UserService) Add(user *user_model.User) error {
 
 	// email must exist and not be null
@@ -77,18 +125,19 @@ func (us *UserService) Add(user *user_model.User) error {
 	}
 
 	// hash password
-	if user.Password == "" {
+	userChosePassword := user.Password != ""
+	if !userChosePassword {
 		user.Password, _ = utility.GenerateRandomString(32)
 	} else {
-		// password complexity
-		if !us.AuthService.PasswordIsComplex(user.Password) {
-			return errors.NewToUser("Password is not complex enough.")
+		// password policy
+		if fieldErrors := us.PasswordPolicyService.Validate(user, user.Password); fieldErrors != nil {
+			return fieldErrors
 		}
 	}
 
 	hashPassword, err := us.AuthService.HashPassword(user.Password)
 	if err != nil {
-		return nil
+		return err
 	}
 	user.Password = hashPassword
 
@@ -109,6 +158,14 @@ func (us *UserService) Add(user *user_model.User) error {
 		return err
 	}
 
+	// start password history
+	if userChosePassword {
+		err = us.PasswordPolicyService.AddToHistory(user.Id, user.Password)
+		if err != nil { // log error but don't fail
+			log.Errorf("Error adding password history for user %v: %s\n", user.Id, err.Error())
+		}
+	}
+
 	return nil
 }
 
@@ -131,9 +188,14 @@ func (us *UserService) Update(id int64, userForUpdate *user_model.User) error {
 }
 func (us *UserService) UpdatePassword(id int64, password string) error {
 
-	//check complexity
-	if !us.AuthService.PasswordIsComplex(password) {
-		return errors.NewToUser("Password is not complex enough.")
+	user, err := us.RepositoriesGroup.UsersRepository.Get(id)
+	if err != nil {
+		return err
+	}
+
+	// check password policy
+	if fieldErrors := us.PasswordPolicyService.Validate(user, password); fieldErrors != nil {
+		return fieldErrors
 	}
 
 	// make hash
@@ -148,9 +210,57 @@ func (us *UserService) UpdatePassword(id int64, password string) error {
 		return err
 	}
 
+	// add to password history
+	err = us.PasswordPolicyService.AddToHistory(id, newHash)
+	if err != nil { // log error but don't fail
+		log.Errorf("Error adding password history for user %v: %s\n", id, err.Error())
+	}
+
 	return nil
 }
 
 func (us *UserService) SetEnabled(id int64, enabled bool) error {
 	return us.RepositoriesGroup.UsersRepository.SetEnabled(id, enabled)
 }
+
+// SoftDelete hides the user and blocks them from authenticating. They can be restored until they are purged after
+// USER_DELETED_RETENTION_DAYS.
+func (us *UserService) SoftDelete(id int64, actorId int64) error {
+	return us.RepositoriesGroup.UsersRepository.SoftDelete(id, actorId)
+}
+
+func (us *UserService) Restore(id int64) error {
+	return us.RepositoriesGroup.UsersRepository.Restore(id)
+}
+
+// Ban blocks the user from authenticating until the ban expires or is lifted. Banning a banned user replaces the ban.
+func (us *UserService) Ban(id int64, ban *user_model.UserBanInput, actorId int64) error {
+	var fieldErrors errors.FieldErrors
+	if ban.Reason == "" {
+		fieldErrors.Add("reason", "Reason is required.")
+	} else if utf8.RuneCountInString(ban.Reason) > 255 {
+		fieldErrors.Add("reason", "Reason must be at most 255 characters.")
+	}
+	if utf8.RuneCountInString(ban.Note) > 1000 {
+		fieldErrors.Add("note", "Note must be at most 1000 characters.")
+	}
+	if ban.Expires != nil && !ban.Expires.After(time.Now()) {
+		fieldErrors.Add("expires", "Expires must be in the future.")
+	}
+	if len(fieldErrors) > 0 {
+		return fieldErrors
+	}
+
+	now := time.Now()
+	return us.RepositoriesGroup.UsersRepository.SetBan(id, &user_model.User{
+		Banned:     &now,
+		BannedBy:   actorId,
+		BanReason:  ban.Reason,
+		BanNote:    ban.Note,
+		BanExpires: ban.Expires,
+	})
+}
+
+func (us *UserService) Unban(id int64) error {
+	return us.RepositoriesGroup.UsersRepository.ClearBan(id)
+}
@@ -0,0 +1,127 @@
+package user_service
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/domain/user/user_repository"
+	"github.com/cqlcorp/gocms/init/repository"
+	"github.com/cqlcorp/gocms/utility/errors"
+)
+
+// fakeUsersRepository has an active user 1 and a deleted user 2
+type fakeUsersRepository struct {
+	user_repository.IUserRepository
+	ban *user_model.User
+}
+
+func (f *fakeUsersRepository) Get(id int64) (*user_model.User, error) {
+	deleted := time.Now()
+	switch id {
+	case 1:
+		return &user_model.User{Id: 1, Email: "active@example.com"}, nil
+	case 2:
+		return &user_model.User{Id: 2, Email: "deleted@example.com", Deleted: &deleted}, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeUsersRepository) GetByEmail(email string) (*user_model.User, error) {
+	switch email {
+	case "active@example.com":
+		return f.Get(1)
+	case "deleted@example.com":
+		return f.Get(2)
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeUsersRepository) SetBan(id int64, user *user_model.User) error {
+	f.ban = user
+	return nil
+}
+
+func testService() (*UserService, *fakeUsersRepository) {
+	repo := &fakeUsersRepository{}
+	return &UserService{RepositoriesGroup: &repository.RepositoriesGroup{UsersRepository: repo}}, repo
+}
+
+func TestGetActive(t *testing.T) {
+	us, _ := testService()
+
+	tests := []struct {
+		id      int64
+		email   string
+		wantErr error
+	}{
+		{1, "active@example.com", nil},
+		{2, "deleted@example.com", sql.ErrNoRows},
+		{3, "missing@example.com", sql.ErrNoRows},
+	}
+	for _, test := range tests {
+		user, err := us.GetActive(test.id)
+		if err != test.wantErr || (err == nil && user.Id != test.id) {
+			t.Errorf("GetActive(%v) = %v, %v, want error %v", test.id, user, err, test.wantErr)
+		}
+		user, err = us.GetActiveByEmail(test.email)
+		if err != test.wantErr || (err == nil && user.Id != test.id) {
+			t.Errorf("GetActiveByEmail(%v) = %v, %v, want error %v", test.email, user, err, test.wantErr)
+		}
+	}
+
+	// admins still see deleted users
+	if user, err := us.Get(2); err != nil || !user.IsDeleted() {
+		t.Errorf("Get(2) = %v, %v, want the deleted user", user, err)
+	}
+}
+
+func TestBan(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		ban        user_model.UserBanInput
+		wantFields []string
+	}{
+		{"permanent", user_model.UserBanInput{Reason: "spam"}, nil},
+		{"until later", user_model.UserBanInput{Reason: "spam", Note: "reported twice", Expires: &future}, nil},
+		{"no reason", user_model.UserBanInput{}, []string{"reason"}},
+		{"long reason", user_model.UserBanInput{Reason: strings.Repeat("a", 256)}, []string{"reason"}},
+		{"long note", user_model.UserBanInput{Reason: "spam", Note: strings.Repeat("a", 1001)}, []string{"note"}},
+		{"expired", user_model.UserBanInput{Reason: "spam", Expires: &past}, []string{"expires"}},
+	}
+	for _, test := range tests {
+		us, repo := testService()
+		err := us.Ban(1, &test.ban, 5)
+		if test.wantFields == nil {
+			if err != nil {
+				t.Errorf("%v: Ban() error: %v", test.name, err)
+				continue
+			}
+			if repo.ban == nil || repo.ban.Banned == nil || repo.ban.BannedBy != 5 || repo.ban.BanReason != test.ban.Reason || repo.ban.BanExpires != test.ban.Expires {
+				t.Errorf("%v: stored ban %+v", test.name, repo.ban)
+			} else if !repo.ban.IsBanned() {
+				t.Errorf("%v: stored ban isn't in effect", test.name)
+			}
+			continue
+		}
+
+		fieldErrors, ok := err.(errors.FieldErrors)
+		if !ok || len(fieldErrors) != len(test.wantFields) {
+			t.Errorf("%v: Ban() error = %v, want errors on %v", test.name, err, test.wantFields)
+			continue
+		}
+		for i, field := range test.wantFields {
+			if fieldErrors[i].Field != field {
+				t.Errorf("%v: error on %v, want %v", test.name, fieldErrors[i].Field, field)
+			}
+		}
+		if repo.ban != nil {
+			t.Errorf("%v: Ban() stored an invalid ban", test.name)
+		}
+	}
+}
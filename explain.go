@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_model"
+)
+
+// runExplainCommand asks a running gocms server why a user is or isn't authorized and prints the trace. It talks to
+// the server rather than the database so that routes registered by running plugins are included.
+//
+// gocms explain -apiKey=<super admin api key> -user=jane@example.com -route="GET /api/admin/group"
+func runExplainCommand(args []string) int {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	server := flags.String("server", defaultExplainServer(), "url of the running gocms server.")
+	apiKey := flags.String("apiKey", os.Getenv("GOCMS_API_KEY"), "api key of a super admin. Defaults to the GOCMS_API_KEY env var.")
+	user := flags.String("user", "", "id or email of the user to explain.")
+	permission := flags.String("permission", "", "permission name or expression. Ex: \"blog.edit AND blog.publish\"")
+	userApiKey := flags.Int64("userApiKey", 0, "id of one of the user's api keys, to explain a request made with that key.")
+	route := flags.String("route", "", "route to explain as \"METHOD /path\". Ex: \"GET /api/admin/group\"")
+	asJson := flags.Bool("json", false, "print the raw json response.")
+	flags.Parse(args)
+
+	if *user == "" || (*permission == "") == (*route == "") || *apiKey == "" {
+		fmt.Fprintln(os.Stderr, "explain needs -apiKey, -user and either -permission or -route.")
+		flags.PrintDefaults()
+		return 2
+	}
+
+	query := url.Values{}
+	query.Set("user", *user)
+	if *userApiKey != 0 {
+		query.Set("apiKey", strconv.FormatInt(*userApiKey, 10))
+	}
+	if *permission != "" {
+		query.Set("permission", *permission)
+	} else {
+		parts := strings.Fields(*route)
+		if len(parts) != 2 {
+			fmt.Fprintln(os.Stderr, "-route must look like \"GET /api/admin/group\".")
+			return 2
+		}
+		query.Set("method", parts[0])
+		query.Set("path", parts[1])
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(*server, "/")+"/api/admin/acl/explain?"+query.Encode(), nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	req.Header.Set("Authorization", "Bearer "+*apiKey)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't reach gocms at %v: %v\n", *server, err.Error())
+		return 1
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if res.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "gocms responded %v: %s\n", res.Status, body)
+		return 1
+	}
+	if *asJson {
+		fmt.Println(string(body))
+		return 0
+	}
+
+	var explanation access_control_model.Explanation
+	err = json.Unmarshal(body, &explanation)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	printExplanation(&explanation)
+	return 0
+}
+
+func printExplanation(explanation *access_control_model.Explanation) {
+	for i, step := range explanation.Trace {
+		fmt.Printf("%2d. %v\n", i+1, step)
+	}
+
+	if len(explanation.Grants) > 0 {
+		fmt.Println("\nPermissions held:")
+		for _, grant := range explanation.Grants {
+			source := "directly"
+			if grant.GroupId != 0 {
+				source = fmt.Sprintf("via group %v (%v)", grant.GroupName, grant.GroupId)
+			}
+			satisfies := ""
+			if len(grant.Satisfies) > 0 {
+				satisfies = " -> grants " + strings.Join(grant.Satisfies, ", ")
+			}
+			fmt.Printf("  %v %v%v\n", grant.Permission, source, satisfies)
+		}
+	}
+
+	decision := "DENIED"
+	if explanation.Allowed {
+		decision = "ALLOWED"
+	}
+	fmt.Printf("\n%v: %v\n", decision, explanation.Reason)
+}
+
+func defaultExplainServer() string {
+	port := os.Getenv("PORT")
+	// the default of the PORT setting, which the server runs on unless it is overridden
+	if port == "" {
+		port = "9090"
+	}
+	return "http://localhost:" + port
+}
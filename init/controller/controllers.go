@@ -6,9 +6,15 @@ import (
 	// "strings"
 
 	"github.com/cqlcorp/gocms/context"
+	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_controller"
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_controller"
 	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_controller"
 	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_middleware"
 	"github.com/cqlcorp/gocms/domain/acl/cors"
+	"github.com/cqlcorp/gocms/domain/acl/group/group_controller"
+	"github.com/cqlcorp/gocms/domain/acl/impersonation/impersonation_controller"
+	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_controller"
+	"github.com/cqlcorp/gocms/domain/acl/policy/policy_controller"
 	"github.com/cqlcorp/gocms/domain/content/documentation"
 	"github.com/cqlcorp/gocms/domain/content/react"
 	"github.com/cqlcorp/gocms/domain/content/template"
@@ -16,12 +22,20 @@ import (
 	"github.com/cqlcorp/gocms/domain/email/email_controller"
 	"github.com/cqlcorp/gocms/domain/health/health_controller"
 	"github.com/cqlcorp/gocms/domain/health/health_middleware"
+	"github.com/cqlcorp/gocms/domain/invitation/invitation_controller"
 	"github.com/cqlcorp/gocms/domain/plugin/plugin_services"
+	"github.com/cqlcorp/gocms/domain/privacy/privacy_controller"
+	"github.com/cqlcorp/gocms/domain/mail/mail_controller"
+	"github.com/cqlcorp/gocms/domain/profile/profile_controller"
+	"github.com/cqlcorp/gocms/domain/security/security_controller"
+	"github.com/cqlcorp/gocms/domain/security/security_middleware"
+	"github.com/cqlcorp/gocms/domain/storage/storage_controller"
 	"github.com/cqlcorp/gocms/domain/user/user_admin_controller"
 	"github.com/cqlcorp/gocms/domain/user/user_controller"
 	"github.com/cqlcorp/gocms/domain/user/user_middleware"
 	"github.com/cqlcorp/gocms/init/service"
 	"github.com/cqlcorp/gocms/routes"
+	"github.com/cqlcorp/gocms/utility/log"
 	"github.com/gin-contrib/multitemplate"
 	"github.com/gin-gonic/gin"
 )
@@ -45,6 +59,21 @@ type ApiControllers struct {
 	AdminUserController *user_admin_controller.UserAdminController
 	UserController      *user_controller.UserController
 	EmailController     *email_controller.EmailController
+	InvitationController *invitation_controller.InvitationController
+	ImpersonationController *impersonation_controller.ImpersonationController
+	ApiKeyController    *api_key_controller.ApiKeyController
+	GroupAdminController *group_controller.GroupAdminController
+	PermissionAdminController *permission_controller.PermissionAdminController
+	PolicyAdminController *policy_controller.PolicyAdminController
+	ExplainAdminController *access_control_controller.ExplainAdminController
+	SecurityController  *security_controller.SecurityController
+	SecurityAdminController *security_controller.SecurityAdminController
+	ProfileAdminController *profile_controller.ProfileAdminController
+	StorageController      *storage_controller.StorageController
+	PrivacyController      *privacy_controller.PrivacyController
+	PrivacyAdminController *privacy_controller.PrivacyAdminController
+	UserImportAdminController *user_admin_controller.UserImportAdminController
+	EmailTemplateAdminController *mail_controller.EmailTemplateAdminController
 }
 
 var (
@@ -53,6 +82,9 @@ var (
 
 func DefaultControllerGroup(r *gin.Engine, sg *service.ServicesGroup) *ControllersGroup {
 
+	// never trust gocms headers sent by the client
+	r.Use(user_middleware.StripGocmsHeaders())
+
 	// create plugin middleware handle
 	pluginMiddlewareProxy := sg.PluginsService.NewPluginMiddlewareProxyByRank()
 	// apply plugin middleware rank 1
@@ -60,7 +92,10 @@ func DefaultControllerGroup(r *gin.Engine, sg *service.ServicesGroup) *Controlle
 
 	// top level middleware
 	r.Use(user_middleware.UUID())
-	r.Use(cors.CORS())
+	cm := cors.DefaultCorsMiddleware(sg)
+	r.Use(cm.CORS())
+	sm := security_middleware.DefaultSecurityMiddleware(sg)
+	r.Use(sm.SecurityHeaders())
 	r.Use(user_middleware.Timezone())
 	am := authentication_middleware.DefaultAuthMiddleware(sg)
 	hm := health_middleware.DefaultHealthMiddleware(sg)
@@ -79,6 +114,9 @@ func DefaultControllerGroup(r *gin.Engine, sg *service.ServicesGroup) *Controlle
 		NoRoute: r.NoRoute,
 	}
 
+	// the admin api can be limited to fewer origins than the public api
+	cm.Override(routes.Auth.Group("/admin"), cors.AdminPolicy)
+
 	// apply auth middleware
 	am.ApplyAuthToRoutes(routes)
 	hm.ApplyHealthToRoutes(routes)
@@ -93,6 +131,21 @@ func DefaultControllerGroup(r *gin.Engine, sg *service.ServicesGroup) *Controlle
 		HealthyController:   health_controller.DefaultHealthController(routes, sg),
 		UserController:      user_controller.DefaultUserController(routes, sg),
 		EmailController:     email_controller.DefaultEmailController(routes, sg),
+		InvitationController: invitation_controller.DefaultInvitationController(routes, sg),
+		ImpersonationController: impersonation_controller.DefaultImpersonationController(routes, sg),
+		ApiKeyController:    api_key_controller.DefaultApiKeyController(routes, sg),
+		GroupAdminController: group_controller.DefaultGroupAdminController(routes, sg),
+		PermissionAdminController: permission_controller.DefaultPermissionAdminController(routes, sg),
+		PolicyAdminController: policy_controller.DefaultPolicyAdminController(routes, sg),
+		ExplainAdminController: access_control_controller.DefaultExplainAdminController(routes, sg),
+		SecurityController:  security_controller.DefaultSecurityController(routes, sg),
+		SecurityAdminController: security_controller.DefaultSecurityAdminController(routes, sg),
+		ProfileAdminController: profile_controller.DefaultProfileAdminController(routes, sg),
+		StorageController:      storage_controller.DefaultStorageController(routes, sg),
+		PrivacyController:      privacy_controller.DefaultPrivacyController(routes, sg),
+		PrivacyAdminController: privacy_controller.DefaultPrivacyAdminController(routes, sg),
+		UserImportAdminController: user_admin_controller.DefaultUserImportAdminController(routes, sg),
+		EmailTemplateAdminController: mail_controller.DefaultEmailTemplateAdminController(routes, sg),
 	}
 
 	// define after for 404 catcher
@@ -106,8 +159,10 @@ func DefaultControllerGroup(r *gin.Engine, sg *service.ServicesGroup) *Controlle
 	// apply plugin middleware rank 3000
 	r.Use(pluginMiddlewareProxy.ApplyForRank(plugin_services.MIDDLEWARE_RANK_3000)...)
 
-	// register plugin routes
-	sg.PluginsService.RegisterActivePluginRoutes(routes)
+	// register plugin routes, plugins with invalid routes are skipped
+	if err := sg.PluginsService.RegisterActivePluginRoutes(routes); err != nil {
+		log.Errorf("Error registering plugin routes: %v\n", err.Error())
+	}
 
 	// apply plugin middleware rank 4000
 	r.Use(pluginMiddlewareProxy.ApplyForRank(plugin_services.MIDDLEWARE_RANK_4000)...)
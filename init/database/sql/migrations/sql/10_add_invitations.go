@@ -0,0 +1,53 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddInvitations() *migrate.Migration {
+	addInvitations := migrate.Migration{
+		Id: "10",
+		Up: []string{`
+			CREATE TABLE gocms_invitations (
+			id int(11) NOT NULL AUTO_INCREMENT,
+			email varchar(255) NOT NULL,
+			invitedBy int(11) NOT NULL,
+			status int(1) NOT NULL DEFAULT 0,
+			expires datetime NOT NULL,
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			lastModified DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			INDEX (email),
+			FOREIGN KEY (invitedBy)
+				REFERENCES gocms_users (id)
+				ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`, `
+			CREATE TABLE gocms_invitations_to_groups (
+			invitationId int(11) NOT NULL,
+			groupId int(11) NOT NULL,
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (invitationId, groupId),
+			FOREIGN KEY (invitationId)
+				REFERENCES gocms_invitations (id)
+				ON DELETE CASCADE,
+			FOREIGN KEY (groupId)
+				REFERENCES gocms_groups (id)
+				ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`, `
+			INSERT INTO gocms_permissions (name, description) VALUES ('invite_users', 'Invite new users by email.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('INVITATION_TIMEOUT', '10080', 'Default minutes before an invitation expires.');`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('INVITATION_ACCEPT_URL', 'http://localhost:9090/invitation', 'Page the invitation link opens. The signed token is added as ?token=.');
+			`,
+		},
+		Down: []string{
+			`DROP TABLE gocms_invitations_to_groups;`,
+			`DROP TABLE gocms_invitations;`,
+			`DELETE FROM gocms_permissions WHERE name='invite_users';`,
+			`DELETE FROM gocms_settings WHERE name='INVITATION_TIMEOUT';`,
+			`DELETE FROM gocms_settings WHERE name='INVITATION_ACCEPT_URL';`,
+		},
+	}
+
+	return &addInvitations
+}
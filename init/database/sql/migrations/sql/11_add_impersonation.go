@@ -0,0 +1,49 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddImpersonation() *migrate.Migration {
+	addImpersonation := migrate.Migration{
+		Id: "11",
+		Up: []string{`
+			CREATE TABLE gocms_impersonations (
+			id int(11) NOT NULL AUTO_INCREMENT,
+			adminId int(11) NOT NULL,
+			userId int(11) NOT NULL,
+			isActive int(1) NOT NULL DEFAULT 1,
+			expires datetime NOT NULL,
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			lastModified DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			FOREIGN KEY (adminId)
+				REFERENCES gocms_users (id)
+				ON DELETE CASCADE,
+			FOREIGN KEY (userId)
+				REFERENCES gocms_users (id)
+				ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`, `
+			CREATE TABLE gocms_audit_logs (
+			id int(11) NOT NULL AUTO_INCREMENT,
+			userId int(11) NOT NULL,
+			actorId int(11) NOT NULL,
+			action varchar(50) NOT NULL,
+			detail varchar(255) NOT NULL DEFAULT '',
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			INDEX (userId),
+			INDEX (actorId)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('IMPERSONATION_TIMEOUT', '30', 'Minutes an admin impersonation token is valid for.');
+			`,
+		},
+		Down: []string{
+			`DROP TABLE gocms_impersonations;`,
+			`DROP TABLE gocms_audit_logs;`,
+			`DELETE FROM gocms_settings WHERE name='IMPERSONATION_TIMEOUT';`,
+		},
+	}
+
+	return &addImpersonation
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddApiKeys() *migrate.Migration {
+	addApiKeys := migrate.Migration{
+		Id: "12",
+		Up: []string{`
+			CREATE TABLE gocms_api_keys (
+			id int(11) NOT NULL AUTO_INCREMENT,
+			userId int(11) NOT NULL,
+			name varchar(255) NOT NULL,
+			prefix varchar(16) NOT NULL UNIQUE,
+			hash varchar(64) NOT NULL,
+			expires datetime NULL DEFAULT NULL,
+			lastUsed datetime NULL DEFAULT NULL,
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			FOREIGN KEY (userId)
+				REFERENCES gocms_users (id)
+				ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`, `
+			CREATE TABLE gocms_api_keys_to_permissions (
+			apiKeyId int(11) NOT NULL,
+			permissionId int(11) NOT NULL,
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (apiKeyId, permissionId),
+			FOREIGN KEY (apiKeyId)
+				REFERENCES gocms_api_keys (id)
+				ON DELETE CASCADE,
+			FOREIGN KEY (permissionId)
+				REFERENCES gocms_permissions (id)
+				ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`,
+		},
+		Down: []string{
+			`DROP TABLE gocms_api_keys_to_permissions;`,
+			`DROP TABLE gocms_api_keys;`,
+		},
+	}
+
+	return &addApiKeys
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddPluginCredentials() *migrate.Migration {
+	addPluginCredentials := migrate.Migration{
+		Id: "13",
+		Up: []string{`
+			ALTER TABLE gocms_plugins ADD COLUMN externalCredential varchar(255) NULL DEFAULT NULL AFTER externalPort;
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('PLUGIN_REQUEST_MAX_AGE', '300', 'Seconds a signed internal api request from a plugin is accepted for.');
+			`,
+		},
+		Down: []string{
+			`ALTER TABLE gocms_plugins DROP COLUMN externalCredential;`,
+			`DELETE FROM gocms_settings WHERE name='PLUGIN_REQUEST_MAX_AGE';`,
+		},
+	}
+
+	return &addPluginCredentials
+}
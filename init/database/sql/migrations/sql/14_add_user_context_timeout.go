@@ -0,0 +1,18 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddUserContextTimeout() *migrate.Migration {
+	addUserContextTimeout := migrate.Migration{
+		Id: "14",
+		Up: []string{`
+			INSERT INTO gocms_settings (name, value, description) VALUES ('USER_CONTEXT_TIMEOUT', '60', 'Seconds the signed user context forwarded to plugins is valid for.');
+			`,
+		},
+		Down: []string{
+			`DELETE FROM gocms_settings WHERE name='USER_CONTEXT_TIMEOUT';`,
+		},
+	}
+
+	return &addUserContextTimeout
+}
@@ -0,0 +1,18 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddManageAclPermission() *migrate.Migration {
+	addManageAclPermission := migrate.Migration{
+		Id: "15",
+		Up: []string{`
+			INSERT INTO gocms_permissions (name, description) VALUES ('manage_acl', 'Manage groups and permissions and assign them to users.');
+			`,
+		},
+		Down: []string{
+			`DELETE FROM gocms_permissions WHERE name='manage_acl';`,
+		},
+	}
+
+	return &addManageAclPermission
+}
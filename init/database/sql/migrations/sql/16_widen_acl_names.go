@@ -0,0 +1,22 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+// WidenAclNames makes room for permissions and groups namespaced by plugin id.
+func WidenAclNames() *migrate.Migration {
+	widenAclNames := migrate.Migration{
+		Id: "16",
+		Up: []string{`
+			ALTER TABLE gocms_permissions MODIFY name varchar(255) NOT NULL;
+			`, `
+			ALTER TABLE gocms_groups MODIFY name varchar(255) NOT NULL;
+			`,
+		},
+		Down: []string{
+			`ALTER TABLE gocms_permissions MODIFY name varchar(30) NOT NULL;`,
+			`ALTER TABLE gocms_groups MODIFY name varchar(30) NOT NULL;`,
+		},
+	}
+
+	return &widenAclNames
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddParentGroups() *migrate.Migration {
+	addParentGroups := migrate.Migration{
+		Id: "17",
+		Up: []string{`
+			CREATE TABLE gocms_groups_to_parent_groups (
+			groupId int(11) NOT NULL,
+			parentGroupId int(11) NOT NULL,
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (groupId, parentGroupId),
+			FOREIGN KEY (groupId)
+				REFERENCES gocms_groups (id)
+				ON DELETE CASCADE,
+			FOREIGN KEY (parentGroupId)
+				REFERENCES gocms_groups (id)
+				ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`,
+		},
+		Down: []string{
+			`DROP TABLE gocms_groups_to_parent_groups;`,
+		},
+	}
+
+	return &addParentGroups
+}
@@ -0,0 +1,59 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddAclPolicies() *migrate.Migration {
+	addAclPolicies := migrate.Migration{
+		Id: "18",
+		Up: []string{`
+			CREATE TABLE gocms_acl_resource_types (
+			id int(11) NOT NULL AUTO_INCREMENT,
+			name varchar(100) NOT NULL UNIQUE,
+			description varchar(255) NOT NULL DEFAULT '',
+			ownerActions varchar(255) NOT NULL DEFAULT '',
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`, `
+			CREATE TABLE gocms_acl_grants (
+			id int(11) NOT NULL AUTO_INCREMENT,
+			resourceType varchar(100) NOT NULL,
+			resourceId varchar(64) NOT NULL,
+			subjectType varchar(10) NOT NULL,
+			subjectId int(11) NOT NULL,
+			action varchar(50) NOT NULL,
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			UNIQUE KEY grant_unique (resourceType, resourceId, subjectType, subjectId, action),
+			FOREIGN KEY (resourceType)
+				REFERENCES gocms_acl_resource_types (name)
+				ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`, `
+			CREATE TABLE gocms_acl_rules (
+			id int(11) NOT NULL AUTO_INCREMENT,
+			resourceType varchar(100) NOT NULL,
+			action varchar(50) NOT NULL,
+			effect varchar(10) NOT NULL,
+			description varchar(255) NOT NULL DEFAULT '',
+			conditions text NOT NULL,
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			FOREIGN KEY (resourceType)
+				REFERENCES gocms_acl_resource_types (name)
+				ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`, `
+			INSERT INTO gocms_permissions (name, description) VALUES ('manage_policies', 'Manage resource types, grants and policy rules.');
+			`,
+		},
+		Down: []string{
+			`DROP TABLE gocms_acl_rules;`,
+			`DROP TABLE gocms_acl_grants;`,
+			`DROP TABLE gocms_acl_resource_types;`,
+			`DELETE FROM gocms_permissions WHERE name='manage_policies';`,
+		},
+	}
+
+	return &addAclPolicies
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddUserPermissionsCache() *migrate.Migration {
+	addUserPermissionsCache := migrate.Migration{
+		Id: "19",
+		Up: []string{`
+			INSERT INTO gocms_settings (name, value, description) VALUES ('USER_PERMISSIONS_CACHE_LIFE', '300', 'Seconds a users effective permissions are cached for. 0 disables the cache.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('ACL_CACHE_POLL_RATE', '5', 'Seconds between checks for acl changes made by other instances. 0 disables polling on single instance installs.');
+			`, `
+			INSERT INTO gocms_runtime (name, value, description) VALUES ('ACL_CACHE_VERSION', '0', 'Bumped when groups, permissions or memberships change so other instances clear their acl caches.');
+			`,
+		},
+		Down: []string{
+			`DELETE FROM gocms_settings WHERE name='USER_PERMISSIONS_CACHE_LIFE';`,
+			`DELETE FROM gocms_settings WHERE name='ACL_CACHE_POLL_RATE';`,
+			`DELETE FROM gocms_runtime WHERE name='ACL_CACHE_VERSION';`,
+		},
+	}
+
+	return &addUserPermissionsCache
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddCorsPolicy() *migrate.Migration {
+	addCorsPolicy := migrate.Migration{
+		Id: "20",
+		Up: []string{`
+			UPDATE gocms_settings SET description='Comma separated origins allowed to make CORS requests. Supports * wildcards, ex: https://*.example.com. A lone * allows any origin without credentials.' WHERE name='CORS_HOST';
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('CORS_ADMIN_HOST', '', 'Comma separated origins allowed to make CORS requests to the admin api. Empty uses CORS_HOST.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('CORS_ALLOW_CREDENTIALS', 'true', 'Allow browsers to send credentials on CORS requests from allowed origins. Never applies when any origin is allowed.');
+			`,
+		},
+		Down: []string{
+			`UPDATE gocms_settings SET description='Hosts allowed to make CORS requests.' WHERE name='CORS_HOST';`,
+			`DELETE FROM gocms_settings WHERE name='CORS_ADMIN_HOST';`,
+			`DELETE FROM gocms_settings WHERE name='CORS_ALLOW_CREDENTIALS';`,
+		},
+	}
+
+	return &addCorsPolicy
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddSecurityHeaders() *migrate.Migration {
+	addSecurityHeaders := migrate.Migration{
+		Id: "21",
+		Up: []string{`
+			INSERT INTO gocms_settings (name, value, description) VALUES ('FRAME_OPTIONS', 'SAMEORIGIN', 'X-Frame-Options header, DENY or SAMEORIGIN. Empty disables the header.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('REFERRER_POLICY', 'strict-origin-when-cross-origin', 'Referrer-Policy header. Empty disables the header.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('HSTS_MAX_AGE', '31536000', 'Seconds browsers should only use https, sent on https requests. 0 disables the header.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('HSTS_INCLUDE_SUBDOMAINS', 'false', 'Apply the https only policy to all subdomains.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('CSP_MODE', 'report-only', 'Content security policy for pages served by GoCMS. off, report-only or enforce.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('CSP_EXTRA_SOURCES', '', 'Space separated sources added to the script, style, img, font and connect directives of the content security policy.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('CSP_REPORT_RETENTION', '30', 'Days content security policy reports are kept. 0 keeps them forever.');
+			`, `
+			CREATE TABLE gocms_csp_reports (
+			id int(11) NOT NULL AUTO_INCREMENT,
+			documentUri varchar(255) NOT NULL DEFAULT '',
+			directive varchar(255) NOT NULL DEFAULT '',
+			blockedUri varchar(255) NOT NULL DEFAULT '',
+			sourceFile varchar(255) NOT NULL DEFAULT '',
+			lineNumber int(11) NOT NULL DEFAULT 0,
+			disposition varchar(255) NOT NULL DEFAULT '',
+			userAgent varchar(255) NOT NULL DEFAULT '',
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			KEY created (created)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`,
+		},
+		Down: []string{
+			`DROP TABLE gocms_csp_reports;`,
+			`DELETE FROM gocms_settings WHERE name IN ('FRAME_OPTIONS', 'REFERRER_POLICY', 'HSTS_MAX_AGE', 'HSTS_INCLUDE_SUBDOMAINS', 'CSP_MODE', 'CSP_EXTRA_SOURCES', 'CSP_REPORT_RETENTION');`,
+		},
+	}
+
+	return &addSecurityHeaders
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddAuthCookies() *migrate.Migration {
+	addAuthCookies := migrate.Migration{
+		Id: "22",
+		Up: []string{`
+			INSERT INTO gocms_settings (name, value, description) VALUES ('AUTH_COOKIES', 'false', 'Allow browser clients to receive their tokens as HttpOnly cookies by sending X-AUTH-MODE: cookie. Cookie authenticated requests must send X-CSRF-Token.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('AUTH_COOKIE_DOMAIN', '', 'Domain of the auth cookies. Empty limits them to the host that set them.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('AUTH_COOKIE_SECURE', 'true', 'Only send auth cookies over https. Disable for local development over http.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('AUTH_COOKIE_SAMESITE', 'Lax', 'SameSite attribute of the auth cookies. Strict, Lax or None. None requires AUTH_COOKIE_SECURE.');
+			`,
+		},
+		Down: []string{
+			`DELETE FROM gocms_settings WHERE name IN ('AUTH_COOKIES', 'AUTH_COOKIE_DOMAIN', 'AUTH_COOKIE_SECURE', 'AUTH_COOKIE_SAMESITE');`,
+		},
+	}
+
+	return &addAuthCookies
+}
@@ -0,0 +1,18 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddUserListIndexes() *migrate.Migration {
+	addUserListIndexes := migrate.Migration{
+		Id: "23",
+		Up: []string{`
+			ALTER TABLE gocms_users ADD INDEX created (created), ADD INDEX fullName (fullName), ADD INDEX lastModified (lastModified);
+			`,
+		},
+		Down: []string{
+			`ALTER TABLE gocms_users DROP INDEX created, DROP INDEX fullName, DROP INDEX lastModified;`,
+		},
+	}
+
+	return &addUserListIndexes
+}
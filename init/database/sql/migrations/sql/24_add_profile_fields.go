@@ -0,0 +1,50 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddProfileFields() *migrate.Migration {
+	addProfileFields := migrate.Migration{
+		Id: "24",
+		Up: []string{`
+			CREATE TABLE gocms_profile_fields (
+			id int(11) NOT NULL AUTO_INCREMENT,
+			name varchar(100) NOT NULL UNIQUE,
+			label varchar(255) NOT NULL DEFAULT '',
+			description varchar(255) NOT NULL DEFAULT '',
+			type varchar(20) NOT NULL,
+			required int(1) NOT NULL DEFAULT 0,
+			options text NOT NULL,
+			min double NULL DEFAULT NULL,
+			max double NULL DEFAULT NULL,
+			pattern varchar(255) NOT NULL DEFAULT '',
+			visibility varchar(20) NOT NULL DEFAULT 'user',
+			editableBy varchar(20) NOT NULL DEFAULT 'user',
+			includeInContext int(1) NOT NULL DEFAULT 0,
+			pluginId varchar(255) NOT NULL DEFAULT '',
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`, `
+			CREATE TABLE gocms_profile_values (
+			userId int(11) NOT NULL,
+			fieldId int(11) NOT NULL,
+			value text NOT NULL,
+			lastModified datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (userId, fieldId),
+			FOREIGN KEY (userId)
+				REFERENCES gocms_users (id)
+				ON DELETE CASCADE,
+			FOREIGN KEY (fieldId)
+				REFERENCES gocms_profile_fields (id)
+				ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`,
+		},
+		Down: []string{
+			`DROP TABLE gocms_profile_values;`,
+			`DROP TABLE gocms_profile_fields;`,
+		},
+	}
+
+	return &addProfileFields
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddUserPhotos() *migrate.Migration {
+	addUserPhotos := migrate.Migration{
+		Id: "25",
+		Up: []string{`
+			INSERT INTO gocms_settings (name, value, description) VALUES ('STORAGE_BACKEND', 'local', 'Where uploaded files are stored, local or s3. Changing it requires a restart.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('STORAGE_LOCAL_DIR', './content/uploads', 'Directory uploaded files are stored in and served from at /uploads with the local backend.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('STORAGE_PUBLIC_URL', '', 'Base url uploaded files are served from, like a CDN. Empty uses /uploads on the PUBLIC_API_URL host for local storage, or the bucket url for s3.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('STORAGE_S3_ENDPOINT', '', 'Url of the s3 compatible service, like https://s3.us-east-1.amazonaws.com. Buckets are addressed by path.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('STORAGE_S3_REGION', 'us-east-1', 'Region used to sign s3 requests.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('STORAGE_S3_BUCKET', '', 'Bucket uploaded files are stored in. It must allow public reads of the files.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('STORAGE_S3_ACCESS_KEY', '', 'Access key for the s3 bucket.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('STORAGE_S3_SECRET_KEY', '', 'Secret key for the s3 bucket.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('USER_PHOTO_MAX_SIZE', '5242880', 'Max size in bytes of an uploaded user photo.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('USER_PHOTO_SIZES', '64,256,512', 'Comma separated widths in pixels of the square variants made from an uploaded user photo.');
+			`, `
+			ALTER TABLE gocms_users ADD photoKey varchar(255) NOT NULL DEFAULT '', ADD photoVariants varchar(1000) NOT NULL DEFAULT '';
+			`,
+		},
+		Down: []string{
+			`ALTER TABLE gocms_users DROP photoKey, DROP photoVariants;`,
+			`DELETE FROM gocms_settings WHERE name IN ('STORAGE_BACKEND', 'STORAGE_LOCAL_DIR', 'STORAGE_PUBLIC_URL', 'STORAGE_S3_ENDPOINT', 'STORAGE_S3_REGION', 'STORAGE_S3_BUCKET', 'STORAGE_S3_ACCESS_KEY', 'STORAGE_S3_SECRET_KEY', 'USER_PHOTO_MAX_SIZE', 'USER_PHOTO_SIZES');`,
+		},
+	}
+
+	return &addUserPhotos
+}
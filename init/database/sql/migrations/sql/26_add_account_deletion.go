@@ -0,0 +1,32 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddAccountDeletion() *migrate.Migration {
+	addAccountDeletion := migrate.Migration{
+		Id: "26",
+		Up: []string{`
+			INSERT INTO gocms_settings (name, value, description) VALUES ('ACCOUNT_DELETION_GRACE_DAYS', '30', 'Days between a user requesting deletion of their account and it being deleted. The user can cancel until then.');
+			`, `
+			CREATE TABLE gocms_deletion_requests (
+			userId int(11) NOT NULL,
+			requested datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			scheduledFor datetime NOT NULL,
+			attempts int(11) NOT NULL DEFAULT 0,
+			lastError varchar(255) NOT NULL DEFAULT '',
+			PRIMARY KEY (userId),
+			KEY scheduledFor (scheduledFor),
+			FOREIGN KEY (userId)
+				REFERENCES gocms_users (id)
+				ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`,
+		},
+		Down: []string{
+			`DROP TABLE gocms_deletion_requests;`,
+			`DELETE FROM gocms_settings WHERE name='ACCOUNT_DELETION_GRACE_DAYS';`,
+		},
+	}
+
+	return &addAccountDeletion
+}
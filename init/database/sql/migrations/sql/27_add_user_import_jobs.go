@@ -0,0 +1,43 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddUserImportJobs() *migrate.Migration {
+	addUserImportJobs := migrate.Migration{
+		Id: "27",
+		Up: []string{`
+			INSERT INTO gocms_settings (name, value, description) VALUES ('USER_IMPORT_MAX_ROWS', '10000', 'Max rows in a user import file.');
+			`, `
+			CREATE TABLE gocms_user_import_jobs (
+			id int(11) NOT NULL AUTO_INCREMENT,
+			createdBy int(11) NOT NULL,
+			filename varchar(255) NOT NULL DEFAULT '',
+			format varchar(10) NOT NULL,
+			dryRun tinyint(1) NOT NULL DEFAULT 0,
+			invite tinyint(1) NOT NULL DEFAULT 0,
+			status varchar(20) NOT NULL,
+			total int(11) NOT NULL DEFAULT 0,
+			processed int(11) NOT NULL DEFAULT 0,
+			added int(11) NOT NULL DEFAULT 0,
+			updated int(11) NOT NULL DEFAULT 0,
+			failed int(11) NOT NULL DEFAULT 0,
+			rowErrors mediumtext NOT NULL,
+			error varchar(255) NOT NULL DEFAULT '',
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			lastModified datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			KEY status (status),
+			FOREIGN KEY (createdBy)
+				REFERENCES gocms_users (id)
+				ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`,
+		},
+		Down: []string{
+			`DROP TABLE gocms_user_import_jobs;`,
+			`DELETE FROM gocms_settings WHERE name='USER_IMPORT_MAX_ROWS';`,
+		},
+	}
+
+	return &addUserImportJobs
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddUserSoftDeleteAndBans() *migrate.Migration {
+	addUserSoftDeleteAndBans := migrate.Migration{
+		Id: "28",
+		Up: []string{`
+			INSERT INTO gocms_settings (name, value, description) VALUES ('USER_DELETED_RETENTION_DAYS', '30', 'Days a deleted user can be restored before they are permanently deleted.');
+			`, `
+			ALTER TABLE gocms_users
+			ADD COLUMN deleted datetime NULL DEFAULT NULL,
+			ADD COLUMN deletedBy int(11) NOT NULL DEFAULT 0,
+			ADD COLUMN banned datetime NULL DEFAULT NULL,
+			ADD COLUMN bannedBy int(11) NOT NULL DEFAULT 0,
+			ADD COLUMN banReason varchar(255) NOT NULL DEFAULT '',
+			ADD COLUMN banNote varchar(1000) NOT NULL DEFAULT '',
+			ADD COLUMN banExpires datetime NULL DEFAULT NULL,
+			ADD KEY deleted (deleted);
+			`,
+		},
+		Down: []string{
+			`ALTER TABLE gocms_users
+			DROP KEY deleted,
+			DROP COLUMN deleted,
+			DROP COLUMN deletedBy,
+			DROP COLUMN banned,
+			DROP COLUMN bannedBy,
+			DROP COLUMN banReason,
+			DROP COLUMN banNote,
+			DROP COLUMN banExpires;`,
+			`DELETE FROM gocms_settings WHERE name='USER_DELETED_RETENTION_DAYS';`,
+		},
+	}
+
+	return &addUserSoftDeleteAndBans
+}
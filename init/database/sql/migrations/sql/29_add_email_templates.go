@@ -0,0 +1,26 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddEmailTemplates() *migrate.Migration {
+	addEmailTemplates := migrate.Migration{
+		Id: "29",
+		Up: []string{`
+			INSERT INTO gocms_settings (name, value, description) VALUES ('DEFAULT_LOCALE', 'en', 'Locale of emails sent to users who haven''t chosen one. Ex: en, en-US, fr.');
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('EMAIL_HEADER_IMAGE', 'img/email_header.jpg', 'Image at the top of emails. A full url or a path relative to ACTIVE_THEME_ASSETS_BASE, empty hides the image.');
+			`, `
+			ALTER TABLE gocms_users
+			ADD COLUMN locale varchar(35) NOT NULL DEFAULT '';
+			`,
+		},
+		Down: []string{
+			`ALTER TABLE gocms_users
+			DROP COLUMN locale;`,
+			`DELETE FROM gocms_settings WHERE name='EMAIL_HEADER_IMAGE';`,
+			`DELETE FROM gocms_settings WHERE name='DEFAULT_LOCALE';`,
+		},
+	}
+
+	return &addEmailTemplates
+}
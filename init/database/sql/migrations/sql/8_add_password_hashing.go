@@ -0,0 +1,26 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddPasswordHashing() *migrate.Migration {
+	addPasswordHashing := migrate.Migration{
+		Id: "8",
+		Up: []string{`
+			INSERT INTO gocms_settings (name, value, description) VALUES ('PASSWORD_HASH_ALGORITHM', 'argon2id', 'Algorithm used for new password hashes. Existing hashes are upgraded on login. argon2id or bcrypt.');`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('ARGON2_MEMORY', '65536', 'Memory used by argon2id when hashing. KiB.');`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('ARGON2_ITERATIONS', '3', 'Number of passes argon2id makes over the memory.');`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('ARGON2_PARALLELISM', '2', 'Number of threads used by argon2id.');`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('BCRYPT_COST', '10', 'Cost used by bcrypt when it is the password hash algorithm.');
+			`,
+		},
+		Down: []string{
+			`DELETE FROM gocms_settings WHERE name='PASSWORD_HASH_ALGORITHM';`,
+			`DELETE FROM gocms_settings WHERE name='ARGON2_MEMORY';`,
+			`DELETE FROM gocms_settings WHERE name='ARGON2_ITERATIONS';`,
+			`DELETE FROM gocms_settings WHERE name='ARGON2_PARALLELISM';`,
+			`DELETE FROM gocms_settings WHERE name='BCRYPT_COST';`,
+		},
+	}
+
+	return &addPasswordHashing
+}
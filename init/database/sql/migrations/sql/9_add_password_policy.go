@@ -0,0 +1,36 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func AddPasswordPolicy() *migrate.Migration {
+	addPasswordPolicy := migrate.Migration{
+		Id: "9",
+		Up: []string{`
+			CREATE TABLE gocms_password_history (
+			id int(11) NOT NULL AUTO_INCREMENT,
+			userId int(11) NOT NULL,
+			password varchar(255) NOT NULL,
+			created datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id),
+			FOREIGN KEY (userId)
+				REFERENCES gocms_users (id)
+				ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+			`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('PASSWORD_MIN_LENGTH', '8', 'Minimum number of characters in a password.');`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('PASSWORD_HISTORY_COUNT', '5', 'Number of previous passwords a user may not reuse. 0 to disable.');`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('PASSWORD_MAX_AGE', '0', 'Days before a password expires and must be changed. 0 to disable.');`, `
+			INSERT INTO gocms_settings (name, value, description) VALUES ('BREACHED_PASSWORDS_DIR', '', 'Directory of k-anonymity prefix files (<PREFIX>.txt) of breached password hashes. Empty to disable.');
+			`,
+		},
+		Down: []string{
+			`DROP TABLE gocms_password_history;`,
+			`DELETE FROM gocms_settings WHERE name='PASSWORD_MIN_LENGTH';`,
+			`DELETE FROM gocms_settings WHERE name='PASSWORD_HISTORY_COUNT';`,
+			`DELETE FROM gocms_settings WHERE name='PASSWORD_MAX_AGE';`,
+			`DELETE FROM gocms_settings WHERE name='BREACHED_PASSWORDS_DIR';`,
+		},
+	}
+
+	return &addPasswordPolicy
+}
@@ -15,6 +15,28 @@ func Default() *migrate.MemoryMigrationSource {
 			MigrateToRSAKeys(),
 			AddDocumentationToggle(),
 			ErrorReportingMigration(),
+			AddPasswordHashing(),
+			AddPasswordPolicy(),
+			AddInvitations(),
+			AddImpersonation(),
+			AddApiKeys(),
+			AddPluginCredentials(),
+			AddUserContextTimeout(),
+			AddManageAclPermission(),
+			WidenAclNames(),
+			AddParentGroups(),
+			AddAclPolicies(),
+			AddUserPermissionsCache(),
+			AddCorsPolicy(),
+			AddSecurityHeaders(),
+			AddAuthCookies(),
+			AddUserListIndexes(),
+			AddProfileFields(),
+			AddUserPhotos(),
+			AddAccountDeletion(),
+			AddUserImportJobs(),
+			AddUserSoftDeleteAndBans(),
+			AddEmailTemplates(),
 		},
 	}
 	return &migrationsList
@@ -1,14 +1,23 @@
 package repository
 
 import (
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_repository"
 	"github.com/cqlcorp/gocms/domain/acl/group/group_repository"
+	"github.com/cqlcorp/gocms/domain/acl/impersonation/impersonation_repository"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_history_repository"
 	"github.com/cqlcorp/gocms/domain/acl/permissions/permission_repository"
+	"github.com/cqlcorp/gocms/domain/acl/policy/policy_repository"
 	"github.com/cqlcorp/gocms/domain/email/email_respository"
+	"github.com/cqlcorp/gocms/domain/invitation/invitation_repository"
 	"github.com/cqlcorp/gocms/domain/plugin/plugin_repository"
+	"github.com/cqlcorp/gocms/domain/privacy/privacy_repository"
+	"github.com/cqlcorp/gocms/domain/profile/profile_repository"
 	"github.com/cqlcorp/gocms/domain/runtime/runtime_repository"
 	"github.com/cqlcorp/gocms/domain/secure_code/secure_code_repository"
+	"github.com/cqlcorp/gocms/domain/security/security_repository"
 	"github.com/cqlcorp/gocms/domain/setting/setting_repository"
 	"github.com/cqlcorp/gocms/domain/user/user_repository"
+	"github.com/cqlcorp/gocms/domain/user/user_import/user_import_repository"
 	"github.com/cqlcorp/gocms/domain/logs/log_repository"
 	"github.com/jmoiron/sqlx"
 )
@@ -23,6 +32,15 @@ type RepositoriesGroup struct {
 	GroupsRepository      group_repository.IGroupsRepository
 	PluginRepository      plugin_repository.IPluginRepository
 	LogRepository		  log_repository.ILogRepository
+	PasswordHistoryRepository password_history_repository.IPasswordHistoryRepository
+	InvitationRepository  invitation_repository.IInvitationRepository
+	ImpersonationRepository impersonation_repository.IImpersonationRepository
+	ApiKeyRepository      api_key_repository.IApiKeyRepository
+	PolicyRepository      policy_repository.IPolicyRepository
+	CspReportRepository   security_repository.ICspReportRepository
+	ProfileRepository     profile_repository.IProfileRepository
+	DeletionRequestRepository privacy_repository.IDeletionRequestRepository
+	UserImportRepository  user_import_repository.IUserImportRepository
 	dbx                   *sqlx.DB
 }
 
@@ -40,6 +58,15 @@ func DefaultRepositoriesGroup(dbx *sqlx.DB) *RepositoriesGroup {
 		GroupsRepository:      group_repository.DefaultGroupsRepository(dbx),
 		PluginRepository:      plugin_repository.DefaultPluginRepository(dbx),
 		LogRepository:	 	   log_repository.DefaultLogRepository(dbx),
+		PasswordHistoryRepository: password_history_repository.DefaultPasswordHistoryRepository(dbx),
+		InvitationRepository:  invitation_repository.DefaultInvitationRepository(dbx),
+		ImpersonationRepository: impersonation_repository.DefaultImpersonationRepository(dbx),
+		ApiKeyRepository:      api_key_repository.DefaultApiKeyRepository(dbx),
+		PolicyRepository:      policy_repository.DefaultPolicyRepository(dbx),
+		CspReportRepository:   security_repository.DefaultCspReportRepository(dbx),
+		ProfileRepository:     profile_repository.DefaultProfileRepository(dbx),
+		DeletionRequestRepository: privacy_repository.DefaultDeletionRequestRepository(dbx),
+		UserImportRepository:  user_import_repository.DefaultUserImportRepository(dbx),
 	}
 	return rg
 }
@@ -3,14 +3,26 @@ package service
 import (
 	"github.com/cqlcorp/gocms/context"
 	"github.com/cqlcorp/gocms/domain/acl/access_control/access_control_service"
+	"github.com/cqlcorp/gocms/domain/acl/api_key/api_key_service"
 	"github.com/cqlcorp/gocms/domain/acl/authentication/authentication_service"
+	"github.com/cqlcorp/gocms/domain/acl/impersonation/impersonation_service"
+	"github.com/cqlcorp/gocms/domain/acl/password_policy/password_policy_service"
 	"github.com/cqlcorp/gocms/domain/acl/permissions/permissions_service"
+	"github.com/cqlcorp/gocms/domain/acl/policy/policy_service"
 	"github.com/cqlcorp/gocms/domain/email/email_service"
 	"github.com/cqlcorp/gocms/domain/health/health_service"
+	"github.com/cqlcorp/gocms/domain/invitation/invitation_service"
 	"github.com/cqlcorp/gocms/domain/mail/mail_service"
+	"github.com/cqlcorp/gocms/domain/photo/photo_service"
+	"github.com/cqlcorp/gocms/domain/plugin/plugin_auth/plugin_auth_service"
 	"github.com/cqlcorp/gocms/domain/plugin/plugin_services"
+	"github.com/cqlcorp/gocms/domain/privacy/privacy_service"
+	"github.com/cqlcorp/gocms/domain/profile/profile_service"
+	"github.com/cqlcorp/gocms/domain/security/security_service"
 	"github.com/cqlcorp/gocms/domain/setting/setting_service"
+	"github.com/cqlcorp/gocms/domain/storage/storage_service"
 	"github.com/cqlcorp/gocms/domain/user/user_service"
+	"github.com/cqlcorp/gocms/domain/user/user_import/user_import_service"
 	"github.com/cqlcorp/gocms/domain/logs/log_service"
 	"github.com/cqlcorp/gocms/init/database"
 	"github.com/cqlcorp/gocms/init/repository"
@@ -23,14 +35,26 @@ type ServicesGroup struct {
 	SettingsService   setting_service.ISettingsService
 	MailService       mail_service.IMailService
 	AuthService       authentication_service.IAuthService
+	PasswordPolicyService password_policy_service.IPasswordPolicyService
 	PermissionService permission_service.IPermissionService
 	GroupService      group_service.IGroupService
 	UserService       user_service.IUserService
 	AclService        access_control_service.IAclService
+	PolicyService     policy_service.IPolicyService
 	EmailService      email_service.IEmailService
+	InvitationService invitation_service.IInvitationService
 	PluginsService    plugin_services.IPluginsService
+	PluginAuthService plugin_auth_service.IPluginAuthService
 	HealthService     health_service.IHealthService
 	LogService		  log_service.ILogService
+	ImpersonationService impersonation_service.IImpersonationService
+	ApiKeyService     api_key_service.IApiKeyService
+	SecurityService   security_service.ISecurityService
+	ProfileService    profile_service.IProfileService
+	StorageService    storage_service.IStorageService
+	PhotoService      photo_service.IPhotoService
+	PrivacyService    privacy_service.IPrivacyService
+	UserImportService user_import_service.IUserImportService
 }
 
 func DefaultServicesGroup(repositoriesGroup *repository.RepositoriesGroup, db *database.Database) *ServicesGroup {
@@ -53,17 +77,35 @@ func DefaultServicesGroup(repositoriesGroup *repository.RepositoriesGroup, db *d
 	aclService := access_control_service.DefaultAclService(repositoriesGroup)
 	aclService.RefreshPermissionsCache()
 
-	permissionService := permission_service.DefaultPermissionService(repositoriesGroup)
-	groupService := group_service.DefaultGroupService(repositoriesGroup)
+	// pick up acl changes made by other instances
+	if context.Config.DbVars.AclCachePollRate > 0 {
+		pollAclCache := time.Duration(context.Config.DbVars.AclCachePollRate) * time.Second
+		context.Schedule.AddTicker(pollAclCache, func() {
+			aclService.PollCacheVersion()
+		})
+	}
+
+	permissionService := permission_service.DefaultPermissionService(repositoriesGroup, aclService)
+	groupService := group_service.DefaultGroupService(repositoriesGroup, aclService)
+	policyService := policy_service.DefaultPolicyService(repositoriesGroup, aclService)
+	apiKeyService := api_key_service.DefaultApiKeyService(repositoriesGroup, aclService)
 
 	authService := authentication_service.DefaultAuthService(repositoriesGroup, mailService)
-	userService := user_service.DefaultUserService(repositoriesGroup, authService, mailService)
+	passwordPolicyService := password_policy_service.DefaultPasswordPolicyService(repositoriesGroup, authService)
+	userService := user_service.DefaultUserService(repositoriesGroup, authService, passwordPolicyService, mailService)
 
 	// email service
 	emailService := email_service.DefaultEmailService(repositoriesGroup, mailService, authService)
 
+	// invitation service
+	invitationService := invitation_service.DefaultInvitationService(repositoriesGroup, userService, emailService, mailService)
+
+	// profile service, plugins register their fields on start
+	profileService := profile_service.DefaultProfileService(repositoriesGroup)
+
 	// plugins service
-	pluginsService := plugin_services.DefaultPluginsService(repositoriesGroup, aclService)
+	pluginAuthService := plugin_auth_service.DefaultPluginAuthService()
+	pluginsService := plugin_services.DefaultPluginsService(repositoriesGroup, aclService, pluginAuthService, profileService, mailService)
 	pluginRelatedErr = pluginsService.RefreshInstalledPlugins()
 	if pluginRelatedErr != nil {
 		log.Errorf("Error finding plugins. Can't start plugin microservice: %s\n", pluginRelatedErr.Error())
@@ -72,6 +114,37 @@ func DefaultServicesGroup(repositoriesGroup *repository.RepositoriesGroup, db *d
 	}
 	logService := log_service.DefaultLogService(repositoriesGroup)
 
+	// impersonation service
+	impersonationService := impersonation_service.DefaultImpersonationService(repositoriesGroup, logService)
+
+	// security service, purge old csp reports daily
+	securityService := security_service.DefaultSecurityService(repositoriesGroup)
+	context.Schedule.AddTicker(24*time.Hour, func() {
+		securityService.PurgeCspReports()
+	})
+
+	// uploaded files and user photos
+	storageService := storage_service.DefaultStorageService()
+	photoService := photo_service.DefaultPhotoService(repositoriesGroup, storageService)
+
+	// privacy service, delete users whose deletion grace period is over hourly
+	privacyService := privacy_service.DefaultPrivacyService(repositoriesGroup, userService, emailService, groupService, apiKeyService,
+		profileService, photoService, logService, mailService, pluginsService)
+	context.Schedule.AddTicker(time.Hour, func() {
+		privacyService.ProcessDueDeletions()
+	})
+
+	// permanently delete users past the retention of deleted users daily
+	context.Schedule.AddTicker(24*time.Hour, func() {
+		privacyService.PurgeDeletedUsers()
+	})
+
+	// bulk user import and export, imports stopped by a restart are failed so they don't look like they are running
+	userImportService := user_import_service.DefaultUserImportService(repositoriesGroup, userService, groupService, profileService, authService)
+	context.Schedule.AddTicker(10*time.Minute, func() {
+		userImportService.FailStaleJobs()
+	})
+
 	// heath service
 	healthService := health_service.DefaultHealthService(db, pluginsService)
 
@@ -79,14 +152,26 @@ func DefaultServicesGroup(repositoriesGroup *repository.RepositoriesGroup, db *d
 		SettingsService:   settingsService,
 		MailService:       mailService,
 		AuthService:       authService,
+		PasswordPolicyService: passwordPolicyService,
 		PermissionService: permissionService,
 		GroupService:      groupService,
 		UserService:       userService,
 		AclService:        aclService,
+		PolicyService:     policyService,
 		EmailService:      emailService,
+		InvitationService: invitationService,
 		PluginsService:    pluginsService,
+		PluginAuthService: pluginAuthService,
 		HealthService:     healthService,
 		LogService: 	   logService,
+		ImpersonationService: impersonationService,
+		ApiKeyService:     apiKeyService,
+		SecurityService:   securityService,
+		ProfileService:    profileService,
+		StorageService:    storageService,
+		PhotoService:      photoService,
+		PrivacyService:    privacyService,
+		UserImportService: userImportService,
 	}
 
 	return sg
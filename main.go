@@ -133,6 +133,11 @@ func (engine *InternalEngine) Listen(uri string) error {
 
 func main() {
 
+	// commands that talk to a running server instead of starting one
+	if len(os.Args) > 1 && os.Args[1] == "explain" {
+		os.Exit(runExplainCommand(os.Args[2:]))
+	}
+
 	// startup defaults
 	egocms, igocms = Default()
 
@@ -0,0 +1,56 @@
+package errors
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FieldError describes a problem with a single field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors are returned by services when a request can be corrected by the user. They are always shown to the user.
+type FieldErrors []*FieldError
+
+func (fe FieldErrors) Error() string {
+	messages := make([]string, len(fe))
+	for i, fieldError := range fe {
+		messages[i] = fieldError.Message
+	}
+	return strings.Join(messages, " ")
+}
+
+func (fe FieldErrors) Include() bool {
+	return true
+}
+
+// Add appends an error for the field.
+func (fe *FieldErrors) Add(field string, message string) {
+	*fe = append(*fe, &FieldError{
+		Field:   field,
+		Message: message,
+	})
+}
+
+// Rename changes the field name of every error for a field. Useful when a service validates a value that the request names differently.
+func (fe FieldErrors) Rename(from string, to string) FieldErrors {
+	for _, fieldError := range fe {
+		if fieldError.Field == from {
+			fieldError.Field = to
+		}
+	}
+	return fe
+}
+
+// ResponseWithFieldErrors responds with the field errors so the client can display them next to the matching inputs.
+func ResponseWithFieldErrors(c *gin.Context, code int, message string, fieldErrors FieldErrors) {
+	c.Abort()
+	c.JSON(code, gin.H{
+		"code":    code,
+		"message": message,
+		"fields":  fieldErrors,
+	})
+}
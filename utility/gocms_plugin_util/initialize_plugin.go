@@ -3,8 +3,15 @@ package gocms_plugin_util
 import (
 	"github.com/cqlcorp/gocms/utility/gocms_plugin_util/manifest_utl"
 	"flag"
+	"os"
 )
 
+// CredentialEnv is the environment variable gocms passes the plugin credential in. It isn't a flag so the secret
+// doesn't show up in the process list.
+const CredentialEnv = "GOCMS_PLUGIN_CREDENTIAL"
+
+var credential string
+
 func Init(manifestFilePath string, db interface{}) (port int) {
 
 	// define flags
@@ -16,10 +23,20 @@ func Init(manifestFilePath string, db interface{}) (port int) {
 		flag.Parse()
 	}
 
+	// read the credential and remove it so processes started by the plugin don't inherit it
+	credential = os.Getenv(CredentialEnv)
+	os.Unsetenv(CredentialEnv)
+
 	// do manifest stuff
 	if *insertManifest {
 		manifest_utl.InsertManifest(manifestFilePath, db)
 	}
 
 	return *p
-}
\ No newline at end of file
+}
+
+// Credential returns the credential gocms passed to the plugin at start. Use it with request_signing.SignRequest
+// when calling the gocms internal api.
+func Credential() string {
+	return credential
+}
@@ -0,0 +1,73 @@
+package request_signing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cqlcorp/gocms/context/consts"
+)
+
+// BodyHash returns the hex encoded sha256 of a request body.
+func BodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Signature returns the hex encoded HMAC-SHA256 of the canonical request using the plugin credential.
+// The canonical request is the method, path (including query), timestamp, nonce and body hash separated by new lines.
+func Signature(secret string, method string, path string, timestamp string, nonce string, bodyHash string) string {
+	canonical := strings.Join([]string{strings.ToUpper(method), path, timestamp, nonce, bodyHash}, "\n")
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidSignature compares the expected signature to the one supplied in constant time.
+func ValidSignature(secret string, method string, path string, timestamp string, nonce string, bodyHash string, signature string) bool {
+	expected := Signature(secret, method, path, timestamp, nonce, bodyHash)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// SignRequest adds the plugin id, timestamp, nonce and signature headers to a request bound for the gocms internal api.
+// The body is read and replaced so the request can still be sent.
+func SignRequest(req *http.Request, pluginId string, secret string) error {
+	var body []byte
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+		body = b
+	}
+
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set(consts.GOCMS_HEADER_PLUGIN_ID, pluginId)
+	req.Header.Set(consts.GOCMS_HEADER_TIMESTAMP, timestamp)
+	req.Header.Set(consts.GOCMS_HEADER_NONCE, nonce)
+	req.Header.Set(consts.GOCMS_HEADER_SIGNATURE, Signature(secret, req.Method, req.URL.RequestURI(), timestamp, nonce, BodyHash(body)))
+
+	return nil
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,156 @@
+package user_context
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cqlcorp/gocms/context/consts"
+	"github.com/cqlcorp/gocms/domain/user/user_model"
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ISSUER is the issuer of every user context token.
+const ISSUER = "gocms"
+
+const (
+	keysMaxAge         = time.Hour
+	keysMinRefreshWait = 30 * time.Second
+)
+
+// Claims are the contents of the user context token gocms forwards to plugins in the X-GOCMS-USER-CONTEXT header.
+// The audience is the id of the plugin the request was proxied to.
+type Claims struct {
+	User *user_model.UserContextHeader `json:"user"`
+	Type string                        `json:"typ"`
+	jwt.StandardClaims
+}
+
+// Validator checks user context tokens using the keys published on the gocms jwks endpoint. Keys are cached and
+// fetched again when they get old or a token is signed with a key id that isn't known yet.
+type Validator struct {
+	JwksUrl  string
+	PluginId string
+	Client   *http.Client
+
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+	mu      sync.Mutex
+}
+
+// NewValidator creates a validator for the plugin. jwksUrl is usually <gocms public url>/.well-known/jwks.json.
+func NewValidator(jwksUrl string, pluginId string) *Validator {
+	return &Validator{
+		JwksUrl:  jwksUrl,
+		PluginId: pluginId,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		keys:     make(map[string]*rsa.PublicKey),
+	}
+}
+
+// FromRequest validates the user context header of a request proxied by gocms. ok is false when the request is
+// anonymous. An error means the header is present but can't be trusted.
+func (v *Validator) FromRequest(req *http.Request) (user *user_model.UserContextHeader, ok bool, err error) {
+	token := req.Header.Get(consts.GOCMS_HEADER_USER_CONTEXT_KEY)
+	if token == "" {
+		return nil, false, nil
+	}
+	user, err = v.Validate(token)
+	if err != nil {
+		return nil, false, err
+	}
+	return user, true, nil
+}
+
+// Validate checks the signature, issuer, audience and expiry of a user context token and returns the user.
+func (v *Validator) Validate(tokenString string) (*user_model.UserContextHeader, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return v.getKey(kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.New("user context token has no expiry")
+	}
+	if claims.Type != consts.TOKEN_TYPE_USER_CONTEXT {
+		return nil, errors.New("token is not a user context token")
+	}
+	if !claims.VerifyIssuer(ISSUER, true) {
+		return nil, errors.New("user context token has the wrong issuer")
+	}
+	if !claims.VerifyAudience(v.PluginId, true) {
+		return nil, errors.New("user context token was issued for another plugin")
+	}
+	if claims.User == nil {
+		return nil, errors.New("user context token has no user")
+	}
+
+	return claims.User, nil
+}
+
+func (v *Validator) getKey(kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key, ok := v.keys[kid]
+	stale := time.Since(v.fetched) > keysMaxAge
+	if ok && !stale {
+		return key, nil
+	}
+
+	// unknown key so it may have been rotated, but don't let bad tokens hammer gocms
+	if stale || time.Since(v.fetched) > keysMinRefreshWait {
+		if err := v.fetchKeys(); err != nil {
+			if ok {
+				return key, nil
+			}
+			return nil, err
+		}
+	}
+
+	key, ok = v.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown key id " + kid)
+	}
+	return key, nil
+}
+
+func (v *Validator) fetchKeys() error {
+	res, err := v.Client.Get(v.JwksUrl)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks request failed with status %v", res.StatusCode)
+	}
+
+	var jwks JWKS
+	if err := json.NewDecoder(res.Body).Decode(&jwks); err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	v.keys = keys
+	v.fetched = time.Now()
+	return nil
+}
@@ -0,0 +1,168 @@
+package user_context
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cqlcorp/gocms/context/consts"
+	"github.com/dgrijalva/jwt-go"
+)
+
+type testKeys struct {
+	current *rsa.PrivateKey
+	rotated *rsa.PrivateKey
+	served  []*rsa.PrivateKey
+	fetches int
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	current, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testKeys{current: current, rotated: rotated, served: []*rsa.PrivateKey{current}}
+}
+
+func (k *testKeys) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	k.fetches++
+	jwks := JWKS{}
+	for _, key := range k.served {
+		jwks.Keys = append(jwks.Keys, NewJWK(&key.PublicKey))
+	}
+	json.NewEncoder(w).Encode(jwks)
+}
+
+// sign creates a token the way CreateToken does, edit changes the claims before signing
+func sign(t *testing.T, key *rsa.PrivateKey, edit func(jwt.MapClaims)) string {
+	claims := jwt.MapClaims{
+		"user":                  map[string]interface{}{"id": 4, "email": "jane@example.com"},
+		"iss":                   ISSUER,
+		"aud":                   "plugin",
+		consts.TOKEN_TYPE_CLAIM: consts.TOKEN_TYPE_USER_CONTEXT,
+		"iat":                   time.Now().Unix(),
+		"exp":                   time.Now().Add(time.Minute).Unix(),
+	}
+	if edit != nil {
+		edit(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = KeyId(&key.PublicKey)
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenString
+}
+
+func TestValidate(t *testing.T) {
+	keys := newTestKeys(t)
+	server := httptest.NewServer(keys)
+	defer server.Close()
+
+	other, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user": map[string]interface{}{"id": 4}, "iss": ISSUER, "aud": "plugin", consts.TOKEN_TYPE_CLAIM: consts.TOKEN_TYPE_USER_CONTEXT, "exp": time.Now().Add(time.Minute).Unix()})
+	hmacToken.Header["kid"] = KeyId(&keys.current.PublicKey)
+	hmacString, _ := hmacToken.SignedString([]byte("secret"))
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"valid", sign(t, keys.current, nil), true},
+		{"login token", sign(t, keys.current, func(c jwt.MapClaims) { c[consts.TOKEN_TYPE_CLAIM] = consts.TOKEN_TYPE_AUTH }), false},
+		{"no type", sign(t, keys.current, func(c jwt.MapClaims) { delete(c, consts.TOKEN_TYPE_CLAIM) }), false},
+		{"other plugin", sign(t, keys.current, func(c jwt.MapClaims) { c["aud"] = "other" }), false},
+		{"no audience", sign(t, keys.current, func(c jwt.MapClaims) { delete(c, "aud") }), false},
+		{"wrong issuer", sign(t, keys.current, func(c jwt.MapClaims) { c["iss"] = "someone" }), false},
+		{"expired", sign(t, keys.current, func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }), false},
+		{"no expiry", sign(t, keys.current, func(c jwt.MapClaims) { delete(c, "exp") }), false},
+		{"no user", sign(t, keys.current, func(c jwt.MapClaims) { delete(c, "user") }), false},
+		{"unknown key", sign(t, other, nil), false},
+		{"hmac signed", hmacString, false},
+		{"garbage", "not.a.token", false},
+	}
+	for _, test := range tests {
+		v := NewValidator(server.URL, "plugin")
+		user, err := v.Validate(test.token)
+		if (err == nil) != test.valid {
+			t.Errorf("%v: Validate() error = %v, want valid %v", test.name, err, test.valid)
+		}
+		if test.valid && (user == nil || user.Id != 4) {
+			t.Errorf("%v: Validate() = %+v, want user 4", test.name, user)
+		}
+	}
+}
+
+func TestValidateKeyRotation(t *testing.T) {
+	keys := newTestKeys(t)
+	server := httptest.NewServer(keys)
+	defer server.Close()
+
+	v := NewValidator(server.URL, "plugin")
+	if _, err := v.Validate(sign(t, keys.current, nil)); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+	if _, err := v.Validate(sign(t, keys.current, nil)); err != nil || keys.fetches != 1 {
+		t.Fatalf("Validate() error = %v after %v fetches, want the cached key", err, keys.fetches)
+	}
+
+	// a new key id is only fetched once the minimum wait has passed
+	keys.served = append(keys.served, keys.rotated)
+	if _, err := v.Validate(sign(t, keys.rotated, nil)); err == nil || keys.fetches != 1 {
+		t.Errorf("Validate() error = %v after %v fetches, want an unknown key without fetching", err, keys.fetches)
+	}
+	v.fetched = time.Now().Add(-keysMinRefreshWait - time.Second)
+	if _, err := v.Validate(sign(t, keys.rotated, nil)); err != nil || keys.fetches != 2 {
+		t.Errorf("Validate() error = %v after %v fetches, want the rotated key fetched", err, keys.fetches)
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	keys := newTestKeys(t)
+	server := httptest.NewServer(keys)
+	defer server.Close()
+	v := NewValidator(server.URL, "plugin")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	if user, ok, err := v.FromRequest(req); user != nil || ok || err != nil {
+		t.Errorf("FromRequest() = %v, %v, %v for an anonymous request", user, ok, err)
+	}
+
+	req.Header.Set(consts.GOCMS_HEADER_USER_CONTEXT_KEY, sign(t, keys.current, func(c jwt.MapClaims) { c["aud"] = "other" }))
+	if _, ok, err := v.FromRequest(req); ok || err == nil {
+		t.Errorf("FromRequest() = %v, %v for a token issued for another plugin", ok, err)
+	}
+
+	req.Header.Set(consts.GOCMS_HEADER_USER_CONTEXT_KEY, sign(t, keys.current, nil))
+	if user, ok, err := v.FromRequest(req); !ok || err != nil || user.Id != 4 {
+		t.Errorf("FromRequest() = %v, %v, %v, want user 4", user, ok, err)
+	}
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	keys := newTestKeys(t)
+	jwk := NewJWK(&keys.current.PublicKey)
+	pub, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatalf("PublicKey() error: %v", err)
+	}
+	if pub.N.Cmp(keys.current.N) != 0 || pub.E != keys.current.E || jwk.Kid != KeyId(pub) {
+		t.Error("JWK doesn't convert back to the same public key")
+	}
+	if KeyId(&keys.current.PublicKey) == KeyId(&keys.rotated.PublicKey) {
+		t.Error("different keys have the same key id")
+	}
+}
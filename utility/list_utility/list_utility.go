@@ -0,0 +1,167 @@
+package list_utility
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cqlcorp/gocms/utility/errors"
+	"github.com/gin-gonic/gin"
+)
+
+const DEFAULT_LIMIT int64 = 50
+const MAX_LIMIT int64 = 500
+
+const TOTAL_COUNT_HEADER = "X-Total-Count"
+
+// QUERY_PARAMS are the parameters read by ParseQuery
+var QUERY_PARAMS = []string{"limit", "offset", "search", "sort"}
+
+/**
+* @apiDefine ListQuery
+* @apiParam (Query) {number} [limit=50] Max items to return, up to 500.
+* @apiParam (Query) {number} [offset=0] Items to skip.
+* @apiParam (Query) {string} [search] Case insensitive text to search for.
+* @apiParam (Query) {string} [sort] Field to sort by, prefix with - for descending. Ex: -created
+ */
+type Query struct {
+	Limit      int64
+	Offset     int64
+	Search     string
+	Sort       string
+	Descending bool
+}
+
+/**
+* @apiDefine ListPage
+* @apiSuccess (Response) {Object[]} items The page of results.
+* @apiSuccess (Response) {number} total Number of results matching the search and filters across all pages.
+* @apiSuccess (Response) {number} limit
+* @apiSuccess (Response) {number} offset
+* @apiSuccess (Response-Header) {number} x-total-count Same as total.
+ */
+type Page struct {
+	Items  interface{} `json:"items"`
+	Total  int64       `json:"total"`
+	Limit  int64       `json:"limit"`
+	Offset int64       `json:"offset"`
+}
+
+// ParseQuery reads the list parameters of a request. Columns maps the sortable fields to their sql columns, the sort
+// field must be one of them.
+func ParseQuery(c *gin.Context, columns map[string]string, defaultSort string) (*Query, error) {
+	query := &Query{
+		Limit:  DEFAULT_LIMIT,
+		Search: strings.TrimSpace(c.Query("search")),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		l, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || l <= 0 {
+			return nil, errors.NewToUser("Limit must be a positive number.")
+		}
+		query.Limit = l
+	}
+	if query.Limit > MAX_LIMIT {
+		query.Limit = MAX_LIMIT
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		o, err := strconv.ParseInt(offset, 10, 64)
+		if err != nil || o < 0 {
+			return nil, errors.NewToUser("Offset must be zero or a positive number.")
+		}
+		query.Offset = o
+	}
+
+	sort := c.DefaultQuery("sort", defaultSort)
+	if strings.HasPrefix(sort, "-") {
+		query.Descending = true
+		sort = sort[1:]
+	}
+	if _, ok := columns[sort]; !ok {
+		fields := make([]string, 0, len(columns))
+		for field := range columns {
+			fields = append(fields, field)
+		}
+		return nil, errors.NewToUser(fmt.Sprintf("Can't sort by '%v'. Sort by one of: %v.", sort, strings.Join(fields, ", ")))
+	}
+	query.Sort = sort
+
+	return query, nil
+}
+
+// OrderBy is the ORDER BY clause for the sort. The tiebreak column keeps pages stable when sorted values repeat.
+func (q *Query) OrderBy(columns map[string]string, tiebreak string) string {
+	direction := "ASC"
+	if q.Descending {
+		direction = "DESC"
+	}
+	orderBy := fmt.Sprintf("ORDER BY %v %v", columns[q.Sort], direction)
+	if tiebreak != "" && columns[q.Sort] != tiebreak {
+		orderBy = fmt.Sprintf("%v, %v %v", orderBy, tiebreak, direction)
+	}
+	return orderBy
+}
+
+// SearchPattern is the search as a LIKE pattern matching anywhere in a value.
+func (q *Query) SearchPattern() string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(q.Search)
+	return "%" + escaped + "%"
+}
+
+// NewPage wraps a page of items with the total for the query.
+func (q *Query) NewPage(items interface{}, total int64) *Page {
+	return &Page{
+		Items:  items,
+		Total:  total,
+		Limit:  q.Limit,
+		Offset: q.Offset,
+	}
+}
+
+// Respond writes a page, with the total in the X-Total-Count header too.
+func Respond(c *gin.Context, page *Page) {
+	c.Header(TOTAL_COUNT_HEADER, strconv.FormatInt(page.Total, 10))
+	c.JSON(http.StatusOK, page)
+}
+
+// HasParams reports if any of the query parameters is set. Lists that returned a plain array before paging was added
+// use it to keep the array for clients that don't send any.
+func HasParams(c *gin.Context, names ...string) bool {
+	for _, name := range names {
+		if _, ok := c.GetQuery(name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseBool reads an optional true/false filter. Nil when not set.
+func ParseBool(c *gin.Context, name string) (*bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, errors.NewToUser(fmt.Sprintf("%v must be true or false.", name))
+	}
+	return &b, nil
+}
+
+// ParseTime reads an optional date (2006-01-02) or RFC3339 time filter. Nil when not set.
+func ParseTime(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, errors.NewToUser(fmt.Sprintf("%v must be a date like 2006-01-02 or an RFC3339 time.", name))
+}
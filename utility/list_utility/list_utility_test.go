@@ -0,0 +1,98 @@
+package list_utility
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var testColumns = map[string]string{
+	"id":      "t.id",
+	"name":    "t.name",
+	"created": "t.created",
+}
+
+func parseQuery(rawQuery string) (*Query, error) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	var query *Query
+	var err error
+	router.GET("/test", func(c *gin.Context) {
+		query, err = ParseQuery(c, testColumns, "-created")
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test?"+rawQuery, nil))
+	return query, err
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		rawQuery string
+		want     *Query
+	}{
+		{"", &Query{Limit: DEFAULT_LIMIT, Sort: "created", Descending: true}},
+		{"sort=name", &Query{Limit: DEFAULT_LIMIT, Sort: "name"}},
+		{"sort=-id&limit=10&offset=20", &Query{Limit: 10, Offset: 20, Sort: "id", Descending: true}},
+		{"limit=100000", &Query{Limit: MAX_LIMIT, Sort: "created", Descending: true}},
+		{"search=+jane+", &Query{Limit: DEFAULT_LIMIT, Search: "jane", Sort: "created", Descending: true}},
+		{"sort=t.name", nil},
+		{"sort=name%3BDROP+TABLE+gocms_users", nil},
+		{"sort=name+DESC", nil},
+		{"sort=--name", nil},
+		{"sort=", nil},
+		{"limit=0", nil},
+		{"limit=-1", nil},
+		{"limit=ten", nil},
+		{"offset=-1", nil},
+	}
+	for _, test := range tests {
+		got, err := parseQuery(test.rawQuery)
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("ParseQuery(%q) = %+v, want an error", test.rawQuery, got)
+			}
+			continue
+		}
+		if err != nil || *got != *test.want {
+			t.Errorf("ParseQuery(%q) = %+v, %v, want %+v", test.rawQuery, got, err, test.want)
+		}
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	tests := []struct {
+		query    Query
+		tiebreak string
+		want     string
+	}{
+		{Query{Sort: "name"}, "t.id", "ORDER BY t.name ASC, t.id ASC"},
+		{Query{Sort: "name", Descending: true}, "t.id", "ORDER BY t.name DESC, t.id DESC"},
+		{Query{Sort: "id"}, "t.id", "ORDER BY t.id ASC"},
+		{Query{Sort: "created"}, "", "ORDER BY t.created ASC"},
+	}
+	for _, test := range tests {
+		if got := test.query.OrderBy(testColumns, test.tiebreak); got != test.want {
+			t.Errorf("OrderBy(%+v) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestSearchPattern(t *testing.T) {
+	tests := []struct {
+		search string
+		want   string
+	}{
+		{"", "%%"},
+		{"jane", "%jane%"},
+		{"100%", `%100\%%`},
+		{"first_name", `%first\_name%`},
+		{`back\slash`, `%back\\slash%`},
+		{`\%_`, `%\\\%\_%`},
+	}
+	for _, test := range tests {
+		query := &Query{Search: test.search}
+		if got := query.SearchPattern(); got != test.want {
+			t.Errorf("SearchPattern(%q) = %q, want %q", test.search, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package localeUtil
+
+import (
+	"regexp"
+	"strings"
+)
+
+// locales are language tags like en, en-US or zh-Hant-TW, see https://tools.ietf.org/html/bcp47
+var localeRegex = regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,8})*$`)
+
+// Normalize returns the locale in its canonical case with dashes. Ok is false if it isn't a language tag.
+func Normalize(locale string) (normalized string, ok bool) {
+	if !localeRegex.MatchString(locale) {
+		return "", false
+	}
+
+	parts := strings.Split(strings.Replace(locale, "_", "-", -1), "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch len(parts[i]) {
+		case 2: // region
+			parts[i] = strings.ToUpper(parts[i])
+		case 4: // script
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		default:
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-"), true
+}
+
+// Fallbacks lists the locales to try in order for the locale. Each locale is followed by its parents, then the default
+// locale and its parents. Invalid locales are skipped and the list always ends with "" for no locale.
+// Ex: Fallbacks("fr-CA", "en-US") = [fr-CA fr en-US en ""]
+func Fallbacks(locale string, defaultLocale string) []string {
+	fallbacks := []string{}
+	seen := map[string]bool{}
+	for _, l := range []string{locale, defaultLocale} {
+		normalized, ok := Normalize(l)
+		if !ok {
+			continue
+		}
+		for normalized != "" {
+			if !seen[normalized] {
+				seen[normalized] = true
+				fallbacks = append(fallbacks, normalized)
+			}
+			if i := strings.LastIndex(normalized, "-"); i > 0 {
+				normalized = normalized[:i]
+			} else {
+				normalized = ""
+			}
+		}
+	}
+	return append(fallbacks, "")
+}
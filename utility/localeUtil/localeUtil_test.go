@@ -0,0 +1,56 @@
+package localeUtil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		locale     string
+		normalized string
+		ok         bool
+	}{
+		{"en", "en", true},
+		{"EN", "en", true},
+		{"en-us", "en-US", true},
+		{"en_US", "en-US", true},
+		{"zh-hant-tw", "zh-Hant-TW", true},
+		{"es-419", "es-419", true},
+		{"fil", "fil", true},
+		{"", "", false},
+		{"e", "", false},
+		{"english", "", false},
+		{"en-", "", false},
+		{"en US", "", false},
+		{"../en", "", false},
+		{"en-US/../../x", "", false},
+	}
+	for _, test := range tests {
+		normalized, ok := Normalize(test.locale)
+		if normalized != test.normalized || ok != test.ok {
+			t.Errorf("Normalize(%q) = %q, %v, want %q, %v", test.locale, normalized, ok, test.normalized, test.ok)
+		}
+	}
+}
+
+func TestFallbacks(t *testing.T) {
+	tests := []struct {
+		locale        string
+		defaultLocale string
+		want          []string
+	}{
+		{"fr-CA", "en-US", []string{"fr-CA", "fr", "en-US", "en", ""}},
+		{"en-GB", "en-US", []string{"en-GB", "en", "en-US", ""}},
+		{"en", "en", []string{"en", ""}},
+		{"zh_hant_tw", "en", []string{"zh-Hant-TW", "zh-Hant", "zh", "en", ""}},
+		{"", "en", []string{"en", ""}},
+		{"bad locale", "", []string{""}},
+		{"", "", []string{""}},
+	}
+	for _, test := range tests {
+		if got := Fallbacks(test.locale, test.defaultLocale); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Fallbacks(%q, %q) = %q, want %q", test.locale, test.defaultLocale, got, test.want)
+		}
+	}
+}